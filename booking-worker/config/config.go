@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,6 +13,8 @@ type Config struct {
 	GRPC      GRPCConfig
 	Queue     QueueConfig
 	Worker    WorkerConfig
+	DLQ       DLQConfig
+	Publisher PositionPublisherConfig
 	Metrics   MetricsConfig
 	Logging   LoggingConfig
 }
@@ -37,6 +40,29 @@ type QueueConfig struct {
 	MaxSize        int
 	TimeoutSeconds int
 	CleanupInterval time.Duration
+	// Expired items are removed in batches of ExpiryBatchSize, up to ExpiryMaxPerCycle per cleanup
+	ExpiryBatchSize   int
+	ExpiryMaxPerCycle int
+	// Weighted fair scheduling defaults for events without an explicit schedule
+	DefaultEventWeight         int
+	DefaultEventMaxConcurrency int
+	// VisibilityTimeout is how long a dequeued item stays leased without a heartbeat
+	// before the reaper returns it to its event queue
+	VisibilityTimeout time.Duration
+	// HeartbeatInterval is how often workers extend the lease of the item they are processing
+	HeartbeatInterval time.Duration
+	// Enqueue policies: one waiting or processing item per user per event, and a cap on
+	// the seats a user can hold across their active items for an event (0 = unlimited)
+	OneActiveItemPerUser bool
+	MaxSeatsPerUser      int
+	// ConfirmedSeatsTTL is how long booked seats count towards MaxSeatsPerUser for an
+	// event whose end time is not on its schedule
+	ConfirmedSeatsTTL time.Duration
+	// Wait estimates use per-event throughput over ETAWindow, counted in ETABucket buckets
+	ETAWindow time.Duration
+	ETABucket time.Duration
+	// LaneShares is each priority lane's default share of an event's dequeues (0 = paused)
+	LaneShares map[string]int
 }
 
 // WorkerConfig holds worker pool settings
@@ -46,6 +72,34 @@ type WorkerConfig struct {
 	RetryInterval time.Duration
 }
 
+// DLQConfig holds dead letter queue replay settings
+type DLQConfig struct {
+	ReplayEnabled   bool
+	ReplayInterval  time.Duration
+	ReplayBatchSize int
+	// ReplayProbeBatchSize caps the items replayed per cycle while the booking-service breaker is half-open
+	ReplayProbeBatchSize int
+	// ReplayMinAge is how long an item must sit in the DLQ before it is replayed
+	ReplayMinAge time.Duration
+	// Per-reason replay caps (0 disables replay for that reason)
+	CircuitOpenMaxReplays  int
+	TimeoutMaxReplays      int
+	ServiceErrorMaxReplays int
+	MaxRetriesMaxReplays   int
+}
+
+// PositionPublisherConfig holds queue position push settings
+type PositionPublisherConfig struct {
+	Enabled  bool
+	Interval time.Duration
+	// MinUserInterval throttles how often a single user is sent an update
+	MinUserInterval time.Duration
+	// BatchSize is the number of updates sent per realtime call
+	BatchSize int
+	// MaxScan caps how far into each event queue updates are pushed per cycle
+	MaxScan int
+}
+
 // MetricsConfig holds metrics settings
 type MetricsConfig struct {
 	Enabled bool
@@ -76,12 +130,47 @@ func LoadConfig() *Config {
 			MaxSize:        getEnvAsInt("QUEUE_MAX_SIZE", 100000),
 			TimeoutSeconds: getEnvAsInt("QUEUE_TIMEOUT_SECONDS", 900), // 15 minutes
 			CleanupInterval: getEnvAsDuration("QUEUE_CLEANUP_INTERVAL", 30*time.Second),
+			ExpiryBatchSize:            getEnvAsInt("QUEUE_EXPIRY_BATCH_SIZE", 100),
+			ExpiryMaxPerCycle:          getEnvAsInt("QUEUE_EXPIRY_MAX_PER_CYCLE", 1000),
+			DefaultEventWeight:         getEnvAsInt("QUEUE_DEFAULT_EVENT_WEIGHT", 1),
+			DefaultEventMaxConcurrency: getEnvAsInt("QUEUE_DEFAULT_EVENT_MAX_CONCURRENCY", 0),
+			VisibilityTimeout:          getEnvAsDuration("QUEUE_VISIBILITY_TIMEOUT", 60*time.Second),
+			HeartbeatInterval:          getEnvAsDuration("QUEUE_HEARTBEAT_INTERVAL", 15*time.Second),
+			OneActiveItemPerUser:       getEnvAsBool("QUEUE_ONE_ACTIVE_ITEM_PER_USER", true),
+			MaxSeatsPerUser:            getEnvAsInt("QUEUE_MAX_SEATS_PER_USER", 8),
+			ConfirmedSeatsTTL:          getEnvAsDuration("QUEUE_CONFIRMED_SEATS_TTL", 30*24*time.Hour),
+			ETAWindow:                  getEnvAsDuration("QUEUE_ETA_WINDOW", 5*time.Minute),
+			ETABucket:                  getEnvAsDuration("QUEUE_ETA_BUCKET", 15*time.Second),
+			LaneShares: getEnvAsIntMap("QUEUE_LANE_SHARES", map[string]int{
+				"presale":       4,
+				"vip":           3,
+				"accessibility": 2,
+				"standard":      1,
+			}),
 		},
 		Worker: WorkerConfig{
 			PoolSize:      getEnvAsInt("WORKER_POOL_SIZE", 10),
 			MaxRetries:    getEnvAsInt("WORKER_MAX_RETRIES", 3),
 			RetryInterval: getEnvAsDuration("WORKER_RETRY_INTERVAL", 5*time.Second),
 		},
+		DLQ: DLQConfig{
+			ReplayEnabled:          getEnvAsBool("DLQ_REPLAY_ENABLED", true),
+			ReplayInterval:         getEnvAsDuration("DLQ_REPLAY_INTERVAL", 30*time.Second),
+			ReplayBatchSize:        getEnvAsInt("DLQ_REPLAY_BATCH_SIZE", 100),
+			ReplayProbeBatchSize:   getEnvAsInt("DLQ_REPLAY_PROBE_BATCH_SIZE", 3),
+			ReplayMinAge:           getEnvAsDuration("DLQ_REPLAY_MIN_AGE", 1*time.Minute),
+			CircuitOpenMaxReplays:  getEnvAsInt("DLQ_CIRCUIT_OPEN_MAX_REPLAYS", 5),
+			TimeoutMaxReplays:      getEnvAsInt("DLQ_TIMEOUT_MAX_REPLAYS", 3),
+			ServiceErrorMaxReplays: getEnvAsInt("DLQ_SERVICE_ERROR_MAX_REPLAYS", 1),
+			MaxRetriesMaxReplays:   getEnvAsInt("DLQ_MAX_RETRIES_MAX_REPLAYS", 0),
+		},
+		Publisher: PositionPublisherConfig{
+			Enabled:         getEnvAsBool("POSITION_PUBLISH_ENABLED", true),
+			Interval:        getEnvAsDuration("POSITION_PUBLISH_INTERVAL", 2*time.Second),
+			MinUserInterval: getEnvAsDuration("POSITION_PUBLISH_MIN_USER_INTERVAL", 10*time.Second),
+			BatchSize:       getEnvAsInt("POSITION_PUBLISH_BATCH_SIZE", 500),
+			MaxScan:         getEnvAsInt("POSITION_PUBLISH_MAX_SCAN", 10000),
+		},
 		Metrics: MetricsConfig{
 			Enabled: getEnvAsBool("METRICS_ENABLED", true),
 			Port:     getEnv("METRICS_PORT", "9091"),
@@ -137,4 +226,27 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	return value
 }
 
+// getEnvAsIntMap parses "key:value,key:value"; keys missing from the variable keep their default
+func getEnvAsIntMap(key string, defaultValue map[string]int) map[string]int {
+	result := make(map[string]int, len(defaultValue))
+	for k, v := range defaultValue {
+		result[k] = v
+	}
 
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return result
+	}
+	for _, pair := range strings.Split(valueStr, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			continue
+		}
+		result[strings.TrimSpace(parts[0])] = value
+	}
+	return result
+}
@@ -3,6 +3,7 @@ package grpc
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"booking-worker/config"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -47,6 +49,21 @@ func (s *BookingWorkerService) EnqueueBooking(ctx context.Context, req *pb.Enque
 	if len(req.SeatNumbers) == 0 && req.SeatCount == 0 {
 		return nil, status.Error(codes.InvalidArgument, "seat_numbers or seat_count is required")
 	}
+	if len(req.IdempotencyKey) > 128 {
+		return nil, status.Error(codes.InvalidArgument, "idempotency_key must be at most 128 characters")
+	}
+	lane := queue.LaneStandard
+	if req.Lane != "" {
+		if !queue.IsValidLane(req.Lane) {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("unknown lane: %s", req.Lane))
+		}
+		lane = queue.Lane(req.Lane)
+	}
+	if lane != queue.LaneStandard {
+		if err := s.checkLaneEntitlement(ctx, req.EventId, req.UserId, lane); err != nil {
+			return nil, err
+		}
+	}
 
 	// Generate queue item ID
 	itemID := uuid.New().String()
@@ -64,28 +81,85 @@ func (s *BookingWorkerService) EnqueueBooking(ctx context.Context, req *pb.Enque
 		TotalAmount: req.TotalAmount,
 		Currency:    req.Currency,
 		Metadata:    req.Metadata,
+		Lane:        lane,
 		EnqueuedAt:  time.Now(),
 		ExpiresAt:   expiresAt,
 	}
 
-	// Enqueue
-	if err := s.queue.Enqueue(ctx, item); err != nil {
-		s.logger.Error("Failed to enqueue booking",
+	// Apply enqueue policies: idempotency key, one active item per user, seat limit
+	redisQueue, hasPolicies := s.queue.(*queue.RedisQueueManager)
+	duplicate := false
+	if hasPolicies {
+		admission, err := redisQueue.Admit(ctx, item, req.IdempotencyKey)
+		if err != nil {
+			s.logger.Error("Failed to check enqueue policies",
+				zap.String("user_id", req.UserId),
+				zap.String("event_id", req.EventId),
+				zap.Error(err),
+			)
+			return nil, status.Error(codes.Internal, fmt.Sprintf("failed to check enqueue policies: %v", err))
+		}
+
+		switch admission.Decision {
+		case queue.AdmissionDuplicate:
+			duplicate = true
+			itemID = admission.ItemID
+		case queue.AdmissionActiveItemExists:
+			return nil, enqueuePolicyError(codes.AlreadyExists, "ACTIVE_ITEM_EXISTS",
+				"user already has an active queue item for this event",
+				map[string]string{
+					"event_id":      req.EventId,
+					"queue_item_id": admission.ItemID,
+				},
+			)
+		case queue.AdmissionSeatLimitExceeded:
+			return nil, enqueuePolicyError(codes.ResourceExhausted, "SEAT_LIMIT_EXCEEDED",
+				fmt.Sprintf("seat limit of %d per user exceeded", s.config.Queue.MaxSeatsPerUser),
+				map[string]string{
+					"event_id":        req.EventId,
+					"max_seats":       strconv.Itoa(s.config.Queue.MaxSeatsPerUser),
+					"held_seats":      strconv.Itoa(admission.HeldSeats),
+					"requested_seats": strconv.Itoa(queue.RequestedSeats(item)),
+				},
+			)
+		}
+	}
+
+	if !duplicate {
+		// Enqueue
+		if err := s.queue.Enqueue(ctx, item); err != nil {
+			s.logger.Error("Failed to enqueue booking",
+				zap.String("user_id", req.UserId),
+				zap.String("event_id", req.EventId),
+				zap.Error(err),
+			)
+			if hasPolicies {
+				if releaseErr := redisQueue.ReleaseAdmission(ctx, item, req.IdempotencyKey); releaseErr != nil {
+					s.logger.Warn("Failed to release admission", zap.String("item_id", itemID), zap.Error(releaseErr))
+				}
+			}
+			return nil, status.Error(codes.Internal, fmt.Sprintf("failed to enqueue booking: %v", err))
+		}
+
+		s.logger.Info("Booking enqueued successfully",
+			zap.String("queue_item_id", itemID),
 			zap.String("user_id", req.UserId),
 			zap.String("event_id", req.EventId),
-			zap.Error(err),
+			zap.String("lane", string(lane)),
 		)
-		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to enqueue booking: %v", err))
 	}
 
-	s.logger.Info("Booking enqueued successfully",
-		zap.String("queue_item_id", itemID),
-		zap.String("user_id", req.UserId),
-		zap.String("event_id", req.EventId),
-	)
-
-	// Get initial position
-	position, err := s.queue.GetPosition(ctx, itemID)
+	// Get initial position within the item's lane (a duplicate may sit in another lane)
+	var position int
+	var err error
+	if hasPolicies {
+		var itemLane queue.Lane
+		if itemLane, position, err = redisQueue.GetLanePosition(ctx, itemID, req.EventId); err == nil {
+			lane = itemLane
+		}
+	} else {
+		position, err = s.queue.GetPosition(ctx, itemID)
+	}
 	if err != nil {
 		s.logger.Warn("Failed to get queue position",
 			zap.String("item_id", itemID),
@@ -94,21 +168,70 @@ func (s *BookingWorkerService) EnqueueBooking(ctx context.Context, req *pb.Enque
 		position = -1 // Unknown position
 	}
 
-	// Estimate wait time (rough estimate: 5 seconds per item)
-	estimatedWait := position * 5
-	if position < 0 {
-		estimatedWait = 0
+	// Estimate wait time from the event's measured throughput and the lane's share of it
+	wait := s.estimateWait(ctx, req.EventId, lane, position)
+
+	message := "Booking request enqueued successfully"
+	if duplicate {
+		message = "Duplicate booking request, returning existing queue item"
 	}
 
 	return &pb.EnqueueBookingResponse{
-		Success:              true,
-		QueueItemId:          itemID,
-		QueuePosition:        int32(position),
-		EstimatedWaitSeconds: int32(estimatedWait),
-		Message:              "Booking request enqueued successfully",
+		Success:                 true,
+		QueueItemId:             itemID,
+		QueuePosition:           int32(position),
+		EstimatedWaitSeconds:    int32(wait.Seconds),
+		EstimatedWaitMinSeconds: int32(wait.MinSeconds),
+		EstimatedWaitMaxSeconds: int32(wait.MaxSeconds),
+		Message:                 message,
+		Duplicate:               duplicate,
+		Lane:                    string(lane),
 	}, nil
 }
 
+// checkLaneEntitlement rejects an enqueue into a priority lane the user has not been granted
+func (s *BookingWorkerService) checkLaneEntitlement(ctx context.Context, eventID, userID string, lane queue.Lane) error {
+	redisQueue, ok := s.queue.(*queue.RedisQueueManager)
+	if !ok {
+		return status.Error(codes.Unimplemented, "priority lanes require the Redis queue")
+	}
+
+	entitled, err := redisQueue.IsEntitledToLane(ctx, eventID, userID, lane)
+	if err != nil {
+		s.logger.Error("Failed to check lane entitlement",
+			zap.String("user_id", userID),
+			zap.String("event_id", eventID),
+			zap.String("lane", string(lane)),
+			zap.Error(err),
+		)
+		return status.Error(codes.Internal, fmt.Sprintf("failed to check lane entitlement: %v", err))
+	}
+	if !entitled {
+		return enqueuePolicyError(codes.PermissionDenied, "LANE_NOT_ENTITLED",
+			fmt.Sprintf("user is not entitled to the %s lane of this event", lane),
+			map[string]string{
+				"event_id": eventID,
+				"lane":     string(lane),
+			},
+		)
+	}
+	return nil
+}
+
+// enqueuePolicyError builds a gRPC error whose ErrorInfo detail names the enqueue policy that rejected the request
+func enqueuePolicyError(code codes.Code, reason, message string, metadata map[string]string) error {
+	st := status.New(code, message)
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   "booking-worker",
+		Metadata: metadata,
+	})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
 // GetQueuePosition gets the current position of a queue item
 func (s *BookingWorkerService) GetQueuePosition(ctx context.Context, req *pb.GetQueuePositionRequest) (*pb.GetQueuePositionResponse, error) {
 	// Validate request
@@ -133,21 +256,54 @@ func (s *BookingWorkerService) GetQueuePosition(ctx context.Context, req *pb.Get
 		queueStatus = "completed"
 	}
 
-	// Estimate wait time
-	estimatedWait := position * 5
-	if position < 0 {
-		estimatedWait = 0
+	// Report the position within the item's lane and estimate from the lane's share of throughput
+	var eventID string
+	var queueLength int
+	lane := queue.LaneStandard
+	if redisQueue, ok := s.queue.(*queue.RedisQueueManager); ok {
+		if eventID, err = redisQueue.GetItemEventID(ctx, req.QueueItemId); err == nil {
+			if itemLane, lanePosition, err := redisQueue.GetLanePosition(ctx, req.QueueItemId, eventID); err == nil {
+				lane, position = itemLane, lanePosition
+			}
+			if lengths, err := redisQueue.GetLaneLengths(ctx, eventID); err == nil {
+				queueLength = lengths[lane]
+			}
+		}
 	}
+	wait := s.estimateWait(ctx, eventID, lane, position)
 
 	return &pb.GetQueuePositionResponse{
-		Success:              true,
-		Position:             int32(position),
-		EstimatedWaitSeconds: int32(estimatedWait),
-		Status:               queueStatus,
-		Message:              "Queue position retrieved",
+		Success:                 true,
+		Position:                int32(position),
+		QueueLength:             int32(queueLength),
+		EstimatedWaitSeconds:    int32(wait.Seconds),
+		EstimatedWaitMinSeconds: int32(wait.MinSeconds),
+		EstimatedWaitMaxSeconds: int32(wait.MaxSeconds),
+		Status:                  queueStatus,
+		Message:                 "Queue position retrieved",
+		Lane:                    string(lane),
 	}, nil
 }
 
+// estimateWait estimates the wait at position within a lane from the event's measured throughput,
+// falling back to the fixed per-item estimate when no measurement is available
+func (s *BookingWorkerService) estimateWait(ctx context.Context, eventID string, lane queue.Lane, position int) *queue.WaitEstimate {
+	redisQueue, ok := s.queue.(*queue.RedisQueueManager)
+	if !ok || eventID == "" {
+		return queue.FallbackWaitEstimate(position)
+	}
+
+	wait, err := redisQueue.EstimateLaneWait(ctx, eventID, lane, position)
+	if err != nil {
+		s.logger.Warn("Failed to estimate wait time",
+			zap.String("event_id", eventID),
+			zap.Error(err),
+		)
+		return queue.FallbackWaitEstimate(position)
+	}
+	return wait
+}
+
 // GetQueueStatus gets the status of a queue for an event
 func (s *BookingWorkerService) GetQueueStatus(ctx context.Context, req *pb.GetQueueStatusRequest) (*pb.GetQueueStatusResponse, error) {
 	// Validate request
@@ -167,18 +323,65 @@ func (s *BookingWorkerService) GetQueueStatus(ctx context.Context, req *pb.GetQu
 	// Get active workers count from processor
 	activeWorkers := s.processor.GetWorkerCount()
 
-	// Calculate processing rate from metrics (simplified: assume 1 item per 5 seconds per worker)
+	// Measured rates over the ETA window (fallback: assume 1 item per 5 seconds per worker)
 	processingRate := float64(activeWorkers) / 5.0
+	var dequeueRate float64
+	var lanes []*pb.LaneStatus
+	if redisQueue, ok := s.queue.(*queue.RedisQueueManager); ok {
+		throughput, err := redisQueue.GetThroughput(ctx, req.EventId)
+		if err != nil {
+			s.logger.Warn("Failed to get queue throughput",
+				zap.String("event_id", req.EventId),
+				zap.Error(err),
+			)
+		} else if throughput.CompletionRate > 0 || throughput.DequeueRate > 0 {
+			processingRate = throughput.CompletionRate
+			dequeueRate = throughput.DequeueRate
+		}
+
+		lanes, err = s.laneStatuses(ctx, redisQueue, req.EventId)
+		if err != nil {
+			s.logger.Warn("Failed to get lane status",
+				zap.String("event_id", req.EventId),
+				zap.Error(err),
+			)
+		}
+	}
 
 	return &pb.GetQueueStatusResponse{
 		Success:        true,
 		QueueLength:    int32(queueLength),
 		ActiveWorkers:  int32(activeWorkers),
 		ProcessingRate: processingRate,
+		DequeueRate:    dequeueRate,
+		Lanes:          lanes,
 		Message:        "Queue status retrieved",
 	}, nil
 }
 
+// laneStatuses reports the length, share and expected dequeue rate of each lane of an event
+func (s *BookingWorkerService) laneStatuses(ctx context.Context, redisQueue *queue.RedisQueueManager, eventID string) ([]*pb.LaneStatus, error) {
+	throughputs, lengths, err := redisQueue.GetLaneThroughputs(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	shares, err := redisQueue.GetLaneShares(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*pb.LaneStatus, 0, len(queue.Lanes))
+	for _, lane := range queue.Lanes {
+		statuses = append(statuses, &pb.LaneStatus{
+			Lane:        string(lane),
+			QueueLength: int32(lengths[lane]),
+			Share:       int32(shares[lane]),
+			DequeueRate: throughputs[lane].DequeueRate,
+		})
+	}
+	return statuses, nil
+}
+
 // CancelQueueItem cancels a queue item with authorization check
 func (s *BookingWorkerService) CancelQueueItem(ctx context.Context, req *pb.CancelQueueItemRequest) (*pb.CancelQueueItemResponse, error) {
 	// Validate request
@@ -217,6 +420,208 @@ func (s *BookingWorkerService) CancelQueueItem(ctx context.Context, req *pb.Canc
 	}, nil
 }
 
+// ReleaseConfirmedSeats stops a cancelled booking's seats counting towards the user's seat limit
+func (s *BookingWorkerService) ReleaseConfirmedSeats(ctx context.Context, req *pb.ReleaseConfirmedSeatsRequest) (*pb.ReleaseConfirmedSeatsResponse, error) {
+	// Validate request
+	if req.EventId == "" {
+		return nil, status.Error(codes.InvalidArgument, "event_id is required")
+	}
+	if req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if req.BookingId == "" {
+		return nil, status.Error(codes.InvalidArgument, "booking_id is required")
+	}
+
+	redisQueue, ok := s.queue.(*queue.RedisQueueManager)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "seat limits require the Redis queue")
+	}
+
+	released, err := redisQueue.ReleaseConfirmedSeats(ctx, req.EventId, req.UserId, req.BookingId)
+	if err != nil {
+		s.logger.Error("Failed to release confirmed seats",
+			zap.String("event_id", req.EventId),
+			zap.String("booking_id", req.BookingId),
+			zap.Error(err),
+		)
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to release confirmed seats: %v", err))
+	}
+
+	s.logger.Info("Confirmed seats released",
+		zap.String("event_id", req.EventId),
+		zap.String("user_id", req.UserId),
+		zap.String("booking_id", req.BookingId),
+		zap.Bool("released", released),
+	)
+
+	message := "Confirmed seats released"
+	if !released {
+		message = "No confirmed seats recorded for booking"
+	}
+	return &pb.ReleaseConfirmedSeatsResponse{
+		Success:  true,
+		Released: released,
+		Message:  message,
+	}, nil
+}
+
+// SetEventSchedule sets the weighted fair scheduling parameters of an event queue
+func (s *BookingWorkerService) SetEventSchedule(ctx context.Context, req *pb.SetEventScheduleRequest) (*pb.SetEventScheduleResponse, error) {
+	// Validate request
+	if req.EventId == "" {
+		return nil, status.Error(codes.InvalidArgument, "event_id is required")
+	}
+	if !req.UseDefaults && req.Weight <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "weight must be positive")
+	}
+	if req.MaxConcurrency < 0 {
+		return nil, status.Error(codes.InvalidArgument, "max_concurrency cannot be negative")
+	}
+	if req.EndsAt < 0 {
+		return nil, status.Error(codes.InvalidArgument, "ends_at cannot be negative")
+	}
+	laneShares := make(map[queue.Lane]int, len(req.LaneShares))
+	for lane, share := range req.LaneShares {
+		if !queue.IsValidLane(lane) {
+			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("unknown lane: %s", lane))
+		}
+		if share < 0 {
+			return nil, status.Error(codes.InvalidArgument, "lane shares cannot be negative")
+		}
+		laneShares[queue.Lane(lane)] = int(share)
+	}
+
+	redisQueue, ok := s.queue.(*queue.RedisQueueManager)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "event scheduling requires the Redis queue")
+	}
+
+	var err error
+	if req.UseDefaults {
+		err = redisQueue.ClearEventSchedule(ctx, req.EventId)
+	} else {
+		err = redisQueue.SetEventSchedule(ctx, req.EventId, int(req.Weight), int(req.MaxConcurrency), laneShares)
+	}
+	if err == nil && req.EndsAt > 0 {
+		err = redisQueue.SetEventEndsAt(ctx, req.EventId, time.Unix(req.EndsAt, 0))
+	}
+	if err != nil {
+		s.logger.Error("Failed to set event schedule",
+			zap.String("event_id", req.EventId),
+			zap.Error(err),
+		)
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to set event schedule: %v", err))
+	}
+
+	schedule, err := redisQueue.GetEventSchedule(ctx, req.EventId)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to get event schedule: %v", err))
+	}
+
+	s.logger.Info("Event schedule updated",
+		zap.String("event_id", req.EventId),
+		zap.Int("weight", schedule.Weight),
+		zap.Int("max_concurrency", schedule.MaxConcurrency),
+		zap.Any("lane_shares", schedule.LaneShares),
+	)
+
+	return &pb.SetEventScheduleResponse{
+		Success:  true,
+		Schedule: eventScheduleToProto(schedule),
+		Message:  "Event schedule updated",
+	}, nil
+}
+
+// GetEventSchedule gets the weighted fair scheduling parameters of an event queue
+func (s *BookingWorkerService) GetEventSchedule(ctx context.Context, req *pb.GetEventScheduleRequest) (*pb.GetEventScheduleResponse, error) {
+	// Validate request
+	if req.EventId == "" {
+		return nil, status.Error(codes.InvalidArgument, "event_id is required")
+	}
+
+	redisQueue, ok := s.queue.(*queue.RedisQueueManager)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "event scheduling requires the Redis queue")
+	}
+
+	schedule, err := redisQueue.GetEventSchedule(ctx, req.EventId)
+	if err != nil {
+		s.logger.Error("Failed to get event schedule",
+			zap.String("event_id", req.EventId),
+			zap.Error(err),
+		)
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to get event schedule: %v", err))
+	}
+
+	return &pb.GetEventScheduleResponse{
+		Success:  true,
+		Schedule: eventScheduleToProto(schedule),
+		Message:  "Event schedule retrieved",
+	}, nil
+}
+
+// UpdateLaneEntitlements grants and revokes users' access to a priority lane of an event
+func (s *BookingWorkerService) UpdateLaneEntitlements(ctx context.Context, req *pb.UpdateLaneEntitlementsRequest) (*pb.UpdateLaneEntitlementsResponse, error) {
+	// Validate request
+	if req.EventId == "" {
+		return nil, status.Error(codes.InvalidArgument, "event_id is required")
+	}
+	if !queue.IsValidLane(req.Lane) || queue.Lane(req.Lane) == queue.LaneStandard {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("lane must be a priority lane: %s", req.Lane))
+	}
+	if len(req.GrantUserIds) == 0 && len(req.RevokeUserIds) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "grant_user_ids or revoke_user_ids is required")
+	}
+
+	redisQueue, ok := s.queue.(*queue.RedisQueueManager)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "priority lanes require the Redis queue")
+	}
+
+	if err := redisQueue.UpdateLaneEntitlements(ctx, req.EventId, queue.Lane(req.Lane), req.GrantUserIds, req.RevokeUserIds); err != nil {
+		s.logger.Error("Failed to update lane entitlements",
+			zap.String("event_id", req.EventId),
+			zap.String("lane", req.Lane),
+			zap.Error(err),
+		)
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to update lane entitlements: %v", err))
+	}
+
+	s.logger.Info("Lane entitlements updated",
+		zap.String("event_id", req.EventId),
+		zap.String("lane", req.Lane),
+		zap.Int("granted", len(req.GrantUserIds)),
+		zap.Int("revoked", len(req.RevokeUserIds)),
+		zap.String("requested_by", req.RequestedBy),
+	)
+
+	return &pb.UpdateLaneEntitlementsResponse{
+		Success: true,
+		Message: "Lane entitlements updated",
+	}, nil
+}
+
+// eventScheduleToProto converts an event schedule to its protobuf representation
+func eventScheduleToProto(schedule *queue.EventSchedule) *pb.EventSchedule {
+	laneShares := make(map[string]int32, len(schedule.LaneShares))
+	for lane, share := range schedule.LaneShares {
+		laneShares[string(lane)] = int32(share)
+	}
+
+	pbSchedule := &pb.EventSchedule{
+		EventId:        schedule.EventID,
+		Weight:         int32(schedule.Weight),
+		MaxConcurrency: int32(schedule.MaxConcurrency),
+		InFlight:       int32(schedule.InFlight),
+		LaneShares:     laneShares,
+	}
+	if !schedule.EndsAt.IsZero() {
+		pbSchedule.EndsAt = schedule.EndsAt.Unix()
+	}
+	return pbSchedule
+}
+
 // Health performs a health check
 func (s *BookingWorkerService) Health(ctx context.Context, req *pb.HealthRequest) (*pb.HealthResponse, error) {
 	// Check Redis connection by getting queue length
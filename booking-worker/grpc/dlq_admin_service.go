@@ -0,0 +1,307 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	pb "booking-worker/internal/protos/booking_worker"
+	"booking-worker/internal/queue"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultDLQPageSize = 50
+	maxDLQBulkSize     = 1000
+)
+
+// ListDLQItems lists dead letter queue items matching a filter, with the number of items
+// matching it in total
+func (s *BookingWorkerService) ListDLQItems(ctx context.Context, req *pb.ListDLQItemsRequest) (*pb.ListDLQItemsResponse, error) {
+	dlq, err := s.dlqManager()
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := dlqFilterFromProto(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := clampDLQLimit(req.Limit, defaultDLQPageSize)
+	items, total, err := dlq.ListDLQItems(ctx, filter, int64(req.Offset), int64(limit))
+	if err != nil {
+		s.logger.Error("Failed to list DLQ items", zap.Error(err))
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to list DLQ items: %v", err))
+	}
+
+	pbItems := make([]*pb.DLQItem, 0, len(items))
+	for _, item := range items {
+		pbItems = append(pbItems, dlqItemToProto(item))
+	}
+
+	return &pb.ListDLQItemsResponse{
+		Success: true,
+		Items:   pbItems,
+		Total:   int32(total),
+		Message: "DLQ items retrieved",
+	}, nil
+}
+
+// GetDLQItem returns a single dead letter queue item
+func (s *BookingWorkerService) GetDLQItem(ctx context.Context, req *pb.GetDLQItemRequest) (*pb.GetDLQItemResponse, error) {
+	if req.DlqItemId == "" {
+		return nil, status.Error(codes.InvalidArgument, "dlq_item_id is required")
+	}
+
+	dlq, err := s.dlqManager()
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := dlq.GetDLQItem(ctx, req.DlqItemId)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("DLQ item not found: %v", err))
+	}
+
+	return &pb.GetDLQItemResponse{
+		Success: true,
+		Item:    dlqItemToProto(item),
+		Message: "DLQ item retrieved",
+	}, nil
+}
+
+// RequeueDLQItems moves DLQ items back to their event queues, by ID or by filter
+func (s *BookingWorkerService) RequeueDLQItems(ctx context.Context, req *pb.RequeueDLQItemsRequest) (*pb.RequeueDLQItemsResponse, error) {
+	dlq, err := s.dlqManager()
+	if err != nil {
+		return nil, err
+	}
+
+	items, notFound, err := s.selectDLQItems(ctx, dlq, req.DlqItemIds, req.Filter, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.RequeueDLQItemsResponse{Success: true, NotFoundIds: notFound}
+	for _, item := range items {
+		if req.DryRun {
+			resp.RequeuedIds = append(resp.RequeuedIds, item.ID)
+			continue
+		}
+
+		if req.ResetExpiry && item.OriginalItem != nil {
+			item.OriginalItem.ExpiresAt = time.Now().Add(time.Duration(s.config.Queue.TimeoutSeconds) * time.Second)
+		}
+
+		if err := dlq.RequeueItem(ctx, item, s.queue); err != nil {
+			s.logger.Warn("Failed to requeue DLQ item",
+				zap.String("dlq_item_id", item.ID),
+				zap.Error(err),
+			)
+			resp.FailedIds = append(resp.FailedIds, item.ID)
+			continue
+		}
+		resp.RequeuedIds = append(resp.RequeuedIds, item.ID)
+	}
+
+	resp.RequeuedCount = int32(len(resp.RequeuedIds))
+	resp.FailedCount = int32(len(resp.FailedIds))
+	resp.Message = fmt.Sprintf("Requeued %d DLQ items, %d failed, %d not found", resp.RequeuedCount, resp.FailedCount, len(notFound))
+	if req.DryRun {
+		resp.Message = fmt.Sprintf("Dry run: %d DLQ items would be requeued, %d not found", resp.RequeuedCount, len(notFound))
+	}
+
+	s.logger.Info("DLQ requeue requested",
+		zap.String("requested_by", req.RequestedBy),
+		zap.Bool("dry_run", req.DryRun),
+		zap.Int32("requeued", resp.RequeuedCount),
+		zap.Int32("failed", resp.FailedCount),
+	)
+
+	return resp, nil
+}
+
+// PurgeDLQItems permanently discards DLQ items, by ID or by filter
+func (s *BookingWorkerService) PurgeDLQItems(ctx context.Context, req *pb.PurgeDLQItemsRequest) (*pb.PurgeDLQItemsResponse, error) {
+	dlq, err := s.dlqManager()
+	if err != nil {
+		return nil, err
+	}
+
+	items, notFound, err := s.selectDLQItems(ctx, dlq, req.DlqItemIds, req.Filter, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.PurgeDLQItemsResponse{Success: true, NotFoundIds: notFound}
+	for _, item := range items {
+		if !req.DryRun {
+			if err := dlq.PurgeFromDLQ(ctx, item.ID); err != nil {
+				// Removed by someone else since it was selected
+				if errors.Is(err, queue.ErrDLQItemNotFound) {
+					resp.NotFoundIds = append(resp.NotFoundIds, item.ID)
+					continue
+				}
+				s.logger.Warn("Failed to purge DLQ item",
+					zap.String("dlq_item_id", item.ID),
+					zap.Error(err),
+				)
+				continue
+			}
+		}
+		resp.PurgedIds = append(resp.PurgedIds, item.ID)
+	}
+
+	resp.PurgedCount = int32(len(resp.PurgedIds))
+	resp.Message = fmt.Sprintf("Purged %d DLQ items, %d not found", resp.PurgedCount, len(resp.NotFoundIds))
+	if req.DryRun {
+		resp.Message = fmt.Sprintf("Dry run: %d DLQ items would be purged, %d not found", resp.PurgedCount, len(resp.NotFoundIds))
+	}
+
+	s.logger.Info("DLQ purge requested",
+		zap.String("requested_by", req.RequestedBy),
+		zap.Bool("dry_run", req.DryRun),
+		zap.Int32("purged", resp.PurgedCount),
+	)
+
+	return resp, nil
+}
+
+// GetDLQStats returns dead letter queue statistics
+func (s *BookingWorkerService) GetDLQStats(ctx context.Context, req *pb.GetDLQStatsRequest) (*pb.GetDLQStatsResponse, error) {
+	dlq, err := s.dlqManager()
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := dlq.GetDLQStats(ctx)
+	if err != nil {
+		s.logger.Error("Failed to get DLQ stats", zap.Error(err))
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to get DLQ stats: %v", err))
+	}
+
+	return &pb.GetDLQStatsResponse{
+		Success:              true,
+		TotalItems:           stats.TotalItems,
+		ItemsByReason:        stats.ItemsByReason,
+		OldestItemAgeSeconds: int64(stats.OldestItemAge.Seconds()),
+		ProcessedToday:       stats.ProcessedToday,
+		Message:              "DLQ stats retrieved",
+	}, nil
+}
+
+// dlqManager returns the DLQ manager or an Unavailable error when the queue has no DLQ
+func (s *BookingWorkerService) dlqManager() (*queue.DLQManager, error) {
+	dlq := s.processor.GetDLQManager()
+	if dlq == nil {
+		return nil, status.Error(codes.Unavailable, "dead letter queue is not available")
+	}
+	return dlq, nil
+}
+
+// selectDLQItems resolves the target items of a bulk operation from explicit IDs or a
+// filter. Explicit IDs that are not in the DLQ are returned separately.
+func (s *BookingWorkerService) selectDLQItems(ctx context.Context, dlq *queue.DLQManager, ids []string, pbFilter *pb.DLQFilter, limit int32) ([]*queue.DLQItem, []string, error) {
+	if len(ids) > 0 {
+		if len(ids) > maxDLQBulkSize {
+			return nil, nil, status.Error(codes.InvalidArgument, fmt.Sprintf("at most %d dlq_item_ids are allowed", maxDLQBulkSize))
+		}
+
+		items := make([]*queue.DLQItem, 0, len(ids))
+		var notFound []string
+		for _, id := range ids {
+			item, err := dlq.GetDLQItem(ctx, id)
+			if errors.Is(err, queue.ErrDLQItemNotFound) {
+				notFound = append(notFound, id)
+				continue
+			}
+			if err != nil {
+				s.logger.Error("Failed to get DLQ item", zap.String("dlq_item_id", id), zap.Error(err))
+				return nil, nil, status.Error(codes.Internal, fmt.Sprintf("failed to get DLQ item %s: %v", id, err))
+			}
+			items = append(items, item)
+		}
+		return items, notFound, nil
+	}
+
+	filter, err := dlqFilterFromProto(pbFilter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Refuse to act on the whole DLQ without an explicit filter
+	if filter.MatchesAll() {
+		return nil, nil, status.Error(codes.InvalidArgument, "dlq_item_ids or a non-empty filter is required")
+	}
+
+	items, err := dlq.FindDLQItems(ctx, filter, 0, int64(clampDLQLimit(limit, maxDLQBulkSize)))
+	if err != nil {
+		s.logger.Error("Failed to find DLQ items", zap.Error(err))
+		return nil, nil, status.Error(codes.Internal, fmt.Sprintf("failed to find DLQ items: %v", err))
+	}
+	return items, nil, nil
+}
+
+// dlqFilterFromProto converts and validates a protobuf DLQ filter
+func dlqFilterFromProto(f *pb.DLQFilter) (queue.DLQFilter, error) {
+	var filter queue.DLQFilter
+	if f == nil {
+		return filter, nil
+	}
+
+	if f.Reason != "" {
+		reason := queue.DLQReason(f.Reason)
+		if !queue.IsValidDLQReason(reason) {
+			return filter, status.Error(codes.InvalidArgument, fmt.Sprintf("invalid reason: %s", f.Reason))
+		}
+		filter.Reason = reason
+	}
+	filter.EventID = f.EventId
+	filter.UserID = f.UserId
+	if f.FailedBefore > 0 {
+		filter.FailedBefore = time.Unix(f.FailedBefore, 0)
+	}
+	return filter, nil
+}
+
+// clampDLQLimit applies the default and upper bound to a requested page size
+func clampDLQLimit(limit int32, defaultLimit int) int {
+	if limit <= 0 {
+		return defaultLimit
+	}
+	if int(limit) > maxDLQBulkSize {
+		return maxDLQBulkSize
+	}
+	return int(limit)
+}
+
+// dlqItemToProto converts a DLQ item to its protobuf representation
+func dlqItemToProto(item *queue.DLQItem) *pb.DLQItem {
+	pbItem := &pb.DLQItem{
+		Id:            item.ID,
+		Reason:        string(item.Reason),
+		ErrorMessage:  item.ErrorMessage,
+		RetryCount:    int32(item.RetryCount),
+		FailedAt:      item.FailedAt.Unix(),
+		LastAttemptAt: item.LastAttemptAt.Unix(),
+		Metadata:      item.Metadata,
+	}
+
+	if original := item.OriginalItem; original != nil {
+		pbItem.QueueItemId = original.ID
+		pbItem.EventId = original.EventID
+		pbItem.UserId = original.UserID
+		pbItem.SeatNumbers = original.SeatNumbers
+		pbItem.SeatCount = int32(original.SeatCount)
+		pbItem.TotalAmount = original.TotalAmount
+		pbItem.Currency = original.Currency
+		pbItem.ItemMetadata = original.Metadata
+	}
+
+	return pbItem
+}
@@ -6,6 +6,7 @@ import (
 
 	"booking-worker/config"
 	realtimepb "booking-worker/internal/protos/realtime"
+	"booking-worker/internal/queue"
 
 	"grpctls"
 
@@ -64,37 +65,92 @@ func NewRealtimeServiceClient(cfg *config.Config, logger *zap.Logger) (*Realtime
 	}, nil
 }
 
-// NotifyQueuePosition notifies a client of their queue position
-func (c *RealtimeServiceClient) NotifyQueuePosition(ctx context.Context, userID, eventID string, position int) error {
+// QueuePositionUpdate is one user's position in a batched notification
+type QueuePositionUpdate struct {
+	UserID   string
+	Position int
+	Wait     *queue.WaitEstimate
+}
+
+// NotifyQueuePositionBatch notifies many clients of one event lane of their queue positions in a single call
+func (c *RealtimeServiceClient) NotifyQueuePositionBatch(ctx context.Context, eventID, lane string, totalInQueue int, updates []QueuePositionUpdate) (int, error) {
+	if c.client == nil {
+		c.logger.Debug("Realtime service not connected, skipping queue position batch",
+			zap.String("event_id", eventID),
+			zap.Int("updates", len(updates)),
+		)
+		return 0, nil
+	}
+
+	req := &realtimepb.NotifyQueuePositionBatchRequest{
+		EventId:      eventID,
+		Lane:         lane,
+		TotalInQueue: int32(totalInQueue),
+		Updates:      make([]*realtimepb.QueuePositionUpdate, 0, len(updates)),
+	}
+	for _, update := range updates {
+		pbUpdate := &realtimepb.QueuePositionUpdate{
+			UserId:   update.UserID,
+			Position: int32(update.Position),
+		}
+		if update.Wait != nil {
+			pbUpdate.EstimatedWaitSeconds = int32(update.Wait.Seconds)
+			pbUpdate.EstimatedWaitMinSeconds = int32(update.Wait.MinSeconds)
+			pbUpdate.EstimatedWaitMaxSeconds = int32(update.Wait.MaxSeconds)
+		}
+		req.Updates = append(req.Updates, pbUpdate)
+	}
+
+	resp, err := c.client.NotifyQueuePositionBatch(ctx, req)
+	if err != nil {
+		c.logger.Error("Failed to notify queue position batch",
+			zap.String("event_id", eventID),
+			zap.Int("updates", len(updates)),
+			zap.Error(err),
+		)
+		return 0, err
+	}
+
+	c.logger.Debug("Queue position batch sent",
+		zap.String("event_id", eventID),
+		zap.Int("updates", len(updates)),
+		zap.Int32("delivered", resp.GetDeliveredCount()),
+	)
+
+	return int(resp.GetDeliveredCount()), nil
+}
+
+// NotifyBookingExpired notifies a client that their waiting booking request expired
+func (c *RealtimeServiceClient) NotifyBookingExpired(ctx context.Context, userID, eventID, itemID string, expiredAt time.Time) error {
 	if c.client == nil {
-		c.logger.Debug("Realtime service not connected, skipping queue position notification",
+		c.logger.Debug("Realtime service not connected, skipping expiry notification",
 			zap.String("user_id", userID),
-			zap.Int("position", position),
+			zap.String("queue_item_id", itemID),
 		)
 		return nil
 	}
 
-	req := &realtimepb.NotifyQueuePositionRequest{
-		UserId:   userID,
-		EventId:  eventID,
-		Position: int32(position),
+	req := &realtimepb.NotifyBookingExpiredRequest{
+		UserId:      userID,
+		EventId:     eventID,
+		QueueItemId: itemID,
+		ExpiredAt:   expiredAt.Unix(),
 	}
 
-	resp, err := c.client.NotifyQueuePosition(ctx, req)
+	resp, err := c.client.NotifyBookingExpired(ctx, req)
 	if err != nil {
-		c.logger.Error("Failed to notify queue position",
+		c.logger.Error("Failed to notify booking expired",
 			zap.String("user_id", userID),
-			zap.String("event_id", eventID),
-			zap.Int("position", position),
+			zap.String("queue_item_id", itemID),
 			zap.Error(err),
 		)
 		return err
 	}
 
-	c.logger.Info("Queue position notification sent",
+	c.logger.Info("Booking expired notification sent",
 		zap.String("user_id", userID),
 		zap.String("event_id", eventID),
-		zap.Int("position", position),
+		zap.String("queue_item_id", itemID),
 		zap.Bool("delivered", resp.GetDelivered()),
 	)
 
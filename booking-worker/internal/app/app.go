@@ -27,6 +27,8 @@ type App struct {
 	processor *worker.Processor
 	grpcServer *grpc.Server
 	metrics   *metrics.Exporter
+	dlqReplayer *queue.DLQReplayer
+	positionPublisher *worker.PositionPublisher
 }
 
 // NewApp creates a new application instance
@@ -76,7 +78,7 @@ func (a *App) Initialize() error {
 
 	// Initialize timeout handler (if queue is Redis-based)
 	if redisQueue, ok := a.queue.(*queue.RedisQueueManager); ok {
-		timeoutHandler, err := queue.NewTimeoutHandler(a.config, redisQueue.GetClient(), a.logger)
+		timeoutHandler, err := queue.NewTimeoutHandler(a.config, redisQueue.GetClient(), processor.GetRealtimeClient(), a.logger)
 		if err != nil {
 			return fmt.Errorf("failed to initialize timeout handler: %w", err)
 		}
@@ -84,6 +86,20 @@ func (a *App) Initialize() error {
 		// Start timeout handler in background
 		go timeoutHandler.Start()
 		a.logger.Info("Timeout handler initialized")
+
+		// Initialize DLQ replayer
+		if dlqManager != nil && a.config.DLQ.ReplayEnabled {
+			a.dlqReplayer = queue.NewDLQReplayer(a.config, dlqManager, a.queue, redisQueue.GetClient(), processor.GetBreakerStates(), a.logger)
+			go a.dlqReplayer.Start()
+			a.logger.Info("DLQ replayer initialized")
+		}
+
+		// Initialize queue position publisher
+		if a.config.Publisher.Enabled {
+			a.positionPublisher = worker.NewPositionPublisher(a.config, redisQueue, processor.GetRealtimeClient(), a.logger)
+			go a.positionPublisher.Start()
+			a.logger.Info("Queue position publisher initialized")
+		}
 	}
 
 	// Initialize gRPC server
@@ -124,6 +140,16 @@ func (a *App) Run() error {
 func (a *App) Shutdown() error {
 	a.logger.Info("Shutting down application...")
 
+	// Stop DLQ replayer
+	if a.dlqReplayer != nil {
+		a.dlqReplayer.Stop()
+	}
+
+	// Stop queue position publisher
+	if a.positionPublisher != nil {
+		a.positionPublisher.Stop()
+	}
+
 	// Stop processor
 	if a.processor != nil {
 		if err := a.processor.Stop(); err != nil {
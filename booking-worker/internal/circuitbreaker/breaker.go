@@ -102,6 +102,11 @@ func (cb *CircuitBreaker) IsOpen() bool {
 	return cb.breaker.State() == gobreaker.StateOpen
 }
 
+// IsClosed returns true if the circuit breaker is closed
+func (cb *CircuitBreaker) IsClosed() bool {
+	return cb.breaker.State() == gobreaker.StateClosed
+}
+
 // Counts returns the internal counts of the circuit breaker
 func (cb *CircuitBreaker) Counts() gobreaker.Counts {
 	return cb.breaker.Counts()
@@ -0,0 +1,235 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Redis key prefix for a user's seat holds per event (HASH itemID -> "seats|admitted at ms")
+	userHoldsKeyPrefix = "booking:user-holds:"
+	// Redis key prefix for client idempotency keys (STRING -> queue item ID)
+	idempotencyKeyPrefix = "booking:idempotency:"
+	// Redis key prefix for a user's booked seats per event (HASH bookingID -> seats)
+	userConfirmedKeyPrefix = "booking:user-confirmed:"
+	// Redis key for event end times (HASH eventID -> unix seconds); booked seats are forgotten then
+	eventEndsAtKey = "booking:event-ends-at"
+	// How long a hold counts as active before its item shows up in the queue
+	admissionGracePeriod = 10 * time.Second
+)
+
+// AdmissionDecision is the outcome of the enqueue policy check
+type AdmissionDecision string
+
+const (
+	AdmissionAdmitted          AdmissionDecision = "admitted"
+	AdmissionDuplicate         AdmissionDecision = "duplicate"
+	AdmissionActiveItemExists  AdmissionDecision = "active_item_exists"
+	AdmissionSeatLimitExceeded AdmissionDecision = "seat_limit_exceeded"
+)
+
+// Admission is the result of Admit
+type Admission struct {
+	Decision AdmissionDecision
+	// ItemID is the admitted item, the original item for a duplicate, or the blocking active item
+	ItemID string
+	// HeldSeats is the number of seats the user already holds or has booked for the event
+	HeldSeats int
+}
+
+// admitScript applies the enqueue policies for one user and event and records the hold.
+// A hold stays active while its item is queued (item-event map) or leased, or for a short
+// grace period after admission so the hold is not pruned before Enqueue lands.
+// Seats of the user's booked items count towards the seat limit until they are released.
+//
+// KEYS[1] user holds hash, KEYS[2] idempotency key, KEYS[3] item-event map, KEYS[4] lease zset,
+// KEYS[5] user confirmed seats hash
+// ARGV[1] item ID, ARGV[2] requested seats, ARGV[3] one active item (1/0),
+// ARGV[4] max seats (0 = unlimited), ARGV[5] hold TTL (s), ARGV[6] has idempotency key (1/0),
+// ARGV[7] now (ms), ARGV[8] grace period (ms)
+var admitScript = redis.NewScript(`
+	if ARGV[6] == '1' then
+		local existing = redis.call('GET', KEYS[2])
+		if existing then
+			return {'duplicate', existing, '0'}
+		end
+	end
+
+	local holds = redis.call('HGETALL', KEYS[1])
+	local active_id = ''
+	local held = 0
+	for i = 1, #holds, 2 do
+		local id = holds[i]
+		local seats, admitted_at = string.match(holds[i + 1], '(%d+)|(%d+)')
+		local alive = tonumber(admitted_at) + tonumber(ARGV[8]) > tonumber(ARGV[7])
+			or redis.call('HEXISTS', KEYS[3], id) == 1
+			or redis.call('ZSCORE', KEYS[4], id)
+		if alive then
+			active_id = id
+			held = held + tonumber(seats)
+		else
+			redis.call('HDEL', KEYS[1], id)
+		end
+	end
+
+	if ARGV[3] == '1' and active_id ~= '' then
+		return {'active_item_exists', active_id, tostring(held)}
+	end
+
+	local confirmed = redis.call('HVALS', KEYS[5])
+	for i = 1, #confirmed do
+		held = held + tonumber(confirmed[i])
+	end
+
+	local max_seats = tonumber(ARGV[4])
+	if max_seats > 0 and held + tonumber(ARGV[2]) > max_seats then
+		return {'seat_limit_exceeded', '', tostring(held)}
+	end
+
+	redis.call('HSET', KEYS[1], ARGV[1], ARGV[2] .. '|' .. ARGV[7])
+	redis.call('EXPIRE', KEYS[1], ARGV[5])
+	if ARGV[6] == '1' then
+		redis.call('SET', KEYS[2], ARGV[1], 'EX', ARGV[5])
+	end
+	return {'admitted', ARGV[1], tostring(held)}
+`)
+
+// Admit checks the enqueue policies for an item and reserves its seats for the user.
+// Call ReleaseAdmission if the item is admitted but the enqueue fails.
+func (r *RedisQueueManager) Admit(ctx context.Context, item *QueueItem, idempotencyKey string) (*Admission, error) {
+	hasKey := "0"
+	if idempotencyKey != "" {
+		hasKey = "1"
+	}
+	oneActive := "0"
+	if r.config.Queue.OneActiveItemPerUser {
+		oneActive = "1"
+	}
+
+	result, err := admitScript.Run(ctx, r.client,
+		[]string{userHoldsKey(item.EventID, item.UserID), idempotencyKeyFor(item.EventID, item.UserID, idempotencyKey), itemEventMapKey, leasesKey,
+			userConfirmedKey(item.EventID, item.UserID)},
+		item.ID,
+		RequestedSeats(item),
+		oneActive,
+		r.config.Queue.MaxSeatsPerUser,
+		r.admissionTTL(),
+		hasKey,
+		time.Now().UnixMilli(),
+		admissionGracePeriod.Milliseconds(),
+	).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run admission check: %w", err)
+	}
+	if len(result) != 3 {
+		return nil, fmt.Errorf("unexpected admission result length: %d", len(result))
+	}
+
+	held, _ := strconv.Atoi(result[2])
+	return &Admission{
+		Decision:  AdmissionDecision(result[0]),
+		ItemID:    result[1],
+		HeldSeats: held,
+	}, nil
+}
+
+// ReleaseAdmission drops the hold and idempotency key recorded by Admit
+func (r *RedisQueueManager) ReleaseAdmission(ctx context.Context, item *QueueItem, idempotencyKey string) error {
+	pipe := r.client.Pipeline()
+	pipe.HDel(ctx, userHoldsKey(item.EventID, item.UserID), item.ID)
+	if idempotencyKey != "" {
+		pipe.Del(ctx, idempotencyKeyFor(item.EventID, item.UserID, idempotencyKey))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to release admission: %w", err)
+	}
+	return nil
+}
+
+// confirmSeatsScript moves an item's hold to the user's booked seats, which expire at the
+// event's end or after the fallback TTL when the end is unknown
+//
+// KEYS[1] user holds hash, KEYS[2] user confirmed seats hash, KEYS[3] event end times hash
+// ARGV[1] item ID, ARGV[2] booking ID, ARGV[3] seats, ARGV[4] event ID, ARGV[5] fallback TTL (s),
+// ARGV[6] now (s)
+var confirmSeatsScript = redis.NewScript(`
+	redis.call('HDEL', KEYS[1], ARGV[1])
+	redis.call('HSET', KEYS[2], ARGV[2], ARGV[3])
+
+	local ends_at = tonumber(redis.call('HGET', KEYS[3], ARGV[4]) or '0')
+	if ends_at > tonumber(ARGV[6]) then
+		redis.call('EXPIREAT', KEYS[2], ends_at)
+	else
+		redis.call('EXPIRE', KEYS[2], ARGV[5])
+	end
+	return 1
+`)
+
+// ConfirmSeats records an item's seats as booked so they keep counting towards the
+// user's seat limit after the item leaves the queue. Recording the same booking twice is a no-op.
+func (r *RedisQueueManager) ConfirmSeats(ctx context.Context, item *QueueItem, bookingID string) error {
+	err := confirmSeatsScript.Run(ctx, r.client,
+		[]string{userHoldsKey(item.EventID, item.UserID), userConfirmedKey(item.EventID, item.UserID), eventEndsAtKey},
+		item.ID,
+		bookingID,
+		RequestedSeats(item),
+		item.EventID,
+		int(r.config.Queue.ConfirmedSeatsTTL.Seconds()),
+		time.Now().Unix(),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("failed to confirm seats: %w", err)
+	}
+	return nil
+}
+
+// ReleaseConfirmedSeats stops a cancelled booking's seats counting towards the user's
+// seat limit. It reports false if the booking was not recorded or already released.
+func (r *RedisQueueManager) ReleaseConfirmedSeats(ctx context.Context, eventID, userID, bookingID string) (bool, error) {
+	removed, err := r.client.HDel(ctx, userConfirmedKey(eventID, userID), bookingID).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to release confirmed seats: %w", err)
+	}
+	return removed > 0, nil
+}
+
+// SetEventEndsAt records when an event ends; users' booked seats for it are forgotten then
+func (r *RedisQueueManager) SetEventEndsAt(ctx context.Context, eventID string, endsAt time.Time) error {
+	if err := r.client.HSet(ctx, eventEndsAtKey, eventID, endsAt.Unix()).Err(); err != nil {
+		return fmt.Errorf("failed to set event end: %w", err)
+	}
+	return nil
+}
+
+// RequestedSeats returns how many seats an item asks for
+func RequestedSeats(item *QueueItem) int {
+	if len(item.SeatNumbers) > 0 {
+		return len(item.SeatNumbers)
+	}
+	return item.SeatCount
+}
+
+// admissionTTL covers an item's whole stay in the queue plus one processing lease
+func (r *RedisQueueManager) admissionTTL() int {
+	return r.config.Queue.TimeoutSeconds + int(r.config.Queue.VisibilityTimeout.Seconds())
+}
+
+// userHoldsKey returns the key of a user's seat holds for an event
+func userHoldsKey(eventID, userID string) string {
+	return fmt.Sprintf("%s%s:%s", userHoldsKeyPrefix, eventID, userID)
+}
+
+// userConfirmedKey returns the key of a user's booked seats for an event
+func userConfirmedKey(eventID, userID string) string {
+	return fmt.Sprintf("%s%s:%s", userConfirmedKeyPrefix, eventID, userID)
+}
+
+// idempotencyKeyFor scopes a client idempotency key to its user and event, so a key
+// reused for another event does not return that event's item
+func idempotencyKeyFor(eventID, userID, key string) string {
+	return fmt.Sprintf("%s%s:%s:%s", idempotencyKeyPrefix, eventID, userID, key)
+}
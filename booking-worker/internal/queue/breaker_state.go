@@ -0,0 +1,87 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/sony/gobreaker"
+)
+
+const (
+	// Redis key for each replica's booking-service breaker state while it is not closed
+	// (HASH replicaID -> "state|reported at ms|open until ms")
+	breakerStatesKey = "booking:breaker-states:booking-service"
+	// Reports older than this belong to replicas that are gone and are ignored
+	breakerReportMaxAge = 10 * time.Minute
+)
+
+// BreakerStates shares the booking-service breaker state of every replica through Redis,
+// so decisions that affect all replicas, such as DLQ replay, do not rest on one replica's view
+type BreakerStates struct {
+	client    *redis.Client
+	replicaID string
+}
+
+// NewBreakerStates creates the shared breaker state for this replica
+func NewBreakerStates(client *redis.Client) *BreakerStates {
+	return &BreakerStates{
+		client:    client,
+		replicaID: uuid.New().String(),
+	}
+}
+
+// Report records this replica's breaker state; an open breaker stays open for openFor
+func (b *BreakerStates) Report(ctx context.Context, state gobreaker.State, openFor time.Duration) error {
+	var err error
+	if state == gobreaker.StateClosed {
+		err = b.client.HDel(ctx, breakerStatesKey, b.replicaID).Err()
+	} else {
+		now := time.Now()
+		var openUntil int64
+		if state == gobreaker.StateOpen {
+			openUntil = now.Add(openFor).UnixMilli()
+		}
+		value := fmt.Sprintf("%s|%d|%d", state.String(), now.UnixMilli(), openUntil)
+		err = b.client.HSet(ctx, breakerStatesKey, b.replicaID, value).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to report breaker state: %w", err)
+	}
+	return nil
+}
+
+// Shared returns the breaker state across replicas: open while any replica's breaker is
+// open, half-open while any replica is still probing or past its open timeout, closed otherwise
+func (b *BreakerStates) Shared(ctx context.Context) (gobreaker.State, error) {
+	reports, err := b.client.HGetAll(ctx, breakerStatesKey).Result()
+	if err != nil {
+		return gobreaker.StateClosed, fmt.Errorf("failed to get breaker states: %w", err)
+	}
+
+	now := time.Now().UnixMilli()
+	shared := gobreaker.StateClosed
+	for replicaID, report := range reports {
+		parts := strings.Split(report, "|")
+		if len(parts) != 3 {
+			continue
+		}
+		reportedAt, _ := strconv.ParseInt(parts[1], 10, 64)
+		openUntil, _ := strconv.ParseInt(parts[2], 10, 64)
+
+		if now-reportedAt > breakerReportMaxAge.Milliseconds() {
+			b.client.HDel(ctx, breakerStatesKey, replicaID)
+			continue
+		}
+		if parts[0] == gobreaker.StateOpen.String() && openUntil > now {
+			return gobreaker.StateOpen, nil
+		}
+		// An open breaker past its timeout lets probes through on its next call
+		shared = gobreaker.StateHalfOpen
+	}
+	return shared, nil
+}
@@ -3,6 +3,7 @@ package queue
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -17,6 +18,37 @@ const (
 	dlqStatsKey  = "booking:dlq:stats"
 )
 
+// ErrDLQItemNotFound is returned when an item is not, or no longer, in the DLQ
+var ErrDLQItemNotFound = errors.New("DLQ item not found")
+
+// ErrRequeueNotAdmitted is returned when a DLQ item fails the enqueue policies on requeue
+var ErrRequeueNotAdmitted = errors.New("DLQ item not admitted")
+
+// removeDLQItemScript removes an item from the DLQ and takes it off the total and its
+// reason's count in one step, so concurrent removals of the same item count it once. The
+// reason count is left alone when the item data expired already.
+//
+// KEYS[1] DLQ list, KEYS[2] item data, KEYS[3] stats hash
+// ARGV[1] item ID, ARGV[2] stats field to count the removal in, empty for none
+var removeDLQItemScript = redis.NewScript(`
+	if redis.call('ZREM', KEYS[1], ARGV[1]) == 0 then
+		return 0
+	end
+	local data = redis.call('GET', KEYS[2])
+	redis.call('DEL', KEYS[2])
+	redis.call('HINCRBY', KEYS[3], 'total_items', -1)
+	if data then
+		local ok, item = pcall(cjson.decode, data)
+		if ok and type(item.reason) == 'string' and item.reason ~= '' then
+			redis.call('HINCRBY', KEYS[3], item.reason, -1)
+		end
+	end
+	if ARGV[2] ~= '' then
+		redis.call('HINCRBY', KEYS[3], ARGV[2], 1)
+	end
+	return 1
+`)
+
 // DLQReason represents the reason for DLQ placement
 type DLQReason string
 
@@ -29,6 +61,16 @@ const (
 	DLQReasonUnknown        DLQReason = "unknown"
 )
 
+// IsValidDLQReason reports whether reason is a known DLQ reason
+func IsValidDLQReason(reason DLQReason) bool {
+	switch reason {
+	case DLQReasonMaxRetries, DLQReasonCircuitOpen, DLQReasonInvalidData,
+		DLQReasonServiceError, DLQReasonTimeout, DLQReasonUnknown:
+		return true
+	}
+	return false
+}
+
 // DLQItem represents an item in the dead letter queue
 type DLQItem struct {
 	ID            string            `json:"id"`
@@ -49,6 +91,40 @@ type DLQStats struct {
 	ProcessedToday  int64            `json:"processed_today"`
 }
 
+// DLQFilter selects DLQ items for listing and bulk operations.
+// Zero-valued fields match every item.
+type DLQFilter struct {
+	Reason       DLQReason
+	EventID      string
+	UserID       string
+	FailedBefore time.Time
+}
+
+// MatchesAll reports whether the filter has no criteria and so matches every item
+func (f DLQFilter) MatchesAll() bool {
+	return f.Reason == "" && f.EventID == "" && f.UserID == "" && f.FailedBefore.IsZero()
+}
+
+// Matches reports whether a DLQ item satisfies the filter
+func (f DLQFilter) Matches(item *DLQItem) bool {
+	if f.Reason != "" && item.Reason != f.Reason {
+		return false
+	}
+	if item.OriginalItem == nil {
+		return f.EventID == "" && f.UserID == ""
+	}
+	if f.EventID != "" && item.OriginalItem.EventID != f.EventID {
+		return false
+	}
+	if f.UserID != "" && item.OriginalItem.UserID != f.UserID {
+		return false
+	}
+	if !f.FailedBefore.IsZero() && !item.FailedAt.Before(f.FailedBefore) {
+		return false
+	}
+	return true
+}
+
 // DLQManager manages the dead letter queue
 type DLQManager struct {
 	client *redis.Client
@@ -120,7 +196,7 @@ func (d *DLQManager) AddToDLQ(ctx context.Context, item *QueueItem, reason DLQRe
 func (d *DLQManager) GetDLQItem(ctx context.Context, dlqItemID string) (*DLQItem, error) {
 	data, err := d.client.Get(ctx, dlqKeyPrefix+dlqItemID).Result()
 	if err == redis.Nil {
-		return nil, fmt.Errorf("DLQ item not found: %s", dlqItemID)
+		return nil, fmt.Errorf("%w: %s", ErrDLQItemNotFound, dlqItemID)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get DLQ item: %w", err)
@@ -142,59 +218,173 @@ func (d *DLQManager) GetDLQItems(ctx context.Context, offset, limit int64) ([]*D
 		return nil, fmt.Errorf("failed to get DLQ item IDs: %w", err)
 	}
 
-	items := make([]*DLQItem, 0, len(ids))
-	for _, id := range ids {
-		item, err := d.GetDLQItem(ctx, id)
-		if err != nil {
-			d.logger.Warn("Failed to get DLQ item", zap.String("id", id), zap.Error(err))
+	return d.loadDLQItems(ctx, ids)
+}
+
+// loadDLQItems fetches the data of DLQ items in one MGET, in the order of ids. Items whose
+// data expired or cannot be decoded are left out.
+func (d *DLQManager) loadDLQItems(ctx context.Context, ids []string) ([]*DLQItem, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = dlqKeyPrefix + id
+	}
+	values, err := d.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DLQ items: %w", err)
+	}
+
+	items := make([]*DLQItem, 0, len(values))
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var item DLQItem
+		if err := json.Unmarshal([]byte(data), &item); err != nil {
+			d.logger.Warn("Failed to unmarshal DLQ item", zap.String("id", ids[i]), zap.Error(err))
 			continue
 		}
-		items = append(items, item)
+		items = append(items, &item)
 	}
 
 	return items, nil
 }
 
-// GetDLQItemsByReason retrieves DLQ items filtered by reason
-func (d *DLQManager) GetDLQItemsByReason(ctx context.Context, reason DLQReason, limit int64) ([]*DLQItem, error) {
-	allItems, err := d.GetDLQItems(ctx, 0, 1000) // Get more and filter
+// GetDLQItemsByReason scans the DLQ oldest-first and returns up to limit items of reason
+// that eligible accepts; a nil eligible accepts every item of reason
+func (d *DLQManager) GetDLQItemsByReason(ctx context.Context, reason DLQReason, limit int64, eligible func(*DLQItem) bool) ([]*DLQItem, error) {
+	return d.scanDLQ(ctx, func(item *DLQItem) bool {
+		return item.Reason == reason && (eligible == nil || eligible(item))
+	}, 0, limit)
+}
+
+// FindDLQItems scans the DLQ oldest-first and returns up to limit items matching the filter,
+// skipping the first offset matches
+func (d *DLQManager) FindDLQItems(ctx context.Context, filter DLQFilter, offset, limit int64) ([]*DLQItem, error) {
+	return d.scanDLQ(ctx, filter.Matches, offset, limit)
+}
+
+// ListDLQItems returns up to limit items matching the filter, oldest first and skipping the
+// first offset matches, with the number of items matching it in total. Unless the filter
+// matches every item, the page and the count come from one scan of the whole DLQ.
+func (d *DLQManager) ListDLQItems(ctx context.Context, filter DLQFilter, offset, limit int64) ([]*DLQItem, int64, error) {
+	if filter.MatchesAll() {
+		items, err := d.GetDLQItems(ctx, offset, limit)
+		if err != nil {
+			return nil, 0, err
+		}
+		total, err := d.GetDLQDepth(ctx)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get DLQ depth: %w", err)
+		}
+		return items, total, nil
+	}
+
+	items := make([]*DLQItem, 0)
+	var total int64
+	err := d.walkDLQ(ctx, func(item *DLQItem) bool {
+		if !filter.Matches(item) {
+			return true
+		}
+		if total >= offset && int64(len(items)) < limit {
+			items = append(items, item)
+		}
+		total++
+		return true
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// scanDLQ pages through the whole DLQ oldest-first and returns up to limit items match
+// accepts, skipping the first offset of them
+func (d *DLQManager) scanDLQ(ctx context.Context, match func(*DLQItem) bool, offset, limit int64) ([]*DLQItem, error) {
+	matched := make([]*DLQItem, 0)
+	var skipped int64
+	err := d.walkDLQ(ctx, func(item *DLQItem) bool {
+		if !match(item) {
+			return true
+		}
+		if skipped < offset {
+			skipped++
+			return true
+		}
+		matched = append(matched, item)
+		return int64(len(matched)) < limit
+	})
 	if err != nil {
 		return nil, err
 	}
+	return matched, nil
+}
 
-	filtered := make([]*DLQItem, 0)
-	for _, item := range allItems {
-		if item.Reason == reason {
-			filtered = append(filtered, item)
-			if int64(len(filtered)) >= limit {
-				break
+// walkDLQ pages through the whole DLQ oldest-first, loading each page with one MGET, and
+// calls visit for every item until it returns false
+func (d *DLQManager) walkDLQ(ctx context.Context, visit func(*DLQItem) bool) error {
+	const pageSize = 500
+
+	for start := int64(0); ; start += pageSize {
+		ids, err := d.client.ZRange(ctx, dlqListKey, start, start+pageSize-1).Result()
+		if err != nil {
+			return fmt.Errorf("failed to get DLQ item IDs: %w", err)
+		}
+
+		items, err := d.loadDLQItems(ctx, ids)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if !visit(item) {
+				return nil
 			}
 		}
+
+		if len(ids) < pageSize {
+			return nil
+		}
 	}
+}
 
-	return filtered, nil
+// removeDLQItem removes an item from the DLQ and its stats, counting the removal in
+// countAs unless it is empty. It reports whether the item was in the DLQ.
+func (d *DLQManager) removeDLQItem(ctx context.Context, dlqItemID, countAs string) (bool, error) {
+	removed, err := removeDLQItemScript.Run(ctx, d.client,
+		[]string{dlqListKey, dlqKeyPrefix + dlqItemID, dlqStatsKey},
+		dlqItemID, countAs,
+	).Int()
+	if err != nil {
+		return false, err
+	}
+	return removed == 1, nil
 }
 
 // RemoveFromDLQ removes an item from the DLQ (after successful reprocessing)
 func (d *DLQManager) RemoveFromDLQ(ctx context.Context, dlqItemID string) error {
-	pipe := d.client.Pipeline()
-
-	// Remove from sorted set
-	pipe.ZRem(ctx, dlqListKey, dlqItemID)
-
-	// Delete item data
-	pipe.Del(ctx, dlqKeyPrefix+dlqItemID)
+	if _, err := d.removeDLQItem(ctx, dlqItemID, "processed_today"); err != nil {
+		return fmt.Errorf("failed to remove item from DLQ: %w", err)
+	}
 
-	// Update stats
-	pipe.HIncrBy(ctx, dlqStatsKey, "total_items", -1)
-	pipe.HIncrBy(ctx, dlqStatsKey, "processed_today", 1)
+	d.logger.Info("Removed item from DLQ", zap.String("dlq_item_id", dlqItemID))
+	return nil
+}
 
-	_, err := pipe.Exec(ctx)
+// PurgeFromDLQ discards an item from the DLQ without reprocessing it
+func (d *DLQManager) PurgeFromDLQ(ctx context.Context, dlqItemID string) error {
+	removed, err := d.removeDLQItem(ctx, dlqItemID, "")
 	if err != nil {
-		return fmt.Errorf("failed to remove item from DLQ: %w", err)
+		return fmt.Errorf("failed to purge item from DLQ: %w", err)
+	}
+	if !removed {
+		return fmt.Errorf("%w: %s", ErrDLQItemNotFound, dlqItemID)
 	}
 
-	d.logger.Info("Removed item from DLQ", zap.String("dlq_item_id", dlqItemID))
+	d.logger.Info("Purged item from DLQ", zap.String("dlq_item_id", dlqItemID))
 	return nil
 }
 
@@ -206,21 +396,47 @@ func (d *DLQManager) RequeueFromDLQ(ctx context.Context, dlqItemID string, mainQ
 		return err
 	}
 
+	return d.RequeueItem(ctx, dlqItem, mainQueue)
+}
+
+// RequeueItem enqueues an already-loaded DLQ item's original item and removes it from the DLQ.
+// The item passes the same enqueue policies as a new one, so a user who has queued or booked
+// again since it failed gets no extra item or seats.
+func (d *DLQManager) RequeueItem(ctx context.Context, dlqItem *DLQItem, mainQueue QueueManager) error {
+	redisQueue, hasPolicies := mainQueue.(*RedisQueueManager)
+	if hasPolicies {
+		admission, err := redisQueue.Admit(ctx, dlqItem.OriginalItem, "")
+		if err != nil {
+			return fmt.Errorf("failed to requeue item: %w", err)
+		}
+		if admission.Decision != AdmissionAdmitted {
+			return fmt.Errorf("%w: %s", ErrRequeueNotAdmitted, admission.Decision)
+		}
+	}
+
 	// Enqueue to main queue
 	if err := mainQueue.Enqueue(ctx, dlqItem.OriginalItem); err != nil {
+		if hasPolicies {
+			if releaseErr := redisQueue.ReleaseAdmission(ctx, dlqItem.OriginalItem, ""); releaseErr != nil {
+				d.logger.Warn("Failed to release admission",
+					zap.String("dlq_item_id", dlqItem.ID),
+					zap.Error(releaseErr),
+				)
+			}
+		}
 		return fmt.Errorf("failed to requeue item: %w", err)
 	}
 
 	// Remove from DLQ
-	if err := d.RemoveFromDLQ(ctx, dlqItemID); err != nil {
+	if err := d.RemoveFromDLQ(ctx, dlqItem.ID); err != nil {
 		d.logger.Warn("Item requeued but failed to remove from DLQ",
-			zap.String("dlq_item_id", dlqItemID),
+			zap.String("dlq_item_id", dlqItem.ID),
 			zap.Error(err),
 		)
 	}
 
 	d.logger.Info("Requeued item from DLQ",
-		zap.String("dlq_item_id", dlqItemID),
+		zap.String("dlq_item_id", dlqItem.ID),
 		zap.String("original_item_id", dlqItem.OriginalItem.ID),
 	)
 
@@ -293,22 +509,21 @@ func (d *DLQManager) CleanupOldItems(ctx context.Context, retentionDays int) (in
 	}
 
 	// Delete old items
-	pipe := d.client.Pipeline()
+	var cleaned int64
 	for _, id := range oldIDs {
-		pipe.ZRem(ctx, dlqListKey, id)
-		pipe.Del(ctx, dlqKeyPrefix+id)
-	}
-	pipe.HIncrBy(ctx, dlqStatsKey, "total_items", -int64(len(oldIDs)))
-
-	_, err = pipe.Exec(ctx)
-	if err != nil {
-		return 0, fmt.Errorf("failed to cleanup old DLQ items: %w", err)
+		removed, err := d.removeDLQItem(ctx, id, "")
+		if err != nil {
+			return cleaned, fmt.Errorf("failed to cleanup old DLQ items: %w", err)
+		}
+		if removed {
+			cleaned++
+		}
 	}
 
 	d.logger.Info("Cleaned up old DLQ items",
-		zap.Int("count", len(oldIDs)),
+		zap.Int64("count", cleaned),
 		zap.Int("retention_days", retentionDays),
 	)
 
-	return int64(len(oldIDs)), nil
+	return cleaned, nil
 }
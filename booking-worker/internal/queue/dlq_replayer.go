@@ -0,0 +1,243 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"booking-worker/config"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/sony/gobreaker"
+	"go.uber.org/zap"
+)
+
+const (
+	// Redis key for the replay cycle lock, so only one replica replays at a time
+	dlqReplayLockKey = "booking:dlq:replay-lock"
+	// QueueItem metadata key counting how many times an item was replayed from the DLQ
+	replayCountMetadataKey = "dlq_replay_count"
+)
+
+// releaseLockScript deletes a lock only while it still holds the caller's token, so a cycle
+// that outlived its lock does not release another replica's
+//
+// KEYS[1] lock key
+// ARGV[1] lock token
+var releaseLockScript = redis.NewScript(`
+	if redis.call('GET', KEYS[1]) == ARGV[1] then
+		return redis.call('DEL', KEYS[1])
+	end
+	return 0
+`)
+
+// ReplayPolicy controls automatic replay for a single DLQ reason
+type ReplayPolicy struct {
+	Reason DLQReason
+	// MaxReplays caps how many times an item may be replayed (0 disables replay)
+	MaxReplays int
+	// RespectBreaker holds replay while the booking-service breaker of any replica is open
+	// and replays only a probe batch while one is half-open
+	RespectBreaker bool
+}
+
+// DLQReplayer periodically re-enqueues DLQ items according to per-reason policies
+type DLQReplayer struct {
+	dlq      *DLQManager
+	queue    QueueManager
+	client   *redis.Client
+	breakers *BreakerStates
+	config   *config.Config
+	policies []ReplayPolicy
+	logger   *zap.Logger
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// NewDLQReplayer creates a new DLQ replayer
+func NewDLQReplayer(cfg *config.Config, dlq *DLQManager, q QueueManager, client *redis.Client, breakers *BreakerStates, logger *zap.Logger) *DLQReplayer {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &DLQReplayer{
+		dlq:      dlq,
+		queue:    q,
+		client:   client,
+		breakers: breakers,
+		config:   cfg,
+		policies: DefaultReplayPolicies(cfg),
+		logger:   logger,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// DefaultReplayPolicies builds the replay policies from configuration.
+// invalid_data and unknown items are never replayed automatically.
+func DefaultReplayPolicies(cfg *config.Config) []ReplayPolicy {
+	return []ReplayPolicy{
+		{Reason: DLQReasonCircuitOpen, MaxReplays: cfg.DLQ.CircuitOpenMaxReplays, RespectBreaker: true},
+		{Reason: DLQReasonTimeout, MaxReplays: cfg.DLQ.TimeoutMaxReplays, RespectBreaker: true},
+		{Reason: DLQReasonServiceError, MaxReplays: cfg.DLQ.ServiceErrorMaxReplays, RespectBreaker: true},
+		{Reason: DLQReasonMaxRetries, MaxReplays: cfg.DLQ.MaxRetriesMaxReplays, RespectBreaker: true},
+	}
+}
+
+// Start starts the replay loop
+func (r *DLQReplayer) Start() {
+	r.logger.Info("Starting DLQ replayer",
+		zap.Duration("replay_interval", r.config.DLQ.ReplayInterval),
+		zap.Int("batch_size", r.config.DLQ.ReplayBatchSize),
+	)
+
+	ticker := time.NewTicker(r.config.DLQ.ReplayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			r.logger.Info("DLQ replayer stopping")
+			return
+		case <-ticker.C:
+			r.replayOnce()
+		}
+	}
+}
+
+// Stop stops the replay loop
+func (r *DLQReplayer) Stop() {
+	r.cancel()
+}
+
+// replayOnce runs a single replay cycle across all policies
+func (r *DLQReplayer) replayOnce() {
+	// Only one replica replays per cycle; the lock expires with the interval in case this
+	// replica dies mid-cycle and is released as soon as the cycle ends
+	token := uuid.New().String()
+	acquired, err := r.client.SetNX(r.ctx, dlqReplayLockKey, token, r.config.DLQ.ReplayInterval).Result()
+	if err != nil {
+		r.logger.Warn("Failed to acquire DLQ replay lock", zap.Error(err))
+		return
+	}
+	if !acquired {
+		return
+	}
+	defer func() {
+		if err := releaseLockScript.Run(context.Background(), r.client, []string{dlqReplayLockKey}, token).Err(); err != nil {
+			r.logger.Warn("Failed to release DLQ replay lock", zap.Error(err))
+		}
+	}()
+
+	// Every replica's breaker guards the same booking-service, so replay follows the shared state.
+	// A half-open breaker lets a few calls through to test the service; replay no more than
+	// that across the policies respecting it.
+	breakerState := gobreaker.StateClosed
+	if r.breakers != nil {
+		if breakerState, err = r.breakers.Shared(r.ctx); err != nil {
+			r.logger.Warn("Failed to get shared breaker state, skipping DLQ replay", zap.Error(err))
+			return
+		}
+	}
+	halfOpen := breakerState == gobreaker.StateHalfOpen
+	budget := r.config.DLQ.ReplayProbeBatchSize
+
+	totalReplayed := 0
+	for _, policy := range r.policies {
+		limit := r.config.DLQ.ReplayBatchSize
+		if halfOpen && policy.RespectBreaker {
+			if budget <= 0 {
+				continue
+			}
+			limit = budget
+		}
+
+		replayed := r.replayReason(policy, limit, breakerState)
+		totalReplayed += replayed
+		if halfOpen && policy.RespectBreaker {
+			budget -= replayed
+		}
+	}
+
+	if totalReplayed > 0 {
+		r.logger.Info("Replayed items from DLQ", zap.Int("count", totalReplayed), zap.Bool("probe", halfOpen))
+	}
+}
+
+// replayReason replays up to limit eligible items for a single policy and returns the count replayed
+func (r *DLQReplayer) replayReason(policy ReplayPolicy, limit int, breakerState gobreaker.State) int {
+	if policy.MaxReplays <= 0 {
+		return 0
+	}
+
+	if policy.RespectBreaker && breakerState == gobreaker.StateOpen {
+		r.logger.Debug("Skipping DLQ replay, booking-service breaker open",
+			zap.String("reason", string(policy.Reason)),
+		)
+		return 0
+	}
+
+	// Items too fresh or out of replays stay in the DLQ; skip them rather than let them fill the batch
+	failedBefore := time.Now().Add(-r.config.DLQ.ReplayMinAge)
+	items, err := r.dlq.GetDLQItemsByReason(r.ctx, policy.Reason, int64(limit), func(item *DLQItem) bool {
+		return item.OriginalItem != nil && item.FailedAt.Before(failedBefore) && replayCount(item.OriginalItem) < policy.MaxReplays
+	})
+	if err != nil {
+		r.logger.Warn("Failed to get DLQ items for replay",
+			zap.String("reason", string(policy.Reason)),
+			zap.Error(err),
+		)
+		return 0
+	}
+
+	replayed := 0
+	for _, item := range items {
+		replays := replayCount(item.OriginalItem)
+
+		// Record the attempt and give the item a fresh expiry so the timeout handler keeps it
+		if item.OriginalItem.Metadata == nil {
+			item.OriginalItem.Metadata = make(map[string]string)
+		}
+		item.OriginalItem.Metadata[replayCountMetadataKey] = strconv.Itoa(replays + 1)
+		item.OriginalItem.ExpiresAt = time.Now().Add(time.Duration(r.config.Queue.TimeoutSeconds) * time.Second)
+
+		if err := r.dlq.RequeueItem(r.ctx, item, r.queue); errors.Is(err, ErrRequeueNotAdmitted) {
+			// The user's queue item or seat limit blocks it for now; it stays in the DLQ
+			r.logger.Debug("DLQ item not admitted for replay",
+				zap.String("dlq_item_id", item.ID),
+				zap.String("reason", string(policy.Reason)),
+				zap.Error(err),
+			)
+			continue
+		} else if err != nil {
+			r.logger.Warn("Failed to replay DLQ item",
+				zap.String("dlq_item_id", item.ID),
+				zap.String("reason", string(policy.Reason)),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		replayed++
+		r.logger.Debug("Replayed DLQ item",
+			zap.String("dlq_item_id", item.ID),
+			zap.String("original_item_id", item.OriginalItem.ID),
+			zap.String("reason", string(policy.Reason)),
+			zap.Int("replay_count", replays+1),
+		)
+	}
+
+	return replayed
+}
+
+// replayCount returns how many times an item has already been replayed from the DLQ
+func replayCount(item *QueueItem) int {
+	if item.Metadata == nil {
+		return 0
+	}
+	count, err := strconv.Atoi(item.Metadata[replayCountMetadataKey])
+	if err != nil {
+		return 0
+	}
+	return count
+}
@@ -15,8 +15,12 @@ type QueueItem struct {
 	TotalAmount float64
 	Currency  string
 	Metadata  map[string]string
+	// Lane is the item's priority lane within its event queue (empty = standard)
+	Lane      Lane
 	EnqueuedAt time.Time
 	ExpiresAt  time.Time
+	// LeaseToken identifies the dequeue holding the item's lease; it is never stored with the item
+	LeaseToken string `json:"-"`
 }
 
 // QueueManager defines the interface for queue operations
@@ -27,6 +31,12 @@ type QueueManager interface {
 	// Dequeue removes and returns an item from the queue (blocking)
 	Dequeue(ctx context.Context, timeout time.Duration) (*QueueItem, error)
 
+	// Heartbeat extends the lease of a dequeued item while it is being processed
+	Heartbeat(ctx context.Context, item *QueueItem) error
+
+	// Ack ends the lease of a dequeued item once processing is finished
+	Ack(ctx context.Context, item *QueueItem) error
+
 	// GetPosition returns the position of an item in the queue
 	GetPosition(ctx context.Context, itemID string) (int, error)
 
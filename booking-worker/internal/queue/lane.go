@@ -0,0 +1,255 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Redis key prefix for per-event lane share overrides (HASH lane -> share)
+	laneSharesKeyPrefix = "booking:scheduler:lane-shares:"
+	// Redis key prefix for per-event lane pass values (HASH lane -> pass, "vtime" -> virtual time)
+	lanePassKeyPrefix = "booking:scheduler:lane-pass:"
+	// Redis key prefix for the users entitled to a priority lane of an event (SET userID)
+	laneEntitlementsKeyPrefix = "booking:lane-entitlements:"
+)
+
+// Lane is a priority class within an event queue
+type Lane string
+
+const (
+	LaneStandard      Lane = "standard"
+	LanePresale       Lane = "presale"
+	LaneVIP           Lane = "vip"
+	LaneAccessibility Lane = "accessibility"
+)
+
+// Lanes lists every lane, highest priority first. The scheduler breaks ties in this order.
+var Lanes = []Lane{LanePresale, LaneVIP, LaneAccessibility, LaneStandard}
+
+// IsValidLane reports whether lane names a known lane
+func IsValidLane(lane string) bool {
+	for _, l := range Lanes {
+		if string(l) == lane {
+			return true
+		}
+	}
+	return false
+}
+
+// ItemLane returns the lane of an item; items without one wait in the standard lane
+func ItemLane(item *QueueItem) Lane {
+	if item.Lane == "" {
+		return LaneStandard
+	}
+	return item.Lane
+}
+
+// IsEntitledToLane reports whether a user may wait in a lane of an event. Everyone may use
+// the standard lane; the others are limited to users granted through UpdateLaneEntitlements.
+func (r *RedisQueueManager) IsEntitledToLane(ctx context.Context, eventID, userID string, lane Lane) (bool, error) {
+	if lane == LaneStandard || lane == "" {
+		return true, nil
+	}
+	entitled, err := r.client.SIsMember(ctx, laneEntitlementsKey(eventID, lane), userID).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check lane entitlement: %w", err)
+	}
+	return entitled, nil
+}
+
+// UpdateLaneEntitlements grants and revokes users' access to a priority lane of an event
+func (r *RedisQueueManager) UpdateLaneEntitlements(ctx context.Context, eventID string, lane Lane, grant, revoke []string) error {
+	if !IsValidLane(string(lane)) {
+		return fmt.Errorf("unknown lane: %s", lane)
+	}
+	if lane == LaneStandard {
+		return fmt.Errorf("the standard lane is open to everyone")
+	}
+
+	key := laneEntitlementsKey(eventID, lane)
+	pipe := r.client.TxPipeline()
+	if len(grant) > 0 {
+		pipe.SAdd(ctx, key, stringsToArgs(grant)...)
+	}
+	if len(revoke) > 0 {
+		pipe.SRem(ctx, key, stringsToArgs(revoke)...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to update lane entitlements: %w", err)
+	}
+	return nil
+}
+
+// laneEntitlementsKey returns the key of the users entitled to one lane of an event
+func laneEntitlementsKey(eventID string, lane Lane) string {
+	return laneEntitlementsKeyPrefix + eventID + ":" + string(lane)
+}
+
+// stringsToArgs converts strings to Redis command arguments
+func stringsToArgs(values []string) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return args
+}
+
+// laneSuffix is appended to an event's queue and position keys. The standard lane keeps
+// the plain per-event keys so queues written before lanes existed are still served.
+func laneSuffix(lane Lane) string {
+	if lane == LaneStandard || lane == "" {
+		return ""
+	}
+	return ":" + string(lane)
+}
+
+// laneQueueKey returns the list key of one lane of an event queue
+func laneQueueKey(eventID string, lane Lane) string {
+	return eventQueueKeyPrefix + eventID + laneSuffix(lane)
+}
+
+// lanePositionKey returns the position tracking key of one lane of an event queue
+func lanePositionKey(eventID string, lane Lane) string {
+	return positionKeyPrefix + eventID + laneSuffix(lane)
+}
+
+// laneNames joins the lane names for Lua scripts, highest priority first
+func laneNames() string {
+	names := make([]string, len(Lanes))
+	for i, lane := range Lanes {
+		names[i] = string(lane)
+	}
+	return strings.Join(names, ",")
+}
+
+// defaultLaneShares joins the configured lane shares as "lane:share" pairs for Lua scripts
+func (r *RedisQueueManager) defaultLaneShares() string {
+	pairs := make([]string, len(Lanes))
+	for i, lane := range Lanes {
+		pairs[i] = fmt.Sprintf("%s:%d", lane, r.defaultLaneShare(lane))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// defaultLaneShare returns the configured share of a lane; lanes left out of the config get 1
+func (r *RedisQueueManager) defaultLaneShare(lane Lane) int {
+	share, ok := r.config.Queue.LaneShares[string(lane)]
+	if !ok || share < 0 {
+		return 1
+	}
+	return share
+}
+
+// GetLaneShares returns the effective lane shares of an event
+func (r *RedisQueueManager) GetLaneShares(ctx context.Context, eventID string) (map[Lane]int, error) {
+	overrides, err := r.client.HGetAll(ctx, laneSharesKeyPrefix+eventID).Result()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get lane shares: %w", err)
+	}
+
+	shares := make(map[Lane]int, len(Lanes))
+	for _, lane := range Lanes {
+		shares[lane] = r.defaultLaneShare(lane)
+		if value, ok := overrides[string(lane)]; ok {
+			if share, err := strconv.Atoi(value); err == nil {
+				shares[lane] = share
+			}
+		}
+	}
+	return shares, nil
+}
+
+// GetLaneLengths returns the number of waiting items in each lane of an event queue
+func (r *RedisQueueManager) GetLaneLengths(ctx context.Context, eventID string) (map[Lane]int, error) {
+	pipe := r.client.Pipeline()
+	cmds := make(map[Lane]*redis.IntCmd, len(Lanes))
+	for _, lane := range Lanes {
+		cmds[lane] = pipe.LLen(ctx, laneQueueKey(eventID, lane))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get lane lengths: %w", err)
+	}
+
+	lengths := make(map[Lane]int, len(Lanes))
+	for lane, cmd := range cmds {
+		lengths[lane] = int(cmd.Val())
+	}
+	return lengths, nil
+}
+
+// findItemLane returns the lane an item waits in and its 0-based rank within that lane
+func (r *RedisQueueManager) findItemLane(ctx context.Context, eventID, itemID string) (Lane, int64, error) {
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(Lanes))
+	for i, lane := range Lanes {
+		cmds[i] = pipe.ZRank(ctx, lanePositionKey(eventID, lane), itemID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return "", 0, fmt.Errorf("failed to get rank: %w", err)
+	}
+
+	for i, cmd := range cmds {
+		if rank, err := cmd.Result(); err == nil {
+			return Lanes[i], rank, nil
+		}
+	}
+	return "", 0, fmt.Errorf("item not found in queue")
+}
+
+// laneShareOfDequeues is the fraction of an event's dequeues a lane currently gets:
+// its share over the shares of all lanes that have waiting items
+func laneShareOfDequeues(lane Lane, shares, lengths map[Lane]int) float64 {
+	if shares[lane] <= 0 {
+		return 0
+	}
+
+	total := 0
+	for _, l := range Lanes {
+		if l == lane || lengths[l] > 0 {
+			total += shares[l]
+		}
+	}
+	return float64(shares[lane]) / float64(total)
+}
+
+// GetLaneThroughputs splits an event's measured throughput across its lanes by their current
+// share of dequeues, and returns the lane lengths it was based on
+func (r *RedisQueueManager) GetLaneThroughputs(ctx context.Context, eventID string) (map[Lane]*Throughput, map[Lane]int, error) {
+	throughput, err := r.GetThroughput(ctx, eventID)
+	if err != nil {
+		return nil, nil, err
+	}
+	shares, err := r.GetLaneShares(ctx, eventID)
+	if err != nil {
+		return nil, nil, err
+	}
+	lengths, err := r.GetLaneLengths(ctx, eventID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	throughputs := make(map[Lane]*Throughput, len(Lanes))
+	for _, lane := range Lanes {
+		throughputs[lane] = throughput.ForShare(laneShareOfDequeues(lane, shares, lengths))
+	}
+	return throughputs, lengths, nil
+}
+
+// EstimateLaneWait estimates the wait of the item at position within a lane, using the
+// event's measured dequeue rate scaled down to the lane's share of it
+func (r *RedisQueueManager) EstimateLaneWait(ctx context.Context, eventID string, lane Lane, position int) (*WaitEstimate, error) {
+	if position <= 0 {
+		return &WaitEstimate{Measured: true}, nil
+	}
+
+	throughputs, _, err := r.GetLaneThroughputs(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	return throughputs[lane].EstimateWait(position), nil
+}
@@ -0,0 +1,233 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// Redis key for leases of items being processed (ZSET itemID -> lease deadline ms)
+	leasesKey = "booking:processing:leases"
+	// Redis key for payloads of items being processed (HASH itemID -> item JSON)
+	processingItemsKey = "booking:processing:items"
+	// Redis key for the token of each live lease (HASH itemID -> token of the dequeue holding it)
+	leaseTokensKey = "booking:processing:tokens"
+	// Max expired leases reaped per cycle
+	leaseReapBatchSize = 100
+)
+
+// ErrLeaseLost is returned when an item's lease expired and it was returned to its queue
+var ErrLeaseLost = errors.New("queue item lease lost")
+
+// heartbeatScript extends a lease only while the caller still holds it, keeping both lease
+// sets in sync. A lease that was reaped, and maybe handed to another worker since, is not
+// the caller's any more.
+//
+// KEYS[1] lease zset, KEYS[2] event in-flight zset, KEYS[3] lease tokens hash
+// ARGV[1] item ID, ARGV[2] new lease deadline (ms), ARGV[3] lease token
+var heartbeatScript = redis.NewScript(`
+	if redis.call('HGET', KEYS[3], ARGV[1]) ~= ARGV[3] or not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+		return 0
+	end
+	redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+	redis.call('ZADD', KEYS[2], ARGV[2], ARGV[1])
+	return 1
+`)
+
+// ackScript ends a lease only while the caller still holds it, freeing its event's
+// concurrency slot. An ack for a lease since reaped leaves the new holder's lease alone.
+//
+// KEYS[1] lease zset, KEYS[2] processing items hash, KEYS[3] event in-flight zset,
+// KEYS[4] lease tokens hash
+// ARGV[1] item ID, ARGV[2] lease token
+var ackScript = redis.NewScript(`
+	if redis.call('HGET', KEYS[4], ARGV[1]) ~= ARGV[2] then
+		return 0
+	end
+	redis.call('ZREM', KEYS[1], ARGV[1])
+	redis.call('HDEL', KEYS[2], ARGV[1])
+	redis.call('ZREM', KEYS[3], ARGV[1])
+	redis.call('HDEL', KEYS[4], ARGV[1])
+	return 1
+`)
+
+// reapLeaseScript ends an expired lease and, when requeue is set, puts the item back at the
+// head of its event queue with its tracking entries restored. It does nothing if a heartbeat
+// extended the lease after the reaper read it.
+//
+// KEYS[1] lease zset, KEYS[2] processing items hash, KEYS[3] active queues set,
+// KEYS[4] item-event map, KEYS[5] queue signal, KEYS[6] expiry index, KEYS[7] lease tokens hash
+// ARGV[1] item ID, ARGV[2] now (ms), ARGV[3] event ID, ARGV[4] requeue (1/0),
+// ARGV[5] item expiry (unix seconds), ARGV[6] lane queue key, ARGV[7] in-flight key prefix,
+// ARGV[8] lane position key, ARGV[9] max signal list index
+var reapLeaseScript = redis.NewScript(`
+	local deadline = redis.call('ZSCORE', KEYS[1], ARGV[1])
+	if not deadline or tonumber(deadline) > tonumber(ARGV[2]) then
+		return 0
+	end
+
+	local data = redis.call('HGET', KEYS[2], ARGV[1])
+	redis.call('ZREM', KEYS[1], ARGV[1])
+	redis.call('HDEL', KEYS[2], ARGV[1])
+	redis.call('HDEL', KEYS[7], ARGV[1])
+	redis.call('ZREM', ARGV[7] .. ARGV[3], ARGV[1])
+	if not data or ARGV[4] ~= '1' then
+		return 2
+	end
+
+	-- RPOP takes from the right, so RPUSH makes the item the next one served in its lane
+	redis.call('RPUSH', ARGV[6], data)
+
+	local position_key = ARGV[8]
+	local first = redis.call('ZRANGE', position_key, 0, 0, 'WITHSCORES')
+	local score = 0
+	if #first > 0 then
+		score = tonumber(first[2]) - 1
+	end
+	redis.call('ZADD', position_key, score, ARGV[1])
+	redis.call('ZADD', KEYS[6], ARGV[5], ARGV[1])
+	redis.call('HSET', KEYS[4], ARGV[1], ARGV[3])
+	redis.call('SADD', KEYS[3], ARGV[3])
+
+	redis.call('LPUSH', KEYS[5], 1)
+	redis.call('LTRIM', KEYS[5], 0, ARGV[9])
+	return 1
+`)
+
+// Heartbeat extends the lease of a dequeued item by the visibility timeout.
+// Returns ErrLeaseLost if the lease expired and the item was reaped, even if the item was
+// dequeued again since.
+func (r *RedisQueueManager) Heartbeat(ctx context.Context, item *QueueItem) error {
+	deadline := time.Now().Add(r.config.Queue.VisibilityTimeout).UnixMilli()
+	extended, err := heartbeatScript.Run(ctx, r.client,
+		[]string{leasesKey, inFlightKeyPrefix + item.EventID, leaseTokensKey},
+		item.ID, deadline, item.LeaseToken,
+	).Int()
+	if err != nil {
+		return fmt.Errorf("failed to extend lease: %w", err)
+	}
+	if extended == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Ack ends the lease of a dequeued item and frees its event's concurrency slot.
+// Returns ErrLeaseLost, and changes nothing, if the lease is no longer the caller's.
+func (r *RedisQueueManager) Ack(ctx context.Context, item *QueueItem) error {
+	acked, err := ackScript.Run(ctx, r.client,
+		[]string{leasesKey, processingItemsKey, inFlightKeyPrefix + item.EventID, leaseTokensKey},
+		item.ID, item.LeaseToken,
+	).Int()
+	if err != nil {
+		return fmt.Errorf("failed to ack item: %w", err)
+	}
+	if acked == 0 {
+		return ErrLeaseLost
+	}
+
+	pipe := r.client.Pipeline()
+	r.recordThroughput(ctx, pipe, item.EventID, throughputCompleted)
+	// A freed slot may unblock a capped event with waiting items
+	signalWorkers(ctx, pipe)
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.Warn("Failed to record completion of acked item",
+			zap.String("item_id", item.ID),
+			zap.Error(err),
+		)
+	}
+	return nil
+}
+
+// reapExpiredLeases returns items whose lease expired without an ack to their event queue.
+// Items that expired while leased are dropped instead, like any other timed-out item.
+func (t *TimeoutHandler) reapExpiredLeases() error {
+	now := time.Now()
+
+	itemIDs, err := t.client.ZRangeByScore(t.ctx, leasesKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.UnixMilli(), 10),
+		Count: leaseReapBatchSize,
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get expired leases: %w", err)
+	}
+
+	requeued, dropped := 0, 0
+	for _, itemID := range itemIDs {
+		var item QueueItem
+		data, err := t.client.HGet(t.ctx, processingItemsKey, itemID).Result()
+		if err != nil && err != redis.Nil {
+			t.logger.Warn("Failed to get leased item",
+				zap.String("item_id", itemID),
+				zap.Error(err),
+			)
+			continue
+		}
+		if data != "" {
+			if err := json.Unmarshal([]byte(data), &item); err != nil {
+				t.logger.Warn("Failed to unmarshal leased item",
+					zap.String("item_id", itemID),
+					zap.Error(err),
+				)
+			}
+		}
+
+		requeue := "0"
+		if item.EventID != "" && now.Before(item.ExpiresAt) {
+			requeue = "1"
+		}
+
+		result, err := reapLeaseScript.Run(t.ctx, t.client,
+			[]string{leasesKey, processingItemsKey, activeQueuesKey, itemEventMapKey, queueSignalKey, expiryIndexKey, leaseTokensKey},
+			itemID,
+			now.UnixMilli(),
+			item.EventID,
+			requeue,
+			item.ExpiresAt.Unix(),
+			laneQueueKey(item.EventID, ItemLane(&item)),
+			inFlightKeyPrefix,
+			lanePositionKey(item.EventID, ItemLane(&item)),
+			queueSignalMaxLen-1,
+		).Int()
+		if err != nil {
+			t.logger.Warn("Failed to reap expired lease",
+				zap.String("item_id", itemID),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		switch result {
+		case 1:
+			requeued++
+			t.logger.Info("Requeued item with expired lease",
+				zap.String("item_id", itemID),
+				zap.String("event_id", item.EventID),
+			)
+		case 2:
+			dropped++
+			t.logger.Info("Dropped expired item with expired lease",
+				zap.String("item_id", itemID),
+				zap.String("event_id", item.EventID),
+			)
+			t.notifyExpired(item.UserID, item.EventID, itemID, now)
+		}
+	}
+
+	if requeued > 0 || dropped > 0 {
+		t.logger.Info("Reaped expired leases",
+			zap.Int("requeued", requeued),
+			zap.Int("dropped", dropped),
+		)
+	}
+
+	return nil
+}
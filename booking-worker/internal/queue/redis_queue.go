@@ -4,11 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sync"
 	"time"
 
 	"booking-worker/config"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
@@ -22,11 +22,9 @@ const (
 
 // RedisQueueManager implements QueueManager using Redis
 type RedisQueueManager struct {
-	client         *redis.Client
-	config         *config.Config
-	logger         *zap.Logger
-	roundRobinIdx  int
-	roundRobinLock sync.Mutex
+	client *redis.Client
+	config *config.Config
+	logger *zap.Logger
 }
 
 // NewRedisQueueManager creates a new Redis queue manager
@@ -59,7 +57,8 @@ func NewRedisQueueManager(cfg *config.Config, logger *zap.Logger) (QueueManager,
 
 // Enqueue adds an item to the queue
 func (r *RedisQueueManager) Enqueue(ctx context.Context, item *QueueItem) error {
-	key := fmt.Sprintf("booking-queue:%s", item.EventID)
+	lane := ItemLane(item)
+	key := laneQueueKey(item.EventID, lane)
 
 	// Serialize item
 	data, err := json.Marshal(item)
@@ -67,8 +66,8 @@ func (r *RedisQueueManager) Enqueue(ctx context.Context, item *QueueItem) error
 		return fmt.Errorf("failed to marshal queue item: %w", err)
 	}
 
-	// Use pipeline for atomic operations
-	pipe := r.client.Pipeline()
+	// MULTI/EXEC, so the dequeue script never pops the item before its tracking entries exist
+	pipe := r.client.TxPipeline()
 
 	// Add to queue (LPUSH)
 	pipe.LPush(ctx, key, data)
@@ -79,21 +78,23 @@ func (r *RedisQueueManager) Enqueue(ctx context.Context, item *QueueItem) error
 	// Map item ID to event ID for O(1) lookup (HSET)
 	pipe.HSet(ctx, itemEventMapKey, item.ID, item.EventID)
 
-	// Add to sorted set for position tracking
-	positionKey := fmt.Sprintf("booking-queue-positions:%s", item.EventID)
+	// Add to the lane's sorted set for position tracking
+	positionKey := lanePositionKey(item.EventID, lane)
 	score := float64(time.Now().UnixNano())
 	pipe.ZAdd(ctx, positionKey, redis.Z{
 		Score:  score,
 		Member: item.ID,
 	})
 
-	// Set expiry for timeout tracking
-	timeoutKey := fmt.Sprintf("booking-timeouts:%s", item.EventID)
-	pipe.ZAdd(ctx, timeoutKey, redis.Z{
+	// Index expiry for timeout tracking
+	pipe.ZAdd(ctx, expiryIndexKey, redis.Z{
 		Score:  float64(item.ExpiresAt.Unix()),
 		Member: item.ID,
 	})
 
+	// Wake an idle worker
+	signalWorkers(ctx, pipe)
+
 	// Execute pipeline
 	if _, err := pipe.Exec(ctx); err != nil {
 		return fmt.Errorf("failed to enqueue item: %w", err)
@@ -102,99 +103,63 @@ func (r *RedisQueueManager) Enqueue(ctx context.Context, item *QueueItem) error
 	r.logger.Debug("Item enqueued",
 		zap.String("item_id", item.ID),
 		zap.String("event_id", item.EventID),
+		zap.String("lane", string(lane)),
 	)
 
 	return nil
 }
 
 // Dequeue removes and returns an item from the queue (blocking)
-// Uses round-robin to fairly process items from different event queues
+// Uses weighted fair scheduling kept in Redis so every replica shares the same rotation.
+// When nothing is available it blocks on the enqueue signal for up to timeout instead of polling.
 func (r *RedisQueueManager) Dequeue(ctx context.Context, timeout time.Duration) (*QueueItem, error) {
-	// Get active event queues from SET (O(N) but N is small - number of events, not items)
-	eventIDs, err := r.client.SMembers(ctx, activeQueuesKey).Result()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get active queues: %w", err)
-	}
-
-	if len(eventIDs) == 0 {
-		// No queues available, wait a bit
-		time.Sleep(500 * time.Millisecond)
-		return nil, nil
-	}
-
-	// Round-robin: select next queue in rotation
-	r.roundRobinLock.Lock()
-	startIdx := r.roundRobinIdx
-	r.roundRobinIdx = (r.roundRobinIdx + 1) % len(eventIDs)
-	r.roundRobinLock.Unlock()
-
-	// Try each queue starting from round-robin index
-	for i := 0; i < len(eventIDs); i++ {
-		idx := (startIdx + i) % len(eventIDs)
-		eventID := eventIDs[idx]
-		queueKey := fmt.Sprintf("booking-queue:%s", eventID)
-
-		// Use RPOP (non-blocking) for fair distribution
-		// BRPOP would block on first queue, starving others
-		result, err := r.client.RPop(ctx, queueKey).Result()
-		if err != nil {
-			if err == redis.Nil {
-				continue // Empty queue, try next
+	// Every dequeue leases under its own token, so only this holder can ack or extend it
+	leaseToken := uuid.New().String()
+
+	eventID, result, err := r.popNextItem(ctx, leaseToken)
+	if err == redis.Nil {
+		// Wait for an enqueue or a released concurrency slot, then try once more
+		if err := r.client.BLPop(ctx, timeout, queueSignalKey).Err(); err != nil {
+			if err == redis.Nil || ctx.Err() != nil {
+				return nil, nil
 			}
-			r.logger.Warn("Failed to pop from queue", zap.String("event_id", eventID), zap.Error(err))
-			continue
+			return nil, fmt.Errorf("failed to wait for queue signal: %w", err)
 		}
-
-		// Parse the item
-		var item QueueItem
-		if err := json.Unmarshal([]byte(result), &item); err != nil {
-			r.logger.Error("Failed to unmarshal queue item", zap.Error(err))
-			continue
+		eventID, result, err = r.popNextItem(ctx, leaseToken)
+	}
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
 		}
-
-		// Cleanup tracking data using pipeline
-		r.cleanupItemTracking(ctx, &item)
-
-		r.logger.Debug("Item dequeued",
-			zap.String("item_id", item.ID),
-			zap.String("event_id", item.EventID),
-		)
-
-		return &item, nil
+		return nil, fmt.Errorf("failed to pop from queue: %w", err)
 	}
 
-	// No items found in any queue, wait before next poll
-	time.Sleep(100 * time.Millisecond)
-	return nil, nil
-}
+	// Parse the item
+	var item QueueItem
+	if err := json.Unmarshal([]byte(result), &item); err != nil {
+		r.logger.Error("Failed to unmarshal queue item", zap.String("event_id", eventID), zap.Error(err))
+		return nil, nil
+	}
+	item.LeaseToken = leaseToken
 
-// cleanupItemTracking removes item from all tracking structures
-func (r *RedisQueueManager) cleanupItemTracking(ctx context.Context, item *QueueItem) {
+	// Count the dequeue towards the event's throughput window
 	pipe := r.client.Pipeline()
-
-	// Remove from position tracking
-	positionKey := fmt.Sprintf("booking-queue-positions:%s", item.EventID)
-	pipe.ZRem(ctx, positionKey, item.ID)
-
-	// Remove from timeout tracking
-	timeoutKey := fmt.Sprintf("booking-timeouts:%s", item.EventID)
-	pipe.ZRem(ctx, timeoutKey, item.ID)
-
-	// Remove from item-event mapping
-	pipe.HDel(ctx, itemEventMapKey, item.ID)
-
+	r.recordThroughput(ctx, pipe, item.EventID, throughputDequeued)
 	if _, err := pipe.Exec(ctx); err != nil {
-		r.logger.Warn("Failed to cleanup item tracking", zap.String("item_id", item.ID), zap.Error(err))
+		r.logger.Warn("Failed to record dequeue throughput", zap.String("event_id", item.EventID), zap.Error(err))
 	}
 
-	// Check if queue is now empty and remove from active queues
-	go r.cleanupEmptyQueue(context.Background(), item.EventID)
+	r.logger.Debug("Item dequeued",
+		zap.String("item_id", item.ID),
+		zap.String("event_id", item.EventID),
+	)
+
+	return &item, nil
 }
 
-// cleanupEmptyQueue removes event from active queues if its queue is empty
+// cleanupEmptyQueue removes event from active queues if all of its lanes are empty
 func (r *RedisQueueManager) cleanupEmptyQueue(ctx context.Context, eventID string) {
-	queueKey := fmt.Sprintf("booking-queue:%s", eventID)
-	length, err := r.client.LLen(ctx, queueKey).Result()
+	length, err := r.GetQueueLength(ctx, eventID)
 	if err != nil {
 		return
 	}
@@ -206,7 +171,7 @@ func (r *RedisQueueManager) cleanupEmptyQueue(ctx context.Context, eventID strin
 	}
 }
 
-// GetPosition returns the position of an item in the queue
+// GetPosition returns the position of an item within its lane of the queue
 func (r *RedisQueueManager) GetPosition(ctx context.Context, itemID string) (int, error) {
 	// O(1) lookup: Get event ID from item-event mapping
 	eventID, err := r.client.HGet(ctx, itemEventMapKey, itemID).Result()
@@ -217,41 +182,62 @@ func (r *RedisQueueManager) GetPosition(ctx context.Context, itemID string) (int
 		return 0, fmt.Errorf("failed to get item event mapping: %w", err)
 	}
 
-	// O(log N) lookup: Get position from sorted set
-	positionKey := fmt.Sprintf("booking-queue-positions:%s", eventID)
-	rank, err := r.client.ZRank(ctx, positionKey, itemID).Result()
+	return r.GetPositionWithEventID(ctx, itemID, eventID)
+}
+
+// GetPositionWithEventID returns position when event ID is known (faster)
+func (r *RedisQueueManager) GetPositionWithEventID(ctx context.Context, itemID, eventID string) (int, error) {
+	_, position, err := r.GetLanePosition(ctx, itemID, eventID)
+	return position, err
+}
+
+// GetLanePosition returns the lane an item waits in and its position within that lane
+func (r *RedisQueueManager) GetLanePosition(ctx context.Context, itemID, eventID string) (Lane, int, error) {
+	// O(log N) lookup per lane: Get position from the lane sorted sets
+	lane, rank, err := r.findItemLane(ctx, eventID, itemID)
 	if err != nil {
-		if err == redis.Nil {
-			return 0, fmt.Errorf("item not found in queue")
-		}
-		return 0, fmt.Errorf("failed to get rank: %w", err)
+		return "", 0, err
 	}
 
 	// Return rank (0-indexed, so add 1 for human-readable position)
-	return int(rank) + 1, nil
+	return lane, int(rank) + 1, nil
 }
 
-// GetPositionWithEventID returns position when event ID is known (faster)
-func (r *RedisQueueManager) GetPositionWithEventID(ctx context.Context, itemID, eventID string) (int, error) {
-	positionKey := fmt.Sprintf("booking-queue-positions:%s", eventID)
-	rank, err := r.client.ZRank(ctx, positionKey, itemID).Result()
+// GetWaitingItems returns up to limit waiting items of one lane of an event in queue order,
+// starting offset items from the front (offset 0 is the next item to be served)
+func (r *RedisQueueManager) GetWaitingItems(ctx context.Context, eventID string, lane Lane, offset, limit int) ([]*QueueItem, error) {
+	queueKey := laneQueueKey(eventID, lane)
+
+	// Items are served from the right, so the front of the queue is the end of the list
+	data, err := r.client.LRange(ctx, queueKey, int64(-(offset + limit)), int64(-(offset + 1))).Result()
 	if err != nil {
-		if err == redis.Nil {
-			return 0, fmt.Errorf("item not found in queue")
+		return nil, fmt.Errorf("failed to get waiting items: %w", err)
+	}
+
+	items := make([]*QueueItem, 0, len(data))
+	for i := len(data) - 1; i >= 0; i-- {
+		var item QueueItem
+		if err := json.Unmarshal([]byte(data[i]), &item); err != nil {
+			continue
 		}
-		return 0, fmt.Errorf("failed to get rank: %w", err)
+		items = append(items, &item)
 	}
-	return int(rank) + 1, nil
+
+	return items, nil
 }
 
-// GetQueueLength returns the current queue length
+// GetQueueLength returns the current queue length across all lanes
 func (r *RedisQueueManager) GetQueueLength(ctx context.Context, eventID string) (int, error) {
-	key := fmt.Sprintf("booking-queue:%s", eventID)
-	length, err := r.client.LLen(ctx, key).Result()
+	lengths, err := r.GetLaneLengths(ctx, eventID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get queue length: %w", err)
 	}
-	return int(length), nil
+
+	total := 0
+	for _, length := range lengths {
+		total += length
+	}
+	return total, nil
 }
 
 // Remove removes an item from the queue by item ID
@@ -271,7 +257,11 @@ func (r *RedisQueueManager) Remove(ctx context.Context, itemID string) error {
 
 // RemoveWithEventID removes an item when event ID is known (faster, avoids hash lookup)
 func (r *RedisQueueManager) RemoveWithEventID(ctx context.Context, itemID, eventID string) error {
-	queueKey := fmt.Sprintf("booking-queue:%s", eventID)
+	lane, _, err := r.findItemLane(ctx, eventID, itemID)
+	if err != nil {
+		return err
+	}
+	queueKey := laneQueueKey(eventID, lane)
 
 	// Lua script for atomic find-and-remove operation
 	// This is more efficient than LRANGE + iterate + LSET + LREM in Go
@@ -304,11 +294,9 @@ func (r *RedisQueueManager) RemoveWithEventID(ctx context.Context, itemID, event
 	// Cleanup tracking data using pipeline
 	pipe := r.client.Pipeline()
 
-	positionKey := fmt.Sprintf("booking-queue-positions:%s", eventID)
-	pipe.ZRem(ctx, positionKey, itemID)
+	pipe.ZRem(ctx, lanePositionKey(eventID, lane), itemID)
 
-	timeoutKey := fmt.Sprintf("booking-timeouts:%s", eventID)
-	pipe.ZRem(ctx, timeoutKey, itemID)
+	pipe.ZRem(ctx, expiryIndexKey, itemID)
 
 	pipe.HDel(ctx, itemEventMapKey, itemID)
 
@@ -359,12 +347,11 @@ func (r *RedisQueueManager) GetQueueStats(ctx context.Context) (map[string]int64
 
 	stats := make(map[string]int64, len(eventIDs))
 	for _, eventID := range eventIDs {
-		queueKey := fmt.Sprintf("booking-queue:%s", eventID)
-		length, err := r.client.LLen(ctx, queueKey).Result()
+		length, err := r.GetQueueLength(ctx, eventID)
 		if err != nil {
 			continue
 		}
-		stats[eventID] = length
+		stats[eventID] = int64(length)
 	}
 
 	return stats, nil
@@ -381,9 +368,12 @@ func (r *RedisQueueManager) GetItem(ctx context.Context, itemID string) (*QueueI
 		return nil, fmt.Errorf("failed to get item event mapping: %w", err)
 	}
 
-	// Search for item in the queue
-	queueKey := fmt.Sprintf("booking-queue:%s", eventID)
-	items, err := r.client.LRange(ctx, queueKey, 0, -1).Result()
+	// Search for item in the lane it waits in
+	lane, _, err := r.findItemLane(ctx, eventID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := r.client.LRange(ctx, laneQueueKey(eventID, lane), 0, -1).Result()
 	if err != nil {
 		return nil, fmt.Errorf("failed to search queue: %w", err)
 	}
@@ -0,0 +1,298 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Redis key for per-event scheduling weights (HASH eventID -> weight)
+	eventWeightsKey = "booking:scheduler:weights"
+	// Redis key for per-event concurrency caps (HASH eventID -> max in-flight items)
+	eventConcurrencyKey = "booking:scheduler:concurrency"
+	// Redis key for per-event virtual pass values (ZSET eventID -> pass)
+	schedulerPassKey = "booking:scheduler:pass"
+	// Redis key for the scheduler's global virtual time (STRING)
+	schedulerVTimeKey = "booking:scheduler:vtime"
+	// Redis key prefix for items currently being processed per event (ZSET itemID -> lease deadline ms)
+	inFlightKeyPrefix = "booking:scheduler:inflight:"
+	// Redis key prefix for event queues (LIST)
+	eventQueueKeyPrefix = "booking-queue:"
+	// Redis key prefix for queue position tracking (ZSET itemID -> enqueued at ns)
+	positionKeyPrefix = "booking-queue-positions:"
+	// Redis key indexing every waiting item by expiry across all events (ZSET itemID -> expires at)
+	expiryIndexKey = "booking:expiry-index"
+	// Redis key idle workers block on until an item may be available (LIST)
+	queueSignalKey = "booking:queue-signal"
+	// Upper bound on pending wake-up signals
+	queueSignalMaxLen = 1000
+)
+
+// EventSchedule describes how an event queue shares the worker pool
+type EventSchedule struct {
+	EventID string
+	// Weight is the event's relative share of dequeues
+	Weight int
+	// MaxConcurrency caps items of this event processed at once (0 = unlimited)
+	MaxConcurrency int
+	// InFlight is the number of items currently being processed
+	InFlight int
+	// LaneShares is each priority lane's share of the event's dequeues (0 = paused)
+	LaneShares map[Lane]int
+	// EndsAt is when the event ends (zero if unknown); users' booked seats count until then
+	EndsAt time.Time
+}
+
+// scheduleDequeueScript pops the next item using stride scheduling across active event queues.
+// Each event has a pass value; the event with the lowest pass that is under its concurrency
+// cap is popped and its pass advances by 1/weight, so higher weights are picked more often.
+// Events that join (or come back from idle) start at the global virtual time, which stops an
+// idle event from banking credit and bursting ahead of everyone else.
+// Within the chosen event the same scheme picks a priority lane by its share, over the lanes
+// that have items. A lane with share 0 is paused; an event whose waiting lanes are all paused
+// is skipped but stays active.
+// The popped item's position, expiry and item-map entries are removed in the same script, and
+// a drained event is dropped from the active set, so no tracking entry outlives its item.
+// The item is leased rather than handed off: its payload stays in the processing hash until
+// acked, and the reaper requeues it if the lease deadline passes without a heartbeat.
+// Only live leases count towards an event's concurrency cap.
+//
+// KEYS[1] active queues set, KEYS[2] pass zset, KEYS[3] weights hash,
+// KEYS[4] concurrency hash, KEYS[5] virtual time, KEYS[6] item-event map,
+// KEYS[7] lease zset, KEYS[8] processing items hash, KEYS[9] expiry index, KEYS[10] lease tokens hash
+// ARGV[1] now (ms), ARGV[2] lease deadline (ms), ARGV[3] default weight,
+// ARGV[4] default max concurrency, ARGV[5] queue key prefix, ARGV[6] in-flight key prefix,
+// ARGV[7] position key prefix, ARGV[8] default lane shares ("lane:share,..." highest priority first),
+// ARGV[9] lane shares key prefix, ARGV[10] lane pass key prefix, ARGV[11] lease token
+var scheduleDequeueScript = redis.NewScript(`
+	local lanes = {}
+	for name, share in string.gmatch(ARGV[8], '([%w_]+):(%d+)') do
+		table.insert(lanes, {name = name, share = tonumber(share)})
+	end
+
+	local function lane_suffix(lane)
+		if lane == 'standard' then
+			return ''
+		end
+		return ':' .. lane
+	end
+
+	-- Returns the lane to serve next (nil if none is eligible) and whether any lane has items
+	local function pick_lane(event_id)
+		local shares_key = ARGV[9] .. event_id
+		local pass_key = ARGV[10] .. event_id
+		local lane_vtime = tonumber(redis.call('HGET', pass_key, 'vtime') or '0')
+		local has_items = false
+		local best, best_pass, best_share
+		for _, lane in ipairs(lanes) do
+			if redis.call('LLEN', ARGV[5] .. event_id .. lane_suffix(lane.name)) > 0 then
+				has_items = true
+				local share = tonumber(redis.call('HGET', shares_key, lane.name) or lane.share)
+				local pass = tonumber(redis.call('HGET', pass_key, lane.name) or lane_vtime)
+				if pass < lane_vtime then
+					pass = lane_vtime
+				end
+				if share > 0 and (not best or pass < best_pass) then
+					best, best_pass, best_share = lane.name, pass, share
+				end
+			end
+		end
+		if best then
+			redis.call('HSET', pass_key, 'vtime', best_pass, best, best_pass + 1 / best_share)
+		end
+		return best, has_items
+	end
+
+	local function has_items(event_id)
+		for _, lane in ipairs(lanes) do
+			if redis.call('LLEN', ARGV[5] .. event_id .. lane_suffix(lane.name)) > 0 then
+				return true
+			end
+		end
+		return false
+	end
+
+	local active = redis.call('SMEMBERS', KEYS[1])
+	if #active == 0 then
+		return false
+	end
+
+	local vtime = tonumber(redis.call('GET', KEYS[5]) or '0')
+	for _, event_id in ipairs(active) do
+		redis.call('ZADD', KEYS[2], 'NX', vtime, event_id)
+	end
+
+	local candidates = redis.call('ZRANGE', KEYS[2], 0, -1, 'WITHSCORES')
+	for i = 1, #candidates, 2 do
+		local event_id = candidates[i]
+		local pass = tonumber(candidates[i + 1])
+
+		if redis.call('SISMEMBER', KEYS[1], event_id) == 0 then
+			redis.call('ZREM', KEYS[2], event_id)
+		else
+			local inflight_key = ARGV[6] .. event_id
+			local limit = tonumber(redis.call('HGET', KEYS[4], event_id) or ARGV[4])
+			if limit <= 0 or redis.call('ZCOUNT', inflight_key, ARGV[1], '+inf') < limit then
+				local lane, waiting = pick_lane(event_id)
+				if lane then
+					local data = redis.call('RPOP', ARGV[5] .. event_id .. lane_suffix(lane))
+					local weight = tonumber(redis.call('HGET', KEYS[3], event_id) or ARGV[3])
+					if weight <= 0 then
+						weight = 1
+					end
+					if pass < vtime then
+						pass = vtime
+					end
+					redis.call('SET', KEYS[5], pass)
+					redis.call('ZADD', KEYS[2], pass + 1 / weight, event_id)
+
+					local item = cjson.decode(data)
+					redis.call('ZREM', ARGV[7] .. event_id .. lane_suffix(lane), item.ID)
+					redis.call('ZREM', KEYS[9], item.ID)
+					redis.call('HDEL', KEYS[6], item.ID)
+					redis.call('ZADD', inflight_key, ARGV[2], item.ID)
+					redis.call('ZADD', KEYS[7], ARGV[2], item.ID)
+					redis.call('HSET', KEYS[8], item.ID, data)
+					redis.call('HSET', KEYS[10], item.ID, ARGV[11])
+
+					if not has_items(event_id) then
+						redis.call('SREM', KEYS[1], event_id)
+						redis.call('ZREM', KEYS[2], event_id)
+						redis.call('DEL', ARGV[10] .. event_id)
+					end
+					return {event_id, data}
+				elseif not waiting then
+					-- Empty queue: drop it until the next enqueue re-registers it
+					redis.call('SREM', KEYS[1], event_id)
+					redis.call('ZREM', KEYS[2], event_id)
+					redis.call('DEL', ARGV[10] .. event_id)
+				end
+			end
+		end
+	end
+
+	return false
+`)
+
+// popNextItem atomically selects the next event queue by weight, then the next lane by share,
+// and pops one item from it, leased under leaseToken.
+// Returns redis.Nil when no event has an item available within its concurrency cap.
+func (r *RedisQueueManager) popNextItem(ctx context.Context, leaseToken string) (string, string, error) {
+	now := time.Now()
+
+	keys := []string{activeQueuesKey, schedulerPassKey, eventWeightsKey, eventConcurrencyKey, schedulerVTimeKey, itemEventMapKey,
+		leasesKey, processingItemsKey, expiryIndexKey, leaseTokensKey}
+	result, err := scheduleDequeueScript.Run(ctx, r.client, keys,
+		now.UnixMilli(),
+		now.Add(r.config.Queue.VisibilityTimeout).UnixMilli(),
+		r.config.Queue.DefaultEventWeight,
+		r.config.Queue.DefaultEventMaxConcurrency,
+		eventQueueKeyPrefix,
+		inFlightKeyPrefix,
+		positionKeyPrefix,
+		r.defaultLaneShares(),
+		laneSharesKeyPrefix,
+		lanePassKeyPrefix,
+		leaseToken,
+	).StringSlice()
+	if err != nil {
+		return "", "", err
+	}
+	if len(result) != 2 {
+		return "", "", fmt.Errorf("unexpected scheduler result length: %d", len(result))
+	}
+
+	return result[0], result[1], nil
+}
+
+// signalWorkers wakes one idle worker blocked in Dequeue.
+// The signal list is capped so bursts of enqueues don't leave a backlog of stale wake-ups.
+func signalWorkers(ctx context.Context, pipe redis.Pipeliner) {
+	pipe.LPush(ctx, queueSignalKey, 1)
+	pipe.LTrim(ctx, queueSignalKey, 0, queueSignalMaxLen-1)
+}
+
+// SetEventSchedule sets the weight, concurrency cap and lane shares of an event queue.
+// Lanes missing from laneShares use the configured default share.
+func (r *RedisQueueManager) SetEventSchedule(ctx context.Context, eventID string, weight, maxConcurrency int, laneShares map[Lane]int) error {
+	if weight <= 0 {
+		return fmt.Errorf("weight must be positive")
+	}
+	if maxConcurrency < 0 {
+		return fmt.Errorf("max concurrency cannot be negative")
+	}
+	for lane, share := range laneShares {
+		if !IsValidLane(string(lane)) {
+			return fmt.Errorf("unknown lane: %s", lane)
+		}
+		if share < 0 {
+			return fmt.Errorf("lane share cannot be negative")
+		}
+	}
+
+	pipe := r.client.Pipeline()
+	pipe.HSet(ctx, eventWeightsKey, eventID, weight)
+	pipe.HSet(ctx, eventConcurrencyKey, eventID, maxConcurrency)
+	pipe.Del(ctx, laneSharesKeyPrefix+eventID)
+	for lane, share := range laneShares {
+		pipe.HSet(ctx, laneSharesKeyPrefix+eventID, string(lane), share)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to set event schedule: %w", err)
+	}
+
+	return nil
+}
+
+// GetEventSchedule returns the effective schedule of an event queue
+func (r *RedisQueueManager) GetEventSchedule(ctx context.Context, eventID string) (*EventSchedule, error) {
+	pipe := r.client.Pipeline()
+	weightCmd := pipe.HGet(ctx, eventWeightsKey, eventID)
+	concurrencyCmd := pipe.HGet(ctx, eventConcurrencyKey, eventID)
+	inFlightCmd := pipe.ZCount(ctx, inFlightKeyPrefix+eventID,
+		strconv.FormatInt(time.Now().UnixMilli(), 10), "+inf")
+	endsAtCmd := pipe.HGet(ctx, eventEndsAtKey, eventID)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get event schedule: %w", err)
+	}
+
+	schedule := &EventSchedule{
+		EventID:        eventID,
+		Weight:         r.config.Queue.DefaultEventWeight,
+		MaxConcurrency: r.config.Queue.DefaultEventMaxConcurrency,
+		InFlight:       int(inFlightCmd.Val()),
+	}
+	if weight, err := weightCmd.Int(); err == nil {
+		schedule.Weight = weight
+	}
+	if maxConcurrency, err := concurrencyCmd.Int(); err == nil {
+		schedule.MaxConcurrency = maxConcurrency
+	}
+	if endsAt, err := endsAtCmd.Int64(); err == nil {
+		schedule.EndsAt = time.Unix(endsAt, 0)
+	}
+
+	laneShares, err := r.GetLaneShares(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	schedule.LaneShares = laneShares
+
+	return schedule, nil
+}
+
+// ClearEventSchedule removes per-event overrides so the event uses the configured defaults
+func (r *RedisQueueManager) ClearEventSchedule(ctx context.Context, eventID string) error {
+	pipe := r.client.Pipeline()
+	pipe.HDel(ctx, eventWeightsKey, eventID)
+	pipe.HDel(ctx, eventConcurrencyKey, eventID)
+	pipe.Del(ctx, laneSharesKeyPrefix+eventID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to clear event schedule: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Redis key prefix for per-event throughput counters, one key per bucket
+	// (booking:throughput:{eventId}:{kind}:{bucket})
+	throughputKeyPrefix = "booking:throughput:"
+
+	throughputDequeued  = "dequeued"
+	throughputCompleted = "completed"
+
+	// fallbackSecondsPerItem is used until an event has throughput history
+	fallbackSecondsPerItem = 5
+)
+
+// Throughput holds the observed rates of an event queue over the sliding window
+type Throughput struct {
+	// DequeueRate and CompletionRate are mean items per second
+	DequeueRate    float64
+	CompletionRate float64
+	// DequeueStdDev is the spread of the per-bucket dequeue rate
+	DequeueStdDev float64
+}
+
+// WaitEstimate is an estimated wait with a confidence range
+type WaitEstimate struct {
+	Seconds    int
+	MinSeconds int
+	MaxSeconds int
+	// Measured is false when the estimate falls back to the fixed per-item heuristic
+	Measured bool
+}
+
+// recordThroughput counts one item in the current bucket of an event's sliding window
+func (r *RedisQueueManager) recordThroughput(ctx context.Context, pipe redis.Pipeliner, eventID, kind string) {
+	bucketSeconds := r.etaBucketSeconds()
+	key := throughputBucketKey(eventID, kind, time.Now().Unix()/bucketSeconds)
+	pipe.Incr(ctx, key)
+	// Keep a bucket until it slides out of the window
+	pipe.Expire(ctx, key, r.config.Queue.ETAWindow+time.Duration(bucketSeconds)*time.Second)
+}
+
+// GetThroughput returns the dequeue and completion rates of an event over the sliding window.
+// The current, partial bucket is skipped so a fresh bucket doesn't drag the rate down.
+func (r *RedisQueueManager) GetThroughput(ctx context.Context, eventID string) (*Throughput, error) {
+	bucketSeconds := r.etaBucketSeconds()
+	buckets := int(int64(r.config.Queue.ETAWindow.Seconds()) / bucketSeconds)
+	if buckets < 1 {
+		buckets = 1
+	}
+	current := time.Now().Unix() / bucketSeconds
+
+	dequeuedKeys := make([]string, 0, buckets)
+	completedKeys := make([]string, 0, buckets)
+	for i := 1; i <= buckets; i++ {
+		dequeuedKeys = append(dequeuedKeys, throughputBucketKey(eventID, throughputDequeued, current-int64(i)))
+		completedKeys = append(completedKeys, throughputBucketKey(eventID, throughputCompleted, current-int64(i)))
+	}
+
+	pipe := r.client.Pipeline()
+	dequeuedCmd := pipe.MGet(ctx, dequeuedKeys...)
+	completedCmd := pipe.MGet(ctx, completedKeys...)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get throughput: %w", err)
+	}
+
+	dequeueMean, dequeueStdDev := bucketRates(dequeuedCmd.Val(), float64(bucketSeconds))
+	completionMean, _ := bucketRates(completedCmd.Val(), float64(bucketSeconds))
+
+	return &Throughput{
+		DequeueRate:    dequeueMean,
+		CompletionRate: completionMean,
+		DequeueStdDev:  dequeueStdDev,
+	}, nil
+}
+
+// FallbackWaitEstimate is the fixed per-item estimate used without throughput history
+func FallbackWaitEstimate(position int) *WaitEstimate {
+	if position <= 0 {
+		return &WaitEstimate{}
+	}
+	seconds := position * fallbackSecondsPerItem
+	return &WaitEstimate{
+		Seconds:    seconds,
+		MinSeconds: seconds / 2,
+		MaxSeconds: seconds * 2,
+	}
+}
+
+// EstimateWait turns the dequeue rate and its spread into an estimate for the item at position
+func (t *Throughput) EstimateWait(position int) *WaitEstimate {
+	rate := t.DequeueRate
+	if position <= 0 {
+		return &WaitEstimate{Measured: rate > 0}
+	}
+	if rate <= 0 {
+		return FallbackWaitEstimate(position)
+	}
+
+	// Don't let a noisy window push the slow bound towards infinity
+	slowRate := math.Max(rate-t.DequeueStdDev, rate/4)
+	fastRate := rate + t.DequeueStdDev
+
+	return &WaitEstimate{
+		Seconds:    int(math.Ceil(float64(position) / rate)),
+		MinSeconds: int(math.Floor(float64(position) / fastRate)),
+		MaxSeconds: int(math.Ceil(float64(position) / slowRate)),
+		Measured:   true,
+	}
+}
+
+// ForShare scales the rates down to the part of the event's traffic a lane receives
+func (t *Throughput) ForShare(fraction float64) *Throughput {
+	return &Throughput{
+		DequeueRate:    t.DequeueRate * fraction,
+		CompletionRate: t.CompletionRate * fraction,
+		DequeueStdDev:  t.DequeueStdDev * fraction,
+	}
+}
+
+// bucketRates returns the mean and standard deviation of per-bucket rates in items per second
+func bucketRates(values []interface{}, bucketSeconds float64) (float64, float64) {
+	// Values run newest to oldest; ignore buckets from before the event had any traffic
+	// so a queue that just opened isn't averaged against an empty window
+	for len(values) > 0 {
+		if _, ok := values[len(values)-1].(string); ok {
+			break
+		}
+		values = values[:len(values)-1]
+	}
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	rates := make([]float64, len(values))
+	var sum float64
+	for i, value := range values {
+		if s, ok := value.(string); ok {
+			count, _ := strconv.ParseFloat(s, 64)
+			rates[i] = count / bucketSeconds
+		}
+		sum += rates[i]
+	}
+	mean := sum / float64(len(rates))
+
+	var variance float64
+	for _, rate := range rates {
+		variance += (rate - mean) * (rate - mean)
+	}
+	variance /= float64(len(rates))
+
+	return mean, math.Sqrt(variance)
+}
+
+// etaBucketSeconds returns the sliding window bucket size in whole seconds
+func (r *RedisQueueManager) etaBucketSeconds() int64 {
+	seconds := int64(r.config.Queue.ETABucket.Seconds())
+	if seconds <= 0 {
+		return 1
+	}
+	return seconds
+}
+
+// throughputBucketKey returns the counter key of one bucket
+func throughputBucketKey(eventID, kind string, bucket int64) string {
+	return fmt.Sprintf("%s%s:%s:%d", throughputKeyPrefix, eventID, kind, bucket)
+}
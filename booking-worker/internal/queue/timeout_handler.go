@@ -2,7 +2,6 @@ package queue
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"time"
 
@@ -12,25 +11,121 @@ import (
 	"go.uber.org/zap"
 )
 
-// TimeoutHandler handles cleanup of expired queue items
+// legacyTimeoutKeyPattern matches the per-event expiry sets that predate the expiry index
+const legacyTimeoutKeyPattern = "booking-timeouts:*"
+
+// ExpiryNotifier tells users that their waiting booking expired
+type ExpiryNotifier interface {
+	NotifyBookingExpired(ctx context.Context, userID, eventID, itemID string, expiredAt time.Time) error
+}
+
+// ExpiredItem is a queue item removed because it expired
+type ExpiredItem struct {
+	ID      string
+	EventID string
+	UserID  string
+}
+
+// expireItemsScript removes a batch of expired items from their event queues and tracking.
+// Items are found through the expiry index and item-event map, and their lane through the lane
+// position sets, so only queues that hold an expired item are touched. Expired items sit near
+// the front of their lane, so each list is scanned from the right (the end served first) in
+// small chunks.
+// Returns a flat list of item ID, event ID and user ID per removed item.
+//
+// KEYS[1] expiry index, KEYS[2] item-event map, KEYS[3] active queues set, KEYS[4] pass zset
+// ARGV[1] now (unix seconds), ARGV[2] batch size, ARGV[3] queue key prefix, ARGV[4] position key prefix,
+// ARGV[5] lane names (comma separated)
+var expireItemsScript = redis.NewScript(`
+	local suffixes = {}
+	for lane in string.gmatch(ARGV[5], '[^,]+') do
+		if lane == 'standard' then
+			table.insert(suffixes, '')
+		else
+			table.insert(suffixes, ':' .. lane)
+		end
+	end
+
+	local expired = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+	local removed = {}
+
+	for _, item_id in ipairs(expired) do
+		redis.call('ZREM', KEYS[1], item_id)
+		local event_id = redis.call('HGET', KEYS[2], item_id)
+		if event_id then
+			redis.call('HDEL', KEYS[2], item_id)
+
+			local queue_key = nil
+			for _, suffix in ipairs(suffixes) do
+				if redis.call('ZREM', ARGV[4] .. event_id .. suffix, item_id) == 1 then
+					queue_key = ARGV[3] .. event_id .. suffix
+					break
+				end
+			end
+
+			local stop = -1
+			if queue_key then
+				stop = redis.call('LLEN', queue_key) - 1
+			end
+			local found = nil
+			while not found and stop >= 0 do
+				local start = math.max(0, stop - 99)
+				local chunk = redis.call('LRANGE', queue_key, start, stop)
+				for i = #chunk, 1, -1 do
+					local item = cjson.decode(chunk[i])
+					if item.ID == item_id then
+						redis.call('LSET', queue_key, start + i - 1, '___EXPIRED___')
+						redis.call('LREM', queue_key, -1, '___EXPIRED___')
+						found = item
+						break
+					end
+				end
+				stop = start - 1
+			end
+
+			if found then
+				table.insert(removed, item_id)
+				table.insert(removed, event_id)
+				table.insert(removed, found.UserID or '')
+			end
+			local waiting = 0
+			for _, suffix in ipairs(suffixes) do
+				waiting = waiting + redis.call('LLEN', ARGV[3] .. event_id .. suffix)
+			end
+			if waiting == 0 then
+				redis.call('SREM', KEYS[3], event_id)
+				redis.call('ZREM', KEYS[4], event_id)
+			end
+		end
+	end
+
+	return removed
+`)
+
+// TimeoutHandler handles cleanup of expired queue items and reaps expired processing leases
 type TimeoutHandler struct {
-	client *redis.Client
-	config *config.Config
-	logger *zap.Logger
-	ctx    context.Context
-	cancel context.CancelFunc
+	client   *redis.Client
+	config   *config.Config
+	notifier ExpiryNotifier
+	logger   *zap.Logger
+	ctx      context.Context
+	cancel   context.CancelFunc
+	// legacyDrained is set once no per-event expiry set is left to merge into the expiry index
+	legacyDrained bool
 }
 
-// NewTimeoutHandler creates a new timeout handler
-func NewTimeoutHandler(cfg *config.Config, client *redis.Client, logger *zap.Logger) (*TimeoutHandler, error) {
+// NewTimeoutHandler creates a new timeout handler.
+// notifier may be nil, in which case expired users are not notified.
+func NewTimeoutHandler(cfg *config.Config, client *redis.Client, notifier ExpiryNotifier, logger *zap.Logger) (*TimeoutHandler, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &TimeoutHandler{
-		client: client,
-		config: cfg,
-		logger: logger,
-		ctx:    ctx,
-		cancel: cancel,
+		client:   client,
+		config:   cfg,
+		notifier: notifier,
+		logger:   logger,
+		ctx:      ctx,
+		cancel:   cancel,
 	}, nil
 }
 
@@ -49,9 +144,17 @@ func (t *TimeoutHandler) Start() {
 			t.logger.Info("Timeout handler stopping")
 			return
 		case <-ticker.C:
+			if !t.legacyDrained {
+				if err := t.drainLegacyTimeouts(); err != nil {
+					t.logger.Error("Error draining legacy expiry sets", zap.Error(err))
+				}
+			}
 			if err := t.cleanupExpiredItems(); err != nil {
 				t.logger.Error("Error cleaning up expired items", zap.Error(err))
 			}
+			if err := t.reapExpiredLeases(); err != nil {
+				t.logger.Error("Error reaping expired leases", zap.Error(err))
+			}
 		}
 	}
 }
@@ -61,77 +164,66 @@ func (t *TimeoutHandler) Stop() {
 	t.cancel()
 }
 
-// cleanupExpiredItems removes expired items from queues
-func (t *TimeoutHandler) cleanupExpiredItems() error {
-	now := time.Now().Unix()
+// drainLegacyTimeouts merges the per-event expiry sets written before the expiry index existed
+// into it and deletes them, so items enqueued before the upgrade expire too. Replicas not yet
+// upgraded keep writing them, so it runs every cycle until a scan finds none.
+func (t *TimeoutHandler) drainLegacyTimeouts() error {
+	found := 0
+	iter := t.client.Scan(t.ctx, 0, legacyTimeoutKeyPattern, 100).Iterator()
+	for iter.Next(t.ctx) {
+		legacyKey := iter.Val()
+		found++
 
-	// Find all timeout tracking keys
-	pattern := "booking-timeouts:*"
-	keys, err := t.client.Keys(t.ctx, pattern).Result()
-	if err != nil {
-		return fmt.Errorf("failed to get timeout keys: %w", err)
+		// MULTI/EXEC, so no item added to the legacy set in between is lost
+		pipe := t.client.TxPipeline()
+		pipe.ZUnionStore(t.ctx, expiryIndexKey, &redis.ZStore{
+			Keys:      []string{expiryIndexKey, legacyKey},
+			Aggregate: "MIN",
+		})
+		pipe.Del(t.ctx, legacyKey)
+		if _, err := pipe.Exec(t.ctx); err != nil {
+			return fmt.Errorf("failed to merge %s into expiry index: %w", legacyKey, err)
+		}
+
+		t.logger.Info("Merged legacy expiry set into expiry index", zap.String("key", legacyKey))
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("failed to scan legacy expiry sets: %w", err)
 	}
 
-	totalCleaned := 0
+	if found == 0 {
+		t.legacyDrained = true
+	}
+	return nil
+}
 
-	for _, timeoutKey := range keys {
-		// Get expired items (score < now)
-		expired, err := t.client.ZRangeByScoreWithScores(t.ctx, timeoutKey, &redis.ZRangeBy{
-			Min:   "0",
-			Max:   fmt.Sprintf("%d", now),
-			Count: 100, // Process in batches
-		}).Result()
+// cleanupExpiredItems removes expired waiting items in bounded batches from the expiry index
+// and tells their users the booking expired
+func (t *TimeoutHandler) cleanupExpiredItems() error {
+	batchSize := t.config.Queue.ExpiryBatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
 
+	totalCleaned := 0
+	for totalCleaned < t.config.Queue.ExpiryMaxPerCycle || t.config.Queue.ExpiryMaxPerCycle <= 0 {
+		now := time.Now()
+		expired, err := t.expireBatch(now, batchSize)
 		if err != nil {
-			t.logger.Warn("Failed to get expired items",
-				zap.String("key", timeoutKey),
-				zap.Error(err),
-			)
-			continue
+			return err
 		}
 
-		if len(expired) == 0 {
-			continue
-		}
-
-		// Extract event ID from key (booking-timeouts:{eventId})
-		eventID := extractEventIDFromKey(timeoutKey)
-
 		for _, item := range expired {
-			itemID := item.Member.(string)
-
-			// Remove from queue
-			if err := t.removeItemFromQueue(t.ctx, eventID, itemID); err != nil {
-				t.logger.Warn("Failed to remove expired item from queue",
-					zap.String("item_id", itemID),
-					zap.String("event_id", eventID),
-					zap.Error(err),
-				)
-				continue
-			}
-
-			// Remove from position tracking
-			positionKey := fmt.Sprintf("booking-queue-positions:%s", eventID)
-			if err := t.client.ZRem(t.ctx, positionKey, itemID).Err(); err != nil {
-				t.logger.Warn("Failed to remove from position tracking",
-					zap.String("item_id", itemID),
-					zap.Error(err),
-				)
-			}
-
-			// Remove from timeout tracking
-			if err := t.client.ZRem(t.ctx, timeoutKey, itemID).Err(); err != nil {
-				t.logger.Warn("Failed to remove from timeout tracking",
-					zap.String("item_id", itemID),
-					zap.Error(err),
-				)
-			}
-
-			totalCleaned++
 			t.logger.Debug("Cleaned up expired item",
-				zap.String("item_id", itemID),
-				zap.String("event_id", eventID),
+				zap.String("item_id", item.ID),
+				zap.String("event_id", item.EventID),
 			)
+			t.notifyExpired(item.UserID, item.EventID, item.ID, now)
+		}
+		totalCleaned += len(expired)
+
+		if len(expired) < batchSize || t.ctx.Err() != nil {
+			break
 		}
 	}
 
@@ -144,46 +236,45 @@ func (t *TimeoutHandler) cleanupExpiredItems() error {
 	return nil
 }
 
-// removeItemFromQueue removes an item from a specific queue
-func (t *TimeoutHandler) removeItemFromQueue(ctx context.Context, eventID, itemID string) error {
-	queueKey := fmt.Sprintf("booking-queue:%s", eventID)
-
-	// Get all items
-	items, err := t.client.LRange(ctx, queueKey, 0, -1).Result()
+// expireBatch removes up to batchSize items that expired by now
+func (t *TimeoutHandler) expireBatch(now time.Time, batchSize int) ([]ExpiredItem, error) {
+	result, err := expireItemsScript.Run(t.ctx, t.client,
+		[]string{expiryIndexKey, itemEventMapKey, activeQueuesKey, schedulerPassKey},
+		now.Unix(),
+		batchSize,
+		eventQueueKeyPrefix,
+		positionKeyPrefix,
+		laneNames(),
+	).StringSlice()
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to expire items: %w", err)
 	}
 
-	// Find and remove the item
-	for i, itemData := range items {
-		// Try to parse as JSON to check ID
-		var item QueueItem
-		if err := json.Unmarshal([]byte(itemData), &item); err != nil {
-			continue
-		}
+	expired := make([]ExpiredItem, 0, len(result)/3)
+	for i := 0; i+2 < len(result); i += 3 {
+		expired = append(expired, ExpiredItem{
+			ID:      result[i],
+			EventID: result[i+1],
+			UserID:  result[i+2],
+		})
+	}
+	return expired, nil
+}
 
-		if item.ID == itemID {
-			// Remove by index using LSET + LREM trick
-			if err := t.client.LSet(ctx, queueKey, int64(i), "___REMOVED___").Err(); err != nil {
-				return err
-			}
-			if err := t.client.LRem(ctx, queueKey, 1, "___REMOVED___").Err(); err != nil {
-				return err
-			}
-			return nil
-		}
+// notifyExpired tells a user their booking expired; failures are logged and not retried
+func (t *TimeoutHandler) notifyExpired(userID, eventID, itemID string, expiredAt time.Time) {
+	if t.notifier == nil || userID == "" {
+		return
 	}
 
-	return fmt.Errorf("item not found in queue")
-}
+	ctx, cancel := context.WithTimeout(t.ctx, 5*time.Second)
+	defer cancel()
 
-// extractEventIDFromKey extracts event ID from a Redis key
-// e.g., "booking-timeouts:event-123" -> "event-123"
-func extractEventIDFromKey(key string) string {
-	prefix := "booking-timeouts:"
-	if len(key) > len(prefix) {
-		return key[len(prefix):]
+	if err := t.notifier.NotifyBookingExpired(ctx, userID, eventID, itemID, expiredAt); err != nil {
+		t.logger.Warn("Failed to notify user of expired booking",
+			zap.String("item_id", itemID),
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
 	}
-	return ""
 }
-
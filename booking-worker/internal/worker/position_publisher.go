@@ -0,0 +1,306 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"booking-worker/config"
+	"booking-worker/grpcclient"
+	"booking-worker/internal/queue"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// Redis key for the publish cycle lock, so only one replica publishes at a time
+	positionPublishLockKey = "booking:position-publisher-lock"
+	// Redis key prefix for the last position sent to each user (HASH userID -> "position|sent at ms")
+	positionNotifyKeyPrefix = "booking:position-notify:"
+	// Redis key for events that have position notify state (SET)
+	positionNotifyEventsKey = "booking:position-notify:events"
+)
+
+// PositionPublisher periodically pushes queue positions and wait estimates to waiting users.
+// Positions are counted within each priority lane. A user is only sent an update when their
+// position changed, and at most once per MinUserInterval; updates are sent to the realtime
+// service in batches per event lane.
+type PositionPublisher struct {
+	queue    *queue.RedisQueueManager
+	client   *redis.Client
+	realtime *grpcclient.RealtimeServiceClient
+	config   *config.Config
+	logger   *zap.Logger
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// lastNotification is what a user was last sent for an event
+type lastNotification struct {
+	position int
+	sentAt   time.Time
+}
+
+// NewPositionPublisher creates a new queue position publisher
+func NewPositionPublisher(cfg *config.Config, q *queue.RedisQueueManager, realtime *grpcclient.RealtimeServiceClient, logger *zap.Logger) *PositionPublisher {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &PositionPublisher{
+		queue:    q,
+		client:   q.GetClient(),
+		realtime: realtime,
+		config:   cfg,
+		logger:   logger,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start starts the publish loop
+func (p *PositionPublisher) Start() {
+	p.logger.Info("Starting queue position publisher",
+		zap.Duration("interval", p.config.Publisher.Interval),
+		zap.Duration("min_user_interval", p.config.Publisher.MinUserInterval),
+		zap.Int("batch_size", p.config.Publisher.BatchSize),
+	)
+
+	ticker := time.NewTicker(p.config.Publisher.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			p.logger.Info("Queue position publisher stopping")
+			return
+		case <-ticker.C:
+			p.publishOnce()
+		}
+	}
+}
+
+// Stop stops the publish loop
+func (p *PositionPublisher) Stop() {
+	p.cancel()
+}
+
+// publishOnce runs a single publish cycle across all active event queues
+func (p *PositionPublisher) publishOnce() {
+	// Only one replica publishes per cycle; the lock expires with the interval
+	token := uuid.New().String()
+	acquired, err := p.client.SetNX(p.ctx, positionPublishLockKey, token, p.config.Publisher.Interval).Result()
+	if err != nil {
+		p.logger.Warn("Failed to acquire position publisher lock", zap.Error(err))
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	eventIDs, err := p.queue.GetActiveQueues(p.ctx)
+	if err != nil {
+		p.logger.Warn("Failed to get active queues", zap.Error(err))
+		return
+	}
+
+	totalSent := 0
+	active := make(map[string]bool, len(eventIDs))
+	for _, eventID := range eventIDs {
+		active[eventID] = true
+		sent, err := p.publishEvent(eventID)
+		if err != nil {
+			p.logger.Warn("Failed to publish queue positions",
+				zap.String("event_id", eventID),
+				zap.Error(err),
+			)
+		}
+		totalSent += sent
+	}
+
+	p.pruneInactiveEvents(active)
+
+	if totalSent > 0 {
+		p.logger.Debug("Published queue positions", zap.Int("count", totalSent))
+	}
+}
+
+// publishEvent sends position updates for each lane of one event queue and returns the number sent
+func (p *PositionPublisher) publishEvent(eventID string) (int, error) {
+	throughputs, lengths, err := p.queue.GetLaneThroughputs(p.ctx, eventID)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, lane := range queue.Lanes {
+		if lengths[lane] == 0 {
+			continue
+		}
+		laneSent, err := p.publishLane(eventID, lane, lengths[lane], throughputs[lane])
+		sent += laneSent
+		if err != nil {
+			return sent, err
+		}
+	}
+
+	return sent, nil
+}
+
+// publishLane sends position updates for one lane; positions and estimates are within the lane
+func (p *PositionPublisher) publishLane(eventID string, lane queue.Lane, total int, throughput *queue.Throughput) (int, error) {
+	batchSize := p.config.Publisher.BatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	scanLimit := total
+	if p.config.Publisher.MaxScan > 0 && scanLimit > p.config.Publisher.MaxScan {
+		scanLimit = p.config.Publisher.MaxScan
+	}
+
+	stateKey := positionNotifyKeyPrefix + eventID
+	sent := 0
+	for offset := 0; offset < scanLimit; offset += batchSize {
+		limit := batchSize
+		if offset+limit > scanLimit {
+			limit = scanLimit - offset
+		}
+
+		items, err := p.queue.GetWaitingItems(p.ctx, eventID, lane, offset, limit)
+		if err != nil {
+			return sent, err
+		}
+		if len(items) == 0 {
+			break
+		}
+
+		updates, err := p.pendingUpdates(stateKey, items, offset, throughput)
+		if err != nil {
+			return sent, err
+		}
+		if len(updates) == 0 {
+			continue
+		}
+
+		if _, err := p.realtime.NotifyQueuePositionBatch(p.ctx, eventID, string(lane), total, updates); err != nil {
+			// Leave the throttle state untouched so the users are retried next cycle
+			return sent, err
+		}
+
+		if err := p.recordSent(eventID, stateKey, updates); err != nil {
+			p.logger.Warn("Failed to record sent queue positions",
+				zap.String("event_id", eventID),
+				zap.Error(err),
+			)
+		}
+		sent += len(updates)
+	}
+
+	return sent, nil
+}
+
+// pendingUpdates returns the updates due for a page of waiting items starting at offset
+func (p *PositionPublisher) pendingUpdates(stateKey string, items []*queue.QueueItem, offset int, throughput *queue.Throughput) ([]grpcclient.QueuePositionUpdate, error) {
+	userIDs := make([]string, len(items))
+	for i, item := range items {
+		userIDs[i] = item.UserID
+	}
+
+	values, err := p.client.HMGet(p.ctx, stateKey, userIDs...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get position notify state: %w", err)
+	}
+
+	now := time.Now()
+	updates := make([]grpcclient.QueuePositionUpdate, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for i, item := range items {
+		// A user with several waiting items is told about the one closest to the front
+		if seen[item.UserID] {
+			continue
+		}
+		seen[item.UserID] = true
+
+		position := offset + i + 1
+		if last, ok := parseLastNotification(values[i]); ok {
+			if last.position == position || now.Sub(last.sentAt) < p.config.Publisher.MinUserInterval {
+				continue
+			}
+		}
+
+		updates = append(updates, grpcclient.QueuePositionUpdate{
+			UserID:   item.UserID,
+			Position: position,
+			Wait:     throughput.EstimateWait(position),
+		})
+	}
+
+	return updates, nil
+}
+
+// recordSent stores what each user was sent so unchanged positions are skipped next cycle
+func (p *PositionPublisher) recordSent(eventID, stateKey string, updates []grpcclient.QueuePositionUpdate) error {
+	nowMs := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	values := make(map[string]interface{}, len(updates))
+	for _, update := range updates {
+		values[update.UserID] = strconv.Itoa(update.Position) + "|" + nowMs
+	}
+
+	pipe := p.client.Pipeline()
+	pipe.HSet(p.ctx, stateKey, values)
+	pipe.Expire(p.ctx, stateKey, time.Duration(p.config.Queue.TimeoutSeconds)*time.Second)
+	pipe.SAdd(p.ctx, positionNotifyEventsKey, eventID)
+	if _, err := pipe.Exec(p.ctx); err != nil {
+		return fmt.Errorf("failed to record position notify state: %w", err)
+	}
+	return nil
+}
+
+// pruneInactiveEvents drops notify state of events whose queues drained
+func (p *PositionPublisher) pruneInactiveEvents(active map[string]bool) {
+	eventIDs, err := p.client.SMembers(p.ctx, positionNotifyEventsKey).Result()
+	if err != nil {
+		p.logger.Warn("Failed to get position notify events", zap.Error(err))
+		return
+	}
+
+	pipe := p.client.Pipeline()
+	pruned := 0
+	for _, eventID := range eventIDs {
+		if active[eventID] {
+			continue
+		}
+		pipe.Del(p.ctx, positionNotifyKeyPrefix+eventID)
+		pipe.SRem(p.ctx, positionNotifyEventsKey, eventID)
+		pruned++
+	}
+	if pruned == 0 {
+		return
+	}
+	if _, err := pipe.Exec(p.ctx); err != nil {
+		p.logger.Warn("Failed to prune position notify state", zap.Error(err))
+	}
+}
+
+// parseLastNotification parses a "position|sent at ms" notify state value
+func parseLastNotification(value interface{}) (lastNotification, bool) {
+	s, ok := value.(string)
+	if !ok {
+		return lastNotification{}, false
+	}
+	parts := strings.SplitN(s, "|", 2)
+	if len(parts) != 2 {
+		return lastNotification{}, false
+	}
+	position, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return lastNotification{}, false
+	}
+	sentAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return lastNotification{}, false
+	}
+	return lastNotification{position: position, sentAt: time.UnixMilli(sentAt)}, true
+}
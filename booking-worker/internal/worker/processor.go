@@ -15,6 +15,8 @@ import (
 
 	"github.com/sony/gobreaker"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // ErrCircuitOpen is returned when the circuit breaker is open
@@ -34,6 +36,7 @@ type Processor struct {
 	workerWg            sync.WaitGroup
 	workers             []*worker
 	bookingBreaker      *circuitbreaker.CircuitBreaker
+	breakerStates       *queue.BreakerStates
 }
 
 type worker struct {
@@ -60,8 +63,19 @@ func NewProcessor(cfg *config.Config, q queue.QueueManager, dlq *queue.DLQManage
 		return nil, fmt.Errorf("failed to create realtime service client: %w", err)
 	}
 
-	// Initialize circuit breaker for booking service
+	// Initialize circuit breaker for booking service, sharing its state with the other replicas
 	breakerCfg := circuitbreaker.DefaultConfig("booking-service", logger)
+	var breakerStates *queue.BreakerStates
+	if redisQueue, ok := q.(*queue.RedisQueueManager); ok {
+		breakerStates = queue.NewBreakerStates(redisQueue.GetClient())
+		logStateChange := breakerCfg.OnStateChange
+		breakerCfg.OnStateChange = func(name string, from gobreaker.State, to gobreaker.State) {
+			logStateChange(name, from, to)
+			if err := breakerStates.Report(context.Background(), to, breakerCfg.Timeout); err != nil {
+				logger.Warn("Failed to share circuit breaker state", zap.String("name", name), zap.Error(err))
+			}
+		}
+	}
 	bookingBreaker := circuitbreaker.New(breakerCfg, logger)
 
 	return &Processor{
@@ -75,6 +89,7 @@ func NewProcessor(cfg *config.Config, q queue.QueueManager, dlq *queue.DLQManage
 		realtimeClient: realtimeClient,
 		workers:        make([]*worker, 0, cfg.Worker.PoolSize),
 		bookingBreaker: bookingBreaker,
+		breakerStates:  breakerStates,
 	}, nil
 }
 
@@ -154,8 +169,13 @@ func (w *worker) run() {
 				continue
 			}
 
+			// Keep the lease alive while processing so the reaper doesn't requeue the item;
+			// processing is cancelled if the lease is lost anyway
+			itemCtx, cancelItem := context.WithCancel(w.ctx)
+			stopHeartbeat := w.processor.keepLeaseAlive(item, cancelItem)
+
 			// Process the item
-			if err := w.processor.ProcessItem(w.ctx, item); err != nil {
+			if err := w.processor.ProcessItem(itemCtx, item); err != nil {
 				w.processor.logger.Error("Error processing item",
 					zap.Int("worker_id", w.id),
 					zap.String("item_id", item.ID),
@@ -163,10 +183,70 @@ func (w *worker) run() {
 				)
 				// TODO: Handle retry logic or dead letter queue
 			}
+
+			leaseLost := stopHeartbeat()
+			cancelItem()
+			if leaseLost {
+				// The item belongs to whoever dequeued it next; acking would end their lease
+				continue
+			}
+
+			// Ack once the item is booked or moved to the DLQ; an unacked item is redelivered
+			if err := w.processor.queue.Ack(context.Background(), item); err != nil {
+				w.processor.logger.Warn("Failed to ack queue item",
+					zap.Int("worker_id", w.id),
+					zap.String("item_id", item.ID),
+					zap.Error(err),
+				)
+			}
 		}
 	}
 }
 
+// keepLeaseAlive extends the item's lease every heartbeat interval until the returned stop function is called.
+// If the lease is lost it calls abort to stop processing the item; stop then reports true.
+func (p *Processor) keepLeaseAlive(item *queue.QueueItem, abort context.CancelFunc) func() bool {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	leaseLost := false
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(p.config.Queue.HeartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := p.queue.Heartbeat(ctx, item); err != nil {
+					if errors.Is(err, queue.ErrLeaseLost) {
+						// The item is back in its queue; the booking idempotency key guards the redelivery
+						p.logger.Warn("Lease lost while processing item, aborting",
+							zap.String("item_id", item.ID),
+						)
+						leaseLost = true
+						abort()
+						return
+					}
+					p.logger.Warn("Failed to extend lease",
+						zap.String("item_id", item.ID),
+						zap.Error(err),
+					)
+				}
+			}
+		}
+	}()
+
+	return func() bool {
+		cancel()
+		<-done
+		return leaseLost
+	}
+}
+
 // GetWorkerCount returns the number of active workers
 func (p *Processor) GetWorkerCount() int {
 	return len(p.workers)
@@ -264,6 +344,17 @@ func (p *Processor) ProcessItem(ctx context.Context, item *queue.QueueItem) erro
 
 		bookingID := result.(string)
 
+		// Keep the booked seats counting towards the user's seat limit once the item is acked
+		if redisQueue, ok := p.queue.(*queue.RedisQueueManager); ok {
+			if err := redisQueue.ConfirmSeats(ctx, item, bookingID); err != nil {
+				p.logger.Warn("Failed to record confirmed seats",
+					zap.String("item_id", item.ID),
+					zap.String("booking_id", bookingID),
+					zap.Error(err),
+				)
+			}
+		}
+
 		// Notify realtime-service of success
 		if err := p.realtimeClient.NotifyBookingResult(ctx, item.UserID, bookingID, true, "Booking created successfully"); err != nil {
 			p.logger.Warn("Failed to notify booking result",
@@ -292,10 +383,7 @@ func (p *Processor) ProcessItem(ctx context.Context, item *queue.QueueItem) erro
 	}
 
 	// Determine DLQ reason based on error
-	dlqReason := queue.DLQReasonMaxRetries
-	if errors.Is(lastErr, ErrCircuitOpen) {
-		dlqReason = queue.DLQReasonCircuitOpen
-	}
+	dlqReason := classifyDLQReason(lastErr)
 
 	// Add to DLQ for later processing/investigation
 	if p.dlq != nil {
@@ -328,4 +416,31 @@ func (p *Processor) GetDLQManager() *queue.DLQManager {
 	return p.dlq
 }
 
+// GetRealtimeClient returns the realtime-service client
+func (p *Processor) GetRealtimeClient() *grpcclient.RealtimeServiceClient {
+	return p.realtimeClient
+}
+
+// GetBreakerStates returns the booking-service breaker state shared across replicas (nil without Redis)
+func (p *Processor) GetBreakerStates() *queue.BreakerStates {
+	return p.breakerStates
+}
+
+// classifyDLQReason maps the last processing error to a DLQ reason so replay policies can act on it
+func classifyDLQReason(err error) queue.DLQReason {
+	switch {
+	case errors.Is(err, ErrCircuitOpen),
+		errors.Is(err, gobreaker.ErrOpenState),
+		errors.Is(err, gobreaker.ErrTooManyRequests):
+		return queue.DLQReasonCircuitOpen
+	case errors.Is(err, context.DeadlineExceeded),
+		status.Code(err) == codes.DeadlineExceeded:
+		return queue.DLQReasonTimeout
+	case status.Code(err) == codes.Unavailable:
+		return queue.DLQReasonServiceError
+	default:
+		return queue.DLQReasonMaxRetries
+	}
+}
+
 
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"booking-worker/config"
+	"booking-worker/internal/queue"
+)
+
+// admit runs the enqueue policies for a new item of seats seats
+func admit(t *testing.T, q *queue.RedisQueueManager, eventID, userID string, seats int) (*queue.QueueItem, *queue.Admission) {
+	t.Helper()
+
+	item := &queue.QueueItem{
+		ID:         uuid.New().String(),
+		EventID:    eventID,
+		UserID:     userID,
+		SeatCount:  seats,
+		EnqueuedAt: time.Now(),
+		ExpiresAt:  time.Now().Add(15 * time.Minute),
+	}
+	admission, err := q.Admit(context.Background(), item, "")
+	if err != nil {
+		t.Fatalf("admit: %v", err)
+	}
+	return item, admission
+}
+
+func TestAdmitCountsConfirmedSeatsUntilReleased(t *testing.T) {
+	q := newTestQueueWith(t, func(cfg *config.Config) {
+		cfg.Queue.MaxSeatsPerUser = 4
+		cfg.Queue.ConfirmedSeatsTTL = time.Hour
+	})
+	ctx := context.Background()
+
+	item, admission := admit(t, q, "event-limit", "user-1", 3)
+	if admission.Decision != queue.AdmissionAdmitted {
+		t.Fatalf("first admission = %s, want admitted", admission.Decision)
+	}
+	if err := q.ConfirmSeats(ctx, item, "booking-1"); err != nil {
+		t.Fatalf("confirm seats: %v", err)
+	}
+
+	// The booked seats still count once the item has left the queue and its hold is gone
+	_, admission = admit(t, q, "event-limit", "user-1", 2)
+	if admission.Decision != queue.AdmissionSeatLimitExceeded || admission.HeldSeats != 3 {
+		t.Fatalf("admission after booking = %s holding %d, want seat_limit_exceeded holding 3",
+			admission.Decision, admission.HeldSeats)
+	}
+
+	released, err := q.ReleaseConfirmedSeats(ctx, "event-limit", "user-1", "booking-1")
+	if err != nil {
+		t.Fatalf("release confirmed seats: %v", err)
+	}
+	if !released {
+		t.Fatal("release reported nothing released, want the booking's seats")
+	}
+
+	_, admission = admit(t, q, "event-limit", "user-1", 2)
+	if admission.Decision != queue.AdmissionAdmitted {
+		t.Errorf("admission after cancellation = %s, want admitted", admission.Decision)
+	}
+}
+
+func TestConfirmedSeatsExpireWithEvent(t *testing.T) {
+	q := newTestQueueWith(t, func(cfg *config.Config) {
+		cfg.Queue.MaxSeatsPerUser = 4
+		cfg.Queue.ConfirmedSeatsTTL = time.Hour
+	})
+	ctx := context.Background()
+
+	endsAt := time.Now().Add(10 * time.Minute).Truncate(time.Second)
+	if err := q.SetEventEndsAt(ctx, "event-ending", endsAt); err != nil {
+		t.Fatalf("set event end: %v", err)
+	}
+	item, _ := admit(t, q, "event-ending", "user-1", 2)
+	if err := q.ConfirmSeats(ctx, item, "booking-1"); err != nil {
+		t.Fatalf("confirm seats: %v", err)
+	}
+
+	expireAt, err := q.GetClient().ExpireTime(ctx, "booking:user-confirmed:event-ending:user-1").Result()
+	if err != nil {
+		t.Fatalf("expire time: %v", err)
+	}
+	if expireAt != time.Duration(endsAt.Unix())*time.Second {
+		t.Errorf("booked seats expire at %v, want the event end %v", expireAt, time.Duration(endsAt.Unix())*time.Second)
+	}
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"go.uber.org/zap"
+
+	"booking-worker/config"
+	"booking-worker/internal/queue"
+)
+
+func TestBreakerStatesShared(t *testing.T) {
+	q := newTestQueue(t)
+	ctx := context.Background()
+
+	first := queue.NewBreakerStates(q.GetClient())
+	second := queue.NewBreakerStates(q.GetClient())
+
+	assertShared := func(want gobreaker.State) {
+		t.Helper()
+		state, err := second.Shared(ctx)
+		if err != nil {
+			t.Fatalf("shared breaker state: %v", err)
+		}
+		if state != want {
+			t.Errorf("shared breaker state = %s, want %s", state, want)
+		}
+	}
+
+	assertShared(gobreaker.StateClosed)
+
+	// Another replica's open breaker holds every replica's replay
+	if err := first.Report(ctx, gobreaker.StateOpen, time.Minute); err != nil {
+		t.Fatalf("report open: %v", err)
+	}
+	assertShared(gobreaker.StateOpen)
+
+	// Once its open timeout passes it only lets probes through
+	if err := first.Report(ctx, gobreaker.StateOpen, 0); err != nil {
+		t.Fatalf("report open: %v", err)
+	}
+	assertShared(gobreaker.StateHalfOpen)
+
+	if err := first.Report(ctx, gobreaker.StateClosed, 0); err != nil {
+		t.Fatalf("report closed: %v", err)
+	}
+	assertShared(gobreaker.StateClosed)
+}
+
+func TestRequeueItemAppliesEnqueuePolicies(t *testing.T) {
+	q := newTestQueueWith(t, func(cfg *config.Config) {
+		cfg.Queue.OneActiveItemPerUser = true
+	})
+	ctx := context.Background()
+	dlq := queue.NewDLQManager(q.GetClient(), zap.NewNop())
+
+	// The failed item's hold lapses once it leaves the queue; drop it rather than wait out the grace period
+	failed, _ := admit(t, q, "event-replay", "user-1", 1)
+	if err := q.ReleaseAdmission(ctx, failed, ""); err != nil {
+		t.Fatalf("release admission: %v", err)
+	}
+	if err := dlq.AddToDLQ(ctx, failed, queue.DLQReasonServiceError, "unavailable", 3); err != nil {
+		t.Fatalf("add to DLQ: %v", err)
+	}
+	items, err := dlq.GetDLQItems(ctx, 0, 10)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("DLQ items = %d (%v), want 1", len(items), err)
+	}
+
+	// The user queued again after the failure, so the replay would be a second active item
+	active, admission := admit(t, q, "event-replay", "user-1", 1)
+	if admission.Decision != queue.AdmissionAdmitted {
+		t.Fatalf("new admission = %s, want admitted", admission.Decision)
+	}
+	if err := q.Enqueue(ctx, active); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	if err := dlq.RequeueItem(ctx, items[0], q); !errors.Is(err, queue.ErrRequeueNotAdmitted) {
+		t.Fatalf("requeue error = %v, want %v", err, queue.ErrRequeueNotAdmitted)
+	}
+	if depth, err := dlq.GetDLQDepth(ctx); err != nil || depth != 1 {
+		t.Errorf("DLQ depth = %d (%v), want the blocked item kept", depth, err)
+	}
+
+	// Once the active item is gone the replay is admitted
+	if err := q.Remove(ctx, active.ID); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if err := q.ReleaseAdmission(ctx, active, ""); err != nil {
+		t.Fatalf("release admission: %v", err)
+	}
+	if err := dlq.RequeueItem(ctx, items[0], q); err != nil {
+		t.Fatalf("requeue: %v", err)
+	}
+	if _, err := q.GetPosition(ctx, failed.ID); err != nil {
+		t.Errorf("requeued item has no queue position: %v", err)
+	}
+}
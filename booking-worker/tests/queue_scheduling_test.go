@@ -0,0 +1,291 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"booking-worker/config"
+	"booking-worker/internal/queue"
+)
+
+// testRedisAddrEnv names the Redis instance (host:port) the queue tests run against.
+// The tests flush its database, so never point it at a Redis holding real data.
+// They are skipped when it is not set, except in CI, which provides one (see
+// .github/workflows/ci.yml).
+const testRedisAddrEnv = "BOOKING_WORKER_TEST_REDIS_ADDR"
+
+// newTestQueue connects a queue manager to an emptied test Redis or skips the test
+func newTestQueue(t *testing.T) *queue.RedisQueueManager {
+	t.Helper()
+	return newTestQueueWith(t, func(*config.Config) {})
+}
+
+// newTestQueueWith is newTestQueue with the test configuration adjusted by configure
+func newTestQueueWith(t *testing.T, configure func(*config.Config)) *queue.RedisQueueManager {
+	t.Helper()
+
+	addr := os.Getenv(testRedisAddrEnv)
+	if addr == "" {
+		if os.Getenv("CI") != "" {
+			t.Fatalf("%s must be set in CI", testRedisAddrEnv)
+		}
+		t.Skipf("%s is not set", testRedisAddrEnv)
+	}
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatalf("parse %s: %v", testRedisAddrEnv, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		t.Fatalf("parse %s port: %v", testRedisAddrEnv, err)
+	}
+
+	cfg := &config.Config{
+		Redis: config.RedisConfig{Host: host, Port: port},
+		Queue: config.QueueConfig{
+			TimeoutSeconds:             900,
+			DefaultEventWeight:         1,
+			DefaultEventMaxConcurrency: 0,
+			VisibilityTimeout:          time.Minute,
+			ETAWindow:                  time.Minute,
+			ETABucket:                  10 * time.Second,
+		},
+	}
+	configure(cfg)
+	manager, err := queue.NewRedisQueueManager(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("connect to test redis: %v", err)
+	}
+	q := manager.(*queue.RedisQueueManager)
+	t.Cleanup(func() { q.Close() })
+
+	// The scheduler's rotation and virtual time are global, so every test starts from scratch
+	if err := q.GetClient().FlushDB(context.Background()).Err(); err != nil {
+		t.Fatalf("flush test redis: %v", err)
+	}
+	return q
+}
+
+// enqueue adds count items for an event to one of its lanes
+func enqueue(t *testing.T, q *queue.RedisQueueManager, eventID string, lane queue.Lane, count int) []*queue.QueueItem {
+	t.Helper()
+
+	items := make([]*queue.QueueItem, count)
+	for i := range items {
+		items[i] = &queue.QueueItem{
+			ID:         uuid.New().String(),
+			EventID:    eventID,
+			UserID:     uuid.New().String(),
+			SeatCount:  1,
+			Lane:       lane,
+			EnqueuedAt: time.Now(),
+			ExpiresAt:  time.Now().Add(15 * time.Minute),
+		}
+		if err := q.Enqueue(context.Background(), items[i]); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+	return items
+}
+
+// dequeue pops the next item, failing the test if none is available
+func dequeue(t *testing.T, q *queue.RedisQueueManager) *queue.QueueItem {
+	t.Helper()
+
+	item, err := q.Dequeue(context.Background(), 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("dequeue: %v", err)
+	}
+	if item == nil {
+		t.Fatal("dequeue returned nothing, want an item")
+	}
+	return item
+}
+
+func TestDequeueSharesByEventWeight(t *testing.T) {
+	q := newTestQueue(t)
+	ctx := context.Background()
+
+	if err := q.SetEventSchedule(ctx, "event-heavy", 3, 0, nil); err != nil {
+		t.Fatalf("set schedule: %v", err)
+	}
+	if err := q.SetEventSchedule(ctx, "event-light", 1, 0, nil); err != nil {
+		t.Fatalf("set schedule: %v", err)
+	}
+	enqueue(t, q, "event-heavy", queue.LaneStandard, 10)
+	enqueue(t, q, "event-light", queue.LaneStandard, 10)
+
+	served := map[string]int{}
+	for i := 0; i < 8; i++ {
+		item := dequeue(t, q)
+		served[item.EventID]++
+		if err := q.Ack(ctx, item); err != nil {
+			t.Fatalf("ack: %v", err)
+		}
+	}
+
+	if served["event-heavy"] != 6 || served["event-light"] != 2 {
+		t.Errorf("served %v over 8 dequeues, want 6 heavy and 2 light", served)
+	}
+}
+
+func TestDequeueRespectsConcurrencyCap(t *testing.T) {
+	q := newTestQueue(t)
+	ctx := context.Background()
+
+	if err := q.SetEventSchedule(ctx, "event-capped", 1, 2, nil); err != nil {
+		t.Fatalf("set schedule: %v", err)
+	}
+	enqueue(t, q, "event-capped", queue.LaneStandard, 3)
+
+	first := dequeue(t, q)
+	dequeue(t, q)
+
+	item, err := q.Dequeue(ctx, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("dequeue: %v", err)
+	}
+	if item != nil {
+		t.Fatalf("dequeued %s with two leases held on a cap of 2", item.ID)
+	}
+
+	// Acking frees the slot for the waiting item
+	if err := q.Ack(ctx, first); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+	dequeue(t, q)
+}
+
+func TestDequeueSharesByLane(t *testing.T) {
+	q := newTestQueue(t)
+	ctx := context.Background()
+
+	shares := map[queue.Lane]int{queue.LanePresale: 3, queue.LaneStandard: 1}
+	if err := q.SetEventSchedule(ctx, "event-lanes", 1, 0, shares); err != nil {
+		t.Fatalf("set schedule: %v", err)
+	}
+	enqueue(t, q, "event-lanes", queue.LanePresale, 10)
+	enqueue(t, q, "event-lanes", queue.LaneStandard, 10)
+
+	served := map[queue.Lane]int{}
+	for i := 0; i < 8; i++ {
+		served[queue.ItemLane(dequeue(t, q))]++
+	}
+
+	if served[queue.LanePresale] != 6 || served[queue.LaneStandard] != 2 {
+		t.Errorf("served %v over 8 dequeues, want 6 presale and 2 standard", served)
+	}
+}
+
+func TestDequeueSkipsPausedLane(t *testing.T) {
+	q := newTestQueue(t)
+	ctx := context.Background()
+
+	shares := map[queue.Lane]int{queue.LaneVIP: 0}
+	if err := q.SetEventSchedule(ctx, "event-paused", 1, 0, shares); err != nil {
+		t.Fatalf("set schedule: %v", err)
+	}
+	enqueue(t, q, "event-paused", queue.LaneVIP, 2)
+	enqueue(t, q, "event-paused", queue.LaneStandard, 2)
+
+	for i := 0; i < 2; i++ {
+		if lane := queue.ItemLane(dequeue(t, q)); lane != queue.LaneStandard {
+			t.Fatalf("dequeued from the %s lane, want standard while vip is paused", lane)
+		}
+	}
+
+	item, err := q.Dequeue(ctx, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("dequeue: %v", err)
+	}
+	if item != nil {
+		t.Fatalf("dequeued %s from a paused lane", item.ID)
+	}
+
+	// The paused items keep waiting and the event stays active for when the lane resumes
+	length, err := q.GetQueueLength(ctx, "event-paused")
+	if err != nil {
+		t.Fatalf("queue length: %v", err)
+	}
+	if length != 2 {
+		t.Errorf("queue length = %d, want the 2 paused items", length)
+	}
+	active, err := q.GetActiveQueues(ctx)
+	if err != nil {
+		t.Fatalf("active queues: %v", err)
+	}
+	if len(active) != 1 || active[0] != "event-paused" {
+		t.Errorf("active queues = %v, want [event-paused]", active)
+	}
+}
+
+func TestDequeueRemovesTrackingEntries(t *testing.T) {
+	q := newTestQueue(t)
+	ctx := context.Background()
+
+	items := enqueue(t, q, "event-drained", queue.LaneStandard, 2)
+
+	first := dequeue(t, q)
+	if first.ID != items[0].ID {
+		t.Fatalf("dequeued %s, want the first enqueued item %s", first.ID, items[0].ID)
+	}
+	if _, err := q.GetPosition(ctx, first.ID); err == nil {
+		t.Error("dequeued item still has a queue position")
+	}
+	if position, err := q.GetPosition(ctx, items[1].ID); err != nil || position != 1 {
+		t.Errorf("waiting item position = %d (%v), want 1", position, err)
+	}
+	if err := q.GetClient().ZScore(ctx, "booking:expiry-index", first.ID).Err(); err == nil {
+		t.Error("dequeued item is still in the expiry index")
+	}
+
+	// Draining the last item drops the event from the active set in the same step
+	dequeue(t, q)
+	active, err := q.GetActiveQueues(ctx)
+	if err != nil {
+		t.Fatalf("active queues: %v", err)
+	}
+	if len(active) != 0 {
+		t.Errorf("active queues = %v after draining, want none", active)
+	}
+}
+
+func TestLaneEntitlements(t *testing.T) {
+	q := newTestQueue(t)
+	ctx := context.Background()
+
+	if err := q.UpdateLaneEntitlements(ctx, "event-presale", queue.LanePresale, []string{"user-1", "user-2"}, nil); err != nil {
+		t.Fatalf("grant lane: %v", err)
+	}
+	if err := q.UpdateLaneEntitlements(ctx, "event-presale", queue.LanePresale, nil, []string{"user-2"}); err != nil {
+		t.Fatalf("revoke lane: %v", err)
+	}
+
+	tests := []struct {
+		userID string
+		lane   queue.Lane
+		want   bool
+	}{
+		{userID: "user-1", lane: queue.LanePresale, want: true},
+		{userID: "user-2", lane: queue.LanePresale, want: false},
+		{userID: "user-3", lane: queue.LanePresale, want: false},
+		{userID: "user-1", lane: queue.LaneVIP, want: false},
+		{userID: "user-3", lane: queue.LaneStandard, want: true},
+	}
+	for _, tt := range tests {
+		entitled, err := q.IsEntitledToLane(ctx, "event-presale", tt.userID, tt.lane)
+		if err != nil {
+			t.Fatalf("check entitlement: %v", err)
+		}
+		if entitled != tt.want {
+			t.Errorf("%s entitled to %s = %v, want %v", tt.userID, tt.lane, entitled, tt.want)
+		}
+	}
+}
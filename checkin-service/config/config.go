@@ -11,6 +11,7 @@ type Config struct {
 	Database      DatabaseConfig
 	GRPC          GRPCConfig
 	TicketService TicketServiceConfig
+	TicketQR      TicketQRConfig
 	Logging       LoggingConfig
 }
 
@@ -40,6 +41,15 @@ type TicketServiceConfig struct {
 	Port int
 }
 
+// TicketQRConfig holds the keys ticket QR codes are verified with. VerifyKeys is a
+// comma separated list of <key id>:<base64 Ed25519 public key>; keep retired keys until
+// the codes they signed have expired.
+type TicketQRConfig struct {
+	VerifyKeys    string
+	Leeway        time.Duration
+	AllowUnsigned bool
+}
+
 type LoggingConfig struct {
 	Level  string
 	Format string
@@ -69,6 +79,11 @@ func LoadConfig() (*Config, error) {
 			Host: getEnv("TICKET_SERVICE_HOST", "ticket-service"),
 			Port: getEnvAsInt("TICKET_SERVICE_PORT", 50054),
 		},
+		TicketQR: TicketQRConfig{
+			VerifyKeys:    getEnv("TICKET_QR_VERIFY_KEYS", ""),
+			Leeway:        getEnvAsDuration("TICKET_QR_LEEWAY", 2*time.Minute),
+			AllowUnsigned: getEnvAsBool("TICKET_QR_ALLOW_UNSIGNED", false),
+		},
 		Logging: LoggingConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "json"),
@@ -100,3 +115,12 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
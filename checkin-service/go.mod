@@ -27,6 +27,12 @@ require (
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
 )
 
-require grpctls v0.0.0
+require (
+	grpctls v0.0.0
+	ticketqr v0.0.0
+)
 
-replace grpctls => ../shared-lib/go/grpctls
+replace (
+	grpctls => ../shared-lib/go/grpctls
+	ticketqr => ../shared-lib/go/ticketqr
+)
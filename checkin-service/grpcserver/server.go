@@ -236,6 +236,12 @@ func domainErrToProto(e *services.CheckInError) checkinpb.CheckInError {
 		return checkinpb.CheckInError_CANCELLED_TICKET
 	case "TICKET_EVENT_MISMATCH":
 		return checkinpb.CheckInError_TICKET_EVENT_MISMATCH
+	case "EVENT_NOT_STARTED":
+		return checkinpb.CheckInError_EVENT_NOT_STARTED
+	case "EVENT_ENDED":
+		return checkinpb.CheckInError_EVENT_ENDED
+	case "INVALID_QR_CODE":
+		return checkinpb.CheckInError_INVALID_QR_CODE
 	default:
 		return checkinpb.CheckInError_CHECKIN_ERROR_UNSPECIFIED
 	}
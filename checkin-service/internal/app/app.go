@@ -8,6 +8,8 @@ import (
 	"syscall"
 	"time"
 
+	"ticketqr"
+
 	"go.uber.org/zap"
 
 	"checkin-service/config"
@@ -56,9 +58,19 @@ func (a *App) Initialize() error {
 		a.logger.Warn("gRPC clients unavailable at startup — will retry on first request", zap.Error(err))
 	}
 
+	// Ticket QR codes are verified offline against ticket-service's public keys
+	qrKeys, err := ticketqr.ParsePublicKeys(a.config.TicketQR.VerifyKeys)
+	if err != nil {
+		return fmt.Errorf("invalid ticket QR verify keys: %w", err)
+	}
+	qrVerifier, err := ticketqr.NewVerifier(qrKeys, a.config.TicketQR.Leeway)
+	if err != nil {
+		return fmt.Errorf("ticket QR verifier: %w", err)
+	}
+
 	// Wire up repositories and services
 	checkinRepo := repositories.NewCheckinRepository(db)
-	a.services = services.NewServices(checkinRepo, clients, a.logger)
+	a.services = services.NewServices(checkinRepo, clients, qrVerifier, a.config.TicketQR.AllowUnsigned, a.logger)
 
 	a.logger.Info("Checkin service initialized successfully")
 	return nil
@@ -2,7 +2,12 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
+
+	"ticketqr"
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
@@ -27,22 +32,81 @@ var (
 	ErrAlreadyCheckedIn    = &CheckInError{"ALREADY_CHECKED_IN", "ticket has already been checked in"}
 	ErrCancelledTicket     = &CheckInError{"CANCELLED_TICKET", "ticket has been cancelled"}
 	ErrTicketEventMismatch = &CheckInError{"TICKET_EVENT_MISMATCH", "ticket does not belong to this event"}
+	ErrInvalidQRCode       = &CheckInError{"INVALID_QR_CODE", "QR code is invalid or has been tampered with"}
+	ErrTicketNotYetValid   = &CheckInError{"EVENT_NOT_STARTED", "ticket is not valid yet"}
+	ErrTicketExpired       = &CheckInError{"EVENT_ENDED", "ticket is no longer valid"}
 )
 
+// legacyQRPrefix starts the unsigned codes issued before QR signing
+const legacyQRPrefix = "TICKET:"
+
 type CheckinService struct {
-	repo    *repositories.CheckinRepository
-	clients *grpcclient.Clients
-	logger  *zap.Logger
+	repo            *repositories.CheckinRepository
+	clients         *grpcclient.Clients
+	qrVerifier      *ticketqr.Verifier
+	allowUnsignedQR bool
+	logger          *zap.Logger
 }
 
-func NewCheckinService(repo *repositories.CheckinRepository, clients *grpcclient.Clients, logger *zap.Logger) *CheckinService {
-	return &CheckinService{repo: repo, clients: clients, logger: logger}
+func NewCheckinService(repo *repositories.CheckinRepository, clients *grpcclient.Clients, qrVerifier *ticketqr.Verifier, allowUnsignedQR bool, logger *zap.Logger) *CheckinService {
+	return &CheckinService{
+		repo:            repo,
+		clients:         clients,
+		qrVerifier:      qrVerifier,
+		allowUnsignedQR: allowUnsignedQR,
+		logger:          logger,
+	}
+}
+
+// VerifyQRCode checks a scanned QR code offline — its signature, key and validity
+// window — and returns what it asserts. It needs no call to ticket-service, so gate
+// devices can run it to reject forged or altered codes before anything else.
+func (s *CheckinService) VerifyQRCode(qrCode string, now time.Time) (*ticketqr.Claims, error) {
+	claims, err := s.qrVerifier.Verify(qrCode, now)
+	switch {
+	case err == nil:
+		return claims, nil
+	case errors.Is(err, ticketqr.ErrNotYetValid):
+		return nil, ErrTicketNotYetValid
+	case errors.Is(err, ticketqr.ErrExpired):
+		return nil, ErrTicketExpired
+	default:
+		s.logger.Warn("Rejected QR code", zap.Error(err))
+		return nil, ErrInvalidQRCode
+	}
+}
+
+// verifyQRCode checks the QR code of a check-in and that it is for the ticket and event
+// being checked in. Legacy unsigned codes pass only while allowUnsignedQR is set.
+func (s *CheckinService) verifyQRCode(req *models.CheckIn) error {
+	if s.allowUnsignedQR && strings.HasPrefix(req.QRCode, legacyQRPrefix) {
+		s.logger.Warn("Accepting unsigned legacy QR code", zap.String("ticket_id", req.TicketID))
+		return nil
+	}
+
+	claims, err := s.VerifyQRCode(req.QRCode, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// Fill ticket_id from the QR code if not provided
+	if req.TicketID == "" {
+		req.TicketID = claims.TicketID
+	}
+	if claims.TicketID != req.TicketID {
+		return ErrInvalidQRCode
+	}
+	if claims.EventID != req.EventID {
+		return ErrTicketEventMismatch
+	}
+	return nil
 }
 
 // CheckIn validates and records an event check-in.
 //
 // Flow:
-//  1. Validate ticket via ticket-service gRPC
+//  0. Verify the QR code signature and validity window offline
+//  1. Validate ticket via ticket-service gRPC, and that the QR code is its current one
 //  2. Ensure ticket belongs to the given event
 //  3. Ensure ticket status is "sold"
 //  4. Idempotency check — reject duplicates
@@ -54,6 +118,11 @@ func (s *CheckinService) CheckIn(ctx context.Context, req *models.CheckIn) (*mod
 		zap.String("event_id", req.EventID),
 	)
 
+	// --- Step 0: Verify QR code offline ---
+	if err := s.verifyQRCode(req); err != nil {
+		return nil, err
+	}
+
 	// --- Step 1: Validate ticket via ticket-service ---
 	ticketResp, err := s.clients.Ticket.GetTicket(ctx, &ticketpb.GetTicketRequest{
 		TicketId: req.TicketID,
@@ -71,6 +140,12 @@ func (s *CheckinService) CheckIn(ctx context.Context, req *models.CheckIn) (*mod
 
 	ticket := ticketResp.Ticket
 
+	// A transfer re-issues the QR code: codes other than the ticket's current one are void
+	if ticket.QrCode != "" && ticket.QrCode != req.QRCode {
+		s.logger.Warn("Superseded QR code presented", zap.String("ticket_id", req.TicketID))
+		return nil, ErrInvalidQRCode
+	}
+
 	// --- Step 2: Check ticket belongs to the event ---
 	if ticket.EventId != req.EventID {
 		return nil, ErrTicketEventMismatch
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"ticketqr"
+
 	"go.uber.org/zap"
 
 	"checkin-service/grpcclient"
@@ -11,9 +13,9 @@ type Services struct {
 	Checkin *CheckinService
 }
 
-func NewServices(repo *repositories.CheckinRepository, clients *grpcclient.Clients, logger *zap.Logger) *Services {
+func NewServices(repo *repositories.CheckinRepository, clients *grpcclient.Clients, qrVerifier *ticketqr.Verifier, allowUnsignedQR bool, logger *zap.Logger) *Services {
 	return &Services{
-		Checkin: NewCheckinService(repo, clients, logger),
+		Checkin: NewCheckinService(repo, clients, qrVerifier, allowUnsignedQR, logger),
 	}
 }
 
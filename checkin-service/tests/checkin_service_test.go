@@ -38,9 +38,12 @@ func TestCheckinService_ErrCodesExist(t *testing.T) {
 	_ = services.ErrInvalidTicket
 	_ = services.ErrCancelledTicket
 	_ = services.ErrTicketEventMismatch
+	_ = services.ErrInvalidQRCode
+	_ = services.ErrTicketNotYetValid
+	_ = services.ErrTicketExpired
 
 	logger, _ := zap.NewDevelopment()
-	svc := services.NewCheckinService(nil, nil, logger)
+	svc := services.NewCheckinService(nil, nil, nil, false, logger)
 	_ = svc
 
 	// Nil repo → panics on real calls; this just validates the constructor compiles.
@@ -73,8 +73,11 @@ func (h *NotificationHandler) NotifyQueuePosition(ctx context.Context, req *pb.N
 	delivered := h.notificationService.NotifyQueuePosition(
 		req.GetUserId(),
 		req.GetEventId(),
+		"",
 		int(req.GetPosition()),
 		int(req.GetEstimatedWaitSeconds()),
+		int(req.GetEstimatedWaitMinSeconds()),
+		int(req.GetEstimatedWaitMaxSeconds()),
 		int(req.GetTotalInQueue()),
 	)
 
@@ -83,6 +86,64 @@ func (h *NotificationHandler) NotifyQueuePosition(ctx context.Context, req *pb.N
 	}, nil
 }
 
+// NotifyQueuePositionBatch handles queue position notifications for many users of one event
+func (h *NotificationHandler) NotifyQueuePositionBatch(ctx context.Context, req *pb.NotifyQueuePositionBatchRequest) (*pb.NotifyQueuePositionBatchResponse, error) {
+	if req.GetEventId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "event_id is required")
+	}
+
+	deliveredCount := 0
+	for _, update := range req.GetUpdates() {
+		if update.GetUserId() == "" {
+			continue
+		}
+		if h.notificationService.NotifyQueuePosition(
+			update.GetUserId(),
+			req.GetEventId(),
+			req.GetLane(),
+			int(update.GetPosition()),
+			int(update.GetEstimatedWaitSeconds()),
+			int(update.GetEstimatedWaitMinSeconds()),
+			int(update.GetEstimatedWaitMaxSeconds()),
+			int(req.GetTotalInQueue()),
+		) {
+			deliveredCount++
+		}
+	}
+
+	logger.Debug("NotifyQueuePositionBatch called",
+		zap.String("event_id", req.GetEventId()),
+		zap.String("lane", req.GetLane()),
+		zap.Int("updates", len(req.GetUpdates())),
+		zap.Int("delivered", deliveredCount),
+	)
+
+	return &pb.NotifyQueuePositionBatchResponse{
+		DeliveredCount: int32(deliveredCount),
+	}, nil
+}
+
+// NotifyBookingExpired handles booking expiry notifications
+func (h *NotificationHandler) NotifyBookingExpired(ctx context.Context, req *pb.NotifyBookingExpiredRequest) (*pb.NotifyBookingExpiredResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "user_id is required")
+	}
+	if req.GetQueueItemId() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "queue_item_id is required")
+	}
+
+	delivered := h.notificationService.NotifyBookingExpired(
+		req.GetUserId(),
+		req.GetEventId(),
+		req.GetQueueItemId(),
+		req.GetExpiredAt(),
+	)
+
+	return &pb.NotifyBookingExpiredResponse{
+		Delivered: delivered,
+	}, nil
+}
+
 // NotifyPaymentStatus handles payment status notifications
 func (h *NotificationHandler) NotifyPaymentStatus(ctx context.Context, req *pb.NotifyPaymentStatusRequest) (*pb.NotifyPaymentStatusResponse, error) {
 	if req.GetUserId() == "" {
@@ -63,13 +63,17 @@ func (s *NotificationService) NotifyBookingResult(userID, bookingID string, succ
 	return delivered, count
 }
 
-// NotifyQueuePosition sends queue position update to a user
-func (s *NotificationService) NotifyQueuePosition(userID, eventID string, position, estimatedWait, totalInQueue int) bool {
+// NotifyQueuePosition sends queue position update to a user.
+// lane names the priority lane the position is counted in (empty for the whole queue).
+func (s *NotificationService) NotifyQueuePosition(userID, eventID, lane string, position, estimatedWait, estimatedWaitMin, estimatedWaitMax, totalInQueue int) bool {
 	payload := websocket.QueuePositionPayload{
-		Position:      position,
-		EstimatedWait: estimatedWait,
-		EventID:       eventID,
-		TotalInQueue:  totalInQueue,
+		Position:         position,
+		EstimatedWait:    estimatedWait,
+		EstimatedWaitMin: estimatedWaitMin,
+		EstimatedWaitMax: estimatedWaitMax,
+		EventID:          eventID,
+		TotalInQueue:     totalInQueue,
+		Lane:             lane,
 	}
 
 	msg, err := websocket.NewMessage(websocket.TypeBookingQueuePosition, payload)
@@ -88,6 +92,37 @@ func (s *NotificationService) NotifyQueuePosition(userID, eventID string, positi
 	return count > 0
 }
 
+// NotifyBookingExpired tells a user their waiting booking request expired
+func (s *NotificationService) NotifyBookingExpired(userID, eventID, queueItemID string, expiredAt int64) bool {
+	payload := websocket.BookingExpiredPayload{
+		QueueItemID: queueItemID,
+		EventID:     eventID,
+		ExpiredAt:   expiredAt,
+	}
+
+	msg, err := websocket.NewMessage(websocket.TypeBookingExpired, payload)
+	if err != nil {
+		logger.Error("failed to create booking expired message", zap.Error(err))
+		return false
+	}
+
+	data, err := msg.Bytes()
+	if err != nil {
+		logger.Error("failed to serialize message", zap.Error(err))
+		return false
+	}
+
+	count := s.hub.SendToUser(userID, data)
+
+	logger.Info("booking expired notification sent",
+		zap.String("user_id", userID),
+		zap.String("queue_item_id", queueItemID),
+		zap.Int("connections", count),
+	)
+
+	return count > 0
+}
+
 // NotifyPaymentStatus sends payment status update to a user
 func (s *NotificationService) NotifyPaymentStatus(userID, bookingID, paymentID, status, message, amount, currency string) bool {
 	payload := websocket.PaymentStatusPayload{
@@ -12,6 +12,7 @@ const (
 	TypeBookingConfirmed     = "booking:confirmed"
 	TypeBookingFailed        = "booking:failed"
 	TypeBookingCancelled     = "booking:cancelled"
+	TypeBookingExpired       = "booking:expired"
 
 	// Payment events
 	TypePaymentProcessing = "payment:processing"
@@ -82,10 +83,13 @@ type ConnectedPayload struct {
 
 // QueuePositionPayload represents the payload for booking:queue_position event
 type QueuePositionPayload struct {
-	Position      int    `json:"position"`
-	EstimatedWait int    `json:"estimated_wait"`
-	EventID       string `json:"event_id"`
-	TotalInQueue  int    `json:"total_in_queue"`
+	Position         int    `json:"position"`
+	EstimatedWait    int    `json:"estimated_wait"`
+	EstimatedWaitMin int    `json:"estimated_wait_min"`
+	EstimatedWaitMax int    `json:"estimated_wait_max"`
+	EventID          string `json:"event_id"`
+	TotalInQueue     int    `json:"total_in_queue"`
+	Lane             string `json:"lane,omitempty"`
 }
 
 // BookingResultPayload represents the payload for booking result events
@@ -100,6 +104,13 @@ type BookingResultPayload struct {
 	Currency         string   `json:"currency,omitempty"`
 }
 
+// BookingExpiredPayload represents the payload for booking:expired event
+type BookingExpiredPayload struct {
+	QueueItemID string `json:"queue_item_id"`
+	EventID     string `json:"event_id"`
+	ExpiredAt   int64  `json:"expired_at"`
+}
+
 // PaymentStatusPayload represents the payload for payment status events
 type PaymentStatusPayload struct {
 	PaymentID string `json:"payment_id"`
@@ -0,0 +1,3 @@
+module ticketqr
+
+go 1.21
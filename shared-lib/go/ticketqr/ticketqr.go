@@ -0,0 +1,248 @@
+// Package ticketqr signs and verifies ticket QR codes. A code is an Ed25519-signed token
+// carrying the ticket, its event and seat, the window it is valid in, a random nonce and
+// the ID of the signing key, so it can be checked with nothing but the public keys — e.g.
+// by a gate scanner that cannot reach ticket-service. Keys rotate by adding a new key ID: signers
+// switch to it while verifiers keep the old public keys until codes signed with them expire.
+//
+// Token format: TQR1.<key id>.<base64url claims JSON>.<base64url signature>, where the
+// signature covers everything before the last dot.
+package ticketqr
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Version prefixes every token, so the format can change without breaking old codes
+const Version = "TQR1"
+
+var (
+	// ErrMalformed is returned for codes that are not a ticket token
+	ErrMalformed = errors.New("ticketqr: malformed token")
+	// ErrUnknownKey is returned for codes signed with a key the verifier does not have
+	ErrUnknownKey = errors.New("ticketqr: unknown signing key")
+	// ErrBadSignature is returned for codes whose signature does not match, e.g. tampered ones
+	ErrBadSignature = errors.New("ticketqr: invalid signature")
+	// ErrNotYetValid is returned before the code's validity window opens
+	ErrNotYetValid = errors.New("ticketqr: token not valid yet")
+	// ErrExpired is returned after the code's validity window closed
+	ErrExpired = errors.New("ticketqr: token expired")
+)
+
+// Claims is what a ticket token asserts
+type Claims struct {
+	TicketID  string    `json:"tid"`
+	EventID   string    `json:"eid"`
+	SeatID    string    `json:"sid,omitempty"`
+	NotBefore time.Time `json:"-"`
+	ExpiresAt time.Time `json:"-"`
+	// IssuedAt tells apart codes re-issued for the same ticket, e.g. after a transfer
+	IssuedAt time.Time `json:"-"`
+	// Nonce makes every code unique, even codes for the same claims issued within the same
+	// second; Sign picks a random one when it is empty
+	Nonce string `json:"-"`
+	// KeyID is the key the token was signed with; set by Verify
+	KeyID string `json:"-"`
+}
+
+// wireClaims is Claims as encoded in a token, with times as Unix seconds
+type wireClaims struct {
+	TicketID  string `json:"tid"`
+	EventID   string `json:"eid"`
+	SeatID    string `json:"sid,omitempty"`
+	NotBefore int64  `json:"nbf"`
+	ExpiresAt int64  `json:"exp"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	Nonce     string `json:"jti,omitempty"`
+}
+
+var encoding = base64.RawURLEncoding
+
+// Signer signs ticket tokens with one key
+type Signer struct {
+	keyID string
+	key   ed25519.PrivateKey
+}
+
+// NewSigner creates a signer that signs with key under keyID
+func NewSigner(keyID string, key ed25519.PrivateKey) (*Signer, error) {
+	if keyID == "" || strings.Contains(keyID, ".") {
+		return nil, fmt.Errorf("ticketqr: invalid key id %q", keyID)
+	}
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("ticketqr: invalid private key for key id %s", keyID)
+	}
+	return &Signer{keyID: keyID, key: key}, nil
+}
+
+// KeyID returns the ID of the key the signer signs with
+func (s *Signer) KeyID() string {
+	return s.keyID
+}
+
+// Sign creates the token for claims
+func (s *Signer) Sign(claims Claims) (string, error) {
+	if claims.TicketID == "" || claims.EventID == "" {
+		return "", errors.New("ticketqr: ticket id and event id are required")
+	}
+	if !claims.ExpiresAt.After(claims.NotBefore) {
+		return "", errors.New("ticketqr: token must expire after it becomes valid")
+	}
+
+	wire := wireClaims{
+		TicketID:  claims.TicketID,
+		EventID:   claims.EventID,
+		SeatID:    claims.SeatID,
+		NotBefore: claims.NotBefore.Unix(),
+		ExpiresAt: claims.ExpiresAt.Unix(),
+		Nonce:     claims.Nonce,
+	}
+	if wire.Nonce == "" {
+		nonce := make([]byte, 12)
+		if _, err := rand.Read(nonce); err != nil {
+			return "", fmt.Errorf("ticketqr: failed to generate nonce: %w", err)
+		}
+		wire.Nonce = encoding.EncodeToString(nonce)
+	}
+	if !claims.IssuedAt.IsZero() {
+		wire.IssuedAt = claims.IssuedAt.Unix()
+	}
+
+	payload, err := json.Marshal(wire)
+	if err != nil {
+		return "", fmt.Errorf("ticketqr: failed to encode claims: %w", err)
+	}
+
+	signed := Version + "." + s.keyID + "." + encoding.EncodeToString(payload)
+	signature := ed25519.Sign(s.key, []byte(signed))
+	return signed + "." + encoding.EncodeToString(signature), nil
+}
+
+// Verifier checks ticket tokens against a set of public keys
+type Verifier struct {
+	keys   map[string]ed25519.PublicKey
+	leeway time.Duration
+}
+
+// NewVerifier creates a verifier that accepts tokens signed with any of keys, by key ID.
+// leeway absorbs clock drift between the signer and the verifying device.
+func NewVerifier(keys map[string]ed25519.PublicKey, leeway time.Duration) (*Verifier, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("ticketqr: at least one verification key is required")
+	}
+	for keyID, key := range keys {
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("ticketqr: invalid public key for key id %s", keyID)
+		}
+	}
+	return &Verifier{keys: keys, leeway: leeway}, nil
+}
+
+// Verify checks that token was signed with a known key, was not changed and is valid at now
+func (v *Verifier) Verify(token string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 || parts[0] != Version {
+		return nil, ErrMalformed
+	}
+
+	key, ok := v.keys[parts[1]]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, parts[1])
+	}
+
+	signature, err := encoding.DecodeString(parts[3])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	signed := token[:len(token)-len(parts[3])-1]
+	if !ed25519.Verify(key, []byte(signed), signature) {
+		return nil, ErrBadSignature
+	}
+
+	payload, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	var wire wireClaims
+	if err := json.Unmarshal(payload, &wire); err != nil {
+		return nil, ErrMalformed
+	}
+
+	claims := &Claims{
+		TicketID:  wire.TicketID,
+		EventID:   wire.EventID,
+		SeatID:    wire.SeatID,
+		NotBefore: time.Unix(wire.NotBefore, 0),
+		ExpiresAt: time.Unix(wire.ExpiresAt, 0),
+		Nonce:     wire.Nonce,
+		KeyID:     parts[1],
+	}
+	if wire.IssuedAt != 0 {
+		claims.IssuedAt = time.Unix(wire.IssuedAt, 0)
+	}
+
+	if now.Add(v.leeway).Before(claims.NotBefore) {
+		return claims, ErrNotYetValid
+	}
+	if !now.Add(-v.leeway).Before(claims.ExpiresAt) {
+		return claims, ErrExpired
+	}
+
+	return claims, nil
+}
+
+// ParsePrivateKeys parses a comma separated list of <key id>:<base64 Ed25519 seed> pairs
+func ParsePrivateKeys(s string) (map[string]ed25519.PrivateKey, error) {
+	seeds, err := parseKeyList(s, ed25519.SeedSize)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]ed25519.PrivateKey, len(seeds))
+	for keyID, seed := range seeds {
+		keys[keyID] = ed25519.NewKeyFromSeed(seed)
+	}
+	return keys, nil
+}
+
+// ParsePublicKeys parses a comma separated list of <key id>:<base64 Ed25519 public key> pairs
+func ParsePublicKeys(s string) (map[string]ed25519.PublicKey, error) {
+	raw, err := parseKeyList(s, ed25519.PublicKeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]ed25519.PublicKey, len(raw))
+	for keyID, key := range raw {
+		keys[keyID] = ed25519.PublicKey(key)
+	}
+	return keys, nil
+}
+
+func parseKeyList(s string, size int) (map[string][]byte, error) {
+	keys := make(map[string][]byte)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		keyID, encoded, ok := strings.Cut(entry, ":")
+		if !ok || keyID == "" || strings.Contains(keyID, ".") {
+			return nil, fmt.Errorf("ticketqr: invalid key entry %q, want <key id>:<base64 key>", keyID)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != size {
+			return nil, fmt.Errorf("ticketqr: invalid key for key id %s", keyID)
+		}
+		keys[keyID] = key
+	}
+	return keys, nil
+}
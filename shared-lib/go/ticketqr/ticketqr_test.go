@@ -0,0 +1,246 @@
+package ticketqr
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return public, private
+}
+
+func newSigner(t *testing.T, keyID string, key ed25519.PrivateKey) *Signer {
+	t.Helper()
+	signer, err := NewSigner(keyID, key)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	return signer
+}
+
+func newVerifier(t *testing.T, keys map[string]ed25519.PublicKey, leeway time.Duration) *Verifier {
+	t.Helper()
+	verifier, err := NewVerifier(keys, leeway)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	return verifier
+}
+
+var (
+	validFrom  = time.Date(2026, 6, 1, 18, 0, 0, 0, time.UTC)
+	validUntil = time.Date(2026, 6, 1, 23, 0, 0, 0, time.UTC)
+)
+
+func testClaims() Claims {
+	return Claims{
+		TicketID:  "ticket-1",
+		EventID:   "event-1",
+		SeatID:    "seat-1",
+		NotBefore: validFrom,
+		ExpiresAt: validUntil,
+		IssuedAt:  validFrom.Add(-time.Hour),
+	}
+}
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	public, private := newKey(t)
+	token, err := newSigner(t, "k1", private).Sign(testClaims())
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	claims, err := newVerifier(t, map[string]ed25519.PublicKey{"k1": public}, 0).Verify(token, validFrom.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	want := testClaims()
+	if claims.TicketID != want.TicketID || claims.EventID != want.EventID || claims.SeatID != want.SeatID {
+		t.Errorf("claims = %+v, want ticket, event and seat of %+v", claims, want)
+	}
+	if !claims.NotBefore.Equal(want.NotBefore) || !claims.ExpiresAt.Equal(want.ExpiresAt) || !claims.IssuedAt.Equal(want.IssuedAt) {
+		t.Errorf("claims times = %v..%v issued %v, want %v..%v issued %v",
+			claims.NotBefore, claims.ExpiresAt, claims.IssuedAt, want.NotBefore, want.ExpiresAt, want.IssuedAt)
+	}
+	if claims.KeyID != "k1" {
+		t.Errorf("KeyID = %q, want k1", claims.KeyID)
+	}
+	if claims.Nonce == "" {
+		t.Error("Nonce is empty, want a random nonce")
+	}
+}
+
+func TestSignSameClaimsYieldsDistinctTokens(t *testing.T) {
+	_, private := newKey(t)
+	signer := newSigner(t, "k1", private)
+
+	first, err := signer.Sign(testClaims())
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	second, err := signer.Sign(testClaims())
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if first == second {
+		t.Error("codes signed for the same claims in the same second are identical")
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	public, private := newKey(t)
+	token, err := newSigner(t, "k1", private).Sign(testClaims())
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	parts := strings.Split(token, ".")
+
+	_, otherPrivate := newKey(t)
+	forged, err := newSigner(t, "k1", otherPrivate).Sign(testClaims())
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	otherClaims := testClaims()
+	otherClaims.TicketID = "ticket-2"
+	otherToken, err := newSigner(t, "k1", private).Sign(otherClaims)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	otherPayload := strings.Split(otherToken, ".")[2]
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"tampered payload", strings.Join([]string{parts[0], parts[1], otherPayload, parts[3]}, "."), ErrBadSignature},
+		{"tampered signature", strings.Join([]string{parts[0], parts[1], parts[2], strings.Split(forged, ".")[3]}, "."), ErrBadSignature},
+		{"forged with another key", forged, ErrBadSignature},
+		{"unknown key id", strings.Join([]string{parts[0], "k2", parts[2], parts[3]}, "."), ErrUnknownKey},
+		{"wrong version", strings.Join([]string{"TQR0", parts[1], parts[2], parts[3]}, "."), ErrMalformed},
+		{"missing part", strings.Join(parts[:3], "."), ErrMalformed},
+		{"undecodable signature", strings.Join([]string{parts[0], parts[1], parts[2], "!"}, "."), ErrMalformed},
+		{"empty", "", ErrMalformed},
+	}
+
+	verifier := newVerifier(t, map[string]ed25519.PublicKey{"k1": public}, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := verifier.Verify(tt.token, validFrom.Add(time.Hour)); !errors.Is(err, tt.want) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyKeyRotation(t *testing.T) {
+	oldPublic, oldPrivate := newKey(t)
+	newPublic, newPrivate := newKey(t)
+
+	oldToken, err := newSigner(t, "k1", oldPrivate).Sign(testClaims())
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	newToken, err := newSigner(t, "k2", newPrivate).Sign(testClaims())
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		keys  map[string]ed25519.PublicKey
+		token string
+		want  error
+	}{
+		{"old code during rotation", map[string]ed25519.PublicKey{"k1": oldPublic, "k2": newPublic}, oldToken, nil},
+		{"new code during rotation", map[string]ed25519.PublicKey{"k1": oldPublic, "k2": newPublic}, newToken, nil},
+		{"old code after old key is dropped", map[string]ed25519.PublicKey{"k2": newPublic}, oldToken, ErrUnknownKey},
+		{"new code before new key is rolled out", map[string]ed25519.PublicKey{"k1": oldPublic}, newToken, ErrUnknownKey},
+		{"code verified with another key's public key", map[string]ed25519.PublicKey{"k1": newPublic}, oldToken, ErrBadSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := newVerifier(t, tt.keys, 0).Verify(tt.token, validFrom.Add(time.Hour))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.want)
+			}
+			if err == nil && claims.KeyID != strings.Split(tt.token, ".")[1] {
+				t.Errorf("KeyID = %q, want the key the code was signed with", claims.KeyID)
+			}
+		})
+	}
+}
+
+func TestVerifyValidityWindow(t *testing.T) {
+	public, private := newKey(t)
+	token, err := newSigner(t, "k1", private).Sign(testClaims())
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	const leeway = 2 * time.Minute
+	tests := []struct {
+		name string
+		now  time.Time
+		want error
+	}{
+		{"before leeway opens", validFrom.Add(-leeway - time.Second), ErrNotYetValid},
+		{"as leeway opens", validFrom.Add(-leeway), nil},
+		{"at valid from", validFrom, nil},
+		{"just before valid until", validUntil.Add(-time.Second), nil},
+		{"at valid until within leeway", validUntil, nil},
+		{"just before leeway closes", validUntil.Add(leeway - time.Second), nil},
+		{"as leeway closes", validUntil.Add(leeway), ErrExpired},
+		{"long after", validUntil.Add(24 * time.Hour), ErrExpired},
+	}
+
+	verifier := newVerifier(t, map[string]ed25519.PublicKey{"k1": public}, leeway)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifier.Verify(token, tt.now)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.want)
+			}
+			if claims == nil || claims.TicketID != "ticket-1" {
+				t.Errorf("claims = %+v, want the code's claims even when it is outside its window", claims)
+			}
+		})
+	}
+}
+
+func TestSignRejectsInvalidClaims(t *testing.T) {
+	_, private := newKey(t)
+	signer := newSigner(t, "k1", private)
+
+	tests := []struct {
+		name   string
+		mutate func(*Claims)
+	}{
+		{"no ticket id", func(c *Claims) { c.TicketID = "" }},
+		{"no event id", func(c *Claims) { c.EventID = "" }},
+		{"expires when it becomes valid", func(c *Claims) { c.ExpiresAt = c.NotBefore }},
+		{"expires before it becomes valid", func(c *Claims) { c.ExpiresAt = c.NotBefore.Add(-time.Hour) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := testClaims()
+			tt.mutate(&claims)
+			if _, err := signer.Sign(claims); err == nil {
+				t.Error("Sign() succeeded, want an error")
+			}
+		})
+	}
+}
@@ -14,6 +14,14 @@ type Config struct {
 	GRPC        GRPCConfig
 	Event       EventServiceConfig
 	Payment     PaymentServiceConfig
+	Realtime    RealtimeServiceConfig
+	Saga        SagaConfig
+	Outbox      OutboxConfig
+	TicketQR    TicketQRConfig
+	Transfer    TransferConfig
+	WaitingRoom WaitingRoomConfig
+	Group       GroupBookingConfig
+	Sweeper     SweeperConfig
 	Logging     LoggingConfig
 	MetricsPort string
 }
@@ -58,6 +66,116 @@ type PaymentServiceConfig struct {
 	Port string
 }
 
+// RealtimeServiceConfig holds Realtime Service gRPC configuration
+type RealtimeServiceConfig struct {
+	Host string
+	Port string
+}
+
+// SagaConfig holds booking saga configuration
+type SagaConfig struct {
+	// LeaseDuration is how long an instance owns a saga without saving progress
+	// before another instance may resume it
+	LeaseDuration time.Duration
+	// ResumeInterval is how often sagas left behind by a restart or a failed step are resumed
+	ResumeInterval  time.Duration
+	ResumeBatchSize int
+	// RetryDelay is the wait before a step that failed with a transient error is retried;
+	// after MaxAttempts failures the saga is compensated
+	RetryDelay  time.Duration
+	MaxAttempts int
+	// HoldExtension is how long seat holds are extended for while the booking is paid for
+	HoldExtension time.Duration
+	// PaymentTimeout is how long, from the start of the saga, a pending payment is polled
+	// for before the booking fails and the payment is cancelled. Keep it below HoldExtension.
+	PaymentTimeout time.Duration
+}
+
+// OutboxConfig holds outbox relay configuration
+type OutboxConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// RetryDelay is the wait before a failed publish is retried, doubled per failed
+	// attempt up to MaxRetryDelay. Events are retried until they are published.
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+	// Retention is how long published events are kept before they are deleted
+	Retention       time.Duration
+	CleanupInterval time.Duration
+	// Redis streams events are published to, by aggregate
+	BookingStream string
+	TicketStream  string
+	// StreamMaxLen caps each stream, approximately, to its most recent entries
+	StreamMaxLen int
+}
+
+// TicketQRConfig holds ticket QR code signing configuration
+type TicketQRConfig struct {
+	// SigningKeyID selects the key new codes are signed with from SigningKeys, a comma
+	// separated list of <key id>:<base64 Ed25519 seed>. To rotate, add a key and switch
+	// SigningKeyID to it; check-in keeps the old public key until its codes expire.
+	SigningKeyID string
+	SigningKeys  string
+	// DefaultValidity is how long codes of tickets without a valid_until stay valid
+	DefaultValidity time.Duration
+}
+
+// TransferConfig holds ticket transfer configuration
+type TransferConfig struct {
+	// DefaultExpiry is how long a recipient has to accept a transfer unless the sender
+	// picks a shorter or longer time, up to MaxExpiry
+	DefaultExpiry time.Duration
+	MaxExpiry     time.Duration
+}
+
+// WaitingRoomConfig holds event waiting room configuration
+type WaitingRoomConfig struct {
+	// TokenSecret signs admission tokens (HMAC-SHA256)
+	TokenSecret string
+	// AdmissionTTL is how long an admitted user has to start a booking session before
+	// the admission expires and they have to queue again
+	AdmissionTTL time.Duration
+	// QueueTimeout is how long a user stays in a waiting room without being admitted
+	QueueTimeout time.Duration
+	// DefaultAdmissionRate is the users admitted per minute by rooms opened without a rate
+	DefaultAdmissionRate int
+	// AdmitInterval is how often users are admitted from each open room, and
+	// AdmitBatchSize caps the users admitted from a room at once
+	AdmitInterval   time.Duration
+	AdmitBatchSize  int
+	CleanupInterval time.Duration
+}
+
+// GroupBookingConfig holds group booking configuration
+type GroupBookingConfig struct {
+	// DefaultPaymentWindow is how long the members of a group have to pay for their
+	// seats unless the organizer picks a shorter or longer time, up to MaxPaymentWindow
+	DefaultPaymentWindow time.Duration
+	MaxPaymentWindow     time.Duration
+	MaxSeats             int
+	// SettleGrace is how long seats stay held past the payment deadline, so the paid
+	// ones are still held while the group is settled
+	SettleGrace time.Duration
+	// LeaseDuration is how long an instance owns a group it settles or cancels before
+	// another instance may take over
+	LeaseDuration time.Duration
+	// SweepInterval is how often groups past their deadline are settled
+	SweepInterval  time.Duration
+	SweepBatchSize int
+}
+
+// SweeperConfig holds expiry sweeper configuration
+type SweeperConfig struct {
+	// Interval is how often expired sessions and reservations are swept
+	Interval time.Duration
+	// BatchSize bounds how many sessions, and how many reservations, are expired at a time
+	BatchSize int
+	// LeaderTTL is how long the sweeping instance stays leader without renewing; another
+	// instance takes over once it lapses
+	LeaderTTL time.Duration
+	LeaderKey string
+}
+
 // LoggingConfig holds logging configuration
 type LoggingConfig struct {
 	Level  string
@@ -109,6 +227,63 @@ func LoadConfig() *Config {
 			Host: getEnv("PAYMENT_SERVICE_HOST", "localhost"),
 			Port: getEnv("PAYMENT_SERVICE_PORT", "50054"),
 		},
+		Realtime: RealtimeServiceConfig{
+			Host: getEnv("REALTIME_SERVICE_HOST", "localhost"),
+			Port: getEnv("REALTIME_SERVICE_PORT", "50057"),
+		},
+		Saga: SagaConfig{
+			LeaseDuration:   getDurationEnv("SAGA_LEASE_DURATION", "1m"),
+			ResumeInterval:  getDurationEnv("SAGA_RESUME_INTERVAL", "15s"),
+			ResumeBatchSize: getIntEnv("SAGA_RESUME_BATCH_SIZE", 20),
+			RetryDelay:      getDurationEnv("SAGA_RETRY_DELAY", "10s"),
+			MaxAttempts:     getIntEnv("SAGA_MAX_ATTEMPTS", 5),
+			HoldExtension:   getDurationEnv("SAGA_HOLD_EXTENSION", "10m"),
+			PaymentTimeout:  getDurationEnv("SAGA_PAYMENT_TIMEOUT", "5m"),
+		},
+		Outbox: OutboxConfig{
+			PollInterval:    getDurationEnv("OUTBOX_POLL_INTERVAL", "500ms"),
+			BatchSize:       getIntEnv("OUTBOX_BATCH_SIZE", 100),
+			RetryDelay:      getDurationEnv("OUTBOX_RETRY_DELAY", "1s"),
+			MaxRetryDelay:   getDurationEnv("OUTBOX_MAX_RETRY_DELAY", "5m"),
+			Retention:       getDurationEnv("OUTBOX_RETENTION", "168h"),
+			CleanupInterval: getDurationEnv("OUTBOX_CLEANUP_INTERVAL", "1h"),
+			BookingStream:   getEnv("OUTBOX_BOOKING_STREAM", "booking-events"),
+			TicketStream:    getEnv("OUTBOX_TICKET_STREAM", "ticket-events"),
+			StreamMaxLen:    getIntEnv("OUTBOX_STREAM_MAX_LEN", 100000),
+		},
+		TicketQR: TicketQRConfig{
+			SigningKeyID:    getEnv("TICKET_QR_SIGNING_KEY_ID", ""),
+			SigningKeys:     getEnv("TICKET_QR_SIGNING_KEYS", ""),
+			DefaultValidity: getDurationEnv("TICKET_QR_DEFAULT_VALIDITY", "8760h"),
+		},
+		Transfer: TransferConfig{
+			DefaultExpiry: getDurationEnv("TICKET_TRANSFER_DEFAULT_EXPIRY", "48h"),
+			MaxExpiry:     getDurationEnv("TICKET_TRANSFER_MAX_EXPIRY", "168h"),
+		},
+		WaitingRoom: WaitingRoomConfig{
+			TokenSecret:          getEnv("WAITING_ROOM_TOKEN_SECRET", ""),
+			AdmissionTTL:         getDurationEnv("WAITING_ROOM_ADMISSION_TTL", "10m"),
+			QueueTimeout:         getDurationEnv("WAITING_ROOM_QUEUE_TIMEOUT", "2h"),
+			DefaultAdmissionRate: getIntEnv("WAITING_ROOM_DEFAULT_ADMISSION_RATE", 100),
+			AdmitInterval:        getDurationEnv("WAITING_ROOM_ADMIT_INTERVAL", "1s"),
+			AdmitBatchSize:       getIntEnv("WAITING_ROOM_ADMIT_BATCH_SIZE", 500),
+			CleanupInterval:      getDurationEnv("WAITING_ROOM_CLEANUP_INTERVAL", "1m"),
+		},
+		Group: GroupBookingConfig{
+			DefaultPaymentWindow: getDurationEnv("GROUP_BOOKING_DEFAULT_PAYMENT_WINDOW", "30m"),
+			MaxPaymentWindow:     getDurationEnv("GROUP_BOOKING_MAX_PAYMENT_WINDOW", "24h"),
+			MaxSeats:             getIntEnv("GROUP_BOOKING_MAX_SEATS", 10),
+			SettleGrace:          getDurationEnv("GROUP_BOOKING_SETTLE_GRACE", "15m"),
+			LeaseDuration:        getDurationEnv("GROUP_BOOKING_LEASE_DURATION", "1m"),
+			SweepInterval:        getDurationEnv("GROUP_BOOKING_SWEEP_INTERVAL", "15s"),
+			SweepBatchSize:       getIntEnv("GROUP_BOOKING_SWEEP_BATCH_SIZE", 20),
+		},
+		Sweeper: SweeperConfig{
+			Interval:  getDurationEnv("EXPIRY_SWEEP_INTERVAL", "5s"),
+			BatchSize: getIntEnv("EXPIRY_SWEEP_BATCH_SIZE", 200),
+			LeaderTTL: getDurationEnv("EXPIRY_SWEEP_LEADER_TTL", "15s"),
+			LeaderKey: getEnv("EXPIRY_SWEEP_LEADER_KEY", "ticket-service:expiry-sweeper:leader"),
+		},
 		Logging: LoggingConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "json"),
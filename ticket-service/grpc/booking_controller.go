@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"go.uber.org/zap"
@@ -10,6 +11,8 @@ import (
 
 	"ticket-service/metrics"
 	"ticket-service/models"
+	"ticket-service/queue"
+	"ticket-service/repositories"
 	"ticket-service/services"
 
 	ticketpb "ticket-service/internal/protos/ticket"
@@ -46,6 +49,7 @@ func (c *BookingController) CreateBookingSession(ctx context.Context, req *ticke
 		IPAddress:      req.IpAddress,
 		UserAgent:      req.UserAgent,
 		CreatedBy:      req.CreatedBy,
+		AdmissionToken: req.AdmissionToken,
 	}
 
 	session, err := c.bookingService.CreateBookingSession(ctx, serviceReq)
@@ -55,6 +59,12 @@ func (c *BookingController) CreateBookingSession(ctx context.Context, req *ticke
 			zap.String("event_id", req.EventId),
 			zap.Error(err),
 		)
+		if errors.Is(err, queue.ErrNotAdmitted) ||
+			errors.Is(err, queue.ErrInvalidAdmissionToken) ||
+			errors.Is(err, queue.ErrAdmissionExpired) {
+			metrics.IncrementGRPCError("booking", "CreateBookingSession", "not_admitted")
+			return nil, status.Errorf(codes.PermissionDenied, "failed to create booking session: %v", err)
+		}
 		metrics.IncrementGRPCError("booking", "CreateBookingSession", "service_error")
 		return nil, status.Errorf(codes.Internal, "failed to create booking session: %v", err)
 	}
@@ -146,6 +156,26 @@ func (c *BookingController) AddSeatToSession(ctx context.Context, req *ticketpb.
 			zap.String("seat_id", req.SeatId),
 			zap.Error(err),
 		)
+		if errors.Is(err, services.ErrSeatUnavailable) {
+			metrics.IncrementGRPCError("booking", "AddSeatToSession", "seat_unavailable")
+			return nil, status.Errorf(codes.AlreadyExists, "failed to add seat to session: %v", err)
+		}
+		if errors.Is(err, services.ErrBookingInProgress) {
+			metrics.IncrementGRPCError("booking", "AddSeatToSession", "booking_in_progress")
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to add seat to session: %v", err)
+		}
+		if errors.Is(err, services.ErrGroupBookingSession) {
+			metrics.IncrementGRPCError("booking", "AddSeatToSession", "group_booking_session")
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to add seat to session: %v", err)
+		}
+		if errors.Is(err, repositories.ErrSessionNotOpen) {
+			metrics.IncrementGRPCError("booking", "AddSeatToSession", "session_not_open")
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to add seat to session: %v", err)
+		}
+		if errors.Is(err, repositories.ErrConflict) {
+			metrics.IncrementGRPCError("booking", "AddSeatToSession", "conflict")
+			return nil, status.Errorf(codes.Aborted, "failed to add seat to session: %v", err)
+		}
 		metrics.IncrementGRPCError("booking", "AddSeatToSession", "service_error")
 		return nil, status.Errorf(codes.Internal, "failed to add seat to session: %v", err)
 	}
@@ -179,6 +209,18 @@ func (c *BookingController) RemoveSeatFromSession(ctx context.Context, req *tick
 			zap.String("seat_id", req.SeatId),
 			zap.Error(err),
 		)
+		if errors.Is(err, services.ErrBookingInProgress) {
+			metrics.IncrementGRPCError("booking", "RemoveSeatFromSession", "booking_in_progress")
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to remove seat from session: %v", err)
+		}
+		if errors.Is(err, services.ErrGroupBookingSession) {
+			metrics.IncrementGRPCError("booking", "RemoveSeatFromSession", "group_booking_session")
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to remove seat from session: %v", err)
+		}
+		if errors.Is(err, repositories.ErrConflict) {
+			metrics.IncrementGRPCError("booking", "RemoveSeatFromSession", "conflict")
+			return nil, status.Errorf(codes.Aborted, "failed to remove seat from session: %v", err)
+		}
 		metrics.IncrementGRPCError("booking", "RemoveSeatFromSession", "service_error")
 		return nil, status.Errorf(codes.Internal, "failed to remove seat from session: %v", err)
 	}
@@ -216,16 +258,30 @@ func (c *BookingController) CompleteBookingSession(ctx context.Context, req *tic
 			zap.String("session_id", req.SessionId),
 			zap.Error(err),
 		)
+		if errors.Is(err, services.ErrBookingFailed) {
+			metrics.IncrementGRPCError("booking", "CompleteBookingSession", "booking_failed")
+			return nil, status.Errorf(codes.Aborted, "failed to complete booking session: %v", err)
+		}
+		if errors.Is(err, services.ErrGroupBookingSession) {
+			metrics.IncrementGRPCError("booking", "CompleteBookingSession", "group_booking_session")
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to complete booking session: %v", err)
+		}
 		metrics.IncrementGRPCError("booking", "CompleteBookingSession", "service_error")
 		return nil, status.Errorf(codes.Internal, "failed to complete booking session: %v", err)
 	}
 
+	message := "Booking session completed successfully"
+	if !result.Success {
+		// The saga is still running and retries the failed step; completing again reports its progress
+		message = "Booking session completion in progress"
+	}
+
 	response := &ticketpb.CompleteBookingSessionResponse{
 		Success:     result.Success,
 		PaymentId:   result.PaymentID,
 		SeatCount:   int32(result.SeatCount),
 		TotalAmount: result.TotalAmount,
-		Message:     "Booking session completed successfully",
+		Message:     message,
 	}
 
 	return response, nil
@@ -250,6 +306,14 @@ func (c *BookingController) CancelBookingSession(ctx context.Context, req *ticke
 			zap.String("session_id", req.SessionId),
 			zap.Error(err),
 		)
+		if errors.Is(err, services.ErrBookingInProgress) {
+			metrics.IncrementGRPCError("booking", "CancelBookingSession", "booking_in_progress")
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to cancel booking session: %v", err)
+		}
+		if errors.Is(err, services.ErrGroupBookingSession) {
+			metrics.IncrementGRPCError("booking", "CancelBookingSession", "group_booking_session")
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to cancel booking session: %v", err)
+		}
 		metrics.IncrementGRPCError("booking", "CancelBookingSession", "service_error")
 		return nil, status.Errorf(codes.Internal, "failed to cancel booking session: %v", err)
 	}
@@ -318,17 +382,18 @@ func (c *BookingController) CleanupExpiredSessions(ctx context.Context, req *tic
 
 func (c *BookingController) convertBookingSessionToProto(session *models.BookingSession) *ticketpb.BookingSession {
 	protoSession := &ticketpb.BookingSession{
-		Id:           session.ID,
-		UserId:       session.UserID,
-		EventId:      session.EventID,
-		SessionToken: session.SessionToken,
-		Status:       session.Status,
-		SeatCount:    int32(session.SeatCount),
-		TotalAmount:  session.TotalAmount,
-		Currency:     session.Currency,
-		ExpiresAt:    session.ExpiresAt.Unix(),
-		CreatedAt:    session.CreatedAt.Unix(),
-		UpdatedAt:    session.UpdatedAt.Unix(),
+		Id:             session.ID,
+		UserId:         session.UserID,
+		EventId:        session.EventID,
+		SessionToken:   session.SessionToken,
+		Status:         session.Status,
+		SeatCount:      int32(session.SeatCount),
+		TotalAmount:    session.TotalAmount,
+		Currency:       session.Currency,
+		ExpiresAt:      session.ExpiresAt.Unix(),
+		HoldExtensions: int32(session.HoldExtensions),
+		CreatedAt:      session.CreatedAt.Unix(),
+		UpdatedAt:      session.UpdatedAt.Unix(),
 		// Computed fields
 		RemainingTime: int64(session.GetRemainingTime().Seconds()),
 		AveragePrice:  session.CalculateAveragePrice(),
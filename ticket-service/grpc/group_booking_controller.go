@@ -0,0 +1,296 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"ticket-service/metrics"
+	"ticket-service/models"
+	"ticket-service/queue"
+	"ticket-service/repositories"
+	"ticket-service/services"
+
+	ticketpb "ticket-service/internal/protos/ticket"
+)
+
+// GroupBookingController handles gRPC requests for group bookings
+type GroupBookingController struct {
+	ticketpb.UnimplementedGroupBookingServiceServer
+	groupService *services.GroupBookingService
+	logger       *zap.Logger
+}
+
+// NewGroupBookingController creates a new group booking controller
+func NewGroupBookingController(groupService *services.GroupBookingService, logger *zap.Logger) *GroupBookingController {
+	return &GroupBookingController{
+		groupService: groupService,
+		logger:       logger,
+	}
+}
+
+// CreateGroupBooking holds adjacent seats for a group whose members pay for them separately
+func (c *GroupBookingController) CreateGroupBooking(ctx context.Context, req *ticketpb.CreateGroupBookingRequest) (*ticketpb.CreateGroupBookingResponse, error) {
+	c.logger.Info("CreateGroupBooking request received",
+		zap.String("organizer_id", req.OrganizerId),
+		zap.String("event_id", req.EventId),
+		zap.Int("seats", len(req.Seats)),
+	)
+
+	cmd := &services.GroupBookingCreateCommand{
+		OrganizerID:          req.OrganizerId,
+		EventID:              req.EventId,
+		Currency:             req.Currency,
+		Seats:                make([]services.GroupBookingSeat, len(req.Seats)),
+		PaymentWindowMinutes: int(req.PaymentWindowMinutes),
+		AdmissionToken:       req.AdmissionToken,
+		IPAddress:            req.IpAddress,
+		UserAgent:            req.UserAgent,
+	}
+	for i, seat := range req.Seats {
+		cmd.Seats[i] = services.GroupBookingSeat{
+			SeatID:          seat.SeatId,
+			ZoneID:          seat.ZoneId,
+			PricingCategory: seat.PricingCategory,
+			BasePrice:       seat.BasePrice,
+			FinalPrice:      seat.FinalPrice,
+			UserID:          seat.UserId,
+		}
+	}
+
+	group, err := c.groupService.CreateGroupBooking(ctx, cmd)
+	if err != nil {
+		c.logger.Error("Failed to create group booking",
+			zap.String("organizer_id", req.OrganizerId),
+			zap.String("event_id", req.EventId),
+			zap.Error(err),
+		)
+		switch {
+		case errors.Is(err, services.ErrSeatsNotAdjacent):
+			metrics.IncrementGRPCError("group_booking", "CreateGroupBooking", "seats_not_adjacent")
+			return nil, status.Errorf(codes.InvalidArgument, "failed to create group booking: %v", err)
+		case errors.Is(err, services.ErrSeatUnavailable):
+			metrics.IncrementGRPCError("group_booking", "CreateGroupBooking", "seat_unavailable")
+			return nil, status.Errorf(codes.AlreadyExists, "failed to create group booking: %v", err)
+		case errors.Is(err, queue.ErrNotAdmitted),
+			errors.Is(err, queue.ErrInvalidAdmissionToken),
+			errors.Is(err, queue.ErrAdmissionExpired):
+			metrics.IncrementGRPCError("group_booking", "CreateGroupBooking", "not_admitted")
+			return nil, status.Errorf(codes.PermissionDenied, "failed to create group booking: %v", err)
+		}
+		metrics.IncrementGRPCError("group_booking", "CreateGroupBooking", "service_error")
+		return nil, status.Errorf(codes.Internal, "failed to create group booking: %v", err)
+	}
+
+	response := &ticketpb.CreateGroupBookingResponse{
+		Success:      true,
+		GroupBooking: convertGroupBookingToProto(group),
+		Message:      "Group booking created successfully",
+	}
+
+	return response, nil
+}
+
+// GetGroupBooking gets a group booking with its shares
+func (c *GroupBookingController) GetGroupBooking(ctx context.Context, req *ticketpb.GetGroupBookingRequest) (*ticketpb.GetGroupBookingResponse, error) {
+	group, err := c.groupService.GetGroupBooking(ctx, req.GroupBookingId)
+	if err != nil {
+		if errors.Is(err, repositories.ErrGroupBookingNotFound) {
+			metrics.IncrementGRPCError("group_booking", "GetGroupBooking", "not_found")
+			return nil, status.Errorf(codes.NotFound, "failed to get group booking: %v", err)
+		}
+		c.logger.Error("Failed to get group booking",
+			zap.String("group_booking_id", req.GroupBookingId),
+			zap.Error(err),
+		)
+		metrics.IncrementGRPCError("group_booking", "GetGroupBooking", "service_error")
+		return nil, status.Errorf(codes.Internal, "failed to get group booking: %v", err)
+	}
+
+	response := &ticketpb.GetGroupBookingResponse{
+		Success:      true,
+		GroupBooking: convertGroupBookingToProto(group),
+	}
+
+	return response, nil
+}
+
+// InviteToGroupSeat assigns an unpaid seat of a group booking to a member
+func (c *GroupBookingController) InviteToGroupSeat(ctx context.Context, req *ticketpb.InviteToGroupSeatRequest) (*ticketpb.InviteToGroupSeatResponse, error) {
+	c.logger.Info("InviteToGroupSeat request received",
+		zap.String("group_booking_id", req.GroupBookingId),
+		zap.String("share_id", req.ShareId),
+		zap.String("user_id", req.UserId),
+	)
+
+	share, err := c.groupService.InviteToGroupSeat(ctx, &services.GroupShareInviteCommand{
+		GroupBookingID: req.GroupBookingId,
+		ShareID:        req.ShareId,
+		OrganizerID:    req.OrganizerId,
+		UserID:         req.UserId,
+	})
+	if err != nil {
+		c.logger.Error("Failed to invite to group booking seat",
+			zap.String("group_booking_id", req.GroupBookingId),
+			zap.String("share_id", req.ShareId),
+			zap.Error(err),
+		)
+		code, errorType := groupBookingErrorCode(err)
+		metrics.IncrementGRPCError("group_booking", "InviteToGroupSeat", errorType)
+		return nil, status.Errorf(code, "failed to invite to group booking seat: %v", err)
+	}
+
+	response := &ticketpb.InviteToGroupSeatResponse{
+		Success: true,
+		Share:   convertGroupBookingShareToProto(share),
+		Message: "Member invited successfully",
+	}
+
+	return response, nil
+}
+
+// PayGroupShare charges a member for their seat of a group booking
+func (c *GroupBookingController) PayGroupShare(ctx context.Context, req *ticketpb.PayGroupShareRequest) (*ticketpb.PayGroupShareResponse, error) {
+	c.logger.Info("PayGroupShare request received",
+		zap.String("group_booking_id", req.GroupBookingId),
+		zap.String("share_id", req.ShareId),
+		zap.String("user_id", req.UserId),
+		zap.String("payment_method", req.PaymentMethod),
+	)
+
+	share, err := c.groupService.PayGroupShare(ctx, &services.GroupSharePayCommand{
+		GroupBookingID: req.GroupBookingId,
+		ShareID:        req.ShareId,
+		UserID:         req.UserId,
+		PaymentMethod:  req.PaymentMethod,
+	})
+	if err != nil {
+		c.logger.Error("Failed to pay group booking share",
+			zap.String("group_booking_id", req.GroupBookingId),
+			zap.String("share_id", req.ShareId),
+			zap.Error(err),
+		)
+		code, errorType := groupBookingErrorCode(err)
+		metrics.IncrementGRPCError("group_booking", "PayGroupShare", errorType)
+		return nil, status.Errorf(code, "failed to pay group booking share: %v", err)
+	}
+
+	response := &ticketpb.PayGroupShareResponse{
+		Success: true,
+		Share:   convertGroupBookingShareToProto(share),
+		Message: "Group booking share paid successfully",
+	}
+
+	return response, nil
+}
+
+// CancelGroupBooking cancels an open group booking, refunding the members who paid
+func (c *GroupBookingController) CancelGroupBooking(ctx context.Context, req *ticketpb.CancelGroupBookingRequest) (*ticketpb.CancelGroupBookingResponse, error) {
+	c.logger.Info("CancelGroupBooking request received",
+		zap.String("group_booking_id", req.GroupBookingId),
+		zap.String("organizer_id", req.OrganizerId),
+		zap.String("reason", req.Reason),
+	)
+
+	group, err := c.groupService.CancelGroupBooking(ctx, &services.GroupBookingCancelCommand{
+		GroupBookingID: req.GroupBookingId,
+		OrganizerID:    req.OrganizerId,
+		Reason:         req.Reason,
+	})
+	if err != nil {
+		c.logger.Error("Failed to cancel group booking",
+			zap.String("group_booking_id", req.GroupBookingId),
+			zap.Error(err),
+		)
+		code, errorType := groupBookingErrorCode(err)
+		metrics.IncrementGRPCError("group_booking", "CancelGroupBooking", errorType)
+		return nil, status.Errorf(code, "failed to cancel group booking: %v", err)
+	}
+
+	message := "Group booking cancelled successfully"
+	if group.Status != models.GroupBookingStatusCancelled {
+		// Refunds or seat releases failed and are retried in the background
+		message = "Group booking cancellation in progress"
+	}
+
+	response := &ticketpb.CancelGroupBookingResponse{
+		Success:      true,
+		GroupBooking: convertGroupBookingToProto(group),
+		Message:      message,
+	}
+
+	return response, nil
+}
+
+// groupBookingErrorCode maps a group booking error to its gRPC code and metrics error type
+func groupBookingErrorCode(err error) (codes.Code, string) {
+	switch {
+	case errors.Is(err, repositories.ErrGroupBookingNotFound), errors.Is(err, services.ErrGroupShareNotFound):
+		return codes.NotFound, "not_found"
+	case errors.Is(err, services.ErrNotGroupOrganizer), errors.Is(err, services.ErrNotShareHolder):
+		return codes.PermissionDenied, "permission_denied"
+	case errors.Is(err, repositories.ErrGroupBookingClosed):
+		return codes.FailedPrecondition, "group_booking_closed"
+	case errors.Is(err, repositories.ErrGroupShareNotPending):
+		return codes.FailedPrecondition, "share_not_pending"
+	case errors.Is(err, services.ErrGroupSharePaymentFailed):
+		return codes.Aborted, "payment_failed"
+	case errors.Is(err, services.ErrGroupSharePaymentPending):
+		return codes.Unavailable, "payment_pending"
+	}
+	return codes.Internal, "service_error"
+}
+
+func convertGroupBookingToProto(group *models.GroupBooking) *ticketpb.GroupBooking {
+	protoGroup := &ticketpb.GroupBooking{
+		Id:               group.ID,
+		BookingSessionId: group.BookingSessionID,
+		EventId:          group.EventID,
+		OrganizerId:      group.OrganizerID,
+		Status:           group.Status,
+		Currency:         group.Currency,
+		PaymentDeadline:  group.PaymentDeadline.Unix(),
+		PaidAmount:       group.PaidAmount(),
+		Shares:           make([]*ticketpb.GroupBookingShare, len(group.Shares)),
+		CreatedAt:        group.CreatedAt.Unix(),
+		UpdatedAt:        group.UpdatedAt.Unix(),
+	}
+
+	for i, share := range group.Shares {
+		protoGroup.Shares[i] = convertGroupBookingShareToProto(share)
+	}
+
+	// Set optional fields
+	if group.SettledAt != nil {
+		protoGroup.SettledAt = group.SettledAt.Unix()
+	}
+	if group.CancelledReason != nil {
+		protoGroup.CancelledReason = *group.CancelledReason
+	}
+
+	return protoGroup
+}
+
+func convertGroupBookingShareToProto(share *models.GroupBookingShare) *ticketpb.GroupBookingShare {
+	protoShare := &ticketpb.GroupBookingShare{
+		Id:       share.ID,
+		SeatId:   share.SeatID,
+		UserId:   share.UserID,
+		Amount:   share.Amount,
+		Currency: share.Currency,
+		Status:   share.Status,
+	}
+
+	// Set optional fields
+	if share.PaymentID != nil {
+		protoShare.PaymentId = *share.PaymentID
+	}
+	if share.PaidAt != nil {
+		protoShare.PaidAt = share.PaidAt.Unix()
+	}
+
+	return protoShare
+}
@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"errors"
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
@@ -9,6 +10,7 @@ import (
 
 	"ticket-service/metrics"
 	"ticket-service/models"
+	"ticket-service/repositories"
 	"ticket-service/services"
 
 	ticketpb "ticket-service/internal/protos/ticket"
@@ -58,6 +60,14 @@ func (c *ReservationController) CreateReservation(ctx context.Context, req *tick
 			zap.String("seat_id", req.SeatId),
 			zap.Error(err),
 		)
+		if errors.Is(err, services.ErrSeatUnavailable) {
+			metrics.IncrementGRPCError("reservation", "CreateReservation", "seat_unavailable")
+			return nil, status.Errorf(codes.AlreadyExists, "failed to create reservation: %v", err)
+		}
+		if errors.Is(err, repositories.ErrSessionNotOpen) {
+			metrics.IncrementGRPCError("reservation", "CreateReservation", "session_not_open")
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to create reservation: %v", err)
+		}
 		metrics.IncrementGRPCError("reservation", "CreateReservation", "service_error")
 		return nil, status.Errorf(codes.Internal, "failed to create reservation: %v", err)
 	}
@@ -344,6 +354,166 @@ func (c *ReservationController) ReleaseReservationsBySession(ctx context.Context
 	return response, nil
 }
 
+// ReserveSeatsBulk reserves several seats for a booking session, all or nothing. Seats
+// that cannot be held are reported in failures rather than as an error status, so the
+// client can offer alternatives for them.
+func (c *ReservationController) ReserveSeatsBulk(ctx context.Context, req *ticketpb.ReserveSeatsBulkRequest) (*ticketpb.ReserveSeatsBulkResponse, error) {
+	c.logger.Info("ReserveSeatsBulk request received",
+		zap.String("booking_session_id", req.BookingSessionId),
+		zap.String("event_id", req.EventId),
+		zap.Int("seats", len(req.Seats)),
+	)
+
+	serviceReq := &services.ReserveSeatsBulkRequest{
+		BookingSessionID: req.BookingSessionId,
+		EventID:          req.EventId,
+		Seats:            make([]services.BulkSeat, len(req.Seats)),
+		TimeoutMinutes:   int(req.TimeoutMinutes),
+		CreatedBy:        req.CreatedBy,
+	}
+	for i, seat := range req.Seats {
+		serviceReq.Seats[i] = services.BulkSeat{
+			SeatID:          seat.SeatId,
+			ZoneID:          seat.ZoneId,
+			PricingCategory: seat.PricingCategory,
+			BasePrice:       seat.BasePrice,
+			FinalPrice:      seat.FinalPrice,
+			Currency:        seat.Currency,
+		}
+	}
+
+	result, err := c.reservationService.ReserveSeatsBulk(ctx, serviceReq)
+	if err != nil {
+		c.logger.Error("Failed to reserve seats in bulk",
+			zap.String("booking_session_id", req.BookingSessionId),
+			zap.Error(err),
+		)
+		var unavailable *services.SeatsUnavailableError
+		switch {
+		case errors.As(err, &unavailable):
+			metrics.IncrementGRPCError("reservation", "ReserveSeatsBulk", "seat_unavailable")
+			return &ticketpb.ReserveSeatsBulkResponse{
+				Success:  false,
+				Failures: convertSeatFailuresToProto(unavailable.Failures),
+				Message:  err.Error(),
+			}, nil
+		case errors.Is(err, repositories.ErrSessionNotOpen):
+			metrics.IncrementGRPCError("reservation", "ReserveSeatsBulk", "session_not_open")
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to reserve seats: %v", err)
+		}
+		metrics.IncrementGRPCError("reservation", "ReserveSeatsBulk", "service_error")
+		return nil, status.Errorf(codes.Internal, "failed to reserve seats: %v", err)
+	}
+
+	response := &ticketpb.ReserveSeatsBulkResponse{
+		Success:       true,
+		Reservations:  make([]*ticketpb.SeatReservation, len(result.Reservations)),
+		ReservedCount: int32(len(result.Reservations)),
+		TotalAmount:   result.TotalAmount,
+		Message:       "Seats reserved successfully",
+	}
+	for i, reservation := range result.Reservations {
+		response.Reservations[i] = c.convertSeatReservationToProto(reservation)
+	}
+
+	return response, nil
+}
+
+// ReserveBestAvailable picks the best seats on sale for a booking session and holds them
+func (c *ReservationController) ReserveBestAvailable(ctx context.Context, req *ticketpb.ReserveBestAvailableRequest) (*ticketpb.ReserveBestAvailableResponse, error) {
+	c.logger.Info("ReserveBestAvailable request received",
+		zap.String("booking_session_id", req.BookingSessionId),
+		zap.String("event_id", req.EventId),
+		zap.Int32("quantity", req.Quantity),
+		zap.String("zone_id", req.ZoneId),
+		zap.Bool("contiguous", req.Contiguous),
+	)
+
+	serviceReq := &services.BestAvailableRequest{
+		BookingSessionID: req.BookingSessionId,
+		EventID:          req.EventId,
+		Quantity:         int(req.Quantity),
+		ZoneID:           req.ZoneId,
+		PricingCategory:  req.PricingCategory,
+		Contiguous:       req.Contiguous,
+		TimeoutMinutes:   int(req.TimeoutMinutes),
+		CreatedBy:        req.CreatedBy,
+	}
+
+	result, err := c.reservationService.ReserveBestAvailable(ctx, serviceReq)
+	if err != nil {
+		c.logger.Error("Failed to reserve best available seats",
+			zap.String("booking_session_id", req.BookingSessionId),
+			zap.Error(err),
+		)
+		switch {
+		case errors.Is(err, services.ErrNotEnoughSeats):
+			metrics.IncrementGRPCError("reservation", "ReserveBestAvailable", "not_enough_seats")
+			return nil, status.Errorf(codes.ResourceExhausted, "failed to reserve seats: %v", err)
+		case errors.Is(err, services.ErrSeatUnavailable):
+			metrics.IncrementGRPCError("reservation", "ReserveBestAvailable", "seat_unavailable")
+			return nil, status.Errorf(codes.Aborted, "failed to reserve seats: %v", err)
+		case errors.Is(err, repositories.ErrSessionNotOpen):
+			metrics.IncrementGRPCError("reservation", "ReserveBestAvailable", "session_not_open")
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to reserve seats: %v", err)
+		}
+		metrics.IncrementGRPCError("reservation", "ReserveBestAvailable", "service_error")
+		return nil, status.Errorf(codes.Internal, "failed to reserve seats: %v", err)
+	}
+
+	response := &ticketpb.ReserveBestAvailableResponse{
+		Success:       true,
+		Reservations:  make([]*ticketpb.SeatReservation, len(result.Reservations)),
+		ReservedCount: int32(len(result.Reservations)),
+		TotalAmount:   result.TotalAmount,
+		Contiguous:    result.Contiguous,
+		Message:       "Seats reserved successfully",
+	}
+	for i, reservation := range result.Reservations {
+		response.Reservations[i] = c.convertSeatReservationToProto(reservation)
+	}
+
+	return response, nil
+}
+
+// ReleaseSeatsBulk releases several reservations at once.Reservations that are not held
+// are reported in failures; the others are released regardless.
+func (c *ReservationController) ReleaseSeatsBulk(ctx context.Context, req *ticketpb.ReleaseSeatsBulkRequest) (*ticketpb.ReleaseSeatsBulkResponse, error) {
+	c.logger.Info("ReleaseSeatsBulk request received",
+		zap.Int("reservations", len(req.ReservationIds)),
+		zap.String("reason", req.Reason),
+	)
+
+	serviceReq := &services.ReleaseSeatsBulkRequest{
+		ReservationIDs: req.ReservationIds,
+		Reason:         req.Reason,
+		ReleasedBy:     req.ReleasedBy,
+	}
+
+	result, err := c.reservationService.ReleaseSeatsBulk(ctx, serviceReq)
+	if err != nil {
+		c.logger.Error("Failed to release seats in bulk", zap.Error(err))
+		metrics.IncrementGRPCError("reservation", "ReleaseSeatsBulk", "service_error")
+		return nil, status.Errorf(codes.Internal, "failed to release seats: %v", err)
+	}
+
+	response := &ticketpb.ReleaseSeatsBulkResponse{
+		Success:                len(result.Failures) == 0,
+		ReleasedCount:          int32(len(result.Released)),
+		ReleasedReservationIds: make([]string, len(result.Released)),
+		Failures:               convertSeatFailuresToProto(result.Failures),
+		Message:                "Seats released successfully",
+	}
+	for i, reservation := range result.Released {
+		response.ReleasedReservationIds[i] = reservation.ID
+	}
+	if len(result.Failures) > 0 {
+		response.Message = "Some reservations were not held"
+	}
+
+	return response, nil
+}
+
 // CheckSeatAvailability checks if a seat is available for reservation
 func (c *ReservationController) CheckSeatAvailability(ctx context.Context, req *ticketpb.CheckSeatAvailabilityRequest) (*ticketpb.CheckSeatAvailabilityResponse, error) {
 	c.logger.Info("CheckSeatAvailability request received",
@@ -398,7 +568,7 @@ func (c *ReservationController) GetReservationStats(ctx context.Context, req *ti
 	return response, nil
 }
 
-// ExtendReservation extends the expiration time of a reservation
+// ExtendReservation extends the seat holds of the booking session a reservation belongs to
 func (c *ReservationController) ExtendReservation(ctx context.Context, req *ticketpb.ExtendReservationRequest) (*ticketpb.ExtendReservationResponse, error) {
 	c.logger.Info("ExtendReservation request received",
 		zap.String("reservation_id", req.ReservationId),
@@ -411,19 +581,100 @@ func (c *ReservationController) ExtendReservation(ctx context.Context, req *tick
 		ExtendedBy:       req.ExtendedBy,
 	}
 
-	err := c.reservationService.ExtendReservation(ctx, serviceReq)
+	extension, err := c.reservationService.ExtendReservation(ctx, serviceReq)
 	if err != nil {
 		c.logger.Error("Failed to extend reservation",
 			zap.String("reservation_id", req.ReservationId),
 			zap.Error(err),
 		)
+		switch {
+		case errors.Is(err, services.ErrHoldExtensionLimit):
+			metrics.IncrementGRPCError("reservation", "ExtendReservation", "extension_limit_reached")
+			return nil, status.Errorf(codes.ResourceExhausted, "failed to extend reservation: %v", err)
+		case errors.Is(err, services.ErrHoldExtensionQueued):
+			metrics.IncrementGRPCError("reservation", "ExtendReservation", "queue_waiting")
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to extend reservation: %v", err)
+		case errors.Is(err, repositories.ErrHoldNotExtended):
+			metrics.IncrementGRPCError("reservation", "ExtendReservation", "not_extendable")
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to extend reservation: %v", err)
+		case errors.Is(err, services.ErrSeatUnavailable):
+			metrics.IncrementGRPCError("reservation", "ExtendReservation", "seat_unavailable")
+			return nil, status.Errorf(codes.Aborted, "failed to extend reservation: %v", err)
+		}
 		metrics.IncrementGRPCError("reservation", "ExtendReservation", "service_error")
 		return nil, status.Errorf(codes.Internal, "failed to extend reservation: %v", err)
 	}
 
 	response := &ticketpb.ExtendReservationResponse{
+		Success:             true,
+		Message:             "Reservation extended successfully",
+		BookingSessionId:    extension.BookingSessionID,
+		ExpiresAt:           extension.ExpiresAt.Unix(),
+		ExtensionsUsed:      int32(extension.ExtensionsUsed),
+		ExtensionsRemaining: int32(extension.ExtensionsRemaining),
+	}
+
+	return response, nil
+}
+
+// GetEventHoldPolicy retrieves the hold extension rules of an event
+func (c *ReservationController) GetEventHoldPolicy(ctx context.Context, req *ticketpb.GetEventHoldPolicyRequest) (*ticketpb.GetEventHoldPolicyResponse, error) {
+	policy, err := c.reservationService.GetEventHoldPolicy(ctx, req.EventId)
+	if err != nil {
+		c.logger.Error("Failed to get event hold policy",
+			zap.String("event_id", req.EventId),
+			zap.Error(err),
+		)
+		metrics.IncrementGRPCError("reservation", "GetEventHoldPolicy", "service_error")
+		return nil, status.Errorf(codes.Internal, "failed to get event hold policy: %v", err)
+	}
+
+	response := &ticketpb.GetEventHoldPolicyResponse{
+		Success: true,
+		Policy:  convertHoldPolicyToProto(policy),
+	}
+
+	return response, nil
+}
+
+// SetEventHoldPolicy sets how often and for how long an event's seat holds may be extended
+func (c *ReservationController) SetEventHoldPolicy(ctx context.Context, req *ticketpb.SetEventHoldPolicyRequest) (*ticketpb.SetEventHoldPolicyResponse, error) {
+	c.logger.Info("SetEventHoldPolicy request received",
+		zap.String("event_id", req.EventId),
+		zap.Int32("max_extensions", req.MaxExtensions),
+		zap.Int32("max_hold_minutes", req.MaxHoldMinutes),
+		zap.Bool("extend_while_queued", req.ExtendWhileQueued),
+		zap.String("updated_by", req.UpdatedBy),
+	)
+
+	policy := &models.EventHoldPolicy{
+		EventID:           req.EventId,
+		MaxExtensions:     int(req.MaxExtensions),
+		MaxHoldMinutes:    int(req.MaxHoldMinutes),
+		ExtendWhileQueued: req.ExtendWhileQueued,
+	}
+	if req.UpdatedBy != "" {
+		policy.UpdatedBy = &req.UpdatedBy
+	}
+
+	if err := policy.Validate(); err != nil {
+		metrics.IncrementGRPCError("reservation", "SetEventHoldPolicy", "validation_error")
+		return nil, status.Errorf(codes.InvalidArgument, "invalid hold policy: %v", err)
+	}
+
+	if err := c.reservationService.SetEventHoldPolicy(ctx, policy); err != nil {
+		c.logger.Error("Failed to set event hold policy",
+			zap.String("event_id", req.EventId),
+			zap.Error(err),
+		)
+		metrics.IncrementGRPCError("reservation", "SetEventHoldPolicy", "service_error")
+		return nil, status.Errorf(codes.Internal, "failed to set event hold policy: %v", err)
+	}
+
+	response := &ticketpb.SetEventHoldPolicyResponse{
 		Success: true,
-		Message: "Reservation extended successfully",
+		Policy:  convertHoldPolicyToProto(policy),
+		Message: "Event hold policy updated successfully",
 	}
 
 	return response, nil
@@ -490,3 +741,29 @@ func (c *ReservationController) convertSeatReservationToProto(reservation *model
 
 	return protoReservation
 }
+
+func convertHoldPolicyToProto(policy *models.EventHoldPolicy) *ticketpb.EventHoldPolicy {
+	protoPolicy := &ticketpb.EventHoldPolicy{
+		EventId:           policy.EventID,
+		MaxExtensions:     int32(policy.MaxExtensions),
+		MaxHoldMinutes:    int32(policy.MaxHoldMinutes),
+		ExtendWhileQueued: policy.ExtendWhileQueued,
+	}
+	if !policy.UpdatedAt.IsZero() {
+		protoPolicy.UpdatedAt = policy.UpdatedAt.Unix()
+	}
+	return protoPolicy
+}
+
+func convertSeatFailuresToProto(failures []services.SeatFailure) []*ticketpb.SeatReservationFailure {
+	protoFailures := make([]*ticketpb.SeatReservationFailure, len(failures))
+	for i, failure := range failures {
+		protoFailures[i] = &ticketpb.SeatReservationFailure{
+			SeatId:        failure.SeatID,
+			ReservationId: failure.ReservationID,
+			Reason:        failure.Reason,
+			Message:       failure.Message,
+		}
+	}
+	return protoFailures
+}
@@ -14,6 +14,7 @@ import (
 
 	"ticket-service/internal/interceptors"
 	ticketpb "ticket-service/internal/protos/ticket"
+	"ticket-service/queue"
 	"ticket-service/services"
 )
 
@@ -21,16 +22,22 @@ import (
 type Server struct {
 	server             *grpc.Server
 	ticketService      *services.TicketService
+	transferService    *services.TicketTransferService
 	bookingService     *services.TicketBookingSessionService
 	reservationService *services.ReservationService
+	groupService       *services.GroupBookingService
+	queueManager       *queue.QueueManager
 	logger             *zap.Logger
 }
 
 // NewServer creates a new gRPC server
 func NewServer(
 	ticketService *services.TicketService,
+	transferService *services.TicketTransferService,
 	bookingService *services.TicketBookingSessionService,
 	reservationService *services.ReservationService,
+	groupService *services.GroupBookingService,
+	queueManager *queue.QueueManager,
 	logger *zap.Logger,
 ) *Server {
 	// Configure gRPC server options
@@ -54,8 +61,11 @@ func NewServer(
 	return &Server{
 		server:             server,
 		ticketService:      ticketService,
+		transferService:    transferService,
 		bookingService:     bookingService,
 		reservationService: reservationService,
+		groupService:       groupService,
+		queueManager:       queueManager,
 		logger:             logger,
 	}
 }
@@ -110,7 +120,7 @@ func (s *Server) Stop() error {
 // registerServices registers all gRPC services
 func (s *Server) registerServices() {
 	// Register Ticket Service
-	ticketController := NewTicketController(s.ticketService, s.logger)
+	ticketController := NewTicketController(s.ticketService, s.transferService, s.logger)
 	ticketpb.RegisterTicketServiceServer(s.server, ticketController)
 
 	// Register Booking Service
@@ -121,5 +131,13 @@ func (s *Server) registerServices() {
 	reservationController := NewReservationController(s.reservationService, s.logger)
 	ticketpb.RegisterReservationServiceServer(s.server, reservationController)
 
+	// Register Waiting Room Service
+	waitingRoomController := NewWaitingRoomController(s.queueManager, s.logger)
+	ticketpb.RegisterWaitingRoomServiceServer(s.server, waitingRoomController)
+
+	// Register Group Booking Service
+	groupBookingController := NewGroupBookingController(s.groupService, s.logger)
+	ticketpb.RegisterGroupBookingServiceServer(s.server, groupBookingController)
+
 	s.logger.Info("gRPC services registered successfully")
 }
@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"go.uber.org/zap"
@@ -10,6 +11,7 @@ import (
 
 	"ticket-service/metrics"
 	"ticket-service/models"
+	"ticket-service/repositories"
 	"ticket-service/services"
 
 	ticketpb "ticket-service/internal/protos/ticket"
@@ -18,15 +20,17 @@ import (
 // TicketController handles gRPC requests for ticket operations
 type TicketController struct {
 	ticketpb.UnimplementedTicketServiceServer
-	ticketService *services.TicketService
-	logger        *zap.Logger
+	ticketService   *services.TicketService
+	transferService *services.TicketTransferService
+	logger          *zap.Logger
 }
 
 // NewTicketController creates a new ticket controller
-func NewTicketController(ticketService *services.TicketService, logger *zap.Logger) *TicketController {
+func NewTicketController(ticketService *services.TicketService, transferService *services.TicketTransferService, logger *zap.Logger) *TicketController {
 	return &TicketController{
-		ticketService: ticketService,
-		logger:        logger,
+		ticketService:   ticketService,
+		transferService: transferService,
+		logger:          logger,
 	}
 }
 
@@ -310,42 +314,41 @@ func (c *TicketController) CancelTicket(ctx context.Context, req *ticketpb.Cance
 	return response, nil
 }
 
-// SearchTickets searches tickets with filters
+// SearchTickets searches tickets with filters, a page at a time
 func (c *TicketController) SearchTickets(ctx context.Context, req *ticketpb.SearchTicketsRequest) (*ticketpb.SearchTicketsResponse, error) {
 	c.logger.Info("SearchTickets request received",
+		zap.String("event_id", req.EventId),
 		zap.Any("filters", req.Filters),
-		zap.Int32("page", req.Page),
+		zap.String("sort_by", req.SortBy),
 		zap.Int32("limit", req.Limit),
+		zap.Bool("has_cursor", req.Cursor != ""),
 	)
 
-	// Convert filters
-	filters := make(map[string]interface{})
-	for key, value := range req.Filters {
-		filters[key] = value
-	}
-
-	tickets, total, err := c.ticketService.SearchTickets(ctx, filters, int(req.Page), int(req.Limit))
+	page, err := c.ticketService.SearchTickets(ctx, convertSearchQueryFromProto(req))
 	if err != nil {
 		c.logger.Error("Failed to search tickets",
 			zap.Error(err),
 		)
+		if errors.Is(err, services.ErrInvalidSearchQuery) || errors.Is(err, repositories.ErrInvalidSearchCursor) {
+			metrics.IncrementGRPCError("ticket", "SearchTickets", "validation_error")
+			return nil, status.Errorf(codes.InvalidArgument, "invalid ticket search: %v", err)
+		}
 		metrics.IncrementGRPCError("ticket", "SearchTickets", "service_error")
 		return nil, status.Errorf(codes.Internal, "failed to search tickets: %v", err)
 	}
 
 	// Convert tickets to proto
-	protoTickets := make([]*ticketpb.Ticket, len(tickets))
-	for i, ticket := range tickets {
+	protoTickets := make([]*ticketpb.Ticket, len(page.Tickets))
+	for i, ticket := range page.Tickets {
 		protoTickets[i] = c.convertTicketToProto(ticket)
 	}
 
 	response := &ticketpb.SearchTicketsResponse{
-		Success: true,
-		Tickets: protoTickets,
-		Total:   int32(total),
-		Page:    req.Page,
-		Limit:   req.Limit,
-		HasMore: int(req.Page)*int(req.Limit) < total,
+		Success:    true,
+		Tickets:    protoTickets,
+		Limit:      req.Limit,
+		HasMore:    page.HasMore(),
+		NextCursor: page.NextCursor,
 	}
 
 	return response, nil
@@ -417,3 +420,61 @@ func (c *TicketController) convertTicketToProto(ticket *models.Ticket) *ticketpb
 
 	return protoTicket
 }
+
+// convertSearchQueryFromProto builds the typed search query of a request, folding in its
+// legacy equality filters
+func convertSearchQueryFromProto(req *ticketpb.SearchTicketsRequest) *models.TicketSearchQuery {
+	query := &models.TicketSearchQuery{
+		EventID:            req.EventId,
+		UserID:             req.UserId,
+		Statuses:           req.Statuses,
+		PaymentStatuses:    req.PaymentStatuses,
+		TicketTypes:        req.TicketTypes,
+		ZoneIDs:            req.ZoneIds,
+		PricingCategories:  req.PricingCategories,
+		TicketNumberPrefix: req.TicketNumberPrefix,
+		MinPrice:           req.MinPrice,
+		MaxPrice:           req.MaxPrice,
+		Created:            convertTimeRangeFromProto(req.Created),
+		Used:               convertTimeRangeFromProto(req.Used),
+		Cancelled:          convertTimeRangeFromProto(req.Cancelled),
+		SortBy:             req.SortBy,
+		SortDesc:           req.SortDesc,
+		Limit:              int(req.Limit),
+		Cursor:             req.Cursor,
+	}
+
+	if query.EventID == "" {
+		query.EventID = req.Filters["event_id"]
+	}
+	if query.UserID == "" {
+		query.UserID = req.Filters["user_id"]
+	}
+	if value := req.Filters["status"]; value != "" {
+		query.Statuses = append(query.Statuses, value)
+	}
+	if value := req.Filters["payment_status"]; value != "" {
+		query.PaymentStatuses = append(query.PaymentStatuses, value)
+	}
+	if value := req.Filters["ticket_type"]; value != "" {
+		query.TicketTypes = append(query.TicketTypes, value)
+	}
+
+	return query
+}
+
+func convertTimeRangeFromProto(timeRange *ticketpb.TimeRange) models.TimeRange {
+	var converted models.TimeRange
+	if timeRange == nil {
+		return converted
+	}
+	if timeRange.From != 0 {
+		from := time.Unix(timeRange.From, 0)
+		converted.From = &from
+	}
+	if timeRange.To != 0 {
+		to := time.Unix(timeRange.To, 0)
+		converted.To = &to
+	}
+	return converted
+}
@@ -0,0 +1,130 @@
+package grpc
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"ticket-service/metrics"
+	"ticket-service/models"
+
+	ticketpb "ticket-service/internal/protos/ticket"
+)
+
+// PreviewTicketRefund shows what cancelling a ticket now would refund under its event's refund policy
+func (c *TicketController) PreviewTicketRefund(ctx context.Context, req *ticketpb.PreviewTicketRefundRequest) (*ticketpb.PreviewTicketRefundResponse, error) {
+	quote, err := c.ticketService.PreviewRefund(ctx, req.TicketId)
+	if err != nil {
+		c.logger.Error("Failed to preview ticket refund",
+			zap.String("ticket_id", req.TicketId),
+			zap.Error(err),
+		)
+		metrics.IncrementGRPCError("ticket", "PreviewTicketRefund", "service_error")
+		return nil, status.Errorf(codes.Internal, "failed to preview ticket refund: %v", err)
+	}
+
+	response := &ticketpb.PreviewTicketRefundResponse{
+		Success:       true,
+		Refundable:    quote.Refundable,
+		RefundAmount:  quote.RefundAmount,
+		RefundPercent: quote.RefundPercent,
+		FinalPrice:    quote.FinalPrice,
+		Currency:      quote.Currency,
+		Reason:        quote.Reason,
+		Policy:        convertRefundPolicyToProto(quote.Policy),
+		Message:       "Ticket refund previewed successfully",
+	}
+	if quote.EventStartsAt != nil {
+		response.EventStartsAt = quote.EventStartsAt.Unix()
+	}
+
+	return response, nil
+}
+
+// GetEventRefundPolicy retrieves the refund policy of an event
+func (c *TicketController) GetEventRefundPolicy(ctx context.Context, req *ticketpb.GetEventRefundPolicyRequest) (*ticketpb.GetEventRefundPolicyResponse, error) {
+	policy, err := c.ticketService.GetEventRefundPolicy(ctx, req.EventId)
+	if err != nil {
+		c.logger.Error("Failed to get event refund policy",
+			zap.String("event_id", req.EventId),
+			zap.Error(err),
+		)
+		metrics.IncrementGRPCError("ticket", "GetEventRefundPolicy", "service_error")
+		return nil, status.Errorf(codes.Internal, "failed to get event refund policy: %v", err)
+	}
+
+	response := &ticketpb.GetEventRefundPolicyResponse{
+		Success: true,
+		Policy:  convertRefundPolicyToProto(policy),
+	}
+
+	return response, nil
+}
+
+// SetEventRefundPolicy replaces the refund tiers of an event. An empty rule set restores
+// the default full refund.
+func (c *TicketController) SetEventRefundPolicy(ctx context.Context, req *ticketpb.SetEventRefundPolicyRequest) (*ticketpb.SetEventRefundPolicyResponse, error) {
+	c.logger.Info("SetEventRefundPolicy request received",
+		zap.String("event_id", req.EventId),
+		zap.Int("rules", len(req.Rules)),
+		zap.String("updated_by", req.UpdatedBy),
+	)
+
+	rules := make([]*models.RefundPolicyRule, len(req.Rules))
+	for i, rule := range req.Rules {
+		rules[i] = &models.RefundPolicyRule{
+			EventID:          req.EventId,
+			HoursBeforeEvent: int(rule.HoursBeforeEvent),
+			RefundPercent:    rule.RefundPercent,
+		}
+	}
+	policy := models.NewEventRefundPolicy(req.EventId, rules)
+
+	if err := policy.Validate(); err != nil {
+		metrics.IncrementGRPCError("ticket", "SetEventRefundPolicy", "validation_error")
+		return nil, status.Errorf(codes.InvalidArgument, "invalid refund policy: %v", err)
+	}
+
+	if err := c.ticketService.SetEventRefundPolicy(ctx, policy, req.UpdatedBy); err != nil {
+		c.logger.Error("Failed to set event refund policy",
+			zap.String("event_id", req.EventId),
+			zap.Error(err),
+		)
+		metrics.IncrementGRPCError("ticket", "SetEventRefundPolicy", "service_error")
+		return nil, status.Errorf(codes.Internal, "failed to set event refund policy: %v", err)
+	}
+
+	if len(policy.Rules) == 0 {
+		policy = models.DefaultEventRefundPolicy(req.EventId)
+	}
+
+	response := &ticketpb.SetEventRefundPolicyResponse{
+		Success: true,
+		Policy:  convertRefundPolicyToProto(policy),
+		Message: "Event refund policy updated successfully",
+	}
+
+	return response, nil
+}
+
+func convertRefundPolicyToProto(policy *models.EventRefundPolicy) *ticketpb.EventRefundPolicy {
+	if policy == nil {
+		return nil
+	}
+
+	protoPolicy := &ticketpb.EventRefundPolicy{
+		EventId:   policy.EventID,
+		Rules:     make([]*ticketpb.RefundPolicyRule, len(policy.Rules)),
+		IsDefault: policy.IsDefault,
+	}
+	for i, rule := range policy.Rules {
+		protoPolicy.Rules[i] = &ticketpb.RefundPolicyRule{
+			HoursBeforeEvent: int32(rule.HoursBeforeEvent),
+			RefundPercent:    rule.RefundPercent,
+		}
+	}
+
+	return protoPolicy
+}
@@ -0,0 +1,311 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"ticket-service/metrics"
+	"ticket-service/models"
+	"ticket-service/repositories"
+	"ticket-service/services"
+
+	ticketpb "ticket-service/internal/protos/ticket"
+)
+
+// InitiateTicketTransfer offers a ticket to another user
+func (c *TicketController) InitiateTicketTransfer(ctx context.Context, req *ticketpb.InitiateTicketTransferRequest) (*ticketpb.InitiateTicketTransferResponse, error) {
+	c.logger.Info("InitiateTicketTransfer request received",
+		zap.String("ticket_id", req.TicketId),
+		zap.String("from_user_id", req.FromUserId),
+		zap.String("to_user_id", req.ToUserId),
+		zap.String("transfer_type", req.TransferType),
+	)
+
+	serviceReq := &services.InitiateTransferRequest{
+		TicketID:     req.TicketId,
+		FromUserID:   req.FromUserId,
+		ToUserID:     req.ToUserId,
+		TransferType: req.TransferType,
+		ExpiresIn:    time.Duration(req.ExpiresInSeconds) * time.Second,
+	}
+
+	transfer, err := c.transferService.InitiateTransfer(ctx, serviceReq)
+	if err != nil {
+		c.logger.Error("Failed to initiate ticket transfer",
+			zap.String("ticket_id", req.TicketId),
+			zap.Error(err),
+		)
+		return nil, c.transferError("InitiateTicketTransfer", "failed to initiate ticket transfer", err)
+	}
+
+	response := &ticketpb.InitiateTicketTransferResponse{
+		Success:  true,
+		Transfer: convertTransferToProto(transfer),
+		Message:  "Ticket transfer initiated successfully",
+	}
+
+	return response, nil
+}
+
+// AcceptTicketTransfer moves a ticket to the recipient of a pending transfer
+func (c *TicketController) AcceptTicketTransfer(ctx context.Context, req *ticketpb.AcceptTicketTransferRequest) (*ticketpb.AcceptTicketTransferResponse, error) {
+	c.logger.Info("AcceptTicketTransfer request received",
+		zap.String("transfer_id", req.TransferId),
+		zap.String("user_id", req.UserId),
+	)
+
+	serviceReq := &services.AcceptTransferRequest{
+		TransferID:    req.TransferId,
+		UserID:        req.UserId,
+		PaymentMethod: req.PaymentMethod,
+	}
+
+	transfer, ticket, err := c.transferService.AcceptTransfer(ctx, serviceReq)
+	if err != nil {
+		c.logger.Error("Failed to accept ticket transfer",
+			zap.String("transfer_id", req.TransferId),
+			zap.Error(err),
+		)
+		return nil, c.transferError("AcceptTicketTransfer", "failed to accept ticket transfer", err)
+	}
+
+	response := &ticketpb.AcceptTicketTransferResponse{
+		Success:  true,
+		Transfer: convertTransferToProto(transfer),
+		Ticket:   c.convertTicketToProto(ticket),
+		Message:  "Ticket transferred successfully",
+	}
+
+	return response, nil
+}
+
+// CancelTicketTransfer withdraws or declines a pending transfer
+func (c *TicketController) CancelTicketTransfer(ctx context.Context, req *ticketpb.CancelTicketTransferRequest) (*ticketpb.CancelTicketTransferResponse, error) {
+	c.logger.Info("CancelTicketTransfer request received",
+		zap.String("transfer_id", req.TransferId),
+		zap.String("user_id", req.UserId),
+		zap.String("reason", req.Reason),
+	)
+
+	err := c.transferService.CancelTransfer(ctx, req.TransferId, req.UserId, req.Reason)
+	if err != nil {
+		c.logger.Error("Failed to cancel ticket transfer",
+			zap.String("transfer_id", req.TransferId),
+			zap.Error(err),
+		)
+		return nil, c.transferError("CancelTicketTransfer", "failed to cancel ticket transfer", err)
+	}
+
+	response := &ticketpb.CancelTicketTransferResponse{
+		Success: true,
+		Message: "Ticket transfer cancelled successfully",
+	}
+
+	return response, nil
+}
+
+// GetTicketTransfer retrieves a ticket transfer by ID
+func (c *TicketController) GetTicketTransfer(ctx context.Context, req *ticketpb.GetTicketTransferRequest) (*ticketpb.GetTicketTransferResponse, error) {
+	transfer, err := c.transferService.GetTransfer(ctx, req.TransferId)
+	if err != nil {
+		c.logger.Error("Failed to get ticket transfer",
+			zap.String("transfer_id", req.TransferId),
+			zap.Error(err),
+		)
+		return nil, c.transferError("GetTicketTransfer", "failed to get ticket transfer", err)
+	}
+
+	response := &ticketpb.GetTicketTransferResponse{
+		Success:  true,
+		Transfer: convertTransferToProto(transfer),
+	}
+
+	return response, nil
+}
+
+// GetTicketOwnershipHistory retrieves the ownership audit trail of a ticket
+func (c *TicketController) GetTicketOwnershipHistory(ctx context.Context, req *ticketpb.GetTicketOwnershipHistoryRequest) (*ticketpb.GetTicketOwnershipHistoryResponse, error) {
+	changes, err := c.transferService.GetOwnershipHistory(ctx, req.TicketId)
+	if err != nil {
+		c.logger.Error("Failed to get ticket ownership history",
+			zap.String("ticket_id", req.TicketId),
+			zap.Error(err),
+		)
+		metrics.IncrementGRPCError("ticket", "GetTicketOwnershipHistory", "service_error")
+		return nil, status.Errorf(codes.Internal, "failed to get ticket ownership history: %v", err)
+	}
+
+	protoChanges := make([]*ticketpb.TicketOwnershipChange, len(changes))
+	for i, change := range changes {
+		protoChanges[i] = convertOwnershipChangeToProto(change)
+	}
+
+	response := &ticketpb.GetTicketOwnershipHistoryResponse{
+		Success: true,
+		Changes: protoChanges,
+	}
+
+	return response, nil
+}
+
+// GetEventTransferPolicy retrieves the transfer policy of an event
+func (c *TicketController) GetEventTransferPolicy(ctx context.Context, req *ticketpb.GetEventTransferPolicyRequest) (*ticketpb.GetEventTransferPolicyResponse, error) {
+	policy, err := c.transferService.GetEventPolicy(ctx, req.EventId)
+	if err != nil {
+		c.logger.Error("Failed to get event transfer policy",
+			zap.String("event_id", req.EventId),
+			zap.Error(err),
+		)
+		metrics.IncrementGRPCError("ticket", "GetEventTransferPolicy", "service_error")
+		return nil, status.Errorf(codes.Internal, "failed to get event transfer policy: %v", err)
+	}
+
+	response := &ticketpb.GetEventTransferPolicyResponse{
+		Success: true,
+		Policy:  convertTransferPolicyToProto(policy),
+	}
+
+	return response, nil
+}
+
+// SetEventTransferPolicy sets whether an event's tickets may be transferred and resold
+func (c *TicketController) SetEventTransferPolicy(ctx context.Context, req *ticketpb.SetEventTransferPolicyRequest) (*ticketpb.SetEventTransferPolicyResponse, error) {
+	c.logger.Info("SetEventTransferPolicy request received",
+		zap.String("event_id", req.EventId),
+		zap.Bool("transfers_enabled", req.TransfersEnabled),
+		zap.Bool("resale_enabled", req.ResaleEnabled),
+		zap.String("updated_by", req.UpdatedBy),
+	)
+
+	policy := &models.EventTransferPolicy{
+		EventID:          req.EventId,
+		TransfersEnabled: req.TransfersEnabled,
+		ResaleEnabled:    req.ResaleEnabled,
+	}
+	if req.UpdatedBy != "" {
+		policy.UpdatedBy = &req.UpdatedBy
+	}
+
+	if err := c.transferService.SetEventPolicy(ctx, policy); err != nil {
+		c.logger.Error("Failed to set event transfer policy",
+			zap.String("event_id", req.EventId),
+			zap.Error(err),
+		)
+		metrics.IncrementGRPCError("ticket", "SetEventTransferPolicy", "service_error")
+		return nil, status.Errorf(codes.Internal, "failed to set event transfer policy: %v", err)
+	}
+
+	response := &ticketpb.SetEventTransferPolicyResponse{
+		Success: true,
+		Policy:  convertTransferPolicyToProto(policy),
+		Message: "Event transfer policy updated successfully",
+	}
+
+	return response, nil
+}
+
+// transferError maps a ticket transfer error to its gRPC status
+func (c *TicketController) transferError(method, message string, err error) error {
+	code, errorType := codes.Internal, "service_error"
+	switch {
+	case errors.Is(err, repositories.ErrTransferNotFound):
+		code, errorType = codes.NotFound, "transfer_not_found"
+	case errors.Is(err, services.ErrNotTicketOwner), errors.Is(err, services.ErrNotTransferParty):
+		code, errorType = codes.PermissionDenied, "not_transfer_party"
+	case errors.Is(err, repositories.ErrTransferPending):
+		code, errorType = codes.AlreadyExists, "transfer_pending"
+	case errors.Is(err, services.ErrTransferNotAllowed),
+		errors.Is(err, repositories.ErrTransferNotPending),
+		errors.Is(err, repositories.ErrTicketOwnerChanged),
+		errors.Is(err, services.ErrTransferExpired):
+		code, errorType = codes.FailedPrecondition, "transfer_not_allowed"
+	case errors.Is(err, services.ErrTransferPaymentFailed):
+		code, errorType = codes.Aborted, "payment_failed"
+	case errors.Is(err, services.ErrTransferPaymentPending):
+		code, errorType = codes.Unavailable, "payment_pending"
+	}
+
+	metrics.IncrementGRPCError("ticket", method, errorType)
+	return status.Errorf(code, "%s: %v", message, err)
+}
+
+func convertTransferToProto(transfer *models.TicketTransfer) *ticketpb.TicketTransfer {
+	protoTransfer := &ticketpb.TicketTransfer{
+		Id:           transfer.ID,
+		TicketId:     transfer.TicketID,
+		EventId:      transfer.EventID,
+		FromUserId:   transfer.FromUserID,
+		ToUserId:     transfer.ToUserID,
+		TransferType: transfer.TransferType,
+		Status:       transfer.Status,
+		Price:        transfer.Price,
+		Currency:     transfer.Currency,
+		ExpiresAt:    transfer.ExpiresAt.Unix(),
+		CreatedAt:    transfer.CreatedAt.Unix(),
+	}
+
+	// Set optional fields
+	if transfer.PaymentReference != nil {
+		protoTransfer.PaymentReference = *transfer.PaymentReference
+	}
+	if transfer.RefundReference != nil {
+		protoTransfer.RefundReference = *transfer.RefundReference
+	}
+	if transfer.AcceptedAt != nil {
+		protoTransfer.AcceptedAt = transfer.AcceptedAt.Unix()
+	}
+	if transfer.CancelledAt != nil {
+		protoTransfer.CancelledAt = transfer.CancelledAt.Unix()
+	}
+	if transfer.CancelledReason != nil {
+		protoTransfer.CancelledReason = *transfer.CancelledReason
+	}
+
+	return protoTransfer
+}
+
+func convertOwnershipChangeToProto(change *models.TicketOwnershipChange) *ticketpb.TicketOwnershipChange {
+	protoChange := &ticketpb.TicketOwnershipChange{
+		Id:         change.ID,
+		TicketId:   change.TicketID,
+		FromUserId: change.FromUserID,
+		ToUserId:   change.ToUserID,
+		ChangeType: change.ChangeType,
+		Price:      change.Price,
+		Currency:   change.Currency,
+		CreatedAt:  change.CreatedAt.Unix(),
+	}
+
+	// Set optional fields
+	if change.TransferID != nil {
+		protoChange.TransferId = *change.TransferID
+	}
+	if change.PaymentReference != nil {
+		protoChange.PaymentReference = *change.PaymentReference
+	}
+	if change.RefundReference != nil {
+		protoChange.RefundReference = *change.RefundReference
+	}
+	if change.ChangedBy != nil {
+		protoChange.ChangedBy = *change.ChangedBy
+	}
+
+	return protoChange
+}
+
+func convertTransferPolicyToProto(policy *models.EventTransferPolicy) *ticketpb.EventTransferPolicy {
+	protoPolicy := &ticketpb.EventTransferPolicy{
+		EventId:          policy.EventID,
+		TransfersEnabled: policy.TransfersEnabled,
+		ResaleEnabled:    policy.ResaleEnabled,
+	}
+	if !policy.UpdatedAt.IsZero() {
+		protoPolicy.UpdatedAt = policy.UpdatedAt.Unix()
+	}
+	return protoPolicy
+}
@@ -0,0 +1,191 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"ticket-service/metrics"
+	"ticket-service/queue"
+
+	ticketpb "ticket-service/internal/protos/ticket"
+)
+
+// WaitingRoomController handles gRPC requests for event waiting rooms
+type WaitingRoomController struct {
+	ticketpb.UnimplementedWaitingRoomServiceServer
+	queueManager *queue.QueueManager
+	logger       *zap.Logger
+}
+
+// NewWaitingRoomController creates a new waiting room controller
+func NewWaitingRoomController(queueManager *queue.QueueManager, logger *zap.Logger) *WaitingRoomController {
+	return &WaitingRoomController{
+		queueManager: queueManager,
+		logger:       logger,
+	}
+}
+
+// JoinWaitingRoom queues a user in the waiting room of an event
+func (c *WaitingRoomController) JoinWaitingRoom(ctx context.Context, req *ticketpb.JoinWaitingRoomRequest) (*ticketpb.JoinWaitingRoomResponse, error) {
+	if req.EventId == "" || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "event_id and user_id are required")
+	}
+
+	position, err := c.queueManager.JoinQueue(ctx, req.UserId, req.EventId, req.SessionId)
+	if err != nil {
+		c.logger.Error("Failed to join waiting room",
+			zap.String("event_id", req.EventId),
+			zap.String("user_id", req.UserId),
+			zap.Error(err),
+		)
+		if errors.Is(err, queue.ErrWaitingRoomClosed) {
+			metrics.IncrementGRPCError("waiting_room", "JoinWaitingRoom", "waiting_room_closed")
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to join waiting room: %v", err)
+		}
+		metrics.IncrementGRPCError("waiting_room", "JoinWaitingRoom", "service_error")
+		return nil, status.Errorf(codes.Internal, "failed to join waiting room: %v", err)
+	}
+
+	response := &ticketpb.JoinWaitingRoomResponse{
+		Success:  true,
+		Position: convertQueuePositionToProto(position),
+		Message:  "Joined waiting room successfully",
+	}
+
+	return response, nil
+}
+
+// GetWaitingRoomPosition gets the position of a queued user, or their admission once admitted
+func (c *WaitingRoomController) GetWaitingRoomPosition(ctx context.Context, req *ticketpb.GetWaitingRoomPositionRequest) (*ticketpb.GetWaitingRoomPositionResponse, error) {
+	position, err := c.queueManager.GetUserPosition(ctx, req.UserId, req.EventId)
+	if err != nil {
+		if errors.Is(err, queue.ErrNotInQueue) {
+			metrics.IncrementGRPCError("waiting_room", "GetWaitingRoomPosition", "not_in_queue")
+			return nil, status.Errorf(codes.NotFound, "failed to get waiting room position: %v", err)
+		}
+		c.logger.Error("Failed to get waiting room position",
+			zap.String("event_id", req.EventId),
+			zap.String("user_id", req.UserId),
+			zap.Error(err),
+		)
+		metrics.IncrementGRPCError("waiting_room", "GetWaitingRoomPosition", "service_error")
+		return nil, status.Errorf(codes.Internal, "failed to get waiting room position: %v", err)
+	}
+
+	response := &ticketpb.GetWaitingRoomPositionResponse{
+		Success:  true,
+		Position: convertQueuePositionToProto(position),
+	}
+
+	return response, nil
+}
+
+// GetWaitingRoomStatus gets the status of an event's waiting room
+func (c *WaitingRoomController) GetWaitingRoomStatus(ctx context.Context, req *ticketpb.GetWaitingRoomStatusRequest) (*ticketpb.GetWaitingRoomStatusResponse, error) {
+	queueStatus, err := c.queueManager.GetQueueStatus(ctx, req.EventId)
+	if err != nil {
+		c.logger.Error("Failed to get waiting room status",
+			zap.String("event_id", req.EventId),
+			zap.Error(err),
+		)
+		metrics.IncrementGRPCError("waiting_room", "GetWaitingRoomStatus", "service_error")
+		return nil, status.Errorf(codes.Internal, "failed to get waiting room status: %v", err)
+	}
+
+	response := &ticketpb.GetWaitingRoomStatusResponse{
+		Success:              true,
+		EventId:              queueStatus.EventID,
+		IsOpen:               queueStatus.IsOpen,
+		AdmissionRate:        int32(queueStatus.AdmissionRate),
+		QueuedUsers:          int32(queueStatus.TotalUsers),
+		ActiveUsers:          int32(queueStatus.ActiveUsers),
+		EstimatedWaitSeconds: int32(queueStatus.EstimatedWaitTime),
+	}
+
+	return response, nil
+}
+
+// OpenWaitingRoom opens the waiting room of an event, or changes its admission rate
+func (c *WaitingRoomController) OpenWaitingRoom(ctx context.Context, req *ticketpb.OpenWaitingRoomRequest) (*ticketpb.OpenWaitingRoomResponse, error) {
+	c.logger.Info("OpenWaitingRoom request received",
+		zap.String("event_id", req.EventId),
+		zap.Int32("admission_rate", req.AdmissionRate),
+	)
+
+	if req.EventId == "" {
+		return nil, status.Error(codes.InvalidArgument, "event_id is required")
+	}
+	if req.AdmissionRate < 0 {
+		return nil, status.Error(codes.InvalidArgument, "admission_rate cannot be negative")
+	}
+
+	room, err := c.queueManager.OpenWaitingRoom(ctx, req.EventId, int(req.AdmissionRate))
+	if err != nil {
+		c.logger.Error("Failed to open waiting room",
+			zap.String("event_id", req.EventId),
+			zap.Error(err),
+		)
+		metrics.IncrementGRPCError("waiting_room", "OpenWaitingRoom", "service_error")
+		return nil, status.Errorf(codes.Internal, "failed to open waiting room: %v", err)
+	}
+
+	response := &ticketpb.OpenWaitingRoomResponse{
+		Success:       true,
+		AdmissionRate: int32(room.AdmissionRate),
+		OpenedAt:      room.OpenedAt.Unix(),
+		Message:       "Waiting room opened successfully",
+	}
+
+	return response, nil
+}
+
+// CloseWaitingRoom closes the waiting room of an event
+func (c *WaitingRoomController) CloseWaitingRoom(ctx context.Context, req *ticketpb.CloseWaitingRoomRequest) (*ticketpb.CloseWaitingRoomResponse, error) {
+	c.logger.Info("CloseWaitingRoom request received",
+		zap.String("event_id", req.EventId),
+	)
+
+	if err := c.queueManager.CloseWaitingRoom(ctx, req.EventId); err != nil {
+		c.logger.Error("Failed to close waiting room",
+			zap.String("event_id", req.EventId),
+			zap.Error(err),
+		)
+		metrics.IncrementGRPCError("waiting_room", "CloseWaitingRoom", "service_error")
+		return nil, status.Errorf(codes.Internal, "failed to close waiting room: %v", err)
+	}
+
+	response := &ticketpb.CloseWaitingRoomResponse{
+		Success: true,
+		Message: "Waiting room closed successfully",
+	}
+
+	return response, nil
+}
+
+func convertQueuePositionToProto(position *queue.QueuePosition) *ticketpb.WaitingRoomPosition {
+	protoPosition := &ticketpb.WaitingRoomPosition{
+		UserId:               position.UserID,
+		EventId:              position.EventID,
+		Position:             int32(position.Position),
+		EstimatedWaitSeconds: int32(position.EstimatedWaitTime),
+		Admitted:             position.Admitted,
+		AdmissionToken:       position.AdmissionToken,
+	}
+
+	// Set optional fields
+	if !position.JoinedAt.IsZero() {
+		protoPosition.JoinedAt = position.JoinedAt.Unix()
+	}
+	if !position.ExpiresAt.IsZero() {
+		protoPosition.ExpiresAt = position.ExpiresAt.Unix()
+	}
+	if !position.AdmissionExpiresAt.IsZero() {
+		protoPosition.AdmissionExpiresAt = position.AdmissionExpiresAt.Unix()
+	}
+
+	return protoPosition
+}
@@ -19,6 +19,7 @@ type EventServiceClient struct {
 	conn               *grpc.ClientConn
 	client             eventpb.EventServiceClient
 	availabilityClient eventpb.AvailabilityServiceClient
+	seatClient         eventpb.EventSeatServiceClient
 	logger             *zap.Logger
 }
 
@@ -33,6 +34,7 @@ func NewEventServiceClient(config config.EventServiceConfig, logger *zap.Logger)
 
 	client := eventpb.NewEventServiceClient(conn)
 	availabilityClient := eventpb.NewAvailabilityServiceClient(conn)
+	seatClient := eventpb.NewEventSeatServiceClient(conn)
 
 	logger.Info("Connected to Event Service",
 		zap.String("address", address),
@@ -42,6 +44,7 @@ func NewEventServiceClient(config config.EventServiceConfig, logger *zap.Logger)
 		conn:               conn,
 		client:             client,
 		availabilityClient: availabilityClient,
+		seatClient:         seatClient,
 		logger:             logger,
 	}, nil
 }
@@ -64,6 +67,102 @@ func (c *EventServiceClient) GetEvent(ctx context.Context, eventID string) (*eve
 	return resp.Event, nil
 }
 
+// GetSeat retrieves a seat of an event's layout
+func (c *EventServiceClient) GetSeat(ctx context.Context, seatID string) (*eventpb.EventSeatFull, error) {
+	req := &eventpb.GetSeatRequest{
+		SeatId: seatID,
+	}
+
+	resp, err := c.seatClient.GetSeat(ctx, req)
+	if err != nil {
+		c.logger.Error("Failed to get seat",
+			zap.String("seat_id", seatID),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	if !resp.Success || resp.Seat == nil {
+		return nil, fmt.Errorf("failed to get seat %s: %s", seatID, resp.Error)
+	}
+
+	return resp.Seat, nil
+}
+
+// ListSeats retrieves every seat of an event's layout, or of one zone when zoneID is set,
+// ordered by row and seat number
+func (c *EventServiceClient) ListSeats(ctx context.Context, eventID, zoneID string) ([]*eventpb.EventSeatFull, error) {
+	const pageSize = 500
+
+	var seats []*eventpb.EventSeatFull
+	for page := int32(1); ; page++ {
+		req := &eventpb.ListSeatsByEventRequest{
+			EventId: eventID,
+			ZoneId:  zoneID,
+			Page:    page,
+			Limit:   pageSize,
+		}
+
+		resp, err := c.seatClient.ListSeatsByEvent(ctx, req)
+		if err != nil {
+			c.logger.Error("Failed to list seats",
+				zap.String("event_id", eventID),
+				zap.String("zone_id", zoneID),
+				zap.Error(err),
+			)
+			return nil, err
+		}
+		if !resp.Success {
+			return nil, fmt.Errorf("failed to list seats of event %s: %s", eventID, resp.Error)
+		}
+
+		seats = append(seats, resp.Seats...)
+		if len(resp.Seats) < pageSize || len(seats) >= int(resp.Total) {
+			return seats, nil
+		}
+	}
+}
+
+// GetAvailability retrieves the availability of every seat of an event, or of one zone
+// when zoneID is set
+func (c *EventServiceClient) GetAvailability(ctx context.Context, eventID, zoneID string) ([]*eventpb.SeatAvailability, error) {
+	var availability []*eventpb.SeatAvailability
+	var respErr string
+	if zoneID != "" {
+		resp, err := c.availabilityClient.GetZoneAvailability(ctx, &eventpb.GetZoneAvailabilityRequest{
+			EventId: eventID,
+			ZoneId:  zoneID,
+		})
+		if err != nil {
+			c.logger.Error("Failed to get zone availability",
+				zap.String("event_id", eventID),
+				zap.String("zone_id", zoneID),
+				zap.Error(err),
+			)
+			return nil, err
+		}
+		availability, respErr = resp.Availability, resp.Error
+	} else {
+		resp, err := c.availabilityClient.GetEventAvailability(ctx, &eventpb.GetEventAvailabilityRequest{
+			EventId: eventID,
+		})
+		if err != nil {
+			c.logger.Error("Failed to get event availability",
+				zap.String("event_id", eventID),
+				zap.Error(err),
+			)
+			return nil, err
+		}
+		availability, respErr = resp.Availability, resp.Error
+	}
+
+	if respErr != "" {
+		return nil, fmt.Errorf("failed to get availability of event %s: %s", eventID, respErr)
+	}
+
+	return availability, nil
+}
+
 // GetSeatAvailability retrieves seat availability
 func (c *EventServiceClient) GetSeatAvailability(ctx context.Context, eventID, seatID string) (*eventpb.GetSeatAvailabilityResponse, error) {
 	req := &eventpb.GetSeatAvailabilityRequest{
@@ -71,6 +71,24 @@ func (c *PaymentServiceClient) CreateRefund(ctx context.Context, req *paymentpb.
 	return resp, nil
 }
 
+// ListRefunds retrieves the refunds of a payment
+func (c *PaymentServiceClient) ListRefunds(ctx context.Context, paymentID string) (*paymentpb.ListRefundsResponse, error) {
+	req := &paymentpb.ListRefundsRequest{
+		PaymentId: paymentID,
+	}
+
+	resp, err := c.client.ListRefunds(ctx, req)
+	if err != nil {
+		c.logger.Error("Failed to list refunds",
+			zap.String("payment_id", paymentID),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	return resp, nil
+}
+
 // GetPayment retrieves payment details
 func (c *PaymentServiceClient) GetPayment(ctx context.Context, paymentID string) (*paymentpb.PaymentResponse, error) {
 	req := &paymentpb.GetPaymentRequest{
@@ -0,0 +1,98 @@
+package grpcclient
+
+import (
+	"context"
+	"fmt"
+	"ticket-service/config"
+
+	"grpctls"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	realtimepb "ticket-service/internal/protos/realtime"
+)
+
+// RealtimeServiceClient handles communication with Realtime Service
+type RealtimeServiceClient struct {
+	conn   *grpc.ClientConn
+	client realtimepb.RealtimeServiceClient
+	logger *zap.Logger
+}
+
+// NewRealtimeServiceClient creates a new Realtime Service gRPC client
+func NewRealtimeServiceClient(config config.RealtimeServiceConfig, logger *zap.Logger) (*RealtimeServiceClient, error) {
+	address := fmt.Sprintf("%s:%s", config.Host, config.Port)
+
+	conn, err := grpc.Dial(address, grpctls.DialOption())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Realtime Service: %w", err)
+	}
+
+	client := realtimepb.NewRealtimeServiceClient(conn)
+
+	logger.Info("Connected to Realtime Service",
+		zap.String("address", address),
+	)
+
+	return &RealtimeServiceClient{
+		conn:   conn,
+		client: client,
+		logger: logger,
+	}, nil
+}
+
+// NotifyBookingResult pushes the outcome of a booking to the user's connections
+func (c *RealtimeServiceClient) NotifyBookingResult(ctx context.Context, req *realtimepb.NotifyBookingResultRequest) error {
+	resp, err := c.client.NotifyBookingResult(ctx, req)
+	if err != nil {
+		c.logger.Error("Failed to notify booking result",
+			zap.String("user_id", req.UserId),
+			zap.String("booking_id", req.BookingId),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	c.logger.Debug("Booking result notification sent",
+		zap.String("user_id", req.UserId),
+		zap.String("booking_id", req.BookingId),
+		zap.Bool("delivered", resp.GetDelivered()),
+	)
+
+	return nil
+}
+
+// BroadcastEvent pushes an event with a JSON payload to every connection in a room and
+// returns how many received it
+func (c *RealtimeServiceClient) BroadcastEvent(ctx context.Context, eventType, room, payload string) (int32, error) {
+	resp, err := c.client.BroadcastEvent(ctx, &realtimepb.BroadcastEventRequest{
+		EventType: eventType,
+		Room:      room,
+		Payload:   payload,
+	})
+	if err != nil {
+		c.logger.Error("Failed to broadcast event",
+			zap.String("event_type", eventType),
+			zap.String("room", room),
+			zap.Error(err),
+		)
+		return 0, err
+	}
+
+	c.logger.Debug("Event broadcast",
+		zap.String("event_type", eventType),
+		zap.String("room", room),
+		zap.Int32("recipients", resp.GetRecipients()),
+	)
+
+	return resp.GetRecipients(), nil
+}
+
+// Close closes the gRPC connection
+func (c *RealtimeServiceClient) Close() error {
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"syscall"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 
 	"ticket-service/config"
@@ -15,6 +16,9 @@ import (
 	"ticket-service/grpc"
 	"ticket-service/grpcclient"
 	"ticket-service/metrics"
+	"ticket-service/models"
+	"ticket-service/outbox"
+	"ticket-service/queue"
 	"ticket-service/repositories"
 	"ticket-service/services"
 )
@@ -24,11 +28,19 @@ type App struct {
 	logger             *zap.Logger
 	config             *config.Config
 	db                 *database.Connection
+	redis              *redis.Client
 	ticketService      *services.TicketService
+	transferService    *services.TicketTransferService
 	bookingService     *services.TicketBookingSessionService
 	reservationService *services.ReservationService
+	groupService       *services.GroupBookingService
+	expirySweeper      *services.ExpirySweeper
 	eventClient        *grpcclient.EventServiceClient
 	paymentClient      *grpcclient.PaymentServiceClient
+	realtimeClient     *grpcclient.RealtimeServiceClient
+	bookingSaga        *services.BookingSagaOrchestrator
+	outboxRelay        *outbox.Relay
+	queueManager       *queue.QueueManager
 	grpcServer         *grpc.Server
 }
 
@@ -71,6 +83,33 @@ func (a *App) Initialize() error {
 	ticketRepo := repositories.NewTicketRepository(a.db.GetDB(), a.logger)
 	bookingRepo := repositories.NewBookingSessionRepository(a.db.GetDB(), a.logger)
 	reservationRepo := repositories.NewSeatReservationRepository(a.db.GetDB(), a.logger)
+	sagaRepo := repositories.NewBookingSagaRepository(a.db.GetDB(), a.logger)
+	outboxRepo := repositories.NewOutboxRepository(a.db.GetDB(), a.logger)
+	transferRepo := repositories.NewTicketTransferRepository(a.db.GetDB(), a.logger)
+	refundPolicyRepo := repositories.NewRefundPolicyRepository(a.db.GetDB(), a.logger)
+	holdPolicyRepo := repositories.NewHoldPolicyRepository(a.db.GetDB(), a.logger)
+	groupRepo := repositories.NewGroupBookingRepository(a.db.GetDB(), a.logger)
+
+	// Initialize Redis, where the outbox relay publishes domain events and the event
+	// waiting rooms queue users
+	a.redis = redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%d", a.config.Redis.Host, a.config.Redis.Port),
+		Password: a.config.Redis.Password,
+		DB:       a.config.Redis.DB,
+		PoolSize: a.config.Redis.PoolSize,
+	})
+
+	publisher := outbox.NewRedisStreamPublisher(a.redis, map[string]string{
+		models.AggregateTypeBooking: a.config.Outbox.BookingStream,
+		models.AggregateTypeTicket:  a.config.Outbox.TicketStream,
+	}, a.config.Outbox.StreamMaxLen)
+	a.outboxRelay = outbox.NewRelay(outboxRepo, publisher, a.config.Outbox, a.logger)
+
+	queueManager, err := queue.NewQueueManager(a.redis, a.config.WaitingRoom, a.logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize waiting rooms: %w", err)
+	}
+	a.queueManager = queueManager
 
 	// Initialize gRPC clients
 	eventClient, err := grpcclient.NewEventServiceClient(a.config.Event, a.logger)
@@ -87,17 +126,42 @@ func (a *App) Initialize() error {
 	}
 	a.paymentClient = paymentClient
 
+	realtimeClient, err := grpcclient.NewRealtimeServiceClient(a.config.Realtime, a.logger)
+	if err != nil {
+		a.logger.Warn("Failed to create Realtime Service client", zap.Error(err))
+		realtimeClient = nil
+	}
+	a.realtimeClient = realtimeClient
+
+	codeIssuer, err := services.NewTicketCodeIssuer(a.config.TicketQR, eventClient, a.logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize ticket code issuer: %w", err)
+	}
+
 	// Initialize services
-	ticketService := services.NewTicketService(ticketRepo, eventClient, paymentClient, a.logger)
-	bookingService := services.NewTicketBookingSessionService(bookingRepo, reservationRepo, eventClient, paymentClient, a.logger)
-	reservationService := services.NewReservationService(reservationRepo, eventClient, a.logger)
+	ticketService := services.NewTicketService(ticketRepo, refundPolicyRepo, eventClient, paymentClient, codeIssuer, a.logger)
+	transferService := services.NewTicketTransferService(ticketRepo, transferRepo, paymentClient, codeIssuer, a.config.Transfer, a.logger)
+	bookingSaga := services.NewBookingSagaOrchestrator(
+		sagaRepo, bookingRepo, reservationRepo, ticketRepo,
+		eventClient, paymentClient, realtimeClient, codeIssuer, a.config.Saga, a.logger,
+	)
+	bookingService := services.NewTicketBookingSessionService(bookingRepo, reservationRepo, groupRepo, eventClient, paymentClient, realtimeClient, bookingSaga, queueManager, a.logger)
+	reservationService := services.NewReservationService(reservationRepo, bookingRepo, holdPolicyRepo, eventClient, realtimeClient, queueManager, a.logger)
+	groupService := services.NewGroupBookingService(
+		groupRepo, bookingRepo, reservationRepo, ticketRepo, bookingService,
+		eventClient, paymentClient, codeIssuer, a.config.Group, a.logger,
+	)
 
 	a.ticketService = ticketService
+	a.transferService = transferService
 	a.bookingService = bookingService
+	a.bookingSaga = bookingSaga
 	a.reservationService = reservationService
+	a.groupService = groupService
+	a.expirySweeper = services.NewExpirySweeper(bookingService, reservationService, a.redis, a.config.Sweeper, a.logger)
 
 	// Initialize gRPC server
-	grpcServer := grpc.NewServer(a.ticketService, a.bookingService, a.reservationService, a.logger)
+	grpcServer := grpc.NewServer(a.ticketService, a.transferService, a.bookingService, a.reservationService, a.groupService, a.queueManager, a.logger)
 	a.grpcServer = grpcServer
 
 	// Initialize Prometheus metrics
@@ -116,6 +180,21 @@ func (a *App) Run() error {
 		}
 	}()
 
+	// Resume booking sagas left in flight, e.g. by a previous run of this instance
+	go a.bookingSaga.Start()
+
+	// Publish domain events written to the outbox
+	go a.outboxRelay.Start()
+
+	// Admit users from the event waiting rooms
+	go a.queueManager.Start()
+
+	// Settle group bookings once their payment deadline passes
+	go a.groupService.Start()
+
+	// Expire lapsed sessions and seat holds while this instance leads the sweep
+	go a.expirySweeper.Start()
+
 	// Prometheus metrics server
 	go func() {
 		mux := http.NewServeMux()
@@ -144,6 +223,14 @@ func (a *App) Run() error {
 		a.logger.Error("Error stopping gRPC server", zap.Error(err))
 	}
 
+	// Stop resuming booking sagas, publishing events, admitting users, settling groups
+	// and sweeping expired holds
+	a.bookingSaga.Stop()
+	a.outboxRelay.Stop()
+	a.queueManager.Stop()
+	a.groupService.Stop()
+	a.expirySweeper.Stop()
+
 	// Close gRPC clients
 	if a.eventClient != nil {
 		a.eventClient.Close()
@@ -151,6 +238,14 @@ func (a *App) Run() error {
 	if a.paymentClient != nil {
 		a.paymentClient.Close()
 	}
+	if a.realtimeClient != nil {
+		a.realtimeClient.Close()
+	}
+
+	// Close Redis
+	if err := a.redis.Close(); err != nil {
+		a.logger.Error("Error closing Redis", zap.Error(err))
+	}
 
 	// Close database
 	if err := a.db.Close(); err != nil {
@@ -166,6 +261,11 @@ func (a *App) GetTicketService() *services.TicketService {
 	return a.ticketService
 }
 
+// GetTransferService returns the ticket transfer service instance
+func (a *App) GetTransferService() *services.TicketTransferService {
+	return a.transferService
+}
+
 // GetBookingService returns the booking service instance
 func (a *App) GetBookingService() *services.TicketBookingSessionService {
 	return a.bookingService
@@ -32,6 +32,14 @@ var (
 		[]string{"event_id", "reason"},
 	)
 
+	TicketsTransferred = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "tickets_transferred_total",
+			Help: "Total number of tickets transferred between users, by transfer type",
+		},
+		[]string{"event_id", "transfer_type"},
+	)
+
 	// Booking metrics
 	BookingSessionsCreated = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -57,6 +65,22 @@ var (
 		[]string{"event_id"},
 	)
 
+	WaitingRoomAdmissions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "waiting_room_admissions_total",
+			Help: "Total number of users admitted from event waiting rooms",
+		},
+		[]string{"event_id"},
+	)
+
+	GroupBookingsFinished = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "group_bookings_finished_total",
+			Help: "Total number of group bookings finished, by final status",
+		},
+		[]string{"event_id", "status"},
+	)
+
 	// Reservation metrics
 	SeatReservationsCreated = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -82,6 +106,14 @@ var (
 		[]string{"event_id", "zone_id"},
 	)
 
+	SeatHoldExtensions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "seat_hold_extensions_total",
+			Help: "Total number of seat hold extension requests, by result",
+		},
+		[]string{"event_id", "result"},
+	)
+
 	// Payment metrics
 	PaymentsProcessed = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -99,6 +131,32 @@ var (
 		[]string{"event_id", "reason"},
 	)
 
+	// Saga metrics
+	BookingSagasFinished = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "booking_sagas_finished_total",
+			Help: "Total number of booking sagas finished, by outcome and the step that failed",
+		},
+		[]string{"status", "failed_step"},
+	)
+
+	// Outbox metrics
+	OutboxEventsPublished = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_events_published_total",
+			Help: "Total number of outbox events published",
+		},
+		[]string{"event_type"},
+	)
+
+	OutboxPublishFailures = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_publish_failures_total",
+			Help: "Total number of failed outbox event publish attempts",
+		},
+		[]string{"event_type"},
+	)
+
 	// Duration metrics
 	BookingDuration = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
@@ -135,6 +193,13 @@ var (
 		[]string{"event_id", "zone_id"},
 	)
 
+	ExpirySweeperLeader = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "expiry_sweeper_leader",
+			Help: "1 while this instance leads the expiry sweeper, 0 otherwise",
+		},
+	)
+
 	// Error metrics
 	GRPCErrors = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -174,6 +239,11 @@ func IncrementTicketRefunded(eventID, reason string) {
 	TicketsRefunded.WithLabelValues(eventID, reason).Inc()
 }
 
+// IncrementTicketTransferred increments the tickets transferred counter
+func IncrementTicketTransferred(eventID, transferType string) {
+	TicketsTransferred.WithLabelValues(eventID, transferType).Inc()
+}
+
 // IncrementBookingSessionCreated increments the booking sessions created counter
 func IncrementBookingSessionCreated(eventID, status string) {
 	BookingSessionsCreated.WithLabelValues(eventID, status).Inc()
@@ -189,6 +259,16 @@ func IncrementBookingSessionCompleted(eventID string) {
 	BookingSessionsCompleted.WithLabelValues(eventID).Inc()
 }
 
+// IncrementWaitingRoomAdmitted adds to the waiting room admissions counter
+func IncrementWaitingRoomAdmitted(eventID string, count int) {
+	WaitingRoomAdmissions.WithLabelValues(eventID).Add(float64(count))
+}
+
+// IncrementGroupBookingFinished increments the group bookings finished counter
+func IncrementGroupBookingFinished(eventID, status string) {
+	GroupBookingsFinished.WithLabelValues(eventID, status).Inc()
+}
+
 // IncrementSeatReservationCreated increments the seat reservations created counter
 func IncrementSeatReservationCreated(eventID, zoneID string) {
 	SeatReservationsCreated.WithLabelValues(eventID, zoneID).Inc()
@@ -204,6 +284,11 @@ func IncrementSeatReservationConfirmed(eventID, zoneID string) {
 	SeatReservationsConfirmed.WithLabelValues(eventID, zoneID).Inc()
 }
 
+// IncrementSeatHoldExtension increments the seat hold extensions counter
+func IncrementSeatHoldExtension(eventID, result string) {
+	SeatHoldExtensions.WithLabelValues(eventID, result).Inc()
+}
+
 // IncrementPaymentProcessed increments the payments processed counter
 func IncrementPaymentProcessed(eventID, status, paymentMethod string) {
 	PaymentsProcessed.WithLabelValues(eventID, status, paymentMethod).Inc()
@@ -214,6 +299,21 @@ func IncrementPaymentFailed(eventID, reason string) {
 	PaymentsFailed.WithLabelValues(eventID, reason).Inc()
 }
 
+// IncrementBookingSagaFinished increments the booking sagas finished counter
+func IncrementBookingSagaFinished(status, failedStep string) {
+	BookingSagasFinished.WithLabelValues(status, failedStep).Inc()
+}
+
+// IncrementOutboxEventPublished increments the outbox events published counter
+func IncrementOutboxEventPublished(eventType string) {
+	OutboxEventsPublished.WithLabelValues(eventType).Inc()
+}
+
+// IncrementOutboxPublishFailure increments the outbox publish failures counter
+func IncrementOutboxPublishFailure(eventType string) {
+	OutboxPublishFailures.WithLabelValues(eventType).Inc()
+}
+
 // ObserveBookingDuration records the booking duration
 func ObserveBookingDuration(eventID, status string, duration float64) {
 	BookingDuration.WithLabelValues(eventID, status).Observe(duration)
@@ -234,6 +334,15 @@ func SetActiveSeatReservations(eventID, zoneID string, count float64) {
 	ActiveSeatReservations.WithLabelValues(eventID, zoneID).Set(count)
 }
 
+// SetExpirySweeperLeader records whether this instance leads the expiry sweeper
+func SetExpirySweeperLeader(leader bool) {
+	if leader {
+		ExpirySweeperLeader.Set(1)
+		return
+	}
+	ExpirySweeperLeader.Set(0)
+}
+
 // IncrementGRPCError increments the gRPC errors counter
 func IncrementGRPCError(service, method, errorType string) {
 	GRPCErrors.WithLabelValues(service, method, errorType).Inc()
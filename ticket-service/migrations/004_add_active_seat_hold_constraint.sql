@@ -0,0 +1,25 @@
+-- Migration: Add active seat hold constraint
+-- Description: At most one active (reserved) hold per seat of an event
+
+-- Retire holds that already expired so they cannot collide with the index
+UPDATE seat_reservations
+SET status = 'expired', released_at = CURRENT_TIMESTAMP, released_reason = 'Reservation expired'
+WHERE status = 'reserved' AND expires_at <= CURRENT_TIMESTAMP;
+
+-- Keep only the oldest of any concurrent holds on the same seat
+UPDATE seat_reservations sr
+SET status = 'released', released_at = CURRENT_TIMESTAMP, released_reason = 'Duplicate hold'
+WHERE sr.status = 'reserved'
+  AND EXISTS (
+    SELECT 1 FROM seat_reservations other
+    WHERE other.event_id = sr.event_id
+      AND other.seat_id = sr.seat_id
+      AND other.status = 'reserved'
+      AND (other.reserved_at, other.id) < (sr.reserved_at, sr.id)
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_seat_reservations_active_hold
+    ON seat_reservations(event_id, seat_id)
+    WHERE status = 'reserved';
+
+COMMENT ON INDEX uq_seat_reservations_active_hold IS 'A seat can only be held by one reservation at a time';
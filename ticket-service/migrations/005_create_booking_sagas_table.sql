@@ -0,0 +1,39 @@
+-- Migration: Create booking sagas table
+-- Description: Persistent state of the saga that completes a booking session
+
+CREATE TABLE IF NOT EXISTS booking_sagas (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    booking_session_id UUID UNIQUE NOT NULL REFERENCES booking_sessions(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'running', -- 'running', 'compensating', 'completed', 'compensated'
+    current_step VARCHAR(30) NOT NULL DEFAULT 'hold', -- 'hold', 'pay', 'confirm_seats', 'issue_tickets', 'notify', 'done'
+    failed_step VARCHAR(30), -- Step whose failure started compensation
+    payment_method VARCHAR(50),
+    payment_id VARCHAR(100),
+    attempts INTEGER NOT NULL DEFAULT 0, -- Failed attempts of the current step
+    last_error TEXT,
+    locked_by VARCHAR(100), -- Instance currently driving the saga
+    locked_until TIMESTAMP WITH TIME ZONE, -- Lease; another instance may resume the saga after it
+    completed_by UUID,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Indexes for performance
+CREATE INDEX IF NOT EXISTS idx_booking_sagas_status ON booking_sagas(status);
+
+-- Sagas that still have work to do, for resuming after a restart
+CREATE INDEX IF NOT EXISTS idx_booking_sagas_in_flight ON booking_sagas(locked_until)
+    WHERE status IN ('running', 'compensating');
+
+-- Trigger to update updated_at timestamp
+DROP TRIGGER IF EXISTS update_booking_sagas_updated_at ON booking_sagas;
+CREATE TRIGGER update_booking_sagas_updated_at
+    BEFORE UPDATE ON booking_sagas
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Add comments
+COMMENT ON TABLE booking_sagas IS 'Persistent state of the saga that completes a booking session';
+COMMENT ON COLUMN booking_sagas.current_step IS 'Next step to run, or to compensate while compensating';
+COMMENT ON COLUMN booking_sagas.locked_until IS 'Lease of the instance driving the saga';
@@ -0,0 +1,8 @@
+-- Migration: Add booking session ticket constraint
+-- Description: At most one live ticket per seat of a booking session, so issuing the tickets of a session can be retried
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_tickets_booking_session_seat
+    ON tickets(booking_session_id, seat_id)
+    WHERE status NOT IN ('cancelled', 'refunded');
+
+COMMENT ON INDEX uq_tickets_booking_session_seat IS 'A seat of a booking session is issued at most one live ticket';
@@ -0,0 +1,34 @@
+-- Migration: Create outbox events table
+-- Description: Transactional outbox of ticket and booking domain events, published by the outbox relay
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    aggregate_type VARCHAR(50) NOT NULL, -- 'booking', 'ticket'
+    aggregate_id VARCHAR(100) NOT NULL,
+    event_type VARCHAR(50) NOT NULL, -- 'booking.confirmed', 'booking.cancelled', 'ticket.refunded', ...
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- 'pending', 'published'
+    retry_count INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    available_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP, -- Not published before, for retry backoff
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_outbox_events_status CHECK (status IN ('pending', 'published'))
+);
+
+-- Index for polling pending events
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events(available_at, created_at)
+    WHERE status = 'pending';
+
+-- Index for cleanup of old published events
+CREATE INDEX IF NOT EXISTS idx_outbox_events_published ON outbox_events(published_at)
+    WHERE status = 'published';
+
+-- Index for aggregate lookup and per-aggregate ordering
+CREATE INDEX IF NOT EXISTS idx_outbox_events_aggregate ON outbox_events(aggregate_type, aggregate_id, created_at);
+
+-- Add comments
+COMMENT ON TABLE outbox_events IS 'Transactional outbox: events are written with the changes they describe and published at least once';
+COMMENT ON COLUMN outbox_events.aggregate_id IS 'ID of the aggregate (booking session ID, ticket ID); events of one aggregate are published in order';
+COMMENT ON COLUMN outbox_events.payload IS 'Full event payload as JSON';
+COMMENT ON COLUMN outbox_events.available_at IS 'Earliest time the relay publishes the event, pushed back after each failed attempt';
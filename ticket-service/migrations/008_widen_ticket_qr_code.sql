@@ -0,0 +1,6 @@
+-- Migration: Widen ticket QR code
+-- Description: QR codes are signed tokens now, longer than the old TICKET:<id>:<event>:<seat> format
+
+ALTER TABLE tickets ALTER COLUMN qr_code TYPE VARCHAR(512);
+
+COMMENT ON COLUMN tickets.qr_code IS 'Signed ticket token (TQR1.<key id>.<claims>.<signature>)';
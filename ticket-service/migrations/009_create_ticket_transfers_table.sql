@@ -0,0 +1,80 @@
+-- Migration: Create ticket transfer tables
+-- Description: Transfers and face-value resales of tickets between users, the
+-- per-event rules for them, and the audit trail of ticket ownership
+
+CREATE TABLE IF NOT EXISTS event_transfer_policies (
+    event_id UUID PRIMARY KEY,
+    transfers_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    resale_enabled BOOLEAN NOT NULL DEFAULT FALSE, -- Face-value resale through the payment service
+    updated_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ticket_transfers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    ticket_id UUID NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    from_user_id UUID NOT NULL,
+    to_user_id UUID NOT NULL,
+    transfer_type VARCHAR(20) NOT NULL DEFAULT 'gift', -- 'gift', 'resale'
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- 'pending', 'accepted', 'cancelled', 'expired'
+    price DECIMAL(10,2) NOT NULL DEFAULT 0, -- Face value paid by the recipient of a resale
+    currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+    payment_reference VARCHAR(255), -- Recipient's payment for a resale
+    refund_reference VARCHAR(255), -- Refund of the previous owner's payment for a resale
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE,
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    cancelled_reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_ticket_transfers_type CHECK (transfer_type IN ('gift', 'resale')),
+    CONSTRAINT chk_ticket_transfers_status CHECK (status IN ('pending', 'accepted', 'cancelled', 'expired')),
+    CONSTRAINT chk_ticket_transfers_users CHECK (from_user_id <> to_user_id)
+);
+
+-- A ticket has at most one open transfer
+CREATE UNIQUE INDEX IF NOT EXISTS uq_ticket_transfers_pending ON ticket_transfers(ticket_id)
+    WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_ticket_transfers_from_user ON ticket_transfers(from_user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_ticket_transfers_to_user ON ticket_transfers(to_user_id, status);
+
+-- Append-only: one row per ownership change of a ticket
+CREATE TABLE IF NOT EXISTS ticket_ownership_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    ticket_id UUID NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    transfer_id UUID REFERENCES ticket_transfers(id),
+    from_user_id UUID NOT NULL,
+    to_user_id UUID NOT NULL,
+    change_type VARCHAR(20) NOT NULL, -- 'gift', 'resale'
+    price DECIMAL(10,2) NOT NULL DEFAULT 0,
+    currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+    payment_reference VARCHAR(255),
+    refund_reference VARCHAR(255),
+    previous_qr_code VARCHAR(512), -- Code invalidated by the change
+    changed_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_ownership_history_ticket ON ticket_ownership_history(ticket_id, created_at);
+
+-- Triggers to update updated_at timestamp
+DROP TRIGGER IF EXISTS update_event_transfer_policies_updated_at ON event_transfer_policies;
+CREATE TRIGGER update_event_transfer_policies_updated_at
+    BEFORE UPDATE ON event_transfer_policies
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+DROP TRIGGER IF EXISTS update_ticket_transfers_updated_at ON ticket_transfers;
+CREATE TRIGGER update_ticket_transfers_updated_at
+    BEFORE UPDATE ON ticket_transfers
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Add comments
+COMMENT ON TABLE event_transfer_policies IS 'Per-event rules for ticket transfers; events without a row allow gifts only';
+COMMENT ON TABLE ticket_transfers IS 'Transfers of tickets between users, gifted or resold at face value';
+COMMENT ON TABLE ticket_ownership_history IS 'Audit trail of ticket ownership changes';
@@ -0,0 +1,21 @@
+-- Migration: Create event refund policy rules table
+-- Description: Per-event refund policies, as tiers of how much of the price is refunded
+-- depending on how long before the event a ticket is cancelled
+
+CREATE TABLE IF NOT EXISTS event_refund_policy_rules (
+    event_id UUID NOT NULL,
+    hours_before_event INTEGER NOT NULL, -- Applies to cancellations at least this long before the event starts
+    refund_percent DECIMAL(5,2) NOT NULL,
+    updated_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (event_id, hours_before_event),
+    CONSTRAINT chk_refund_policy_hours CHECK (hours_before_event >= 0),
+    CONSTRAINT chk_refund_policy_percent CHECK (refund_percent >= 0 AND refund_percent <= 100)
+);
+
+-- Partially refunded tickets keep the amount refunded in refunded_amount
+COMMENT ON COLUMN tickets.payment_status IS '''pending'', ''paid'', ''failed'', ''refunded'' or ''partially_refunded''';
+
+-- Add comments
+COMMENT ON TABLE event_refund_policy_rules IS 'Refund policy tiers per event; events without rules refund in full';
@@ -0,0 +1,11 @@
+-- Migration: Add ticket search indexes
+-- Description: Keyset pagination of ticket searches, mostly scoped to an event, in each sort order
+-- with the ticket ID as tie-breaker, and ticket number prefix lookups
+
+CREATE INDEX IF NOT EXISTS idx_tickets_event_created_id ON tickets(event_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_tickets_event_price_id ON tickets(event_id, final_price, id);
+CREATE INDEX IF NOT EXISTS idx_tickets_event_number ON tickets(event_id, ticket_number);
+CREATE INDEX IF NOT EXISTS idx_tickets_user_created_id ON tickets(user_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_tickets_ticket_number_prefix ON tickets(ticket_number text_pattern_ops);
+
+COMMENT ON INDEX idx_tickets_ticket_number_prefix IS 'Ticket number prefix searches (LIKE ''prefix%'')';
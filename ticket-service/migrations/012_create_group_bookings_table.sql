@@ -0,0 +1,67 @@
+-- Migration: Create group booking tables
+-- Description: Group booking sessions, where an organizer holds adjacent seats for a
+-- group and every member pays for their own seat before a shared deadline
+
+CREATE TABLE IF NOT EXISTS group_bookings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    booking_session_id UUID UNIQUE NOT NULL REFERENCES booking_sessions(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    organizer_id UUID NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- 'open', 'settling', 'cancelling', 'completed', 'expired', 'cancelled'
+    currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+    payment_deadline TIMESTAMP WITH TIME ZONE NOT NULL, -- Unpaid seats are released after it
+    locked_by VARCHAR(100), -- Instance currently settling or cancelling the group
+    locked_until TIMESTAMP WITH TIME ZONE, -- Lease; another instance may take over after it
+    settled_at TIMESTAMP WITH TIME ZONE,
+    cancelled_reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_group_bookings_status CHECK (status IN ('open', 'settling', 'cancelling', 'completed', 'expired', 'cancelled'))
+);
+
+CREATE TABLE IF NOT EXISTS group_booking_shares (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    group_booking_id UUID NOT NULL REFERENCES group_bookings(id) ON DELETE CASCADE,
+    seat_reservation_id UUID NOT NULL REFERENCES seat_reservations(id),
+    seat_id UUID NOT NULL,
+    user_id UUID NOT NULL, -- Member paying for the seat; the organizer until someone is invited
+    amount DECIMAL(10,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- 'pending', 'paid', 'released', 'refunded'
+    payment_method VARCHAR(50),
+    payment_id VARCHAR(100),
+    paid_at TIMESTAMP WITH TIME ZONE,
+    released_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT uq_group_booking_shares_seat UNIQUE (group_booking_id, seat_id),
+    CONSTRAINT chk_group_booking_shares_status CHECK (status IN ('pending', 'paid', 'released', 'refunded'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_bookings_organizer ON group_bookings(organizer_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_group_booking_shares_user ON group_booking_shares(user_id, status);
+
+-- Groups that still have work to do: open ones once their deadline passes, and those
+-- being settled or cancelled
+CREATE INDEX IF NOT EXISTS idx_group_bookings_due ON group_bookings(payment_deadline)
+    WHERE status IN ('open', 'settling', 'cancelling');
+
+-- Triggers to update updated_at timestamp
+DROP TRIGGER IF EXISTS update_group_bookings_updated_at ON group_bookings;
+CREATE TRIGGER update_group_bookings_updated_at
+    BEFORE UPDATE ON group_bookings
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+DROP TRIGGER IF EXISTS update_group_booking_shares_updated_at ON group_booking_shares;
+CREATE TRIGGER update_group_booking_shares_updated_at
+    BEFORE UPDATE ON group_booking_shares
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Add comments
+COMMENT ON TABLE group_bookings IS 'Booking sessions held by an organizer for a group whose members pay separately';
+COMMENT ON TABLE group_booking_shares IS 'One seat of a group booking and the member paying for it';
+COMMENT ON COLUMN group_bookings.locked_until IS 'Lease of the instance settling or cancelling the group';
@@ -0,0 +1,28 @@
+-- Migration: Create event hold policies table
+-- Description: Per-event rules for extending seat holds, and the number of times each
+-- booking session extended its hold
+
+CREATE TABLE IF NOT EXISTS event_hold_policies (
+    event_id UUID PRIMARY KEY,
+    max_extensions INTEGER NOT NULL DEFAULT 2, -- Extensions allowed per booking session
+    max_hold_minutes INTEGER NOT NULL DEFAULT 30, -- Holds never last longer than this after the session started
+    extend_while_queued BOOLEAN NOT NULL DEFAULT FALSE, -- Whether holds can be extended while users wait in the event's waiting room
+    updated_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_hold_policy_max_extensions CHECK (max_extensions >= 0),
+    CONSTRAINT chk_hold_policy_max_hold_minutes CHECK (max_hold_minutes > 0)
+);
+
+ALTER TABLE booking_sessions ADD COLUMN IF NOT EXISTS hold_extensions INTEGER NOT NULL DEFAULT 0;
+
+DROP TRIGGER IF EXISTS update_event_hold_policies_updated_at ON event_hold_policies;
+CREATE TRIGGER update_event_hold_policies_updated_at
+    BEFORE UPDATE ON event_hold_policies
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Add comments
+COMMENT ON TABLE event_hold_policies IS 'Per-event rules for extending seat holds; events without a row use the defaults';
+COMMENT ON COLUMN booking_sessions.hold_extensions IS 'Times the session extended its seat holds';
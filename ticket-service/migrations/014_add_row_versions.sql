@@ -0,0 +1,13 @@
+-- Migration: Add row versions
+-- Description: Version counters on tickets, booking sessions and seat reservations for
+-- optimistic concurrency control. Every update bumps the version; compare-and-swap updates
+-- only apply to the version they read.
+
+ALTER TABLE tickets ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE booking_sessions ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE seat_reservations ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- Add comments
+COMMENT ON COLUMN tickets.version IS 'Bumped by every update; compare-and-swap updates check it';
+COMMENT ON COLUMN booking_sessions.version IS 'Bumped by every update; compare-and-swap updates check it';
+COMMENT ON COLUMN seat_reservations.version IS 'Bumped by every update; compare-and-swap updates check it';
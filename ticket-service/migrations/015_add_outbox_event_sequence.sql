@@ -0,0 +1,12 @@
+-- Migration: Add outbox event sequence
+-- Description: Insertion order of outbox events. Events written in one transaction share
+-- their created_at, so the relay orders each aggregate's events by sequence instead.
+
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS sequence BIGSERIAL;
+
+-- Index for per-aggregate ordering of pending events
+CREATE INDEX IF NOT EXISTS idx_outbox_events_aggregate_pending ON outbox_events(aggregate_type, aggregate_id, sequence)
+    WHERE status = 'pending';
+
+-- Add comments
+COMMENT ON COLUMN outbox_events.sequence IS 'Insertion order; an event is published only once every earlier event of its aggregate is';
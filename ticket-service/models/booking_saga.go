@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// BookingSaga is the persistent state of the saga that completes a booking session
+type BookingSaga struct {
+	ID               string     `json:"id" db:"id"`
+	BookingSessionID string     `json:"booking_session_id" db:"booking_session_id"`
+	Status           string     `json:"status" db:"status"`
+	CurrentStep      string     `json:"current_step" db:"current_step"`
+	FailedStep       *string    `json:"failed_step" db:"failed_step"`
+	PaymentMethod    *string    `json:"payment_method" db:"payment_method"`
+	PaymentID        *string    `json:"payment_id" db:"payment_id"`
+	Attempts         int        `json:"attempts" db:"attempts"`
+	LastError        *string    `json:"last_error" db:"last_error"`
+	LockedBy         *string    `json:"locked_by" db:"locked_by"`
+	LockedUntil      *time.Time `json:"locked_until" db:"locked_until"`
+	CompletedBy      *string    `json:"completed_by" db:"completed_by"`
+	CompletedAt      *time.Time `json:"completed_at" db:"completed_at"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Booking Saga Status Constants
+const (
+	BookingSagaStatusRunning      = "running"
+	BookingSagaStatusCompensating = "compensating"
+	BookingSagaStatusCompleted    = "completed"
+	BookingSagaStatusCompensated  = "compensated"
+)
+
+// Booking Saga Step Constants, in execution order
+const (
+	BookingSagaStepHold         = "hold"
+	BookingSagaStepPay          = "pay"
+	BookingSagaStepConfirmSeats = "confirm_seats"
+	BookingSagaStepIssueTickets = "issue_tickets"
+	BookingSagaStepNotify       = "notify"
+	BookingSagaStepDone         = "done"
+)
+
+// IsInFlight reports whether the saga still has steps or compensations to run
+func (s *BookingSaga) IsInFlight() bool {
+	return s.Status == BookingSagaStatusRunning || s.Status == BookingSagaStatusCompensating
+}
+
+// NewBookingSaga creates a saga for a booking session, starting at the hold step
+func NewBookingSaga(bookingSessionID, paymentMethod, completedBy string) *BookingSaga {
+	now := time.Now()
+	saga := &BookingSaga{
+		BookingSessionID: bookingSessionID,
+		Status:           BookingSagaStatusRunning,
+		CurrentStep:      BookingSagaStepHold,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if paymentMethod != "" {
+		saga.PaymentMethod = &paymentMethod
+	}
+	if completedBy != "" {
+		saga.CompletedBy = &completedBy
+	}
+	return saga
+}
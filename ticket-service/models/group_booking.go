@@ -0,0 +1,137 @@
+package models
+
+import "time"
+
+// GroupBooking is a booking session an organizer holds for a group. Every seat is a
+// share paid for by one member of the group before the payment deadline; seats whose
+// share is unpaid by then are released and the paid ones are issued to their members.
+type GroupBooking struct {
+	ID               string               `json:"id" db:"id"`
+	BookingSessionID string               `json:"booking_session_id" db:"booking_session_id"`
+	EventID          string               `json:"event_id" db:"event_id"`
+	OrganizerID      string               `json:"organizer_id" db:"organizer_id"`
+	Status           string               `json:"status" db:"status"`
+	Currency         string               `json:"currency" db:"currency"`
+	PaymentDeadline  time.Time            `json:"payment_deadline" db:"payment_deadline"`
+	LockedBy         *string              `json:"-" db:"locked_by"`
+	LockedUntil      *time.Time           `json:"-" db:"locked_until"`
+	SettledAt        *time.Time           `json:"settled_at" db:"settled_at"`
+	CancelledReason  *string              `json:"cancelled_reason" db:"cancelled_reason"`
+	CreatedAt        time.Time            `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time            `json:"updated_at" db:"updated_at"`
+	Shares           []*GroupBookingShare `json:"shares" db:"-"`
+}
+
+// GroupBookingShare is one seat of a group booking and the member who pays for it
+type GroupBookingShare struct {
+	ID                string     `json:"id" db:"id"`
+	GroupBookingID    string     `json:"group_booking_id" db:"group_booking_id"`
+	SeatReservationID string     `json:"seat_reservation_id" db:"seat_reservation_id"`
+	SeatID            string     `json:"seat_id" db:"seat_id"`
+	UserID            string     `json:"user_id" db:"user_id"`
+	Amount            float64    `json:"amount" db:"amount"`
+	Currency          string     `json:"currency" db:"currency"`
+	Status            string     `json:"status" db:"status"`
+	PaymentMethod     *string    `json:"payment_method" db:"payment_method"`
+	PaymentID         *string    `json:"payment_id" db:"payment_id"`
+	PaidAt            *time.Time `json:"paid_at" db:"paid_at"`
+	ReleasedAt        *time.Time `json:"released_at" db:"released_at"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Group Booking Status Constants
+const (
+	// GroupBookingStatusOpen collects payments until the deadline
+	GroupBookingStatusOpen = "open"
+	// GroupBookingStatusSettling releases the unpaid seats and issues the paid ones
+	GroupBookingStatusSettling = "settling"
+	// GroupBookingStatusCancelling refunds the paid shares and releases every seat
+	GroupBookingStatusCancelling = "cancelling"
+	GroupBookingStatusCompleted  = "completed"
+	// GroupBookingStatusExpired ended without any paid share
+	GroupBookingStatusExpired   = "expired"
+	GroupBookingStatusCancelled = "cancelled"
+)
+
+// Group Booking Share Status Constants
+const (
+	GroupShareStatusPending  = "pending"
+	GroupShareStatusPaid     = "paid"
+	GroupShareStatusReleased = "released"
+	GroupShareStatusRefunded = "refunded"
+)
+
+// IsOpen reports whether the group still takes payments
+func (g *GroupBooking) IsOpen() bool {
+	return g.Status == GroupBookingStatusOpen && time.Now().Before(g.PaymentDeadline)
+}
+
+// IsFinished reports whether the group was completed, expired or cancelled
+func (g *GroupBooking) IsFinished() bool {
+	switch g.Status {
+	case GroupBookingStatusCompleted, GroupBookingStatusExpired, GroupBookingStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// Share returns the share with the given ID, or nil
+func (g *GroupBooking) Share(shareID string) *GroupBookingShare {
+	for _, share := range g.Shares {
+		if share.ID == shareID {
+			return share
+		}
+	}
+	return nil
+}
+
+// SharesWithStatus returns the shares in the given status
+func (g *GroupBooking) SharesWithStatus(status string) []*GroupBookingShare {
+	matched := make([]*GroupBookingShare, 0, len(g.Shares))
+	for _, share := range g.Shares {
+		if share.Status == status {
+			matched = append(matched, share)
+		}
+	}
+	return matched
+}
+
+// PaidAmount is the total paid by the members of the group
+func (g *GroupBooking) PaidAmount() float64 {
+	var total float64
+	for _, share := range g.SharesWithStatus(GroupShareStatusPaid) {
+		total += share.Amount
+	}
+	return total
+}
+
+// NewGroupBooking creates an open group booking for a booking session
+func NewGroupBooking(bookingSessionID, eventID, organizerID, currency string, paymentDeadline time.Time) *GroupBooking {
+	now := time.Now()
+	return &GroupBooking{
+		BookingSessionID: bookingSessionID,
+		EventID:          eventID,
+		OrganizerID:      organizerID,
+		Status:           GroupBookingStatusOpen,
+		Currency:         currency,
+		PaymentDeadline:  paymentDeadline,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+}
+
+// NewGroupBookingShare creates the pending share of a held seat, paid for by userID
+func NewGroupBookingShare(reservation *SeatReservation, userID string) *GroupBookingShare {
+	now := time.Now()
+	return &GroupBookingShare{
+		SeatReservationID: reservation.ID,
+		SeatID:            reservation.SeatID,
+		UserID:            userID,
+		Amount:            reservation.FinalPrice,
+		Currency:          reservation.Currency,
+		Status:            GroupShareStatusPending,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// EventHoldPolicy holds the rules an organizer set for extending seat holds of an event
+type EventHoldPolicy struct {
+	EventID string `json:"event_id" db:"event_id"`
+	// MaxExtensions is how many times a booking session may extend its holds
+	MaxExtensions int `json:"max_extensions" db:"max_extensions"`
+	// MaxHoldMinutes caps how long holds last, counted from when the session started
+	MaxHoldMinutes int `json:"max_hold_minutes" db:"max_hold_minutes"`
+	// ExtendWhileQueued allows extensions while users wait in the event's waiting room
+	ExtendWhileQueued bool      `json:"extend_while_queued" db:"extend_while_queued"`
+	UpdatedBy         *string   `json:"updated_by" db:"updated_by"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// DefaultEventHoldPolicy is the policy of events an organizer has not configured: two
+// extensions, holds of at most 30 minutes, and none while users are queueing
+func DefaultEventHoldPolicy(eventID string) *EventHoldPolicy {
+	return &EventHoldPolicy{
+		EventID:        eventID,
+		MaxExtensions:  2,
+		MaxHoldMinutes: 30,
+	}
+}
+
+// Validate checks the rules of the policy
+func (p *EventHoldPolicy) Validate() error {
+	if p.EventID == "" {
+		return fmt.Errorf("event_id is required")
+	}
+	if p.MaxExtensions < 0 {
+		return fmt.Errorf("max_extensions cannot be negative")
+	}
+	if p.MaxHoldMinutes <= 0 {
+		return fmt.Errorf("max_hold_minutes must be positive")
+	}
+	return nil
+}
+
+// HoldDeadline returns the latest a hold of a session started at startedAt may last until
+func (p *EventHoldPolicy) HoldDeadline(startedAt time.Time) time.Time {
+	return startedAt.Add(time.Duration(p.MaxHoldMinutes) * time.Minute)
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// OutboxEvent is a domain event written to the outbox in the same transaction as the
+// change it describes, and published by the outbox relay
+type OutboxEvent struct {
+	ID            string     `json:"id" db:"id"`
+	Sequence      int64      `json:"sequence" db:"sequence"`
+	AggregateType string     `json:"aggregate_type" db:"aggregate_type"`
+	AggregateID   string     `json:"aggregate_id" db:"aggregate_id"`
+	EventType     string     `json:"event_type" db:"event_type"`
+	Payload       string     `json:"payload" db:"payload"`
+	Status        string     `json:"status" db:"status"`
+	RetryCount    int        `json:"retry_count" db:"retry_count"`
+	LastError     *string    `json:"last_error" db:"last_error"`
+	AvailableAt   time.Time  `json:"available_at" db:"available_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	PublishedAt   *time.Time `json:"published_at" db:"published_at"`
+}
+
+// Outbox Event Status Constants
+const (
+	OutboxStatusPending   = "pending"
+	OutboxStatusPublished = "published"
+)
+
+// Outbox Aggregate Type Constants
+const (
+	AggregateTypeBooking = "booking"
+	AggregateTypeTicket  = "ticket"
+)
+
+// Outbox Event Type Constants
+const (
+	EventTypeBookingConfirmed  = "booking.confirmed"
+	EventTypeBookingCancelled  = "booking.cancelled"
+	EventTypeBookingExpired    = "booking.expired"
+	EventTypeBookingFailed     = "booking.failed"
+	EventTypeTicketCancelled   = "ticket.cancelled"
+	EventTypeTicketRefunded    = "ticket.refunded"
+	EventTypeTicketTransferred = "ticket.transferred"
+)
+
+// Booking Event Status Constants, as expected by BookingEvent consumers
+const (
+	BookingEventStatusConfirmed = "CONFIRMED"
+	BookingEventStatusCancelled = "CANCELLED"
+	BookingEventStatusExpired   = "EXPIRED"
+	BookingEventStatusFailed    = "FAILED"
+)
+
+// BookingEventPayload is the payload of booking events. It matches the BookingEvent
+// message consumed by email-worker.
+type BookingEventPayload struct {
+	BookingID          string   `json:"bookingId"`
+	BookingReference   string   `json:"bookingReference"`
+	UserID             string   `json:"userId"`
+	EventID            string   `json:"eventId"`
+	Status             string   `json:"status"`
+	PaymentStatus      string   `json:"paymentStatus"`
+	TotalAmount        float64  `json:"totalAmount"`
+	Currency           string   `json:"currency"`
+	SeatCount          int      `json:"seatCount"`
+	SeatNumbers        []string `json:"seatNumbers"`
+	TicketIDs          []string `json:"ticketIds,omitempty"`
+	PaymentReference   string   `json:"paymentReference,omitempty"`
+	ConfirmedAt        string   `json:"confirmedAt,omitempty"`
+	CancelledAt        string   `json:"cancelledAt,omitempty"`
+	CancellationReason string   `json:"cancellationReason,omitempty"`
+	FailureReason      string   `json:"failureReason,omitempty"`
+	Timestamp          string   `json:"timestamp"`
+}
+
+// TicketEventPayload is the payload of ticket events
+type TicketEventPayload struct {
+	TicketID         string  `json:"ticketId"`
+	TicketNumber     string  `json:"ticketNumber"`
+	BookingID        string  `json:"bookingId,omitempty"`
+	UserID           string  `json:"userId"`
+	PreviousUserID   string  `json:"previousUserId,omitempty"`
+	EventID          string  `json:"eventId"`
+	SeatID           string  `json:"seatId"`
+	Status           string  `json:"status"`
+	PaymentStatus    string  `json:"paymentStatus"`
+	FinalPrice       float64 `json:"finalPrice"`
+	RefundedAmount   float64 `json:"refundedAmount,omitempty"`
+	Currency         string  `json:"currency"`
+	PaymentReference string  `json:"paymentReference,omitempty"`
+	Reason           string  `json:"reason,omitempty"`
+	Timestamp        string  `json:"timestamp"`
+}
+
+// NewOutboxEvent creates a pending outbox event with payload encoded as JSON
+func NewOutboxEvent(aggregateType, aggregateID, eventType string, payload interface{}) (*OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %w", eventType, err)
+	}
+
+	now := time.Now()
+	return &OutboxEvent{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       string(data),
+		Status:        OutboxStatusPending,
+		AvailableAt:   now,
+		CreatedAt:     now,
+	}, nil
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// RefundPolicyRule is a tier of an event's refund policy: tickets cancelled at least
+// HoursBeforeEvent before the event starts get RefundPercent of their price back
+type RefundPolicyRule struct {
+	EventID          string    `json:"event_id" db:"event_id"`
+	HoursBeforeEvent int       `json:"hours_before_event" db:"hours_before_event"`
+	RefundPercent    float64   `json:"refund_percent" db:"refund_percent"`
+	UpdatedBy        *string   `json:"updated_by" db:"updated_by"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// EventRefundPolicy is the refund policy of an event, its rules ordered from the earliest
+// cancellation to the latest. Cancellations later than every rule are not refunded.
+type EventRefundPolicy struct {
+	EventID string              `json:"event_id"`
+	Rules   []*RefundPolicyRule `json:"rules"`
+	// IsDefault marks the policy of events an organizer has not configured
+	IsDefault bool `json:"is_default"`
+}
+
+// DefaultEventRefundPolicy is the policy of events without rules: a full refund, whenever
+// the ticket is cancelled
+func DefaultEventRefundPolicy(eventID string) *EventRefundPolicy {
+	return &EventRefundPolicy{
+		EventID:   eventID,
+		Rules:     []*RefundPolicyRule{{EventID: eventID, HoursBeforeEvent: 0, RefundPercent: 100}},
+		IsDefault: true,
+	}
+}
+
+// NewEventRefundPolicy creates the refund policy of an event from its rules
+func NewEventRefundPolicy(eventID string, rules []*RefundPolicyRule) *EventRefundPolicy {
+	sorted := make([]*RefundPolicyRule, len(rules))
+	copy(sorted, rules)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].HoursBeforeEvent > sorted[j].HoursBeforeEvent
+	})
+	return &EventRefundPolicy{EventID: eventID, Rules: sorted}
+}
+
+// Validate checks the rules of the policy
+func (p *EventRefundPolicy) Validate() error {
+	if p.EventID == "" {
+		return fmt.Errorf("event_id is required")
+	}
+	seen := make(map[int]bool, len(p.Rules))
+	for _, rule := range p.Rules {
+		if rule.HoursBeforeEvent < 0 {
+			return fmt.Errorf("hours_before_event cannot be negative")
+		}
+		if rule.RefundPercent < 0 || rule.RefundPercent > 100 {
+			return fmt.Errorf("refund_percent must be between 0 and 100")
+		}
+		if seen[rule.HoursBeforeEvent] {
+			return fmt.Errorf("duplicate rule for %d hours before the event", rule.HoursBeforeEvent)
+		}
+		seen[rule.HoursBeforeEvent] = true
+	}
+	return nil
+}
+
+// RefundPercent returns the percentage of the price refunded for a cancellation
+// timeUntilEvent before the event starts. Nothing is refunded once the event started.
+func (p *EventRefundPolicy) RefundPercent(timeUntilEvent time.Duration) float64 {
+	if timeUntilEvent < 0 {
+		return 0
+	}
+	for _, rule := range p.Rules {
+		if timeUntilEvent >= time.Duration(rule.HoursBeforeEvent)*time.Hour {
+			return rule.RefundPercent
+		}
+	}
+	return 0
+}
@@ -35,6 +35,7 @@ type Ticket struct {
 	RefundedAt       *time.Time `json:"refunded_at" db:"refunded_at"`
 	RefundedAmount   *float64   `json:"refunded_amount" db:"refunded_amount"`
 	Metadata         *string    `json:"metadata" db:"metadata"`
+	Version          int        `json:"version" db:"version"`
 	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
 	CreatedBy        *string    `json:"created_by" db:"created_by"`
@@ -52,12 +53,14 @@ type BookingSession struct {
 	TotalAmount     float64    `json:"total_amount" db:"total_amount"`
 	Currency        string     `json:"currency" db:"currency"`
 	ExpiresAt       time.Time  `json:"expires_at" db:"expires_at"`
+	HoldExtensions  int        `json:"hold_extensions" db:"hold_extensions"`
 	CompletedAt     *time.Time `json:"completed_at" db:"completed_at"`
 	CancelledAt     *time.Time `json:"cancelled_at" db:"cancelled_at"`
 	CancelledReason *string    `json:"cancelled_reason" db:"cancelled_reason"`
 	IPAddress       *string    `json:"ip_address" db:"ip_address"`
 	UserAgent       *string    `json:"user_agent" db:"user_agent"`
 	Metadata        *string    `json:"metadata" db:"metadata"`
+	Version         int        `json:"version" db:"version"`
 	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
 	CreatedBy       *string    `json:"created_by" db:"created_by"`
@@ -82,6 +85,7 @@ type SeatReservation struct {
 	FinalPrice       float64    `json:"final_price" db:"final_price"`
 	Currency         string     `json:"currency" db:"currency"`
 	Metadata         *string    `json:"metadata" db:"metadata"`
+	Version          int        `json:"version" db:"version"`
 	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
 	CreatedBy        *string    `json:"created_by" db:"created_by"`
@@ -103,6 +107,8 @@ const (
 	PaymentStatusPaid     = "paid"
 	PaymentStatusFailed   = "failed"
 	PaymentStatusRefunded = "refunded"
+	// PaymentStatusPartiallyRefunded is a ticket refunded less than its price, see RefundedAmount
+	PaymentStatusPartiallyRefunded = "partially_refunded"
 )
 
 // Booking Session Status Constants
@@ -288,7 +294,7 @@ func isValidTicketStatus(status string) bool {
 }
 
 func isValidPaymentStatus(status string) bool {
-	validStatuses := []string{PaymentStatusPending, PaymentStatusPaid, PaymentStatusFailed, PaymentStatusRefunded}
+	validStatuses := []string{PaymentStatusPending, PaymentStatusPaid, PaymentStatusFailed, PaymentStatusRefunded, PaymentStatusPartiallyRefunded}
 	for _, validStatus := range validStatuses {
 		if status == validStatus {
 			return true
@@ -447,6 +453,7 @@ func NewTicket(eventID, seatID, zoneID, userID, ticketNumber, pricingCategory st
 		PaymentStatus:   PaymentStatusPending,
 		ValidFrom:       now,
 		ValidUntil:      nil, // Will be set based on event
+		Version:         1,
 		CreatedAt:       now,
 		UpdatedAt:       now,
 	}
@@ -465,6 +472,7 @@ func NewBookingSession(userID, eventID, sessionToken string, seatCount int, tota
 		TotalAmount:  totalAmount,
 		Currency:     currency,
 		ExpiresAt:    expiresAt,
+		Version:      1,
 		CreatedAt:    now,
 		UpdatedAt:    now,
 	}
@@ -487,6 +495,7 @@ func NewSeatReservation(bookingSessionID, eventID, seatID, zoneID, reservationTo
 		BasePrice:        basePrice,
 		FinalPrice:       finalPrice,
 		Currency:         currency,
+		Version:          1,
 		CreatedAt:        now,
 		UpdatedAt:        now,
 	}
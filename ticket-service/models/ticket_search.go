@@ -0,0 +1,97 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Ticket search sort fields
+const (
+	TicketSortCreatedAt    = "created_at"
+	TicketSortFinalPrice   = "final_price"
+	TicketSortTicketNumber = "ticket_number"
+)
+
+// Ticket search page sizes
+const (
+	DefaultTicketSearchLimit = 50
+	MaxTicketSearchLimit     = 1000
+)
+
+// TimeRange bounds a timestamp, From inclusive and To exclusive. Either bound may be nil.
+type TimeRange struct {
+	From *time.Time `json:"from,omitempty"`
+	To   *time.Time `json:"to,omitempty"`
+}
+
+// IsSet reports whether the range has a bound
+func (r TimeRange) IsSet() bool {
+	return r.From != nil || r.To != nil
+}
+
+// TicketSearchQuery is a typed ticket search. Filters are combined with AND; empty
+// filters match every ticket. Results are paged with an opaque keyset cursor.
+type TicketSearchQuery struct {
+	EventID            string    `json:"event_id,omitempty"`
+	UserID             string    `json:"user_id,omitempty"`
+	Statuses           []string  `json:"statuses,omitempty"`
+	PaymentStatuses    []string  `json:"payment_statuses,omitempty"`
+	TicketTypes        []string  `json:"ticket_types,omitempty"`
+	ZoneIDs            []string  `json:"zone_ids,omitempty"`
+	PricingCategories  []string  `json:"pricing_categories,omitempty"`
+	TicketNumberPrefix string    `json:"ticket_number_prefix,omitempty"`
+	MinPrice           *float64  `json:"min_price,omitempty"`
+	MaxPrice           *float64  `json:"max_price,omitempty"`
+	Created            TimeRange `json:"created,omitempty"`
+	Used               TimeRange `json:"used,omitempty"`
+	Cancelled          TimeRange `json:"cancelled,omitempty"`
+	SortBy             string    `json:"sort_by,omitempty"` // Defaults to created_at
+	SortDesc           bool      `json:"sort_desc,omitempty"`
+	Limit              int       `json:"limit,omitempty"`
+	Cursor             string    `json:"cursor,omitempty"` // NextCursor of the previous page
+}
+
+// TicketSearchPage is a page of ticket search results
+type TicketSearchPage struct {
+	Tickets    []*Ticket `json:"tickets"`
+	NextCursor string    `json:"next_cursor,omitempty"` // Empty on the last page
+}
+
+// HasMore reports whether there is a page after this one
+func (p *TicketSearchPage) HasMore() bool {
+	return p.NextCursor != ""
+}
+
+// Normalize fills in the defaults of the query
+func (q *TicketSearchQuery) Normalize() {
+	if q.SortBy == "" {
+		q.SortBy = TicketSortCreatedAt
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultTicketSearchLimit
+	}
+}
+
+// Validate checks the query
+func (q *TicketSearchQuery) Validate() error {
+	switch q.SortBy {
+	case "", TicketSortCreatedAt, TicketSortFinalPrice, TicketSortTicketNumber:
+	default:
+		return fmt.Errorf("invalid sort_by: %s", q.SortBy)
+	}
+	if q.Limit < 0 || q.Limit > MaxTicketSearchLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxTicketSearchLimit)
+	}
+	if q.MinPrice != nil && q.MaxPrice != nil && *q.MinPrice > *q.MaxPrice {
+		return fmt.Errorf("min_price cannot exceed max_price")
+	}
+	for _, r := range []struct {
+		name string
+		TimeRange
+	}{{"created", q.Created}, {"used", q.Used}, {"cancelled", q.Cancelled}} {
+		if r.From != nil && r.To != nil && !r.From.Before(*r.To) {
+			return fmt.Errorf("%s range must end after it starts", r.name)
+		}
+	}
+	return nil
+}
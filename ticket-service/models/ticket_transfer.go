@@ -0,0 +1,101 @@
+package models
+
+import "time"
+
+// TicketTransfer moves a ticket from its owner to another user once the recipient accepts
+type TicketTransfer struct {
+	ID               string     `json:"id" db:"id"`
+	TicketID         string     `json:"ticket_id" db:"ticket_id"`
+	EventID          string     `json:"event_id" db:"event_id"`
+	FromUserID       string     `json:"from_user_id" db:"from_user_id"`
+	ToUserID         string     `json:"to_user_id" db:"to_user_id"`
+	TransferType     string     `json:"transfer_type" db:"transfer_type"`
+	Status           string     `json:"status" db:"status"`
+	Price            float64    `json:"price" db:"price"`
+	Currency         string     `json:"currency" db:"currency"`
+	PaymentReference *string    `json:"payment_reference" db:"payment_reference"`
+	RefundReference  *string    `json:"refund_reference" db:"refund_reference"`
+	ExpiresAt        time.Time  `json:"expires_at" db:"expires_at"`
+	AcceptedAt       *time.Time `json:"accepted_at" db:"accepted_at"`
+	CancelledAt      *time.Time `json:"cancelled_at" db:"cancelled_at"`
+	CancelledReason  *string    `json:"cancelled_reason" db:"cancelled_reason"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// TicketOwnershipChange is an entry of a ticket's ownership audit trail
+type TicketOwnershipChange struct {
+	ID               string    `json:"id" db:"id"`
+	TicketID         string    `json:"ticket_id" db:"ticket_id"`
+	TransferID       *string   `json:"transfer_id" db:"transfer_id"`
+	FromUserID       string    `json:"from_user_id" db:"from_user_id"`
+	ToUserID         string    `json:"to_user_id" db:"to_user_id"`
+	ChangeType       string    `json:"change_type" db:"change_type"`
+	Price            float64   `json:"price" db:"price"`
+	Currency         string    `json:"currency" db:"currency"`
+	PaymentReference *string   `json:"payment_reference" db:"payment_reference"`
+	RefundReference  *string   `json:"refund_reference" db:"refund_reference"`
+	PreviousQRCode   *string   `json:"previous_qr_code" db:"previous_qr_code"`
+	ChangedBy        *string   `json:"changed_by" db:"changed_by"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// EventTransferPolicy holds the transfer rules an organizer set for an event
+type EventTransferPolicy struct {
+	EventID          string    `json:"event_id" db:"event_id"`
+	TransfersEnabled bool      `json:"transfers_enabled" db:"transfers_enabled"`
+	ResaleEnabled    bool      `json:"resale_enabled" db:"resale_enabled"`
+	UpdatedBy        *string   `json:"updated_by" db:"updated_by"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Ticket Transfer Type Constants
+const (
+	TransferTypeGift   = "gift"
+	TransferTypeResale = "resale"
+)
+
+// Ticket Transfer Status Constants
+const (
+	TransferStatusPending   = "pending"
+	TransferStatusAccepted  = "accepted"
+	TransferStatusCancelled = "cancelled"
+	TransferStatusExpired   = "expired"
+)
+
+// DefaultEventTransferPolicy is the policy of events an organizer has not configured:
+// tickets may be given away but not resold
+func DefaultEventTransferPolicy(eventID string) *EventTransferPolicy {
+	return &EventTransferPolicy{
+		EventID:          eventID,
+		TransfersEnabled: true,
+	}
+}
+
+// IsExpired reports whether a pending transfer can no longer be accepted
+func (t *TicketTransfer) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+// NewTicketTransfer creates a pending transfer of a ticket to toUserID. Resales are
+// priced at the ticket's face value.
+func NewTicketTransfer(ticket *Ticket, toUserID, transferType string, expiresAt time.Time) *TicketTransfer {
+	now := time.Now()
+	transfer := &TicketTransfer{
+		TicketID:     ticket.ID,
+		EventID:      ticket.EventID,
+		FromUserID:   ticket.UserID,
+		ToUserID:     toUserID,
+		TransferType: transferType,
+		Status:       TransferStatusPending,
+		Currency:     ticket.Currency,
+		ExpiresAt:    expiresAt,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if transferType == TransferTypeResale {
+		transfer.Price = ticket.FinalPrice
+	}
+	return transfer
+}
@@ -0,0 +1,60 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"ticket-service/models"
+)
+
+// Publisher publishes outbox events to a message broker
+type Publisher interface {
+	Publish(ctx context.Context, event *models.OutboxEvent) error
+}
+
+// RedisStreamPublisher publishes outbox events to Redis streams, one stream per aggregate type
+type RedisStreamPublisher struct {
+	redis   *redis.Client
+	streams map[string]string
+	maxLen  int64
+}
+
+// NewRedisStreamPublisher creates a publisher that appends events to the stream of their
+// aggregate type, keeping roughly the maxLen most recent entries of each stream
+func NewRedisStreamPublisher(redis *redis.Client, streams map[string]string, maxLen int) *RedisStreamPublisher {
+	return &RedisStreamPublisher{
+		redis:   redis,
+		streams: streams,
+		maxLen:  int64(maxLen),
+	}
+}
+
+// Publish appends event to its stream. Consumers deduplicate on event_id, since an event
+// is published again when the relay fails to record that it was published.
+func (p *RedisStreamPublisher) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	stream, ok := p.streams[event.AggregateType]
+	if !ok {
+		return fmt.Errorf("no stream for aggregate type %s", event.AggregateType)
+	}
+
+	err := p.redis.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
+		MaxLen: p.maxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"event_id":     event.ID,
+			"event_type":   event.EventType,
+			"aggregate_id": event.AggregateID,
+			"payload":      event.Payload,
+			"created_at":   event.CreatedAt.UTC().Format(time.RFC3339Nano),
+		},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to publish to stream %s: %w", stream, err)
+	}
+
+	return nil
+}
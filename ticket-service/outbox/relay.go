@@ -0,0 +1,121 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"ticket-service/config"
+	"ticket-service/metrics"
+	"ticket-service/models"
+	"ticket-service/repositories"
+)
+
+// Relay publishes the events written to the outbox. Delivery is at least once: an event
+// stays pending until it is published, and may be published more than once. Any number of
+// relays may run; each aggregate's events are still published in order.
+//
+// Events go to Redis streams. Kafka consumers such as email-worker's BookingEvent consumer
+// do not see them until a Kafka Publisher is added.
+type Relay struct {
+	outboxRepo *repositories.OutboxRepository
+	publisher  Publisher
+	config     config.OutboxConfig
+	logger     *zap.Logger
+	ctx        context.Context
+	cancel     context.CancelFunc
+}
+
+// NewRelay creates a new outbox relay
+func NewRelay(outboxRepo *repositories.OutboxRepository, publisher Publisher, cfg config.OutboxConfig, logger *zap.Logger) *Relay {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Relay{
+		outboxRepo: outboxRepo,
+		publisher:  publisher,
+		config:     cfg,
+		logger:     logger,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Start publishes pending events every PollInterval and deletes old published events
+// every CleanupInterval until Stop is called
+func (r *Relay) Start() {
+	r.logger.Info("Starting outbox relay",
+		zap.Duration("poll_interval", r.config.PollInterval),
+		zap.Int("batch_size", r.config.BatchSize),
+	)
+
+	pollTicker := time.NewTicker(r.config.PollInterval)
+	defer pollTicker.Stop()
+
+	cleanupTicker := time.NewTicker(r.config.CleanupInterval)
+	defer cleanupTicker.Stop()
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			r.logger.Info("Outbox relay stopping")
+			return
+		case <-pollTicker.C:
+			r.publishPending()
+		case <-cleanupTicker.C:
+			r.cleanup()
+		}
+	}
+}
+
+// Stop stops the relay
+func (r *Relay) Stop() {
+	r.cancel()
+}
+
+// publishPending publishes batches of pending events until there are none left that are due
+func (r *Relay) publishPending() {
+	for r.ctx.Err() == nil {
+		published, failed, err := r.outboxRepo.PublishPending(r.ctx, r.config.BatchSize,
+			r.config.RetryDelay, r.config.MaxRetryDelay, r.publish)
+		if err != nil {
+			r.logger.Error("Failed to publish outbox events", zap.Error(err))
+			return
+		}
+
+		if published > 0 || failed > 0 {
+			r.logger.Debug("Outbox events processed",
+				zap.Int("published", published),
+				zap.Int("failed", failed),
+			)
+		}
+
+		// A batch holds one event per aggregate, so later events of the aggregates just
+		// published may be due now; failed events wait for their retry
+		if published == 0 {
+			return
+		}
+	}
+}
+
+func (r *Relay) publish(ctx context.Context, event *models.OutboxEvent) error {
+	if err := r.publisher.Publish(ctx, event); err != nil {
+		metrics.IncrementOutboxPublishFailure(event.EventType)
+		return err
+	}
+
+	metrics.IncrementOutboxEventPublished(event.EventType)
+	return nil
+}
+
+// cleanup deletes events published longer than Retention ago
+func (r *Relay) cleanup() {
+	deleted, err := r.outboxRepo.DeletePublishedBefore(r.ctx, time.Now().Add(-r.config.Retention))
+	if err != nil {
+		r.logger.Error("Failed to clean up outbox events", zap.Error(err))
+		return
+	}
+
+	if deleted > 0 {
+		r.logger.Info("Deleted published outbox events", zap.Int64("deleted", deleted))
+	}
+}
@@ -0,0 +1,76 @@
+package queue
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidAdmissionToken is returned when an admission token is malformed, has a bad
+	// signature or was issued for another user or event
+	ErrInvalidAdmissionToken = errors.New("invalid admission token")
+	// ErrAdmissionExpired is returned when an admission token expired before it was used
+	ErrAdmissionExpired = errors.New("admission has expired")
+	// ErrNotAdmitted is returned when a user books an event with an open waiting room
+	// without an admission, or with one that was used already
+	ErrNotAdmitted = errors.New("user has not been admitted from the waiting room")
+)
+
+// AdmissionClaims are the claims of an admission token
+type AdmissionClaims struct {
+	EventID   string `json:"eid"`
+	UserID    string `json:"uid"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// admissionSigner signs and verifies admission tokens, <b64url claims>.<b64url HMAC-SHA256>
+type admissionSigner struct {
+	secret []byte
+}
+
+func (s *admissionSigner) sign(claims *AdmissionClaims) string {
+	payload, _ := json.Marshal(claims)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.mac(encoded))
+}
+
+// verify checks the signature and expiry of a token issued to userID for eventID
+func (s *admissionSigner) verify(token, eventID, userID string, now time.Time) (*AdmissionClaims, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidAdmissionToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, s.mac(encoded)) {
+		return nil, ErrInvalidAdmissionToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidAdmissionToken
+	}
+	var claims AdmissionClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidAdmissionToken
+	}
+	if claims.EventID != eventID || claims.UserID != userID {
+		return nil, ErrInvalidAdmissionToken
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return nil, ErrAdmissionExpired
+	}
+
+	return &claims, nil
+}
+
+func (s *admissionSigner) mac(encoded string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
@@ -2,17 +2,33 @@ package queue
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
+
+	"ticket-service/config"
+)
+
+var (
+	// ErrWaitingRoomClosed is returned when joining the queue of an event without an open waiting room
+	ErrWaitingRoomClosed = errors.New("event has no open waiting room")
+	// ErrNotInQueue is returned when the user is neither queued nor admitted
+	ErrNotInQueue = errors.New("user not in queue")
 )
 
-// QueueManager manages user queues for high-demand events
+// QueueManager manages user queues for high-demand events. Events with an open waiting
+// room admit their queued users at the room's admission rate, and only admitted users
+// may start a booking session.
 type QueueManager struct {
 	redis  *redis.Client
+	signer *admissionSigner
+	config config.WaitingRoomConfig
 	logger *zap.Logger
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // QueueConfig holds queue configuration
@@ -23,14 +39,19 @@ type QueueConfig struct {
 	PositionUpdateInterval time.Duration
 }
 
-// QueuePosition represents user position in queue
+// QueuePosition represents user position in queue. Admitted users have no position but
+// an admission token to start their booking session with before AdmissionExpiresAt.
 type QueuePosition struct {
-	UserID    string    `json:"user_id"`
-	EventID   string    `json:"event_id"`
-	Position  int       `json:"position"`
-	JoinedAt  time.Time `json:"joined_at"`
-	ExpiresAt time.Time `json:"expires_at"`
-	SessionID string    `json:"session_id"`
+	UserID             string    `json:"user_id"`
+	EventID            string    `json:"event_id"`
+	Position           int       `json:"position"`
+	EstimatedWaitTime  int       `json:"estimated_wait_time"` // seconds
+	JoinedAt           time.Time `json:"joined_at"`
+	ExpiresAt          time.Time `json:"expires_at"`
+	SessionID          string    `json:"session_id"`
+	Admitted           bool      `json:"admitted"`
+	AdmissionToken     string    `json:"admission_token,omitempty"`
+	AdmissionExpiresAt time.Time `json:"admission_expires_at,omitempty"`
 }
 
 // QueueStatus represents queue status
@@ -40,51 +61,59 @@ type QueueStatus struct {
 	ActiveUsers       int    `json:"active_users"`
 	EstimatedWaitTime int    `json:"estimated_wait_time"` // seconds
 	IsOpen            bool   `json:"is_open"`
+	AdmissionRate     int    `json:"admission_rate"` // users per minute
 }
 
 // NewQueueManager creates a new queue manager
-func NewQueueManager(redis *redis.Client, logger *zap.Logger) *QueueManager {
+func NewQueueManager(redis *redis.Client, cfg config.WaitingRoomConfig, logger *zap.Logger) (*QueueManager, error) {
+	if cfg.TokenSecret == "" {
+		return nil, fmt.Errorf("waiting room token secret is required")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
 	return &QueueManager{
 		redis:  redis,
+		signer: &admissionSigner{secret: []byte(cfg.TokenSecret)},
+		config: cfg,
 		logger: logger,
-	}
+		ctx:    ctx,
+		cancel: cancel,
+	}, nil
 }
 
-// JoinQueue adds user to event queue
+// JoinQueue adds user to the queue of an event's waiting room. Users already queued keep
+// their place; users already admitted get their admission.
 func (qm *QueueManager) JoinQueue(ctx context.Context, userID, eventID, sessionID string) (*QueuePosition, error) {
-	queueKey := fmt.Sprintf("queue:%s", eventID)
-	userKey := fmt.Sprintf("queue_user:%s:%s", eventID, userID)
+	queueKey := queueKey(eventID)
+	userKey := queueUserKey(eventID, userID)
 
-	// Check if user already in queue
-	exists, err := qm.redis.Exists(ctx, userKey).Result()
+	room, err := qm.GetWaitingRoom(ctx, eventID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check user queue status: %w", err)
+		return nil, err
+	}
+	if room == nil {
+		return nil, ErrWaitingRoomClosed
 	}
 
-	if exists > 0 {
-		// User already in queue, return current position
-		return qm.GetUserPosition(ctx, userID, eventID)
+	// Check if user already in queue or admitted
+	position, err := qm.GetUserPosition(ctx, userID, eventID)
+	if err == nil {
+		return position, nil
+	}
+	if !errors.Is(err, ErrNotInQueue) {
+		return nil, err
 	}
 
 	// Add user to queue
-	position := &QueuePosition{
+	now := time.Now()
+	position = &QueuePosition{
 		UserID:    userID,
 		EventID:   eventID,
-		JoinedAt:  time.Now(),
-		ExpiresAt: time.Now().Add(30 * time.Minute), // 30 min queue timeout
+		JoinedAt:  now,
+		ExpiresAt: now.Add(qm.config.QueueTimeout),
 		SessionID: sessionID,
 	}
 
-	// Add to Redis sorted set (position based on join time)
-	score := float64(time.Now().UnixNano())
-	err = qm.redis.ZAdd(ctx, queueKey, redis.Z{
-		Score:  score,
-		Member: userID,
-	}).Err()
-	if err != nil {
-		return nil, fmt.Errorf("failed to add user to queue: %w", err)
-	}
-
 	// Store user details
 	err = qm.redis.HSet(ctx, userKey, map[string]interface{}{
 		"user_id":    userID,
@@ -98,7 +127,18 @@ func (qm *QueueManager) JoinQueue(ctx context.Context, userID, eventID, sessionI
 	}
 
 	// Set expiration
-	qm.redis.Expire(ctx, userKey, 30*time.Minute)
+	qm.redis.Expire(ctx, userKey, qm.config.QueueTimeout)
+
+	// Add to Redis sorted set (position based on join time), keeping the place of a
+	// concurrent join of the same user
+	score := float64(now.UnixNano())
+	err = qm.redis.ZAddNX(ctx, queueKey, redis.Z{
+		Score:  score,
+		Member: userID,
+	}).Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to add user to queue: %w", err)
+	}
 
 	// Get position
 	rank, err := qm.redis.ZRank(ctx, queueKey, userID).Result()
@@ -107,6 +147,7 @@ func (qm *QueueManager) JoinQueue(ctx context.Context, userID, eventID, sessionI
 	}
 
 	position.Position = int(rank) + 1
+	position.EstimatedWaitTime = estimateWaitTime(position.Position, room.AdmissionRate)
 
 	qm.logger.Info("User joined queue",
 		zap.String("user_id", userID),
@@ -117,16 +158,16 @@ func (qm *QueueManager) JoinQueue(ctx context.Context, userID, eventID, sessionI
 	return position, nil
 }
 
-// GetUserPosition gets user position in queue
+// GetUserPosition gets user position in queue, or the admission of an admitted user
 func (qm *QueueManager) GetUserPosition(ctx context.Context, userID, eventID string) (*QueuePosition, error) {
-	queueKey := fmt.Sprintf("queue:%s", eventID)
-	userKey := fmt.Sprintf("queue_user:%s:%s", eventID, userID)
+	queueKey := queueKey(eventID)
+	userKey := queueUserKey(eventID, userID)
 
 	// Get position
 	rank, err := qm.redis.ZRank(ctx, queueKey, userID).Result()
 	if err != nil {
 		if err == redis.Nil {
-			return nil, fmt.Errorf("user not in queue")
+			return qm.getAdmission(ctx, userID, eventID)
 		}
 		return nil, fmt.Errorf("failed to get user position: %w", err)
 	}
@@ -140,19 +181,25 @@ func (qm *QueueManager) GetUserPosition(ctx context.Context, userID, eventID str
 	joinedAt, _ := time.Parse(time.RFC3339, userData["joined_at"])
 	expiresAt, _ := time.Parse(time.RFC3339, userData["expires_at"])
 
-	return &QueuePosition{
+	position := &QueuePosition{
 		UserID:    userID,
 		EventID:   eventID,
 		Position:  int(rank) + 1,
 		JoinedAt:  joinedAt,
 		ExpiresAt: expiresAt,
 		SessionID: userData["session_id"],
-	}, nil
+	}
+
+	if room, err := qm.GetWaitingRoom(ctx, eventID); err == nil && room != nil {
+		position.EstimatedWaitTime = estimateWaitTime(position.Position, room.AdmissionRate)
+	}
+
+	return position, nil
 }
 
 // GetQueueStatus gets queue status
 func (qm *QueueManager) GetQueueStatus(ctx context.Context, eventID string) (*QueueStatus, error) {
-	queueKey := fmt.Sprintf("queue:%s", eventID)
+	queueKey := queueKey(eventID)
 
 	// Get total users in queue
 	totalUsers, err := qm.redis.ZCard(ctx, queueKey).Result()
@@ -166,56 +213,39 @@ func (qm *QueueManager) GetQueueStatus(ctx context.Context, eventID string) (*Qu
 		activeUsers = 0 // If no active users set exists
 	}
 
-	// Calculate estimated wait time (rough estimate)
-	estimatedWaitTime := int(totalUsers * 2) // 2 seconds per user
-
-	return &QueueStatus{
-		EventID:           eventID,
-		TotalUsers:        int(totalUsers),
-		ActiveUsers:       int(activeUsers),
-		EstimatedWaitTime: estimatedWaitTime,
-		IsOpen:            true, // Queue is always open
-	}, nil
-}
-
-// ProcessNextUsers processes next batch of users from queue
-func (qm *QueueManager) ProcessNextUsers(ctx context.Context, eventID string, batchSize int) ([]string, error) {
-	queueKey := fmt.Sprintf("queue:%s", eventID)
-	activeUsersKey := fmt.Sprintf("active_users:%s", eventID)
-
-	// Get next batch of users
-	users, err := qm.redis.ZRange(ctx, queueKey, 0, int64(batchSize-1)).Result()
+	room, err := qm.GetWaitingRoom(ctx, eventID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get next users: %w", err)
+		return nil, err
 	}
 
-	if len(users) == 0 {
-		return []string{}, nil
+	status := &QueueStatus{
+		EventID:     eventID,
+		TotalUsers:  int(totalUsers),
+		ActiveUsers: int(activeUsers),
+	}
+	if room != nil {
+		status.IsOpen = true
+		status.AdmissionRate = room.AdmissionRate
+		status.EstimatedWaitTime = estimateWaitTime(int(totalUsers), room.AdmissionRate)
 	}
 
-	// Move users to active set
-	for _, userID := range users {
-		err = qm.redis.SAdd(ctx, activeUsersKey, userID).Err()
-		if err != nil {
-			qm.logger.Error("Failed to add user to active set", zap.Error(err))
-			continue
-		}
-
-		// Remove from queue
-		err = qm.redis.ZRem(ctx, queueKey, userID).Err()
-		if err != nil {
-			qm.logger.Error("Failed to remove user from queue", zap.Error(err))
-		}
+	return status, nil
+}
 
-		// Remove user details
-		userKey := fmt.Sprintf("queue_user:%s:%s", eventID, userID)
-		qm.redis.Del(ctx, userKey)
+// ProcessNextUsers admits the next batch of users from queue at once, regardless of the
+// admission rate
+func (qm *QueueManager) ProcessNextUsers(ctx context.Context, eventID string, batchSize int) ([]string, error) {
+	users, err := qm.admit(ctx, eventID, batchSize, false)
+	if err != nil {
+		return nil, err
 	}
 
-	qm.logger.Info("Processed users from queue",
-		zap.String("event_id", eventID),
-		zap.Int("batch_size", len(users)),
-	)
+	if len(users) > 0 {
+		qm.logger.Info("Processed users from queue",
+			zap.String("event_id", eventID),
+			zap.Int("batch_size", len(users)),
+		)
+	}
 
 	return users, nil
 }
@@ -239,7 +269,7 @@ func (qm *QueueManager) CompleteUserBooking(ctx context.Context, userID, eventID
 
 // CleanupExpiredUsers removes expired users from queue
 func (qm *QueueManager) CleanupExpiredUsers(ctx context.Context, eventID string) error {
-	queueKey := fmt.Sprintf("queue:%s", eventID)
+	queueKey := queueKey(eventID)
 	now := time.Now()
 
 	// Get all users in queue
@@ -250,8 +280,13 @@ func (qm *QueueManager) CleanupExpiredUsers(ctx context.Context, eventID string)
 
 	expiredUsers := []string{}
 	for _, userID := range users {
-		userKey := fmt.Sprintf("queue_user:%s:%s", eventID, userID)
+		userKey := queueUserKey(eventID, userID)
 		expiresAtStr, err := qm.redis.HGet(ctx, userKey, "expires_at").Result()
+		if err == redis.Nil {
+			// The user details expired with the queue timeout
+			expiredUsers = append(expiredUsers, userID)
+			continue
+		}
 		if err != nil {
 			continue
 		}
@@ -269,7 +304,7 @@ func (qm *QueueManager) CleanupExpiredUsers(ctx context.Context, eventID string)
 	// Remove expired users
 	for _, userID := range expiredUsers {
 		qm.redis.ZRem(ctx, queueKey, userID)
-		userKey := fmt.Sprintf("queue_user:%s:%s", eventID, userID)
+		userKey := queueUserKey(eventID, userID)
 		qm.redis.Del(ctx, userKey)
 	}
 
@@ -282,3 +317,19 @@ func (qm *QueueManager) CleanupExpiredUsers(ctx context.Context, eventID string)
 
 	return nil
 }
+
+func queueKey(eventID string) string {
+	return fmt.Sprintf("queue:%s", eventID)
+}
+
+func queueUserKey(eventID, userID string) string {
+	return fmt.Sprintf("queue_user:%s:%s", eventID, userID)
+}
+
+// estimateWaitTime estimates the seconds until the user at position is admitted
+func estimateWaitTime(position, admissionRate int) int {
+	if admissionRate <= 0 {
+		return 0
+	}
+	return position * 60 / admissionRate
+}
@@ -0,0 +1,353 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"ticket-service/metrics"
+)
+
+// waitingRoomsKey is the set of events with an open waiting room
+const waitingRoomsKey = "queue_rooms"
+
+// admitScript pops the next users of a queue and marks them admitted until their
+// admission expires, in one step so a user is never dropped between the two. When
+// rated, it admits as many users as the room's admission rate allows since it last
+// admitted; time the queue was empty does not count.
+//
+// KEYS: room, queue. ARGV: now (ms), max users, admission TTL (ms), admitted key
+// prefix, admission marker, rated ("1").
+var admitScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local count = tonumber(ARGV[2])
+local rated = ARGV[6] == '1'
+local rate, last
+if rated then
+	rate = tonumber(redis.call('HGET', KEYS[1], 'admission_rate'))
+	if not rate or rate <= 0 then
+		return {}
+	end
+	last = tonumber(redis.call('HGET', KEYS[1], 'admitted_at'))
+	if not last then
+		redis.call('HSET', KEYS[1], 'admitted_at', now)
+		return {}
+	end
+	local allowance = math.floor((now - last) * rate / 60000)
+	if allowance <= 0 then
+		return {}
+	end
+	if allowance < count then
+		count = allowance
+	end
+end
+local popped = redis.call('ZPOPMIN', KEYS[2], count)
+local users = {}
+for i = 1, #popped, 2 do
+	users[#users + 1] = popped[i]
+	redis.call('SET', ARGV[4] .. popped[i], ARGV[5], 'PX', ARGV[3])
+end
+if rated then
+	if #users < count then
+		redis.call('HSET', KEYS[1], 'admitted_at', now)
+	else
+		redis.call('HSET', KEYS[1], 'admitted_at', last + math.floor(count * 60000 / rate))
+	end
+end
+return users
+`)
+
+// consumeScript deletes an admission marker if it is still the given one
+var consumeScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// WaitingRoom is the waiting room of an event
+type WaitingRoom struct {
+	EventID       string    `json:"event_id"`
+	AdmissionRate int       `json:"admission_rate"` // users per minute
+	OpenedAt      time.Time `json:"opened_at"`
+}
+
+// OpenWaitingRoom opens the waiting room of an event, or changes its admission rate.
+// From then on, users have to queue and be admitted before they can book the event.
+func (qm *QueueManager) OpenWaitingRoom(ctx context.Context, eventID string, admissionRate int) (*WaitingRoom, error) {
+	if admissionRate <= 0 {
+		admissionRate = qm.config.DefaultAdmissionRate
+	}
+
+	roomKey := waitingRoomKey(eventID)
+	now := time.Now()
+
+	pipe := qm.redis.TxPipeline()
+	pipe.HSet(ctx, roomKey, "admission_rate", admissionRate)
+	pipe.HSetNX(ctx, roomKey, "opened_at", now.Format(time.RFC3339))
+	pipe.SAdd(ctx, waitingRoomsKey, eventID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to open waiting room: %w", err)
+	}
+
+	qm.logger.Info("Waiting room opened",
+		zap.String("event_id", eventID),
+		zap.Int("admission_rate", admissionRate),
+	)
+
+	return qm.GetWaitingRoom(ctx, eventID)
+}
+
+// CloseWaitingRoom closes the waiting room of an event, dropping its queue. Users no
+// longer need an admission to book the event.
+func (qm *QueueManager) CloseWaitingRoom(ctx context.Context, eventID string) error {
+	pipe := qm.redis.TxPipeline()
+	pipe.SRem(ctx, waitingRoomsKey, eventID)
+	pipe.Del(ctx, waitingRoomKey(eventID), queueKey(eventID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to close waiting room: %w", err)
+	}
+
+	qm.logger.Info("Waiting room closed", zap.String("event_id", eventID))
+	return nil
+}
+
+// GetWaitingRoom gets the waiting room of an event, or nil when it has none open
+func (qm *QueueManager) GetWaitingRoom(ctx context.Context, eventID string) (*WaitingRoom, error) {
+	roomData, err := qm.redis.HGetAll(ctx, waitingRoomKey(eventID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get waiting room: %w", err)
+	}
+	if len(roomData) == 0 {
+		return nil, nil
+	}
+
+	admissionRate, _ := strconv.Atoi(roomData["admission_rate"])
+	openedAt, _ := time.Parse(time.RFC3339, roomData["opened_at"])
+
+	return &WaitingRoom{
+		EventID:       eventID,
+		AdmissionRate: admissionRate,
+		OpenedAt:      openedAt,
+	}, nil
+}
+
+// ConsumeAdmission checks that a user may start booking an event and uses up their
+// admission, so a token starts one booking session only. It returns nil claims when the
+// event has no open waiting room.
+func (qm *QueueManager) ConsumeAdmission(ctx context.Context, eventID, userID, token string) (*AdmissionClaims, error) {
+	room, err := qm.GetWaitingRoom(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if room == nil {
+		return nil, nil
+	}
+
+	if token == "" {
+		return nil, ErrNotAdmitted
+	}
+	claims, err := qm.signer.verify(token, eventID, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	consumed, err := consumeScript.Run(ctx, qm.redis,
+		[]string{admittedKey(eventID, userID)}, admissionMarker(claims),
+	).Int()
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume admission: %w", err)
+	}
+	if consumed == 0 {
+		return nil, ErrNotAdmitted
+	}
+
+	qm.redis.SAdd(ctx, fmt.Sprintf("active_users:%s", eventID), userID)
+
+	return claims, nil
+}
+
+// RestoreAdmission gives back an admission consumed by a booking session that could not
+// be started, for the rest of its lifetime
+func (qm *QueueManager) RestoreAdmission(ctx context.Context, claims *AdmissionClaims) error {
+	ttl := time.Until(time.Unix(claims.ExpiresAt, 0))
+	if ttl <= 0 {
+		return nil
+	}
+
+	err := qm.redis.SetNX(ctx, admittedKey(claims.EventID, claims.UserID), admissionMarker(claims), ttl).Err()
+	if err != nil {
+		return fmt.Errorf("failed to restore admission: %w", err)
+	}
+
+	qm.redis.SRem(ctx, fmt.Sprintf("active_users:%s", claims.EventID), claims.UserID)
+	return nil
+}
+
+// Start admits users from each open waiting room every AdmitInterval and drops users
+// queued longer than QueueTimeout every CleanupInterval, until Stop is called. Several
+// instances may run it; admissions stay within each room's rate.
+func (qm *QueueManager) Start() {
+	qm.logger.Info("Starting waiting room admissions",
+		zap.Duration("admit_interval", qm.config.AdmitInterval),
+		zap.Int("admit_batch_size", qm.config.AdmitBatchSize),
+	)
+
+	admitTicker := time.NewTicker(qm.config.AdmitInterval)
+	defer admitTicker.Stop()
+
+	cleanupTicker := time.NewTicker(qm.config.CleanupInterval)
+	defer cleanupTicker.Stop()
+
+	for {
+		select {
+		case <-qm.ctx.Done():
+			qm.logger.Info("Waiting room admissions stopping")
+			return
+		case <-admitTicker.C:
+			qm.forEachRoom(func(eventID string) error {
+				_, err := qm.admit(qm.ctx, eventID, qm.config.AdmitBatchSize, true)
+				return err
+			})
+		case <-cleanupTicker.C:
+			qm.forEachRoom(func(eventID string) error {
+				return qm.CleanupExpiredUsers(qm.ctx, eventID)
+			})
+		}
+	}
+}
+
+// Stop stops admitting users
+func (qm *QueueManager) Stop() {
+	qm.cancel()
+}
+
+func (qm *QueueManager) forEachRoom(fn func(eventID string) error) {
+	eventIDs, err := qm.redis.SMembers(qm.ctx, waitingRoomsKey).Result()
+	if err != nil {
+		qm.logger.Error("Failed to list waiting rooms", zap.Error(err))
+		return
+	}
+
+	for _, eventID := range eventIDs {
+		if err := fn(eventID); err != nil {
+			qm.logger.Error("Failed to process waiting room",
+				zap.String("event_id", eventID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// admit admits up to count queued users of an event, within the room's admission rate
+// when rated
+func (qm *QueueManager) admit(ctx context.Context, eventID string, count int, rated bool) ([]string, error) {
+	if count <= 0 {
+		return []string{}, nil
+	}
+
+	now := time.Now()
+	marker := admissionMarker(&AdmissionClaims{
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(qm.config.AdmissionTTL).Unix(),
+	})
+	ratedArg := "0"
+	if rated {
+		ratedArg = "1"
+	}
+
+	users, err := admitScript.Run(ctx, qm.redis,
+		[]string{waitingRoomKey(eventID), queueKey(eventID)},
+		now.UnixMilli(), count, qm.config.AdmissionTTL.Milliseconds(),
+		admittedKey(eventID, ""), marker, ratedArg,
+	).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to admit users: %w", err)
+	}
+
+	if len(users) == 0 {
+		return users, nil
+	}
+
+	// Remove user details
+	userKeys := make([]string, len(users))
+	for i, userID := range users {
+		userKeys[i] = queueUserKey(eventID, userID)
+	}
+	qm.redis.Del(ctx, userKeys...)
+
+	metrics.IncrementWaitingRoomAdmitted(eventID, len(users))
+
+	qm.logger.Debug("Admitted users from waiting room",
+		zap.String("event_id", eventID),
+		zap.Int("count", len(users)),
+	)
+
+	return users, nil
+}
+
+// getAdmission returns the admission of an admitted user, with a token to book with
+func (qm *QueueManager) getAdmission(ctx context.Context, userID, eventID string) (*QueuePosition, error) {
+	marker, err := qm.redis.Get(ctx, admittedKey(eventID, userID)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrNotInQueue
+		}
+		return nil, fmt.Errorf("failed to get admission: %w", err)
+	}
+
+	issuedAt, expiresAt, ok := parseAdmissionMarker(marker)
+	if !ok {
+		return nil, fmt.Errorf("invalid admission of user %s", userID)
+	}
+
+	claims := &AdmissionClaims{
+		EventID:   eventID,
+		UserID:    userID,
+		IssuedAt:  issuedAt,
+		ExpiresAt: expiresAt,
+	}
+
+	return &QueuePosition{
+		UserID:             userID,
+		EventID:            eventID,
+		Admitted:           true,
+		AdmissionToken:     qm.signer.sign(claims),
+		AdmissionExpiresAt: time.Unix(expiresAt, 0),
+	}, nil
+}
+
+func waitingRoomKey(eventID string) string {
+	return fmt.Sprintf("queue_room:%s", eventID)
+}
+
+func admittedKey(eventID, userID string) string {
+	return fmt.Sprintf("queue_admitted:%s:%s", eventID, userID)
+}
+
+// admissionMarker is what an admitted user's key holds, <issued at>:<expires at>, so a
+// token from an earlier admission does not match a later one
+func admissionMarker(claims *AdmissionClaims) string {
+	return fmt.Sprintf("%d:%d", claims.IssuedAt, claims.ExpiresAt)
+}
+
+func parseAdmissionMarker(marker string) (issuedAt, expiresAt int64, ok bool) {
+	issued, expires, found := strings.Cut(marker, ":")
+	if !found {
+		return 0, 0, false
+	}
+	issuedAt, err := strconv.ParseInt(issued, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	expiresAt, err = strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return issuedAt, expiresAt, true
+}
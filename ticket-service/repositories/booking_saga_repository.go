@@ -0,0 +1,198 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"ticket-service/models"
+)
+
+var (
+	// ErrBookingSagaExists is returned when the booking session already has a saga
+	ErrBookingSagaExists = errors.New("booking session already has a saga")
+	// ErrBookingSagaLeaseLost is returned when another instance took over the saga
+	ErrBookingSagaLeaseLost = errors.New("booking saga lease lost")
+)
+
+// BookingSagaRepository handles database operations for booking sagas
+type BookingSagaRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewBookingSagaRepository creates a new booking saga repository
+func NewBookingSagaRepository(db *sqlx.DB, logger *zap.Logger) *BookingSagaRepository {
+	return &BookingSagaRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create creates a new booking saga leased to owner. It fails with ErrBookingSagaExists
+// when the booking session already has one.
+func (r *BookingSagaRepository) Create(ctx context.Context, saga *models.BookingSaga, owner string, lease time.Duration) error {
+	query := `
+		INSERT INTO booking_sagas (
+			id, booking_session_id, status, current_step, payment_method,
+			completed_by, locked_by, locked_until
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP + $8::bigint * INTERVAL '1 millisecond'
+		)
+		ON CONFLICT (booking_session_id) DO NOTHING
+		RETURNING locked_until
+	`
+
+	// Generate UUID if not provided
+	if saga.ID == "" {
+		saga.ID = uuid.New().String()
+	}
+
+	var lockedUntil time.Time
+	err := r.db.GetContext(ctx, &lockedUntil, query,
+		saga.ID, saga.BookingSessionID, saga.Status, saga.CurrentStep,
+		saga.PaymentMethod, saga.CompletedBy, owner, lease.Milliseconds(),
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrBookingSagaExists
+		}
+		r.logger.Error("Failed to create booking saga",
+			zap.String("booking_session_id", saga.BookingSessionID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to create booking saga: %w", err)
+	}
+
+	saga.LockedBy = &owner
+	saga.LockedUntil = &lockedUntil
+	return nil
+}
+
+// GetByBookingSessionID retrieves the saga of a booking session
+func (r *BookingSagaRepository) GetByBookingSessionID(ctx context.Context, bookingSessionID string) (*models.BookingSaga, error) {
+	query := `SELECT * FROM booking_sagas WHERE booking_session_id = $1`
+
+	var saga models.BookingSaga
+	err := r.db.GetContext(ctx, &saga, query, bookingSessionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get booking saga: %w", err)
+	}
+
+	return &saga, nil
+}
+
+// Claim leases an in-flight saga to owner unless it is leased already, including to owner itself
+func (r *BookingSagaRepository) Claim(ctx context.Context, id, owner string, lease time.Duration) (*models.BookingSaga, error) {
+	query := `
+		UPDATE booking_sagas SET
+			locked_by = $2, locked_until = CURRENT_TIMESTAMP + $3::bigint * INTERVAL '1 millisecond'
+		WHERE id = $1 AND status IN ('running', 'compensating')
+			AND (locked_until IS NULL OR locked_until < CURRENT_TIMESTAMP OR locked_by = $2)
+		RETURNING *
+	`
+
+	var saga models.BookingSaga
+	err := r.db.GetContext(ctx, &saga, query, id, owner, lease.Milliseconds())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim booking saga: %w", err)
+	}
+
+	return &saga, nil
+}
+
+// ClaimResumable leases up to limit in-flight sagas whose lease ran out, e.g. because the
+// instance driving them stopped, or whose retry is due
+func (r *BookingSagaRepository) ClaimResumable(ctx context.Context, owner string, lease time.Duration, limit int) ([]*models.BookingSaga, error) {
+	query := `
+		UPDATE booking_sagas SET
+			locked_by = $1, locked_until = CURRENT_TIMESTAMP + $2::bigint * INTERVAL '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM booking_sagas
+			WHERE status IN ('running', 'compensating')
+				AND (locked_until IS NULL OR locked_until < CURRENT_TIMESTAMP)
+			ORDER BY created_at ASC
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`
+
+	var sagas []*models.BookingSaga
+	err := r.db.SelectContext(ctx, &sagas, query, owner, lease.Milliseconds(), limit)
+	if err != nil {
+		r.logger.Error("Failed to claim resumable booking sagas", zap.Error(err))
+		return nil, fmt.Errorf("failed to claim resumable booking sagas: %w", err)
+	}
+
+	return sagas, nil
+}
+
+// Save persists the progress of a saga leased to owner and renews the lease. Sagas that
+// finished release their lease. It fails with ErrBookingSagaLeaseLost when owner no
+// longer holds the lease.
+func (r *BookingSagaRepository) Save(ctx context.Context, saga *models.BookingSaga, owner string, lease time.Duration) error {
+	query := `
+		UPDATE booking_sagas SET
+			status = $3, current_step = $4, failed_step = $5, payment_id = $6,
+			attempts = $7, last_error = $8,
+			completed_at = CASE WHEN $3 IN ('completed', 'compensated') THEN CURRENT_TIMESTAMP ELSE completed_at END,
+			locked_by = CASE WHEN $3 IN ('completed', 'compensated') THEN NULL ELSE locked_by END,
+			locked_until = CASE WHEN $3 IN ('completed', 'compensated') THEN NULL
+				ELSE CURRENT_TIMESTAMP + $9::bigint * INTERVAL '1 millisecond' END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND locked_by = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		saga.ID, owner, saga.Status, saga.CurrentStep, saga.FailedStep,
+		saga.PaymentID, saga.Attempts, saga.LastError, lease.Milliseconds(),
+	)
+	if err != nil {
+		r.logger.Error("Failed to save booking saga",
+			zap.String("saga_id", saga.ID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to save booking saga: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrBookingSagaLeaseLost
+	}
+
+	return nil
+}
+
+// HasInFlight reports whether the booking session has a saga that is still running or compensating
+func (r *BookingSagaRepository) HasInFlight(ctx context.Context, bookingSessionID string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM booking_sagas
+			WHERE booking_session_id = $1 AND status IN ('running', 'compensating')
+		)
+	`
+
+	var exists bool
+	if err := r.db.GetContext(ctx, &exists, query, bookingSessionID); err != nil {
+		return false, fmt.Errorf("failed to check booking saga: %w", err)
+	}
+
+	return exists, nil
+}
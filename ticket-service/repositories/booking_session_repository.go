@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -13,6 +14,11 @@ import (
 	"ticket-service/models"
 )
 
+// ErrHoldNotExtended is returned when a booking session's hold cannot be extended: the
+// session is no longer active, used up its extensions, already holds its seats for longer,
+// is being completed or belongs to a group booking, or one of its seat holds expired
+var ErrHoldNotExtended = errors.New("booking session hold cannot be extended")
+
 // BookingSessionRepository handles database operations for booking sessions
 type BookingSessionRepository struct {
 	db     *sqlx.DB
@@ -193,22 +199,28 @@ func (r *BookingSessionRepository) GetActiveSessions(ctx context.Context, eventI
 	return sessions, nil
 }
 
-// Update updates a booking session
+// Update updates a booking session if it is still at the version it was read at, and moves
+// it to the next version. It fails with a *ConflictError if the session changed in the
+// meantime.
 func (r *BookingSessionRepository) Update(ctx context.Context, session *models.BookingSession) error {
 	query := `
 		UPDATE booking_sessions SET
 			status = $2, seat_count = $3, total_amount = $4,
 			completed_at = $5, cancelled_at = $6, cancelled_reason = $7,
-			metadata = $8, updated_by = $9, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $1
+			metadata = $8, updated_by = $9, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $1 AND version = $10
+		RETURNING version, updated_at
 	`
 
-	result, err := r.db.ExecContext(ctx, query,
+	err := r.db.QueryRowxContext(ctx, query,
 		session.ID, session.Status, session.SeatCount, session.TotalAmount,
 		session.CompletedAt, session.CancelledAt, session.CancelledReason,
-		session.Metadata, session.UpdatedBy,
-	)
+		session.Metadata, session.UpdatedBy, session.Version,
+	).Scan(&session.Version, &session.UpdatedAt)
 
+	if err == sql.ErrNoRows {
+		return staleVersion(ctx, r.db, "booking_sessions", "booking session", session.ID, session.Version)
+	}
 	if err != nil {
 		r.logger.Error("Failed to update booking session",
 			zap.String("session_id", session.ID),
@@ -217,17 +229,9 @@ func (r *BookingSessionRepository) Update(ctx context.Context, session *models.B
 		return fmt.Errorf("failed to update booking session: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("booking session not found: %s", session.ID)
-	}
-
 	r.logger.Info("Booking session updated successfully",
 		zap.String("session_id", session.ID),
+		zap.Int("version", session.Version),
 	)
 
 	return nil
@@ -243,7 +247,7 @@ func (r *BookingSessionRepository) UpdateStatus(ctx context.Context, id, status,
 		query = `
 			UPDATE booking_sessions SET 
 				status = $2, completed_at = CURRENT_TIMESTAMP, 
-				updated_by = $3, updated_at = CURRENT_TIMESTAMP 
+				updated_by = $3, updated_at = CURRENT_TIMESTAMP, version = version + 1 
 			WHERE id = $1
 		`
 		args = []interface{}{id, status, updatedBy}
@@ -251,14 +255,14 @@ func (r *BookingSessionRepository) UpdateStatus(ctx context.Context, id, status,
 		query = `
 			UPDATE booking_sessions SET 
 				status = $2, cancelled_at = CURRENT_TIMESTAMP, 
-				updated_by = $3, updated_at = CURRENT_TIMESTAMP 
+				updated_by = $3, updated_at = CURRENT_TIMESTAMP, version = version + 1 
 			WHERE id = $1
 		`
 		args = []interface{}{id, status, updatedBy}
 	default:
 		query = `
 			UPDATE booking_sessions SET 
-				status = $2, updated_by = $3, updated_at = CURRENT_TIMESTAMP 
+				status = $2, updated_by = $3, updated_at = CURRENT_TIMESTAMP, version = version + 1 
 			WHERE id = $1
 		`
 		args = []interface{}{id, status, updatedBy}
@@ -286,16 +290,22 @@ func (r *BookingSessionRepository) UpdateStatus(ctx context.Context, id, status,
 	return nil
 }
 
-// Cancel cancels a booking session
-func (r *BookingSessionRepository) Cancel(ctx context.Context, id, reason, cancelledBy string) error {
+// Cancel cancels a booking session, together with events
+func (r *BookingSessionRepository) Cancel(ctx context.Context, id, reason, cancelledBy string, events ...*models.OutboxEvent) error {
 	query := `
 		UPDATE booking_sessions SET 
 			status = 'cancelled', cancelled_at = CURRENT_TIMESTAMP, 
-			cancelled_reason = $2, updated_by = $3, updated_at = CURRENT_TIMESTAMP 
+			cancelled_reason = $2, updated_by = NULLIF($3, '')::uuid, updated_at = CURRENT_TIMESTAMP, version = version + 1 
 		WHERE id = $1
 	`
 
-	result, err := r.db.ExecContext(ctx, query, id, reason, cancelledBy)
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, id, reason, cancelledBy)
 	if err != nil {
 		r.logger.Error("Failed to cancel booking session",
 			zap.String("session_id", id),
@@ -313,6 +323,14 @@ func (r *BookingSessionRepository) Cancel(ctx context.Context, id, reason, cance
 		return fmt.Errorf("booking session not found: %s", id)
 	}
 
+	if err := insertOutboxEvents(ctx, tx, events); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit booking session cancellation: %w", err)
+	}
+
 	r.logger.Info("Booking session cancelled successfully",
 		zap.String("session_id", id),
 	)
@@ -320,12 +338,92 @@ func (r *BookingSessionRepository) Cancel(ctx context.Context, id, reason, cance
 	return nil
 }
 
+// ExtendHold moves the deadline of a booking session and of its seat holds to expiresAt
+// and counts the extension, all or nothing. It fails with ErrHoldNotExtended unless the
+// session has extensions left below maxExtensions and exactly holds seats are still held.
+// Holds are never shortened.
+func (r *BookingSessionRepository) ExtendHold(ctx context.Context, id string, expiresAt time.Time, maxExtensions, holds int, extendedBy string) (*models.BookingSession, error) {
+	sessionQuery := `
+		UPDATE booking_sessions SET 
+			expires_at = $2, hold_extensions = hold_extensions + 1, 
+			updated_by = NULLIF($4, '')::uuid, updated_at = CURRENT_TIMESTAMP, version = version + 1 
+		WHERE id = $1 AND status = 'active'
+			AND expires_at > CURRENT_TIMESTAMP AND expires_at < $2
+			AND hold_extensions < $3
+			AND NOT EXISTS (
+				SELECT 1 FROM booking_sagas
+				WHERE booking_sagas.booking_session_id = booking_sessions.id
+					AND booking_sagas.status IN ('running', 'compensating')
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM group_bookings
+				WHERE group_bookings.booking_session_id = booking_sessions.id
+			)
+		RETURNING *
+	`
+
+	holdsQuery := `
+		UPDATE seat_reservations SET 
+			expires_at = GREATEST(expires_at, $2), 
+			updated_by = NULLIF($3, '')::uuid, updated_at = CURRENT_TIMESTAMP, version = version + 1 
+		WHERE booking_session_id = $1 AND status = 'reserved' AND expires_at > CURRENT_TIMESTAMP
+	`
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var session models.BookingSession
+	err = tx.GetContext(ctx, &session, sessionQuery, id, expiresAt, maxExtensions, extendedBy)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrHoldNotExtended
+		}
+		r.logger.Error("Failed to extend booking session hold",
+			zap.String("session_id", id),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to extend booking session hold: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, holdsQuery, id, expiresAt, extendedBy)
+	if err != nil {
+		r.logger.Error("Failed to extend seat holds",
+			zap.String("session_id", id),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to extend seat holds: %w", err)
+	}
+
+	extended, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if int(extended) != holds {
+		return nil, fmt.Errorf("%w: %d of %d seat holds are still held", ErrHoldNotExtended, extended, holds)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit booking session hold extension: %w", err)
+	}
+
+	r.logger.Info("Booking session hold extended successfully",
+		zap.String("session_id", id),
+		zap.Time("expires_at", expiresAt),
+		zap.Int("hold_extensions", session.HoldExtensions),
+	)
+
+	return &session, nil
+}
+
 // Complete completes a booking session
 func (r *BookingSessionRepository) Complete(ctx context.Context, id, completedBy string) error {
 	query := `
 		UPDATE booking_sessions SET 
 			status = 'completed', completed_at = CURRENT_TIMESTAMP, 
-			updated_by = $2, updated_at = CURRENT_TIMESTAMP 
+			updated_by = NULLIF($2, '')::uuid, updated_at = CURRENT_TIMESTAMP, version = version + 1 
 		WHERE id = $1
 	`
 
@@ -354,16 +452,42 @@ func (r *BookingSessionRepository) Complete(ctx context.Context, id, completedBy
 	return nil
 }
 
-// GetExpiredSessions retrieves expired booking sessions
-func (r *BookingSessionRepository) GetExpiredSessions(ctx context.Context, before time.Time) ([]*models.BookingSession, error) {
+// ExpiredSessionCursor is the position of a pass over expired booking sessions: the
+// expiry and ID of the last session looked at
+type ExpiredSessionCursor struct {
+	ExpiresAt time.Time
+	ID        string
+}
+
+// GetExpiredSessions retrieves up to limit expired booking sessions, longest expired
+// first, starting after cursor; a nil cursor starts from the beginning. Paging with the
+// cursor gets past sessions that could not be expired instead of fetching them again.
+// Sessions whose completion saga is still in flight are left to the saga, and group
+// booking sessions to their group.
+func (r *BookingSessionRepository) GetExpiredSessions(ctx context.Context, before time.Time, cursor *ExpiredSessionCursor, limit int) ([]*models.BookingSession, error) {
 	query := `
-		SELECT * FROM booking_sessions 
+		SELECT * FROM booking_sessions
 		WHERE expires_at < $1 AND status = 'active'
-		ORDER BY expires_at ASC
+			AND NOT EXISTS (
+				SELECT 1 FROM booking_sagas
+				WHERE booking_sagas.booking_session_id = booking_sessions.id
+					AND booking_sagas.status IN ('running', 'compensating')
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM group_bookings
+				WHERE group_bookings.booking_session_id = booking_sessions.id
+			)
 	`
+	args := []interface{}{before}
+	if cursor != nil {
+		query += ` AND (expires_at, id) > ($2, $3)`
+		args = append(args, cursor.ExpiresAt, cursor.ID)
+	}
+	query += fmt.Sprintf(` ORDER BY expires_at ASC, id ASC LIMIT $%d`, len(args)+1)
+	args = append(args, limit)
 
 	var sessions []*models.BookingSession
-	err := r.db.SelectContext(ctx, &sessions, query, before)
+	err := r.db.SelectContext(ctx, &sessions, query, args...)
 	if err != nil {
 		r.logger.Error("Failed to get expired booking sessions",
 			zap.Time("before", before),
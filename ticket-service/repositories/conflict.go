@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrConflict is matched by every ConflictError, for callers that retry on any conflict
+var ErrConflict = errors.New("row was updated concurrently")
+
+// ConflictError is returned by a compare-and-swap update when the row changed since it
+// was read. The caller reads the row again and retries.
+type ConflictError struct {
+	Entity string
+	ID     string
+	// Version is the version the update expected
+	Version int
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s %s was updated concurrently: version %d is stale", e.Entity, e.ID, e.Version)
+}
+
+// Is reports whether target is ErrConflict
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// staleVersion explains why a compare-and-swap update of a row in table matched nothing:
+// the row is gone, or it changed since version
+func staleVersion(ctx context.Context, db *sqlx.DB, table, entity, id string, version int) error {
+	var exists bool
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1)`, table)
+	if err := db.GetContext(ctx, &exists, query, id); err != nil {
+		return fmt.Errorf("failed to check %s: %w", entity, err)
+	}
+	if !exists {
+		return fmt.Errorf("%s not found: %s", entity, id)
+	}
+	return &ConflictError{Entity: entity, ID: id, Version: version}
+}
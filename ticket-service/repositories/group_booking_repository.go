@@ -0,0 +1,470 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+
+	"ticket-service/models"
+)
+
+var (
+	// ErrGroupBookingNotFound is returned when there is no group booking with the given ID
+	ErrGroupBookingNotFound = errors.New("group booking not found")
+	// ErrGroupBookingClosed is returned when a group booking no longer takes payments or
+	// changes, because its deadline passed or it is being settled or cancelled
+	ErrGroupBookingClosed = errors.New("group booking is closed")
+	// ErrGroupShareNotPending is returned when a share was paid or released already
+	ErrGroupShareNotPending = errors.New("group booking share is not pending")
+	// ErrGroupBookingLeaseLost is returned when another instance took over the group
+	ErrGroupBookingLeaseLost = errors.New("group booking lease lost")
+)
+
+// GroupBookingRepository handles database operations for group bookings and their shares
+type GroupBookingRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewGroupBookingRepository creates a new group booking repository
+func NewGroupBookingRepository(db *sqlx.DB, logger *zap.Logger) *GroupBookingRepository {
+	return &GroupBookingRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create creates a group booking with its shares in one transaction
+func (r *GroupBookingRepository) Create(ctx context.Context, group *models.GroupBooking) error {
+	groupQuery := `
+		INSERT INTO group_bookings (
+			id, booking_session_id, event_id, organizer_id, status, currency, payment_deadline
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		)
+	`
+
+	shareQuery := `
+		INSERT INTO group_booking_shares (
+			id, group_booking_id, seat_reservation_id, seat_id, user_id, amount, currency, status
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+		)
+	`
+
+	// Generate UUID if not provided
+	if group.ID == "" {
+		group.ID = uuid.New().String()
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, groupQuery,
+		group.ID, group.BookingSessionID, group.EventID, group.OrganizerID,
+		group.Status, group.Currency, group.PaymentDeadline,
+	)
+	if err != nil {
+		r.logger.Error("Failed to create group booking",
+			zap.String("booking_session_id", group.BookingSessionID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to create group booking: %w", err)
+	}
+
+	for _, share := range group.Shares {
+		if share.ID == "" {
+			share.ID = uuid.New().String()
+		}
+		share.GroupBookingID = group.ID
+
+		_, err := tx.ExecContext(ctx, shareQuery,
+			share.ID, share.GroupBookingID, share.SeatReservationID, share.SeatID,
+			share.UserID, share.Amount, share.Currency, share.Status,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create group booking share for seat %s: %w", share.SeatID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit group booking: %w", err)
+	}
+
+	r.logger.Info("Group booking created successfully",
+		zap.String("group_booking_id", group.ID),
+		zap.String("booking_session_id", group.BookingSessionID),
+		zap.Int("shares", len(group.Shares)),
+	)
+
+	return nil
+}
+
+// GetByID retrieves a group booking by ID, with its shares
+func (r *GroupBookingRepository) GetByID(ctx context.Context, id string) (*models.GroupBooking, error) {
+	query := `SELECT * FROM group_bookings WHERE id = $1`
+
+	var group models.GroupBooking
+	err := r.db.GetContext(ctx, &group, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %s", ErrGroupBookingNotFound, id)
+		}
+		r.logger.Error("Failed to get group booking by ID",
+			zap.String("group_booking_id", id),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to get group booking: %w", err)
+	}
+
+	if group.Shares, err = r.GetShares(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+// GetShares retrieves the shares of a group booking
+func (r *GroupBookingRepository) GetShares(ctx context.Context, groupBookingID string) ([]*models.GroupBookingShare, error) {
+	query := `SELECT * FROM group_booking_shares WHERE group_booking_id = $1 ORDER BY created_at ASC, seat_id ASC`
+
+	var shares []*models.GroupBookingShare
+	if err := r.db.SelectContext(ctx, &shares, query, groupBookingID); err != nil {
+		r.logger.Error("Failed to get group booking shares",
+			zap.String("group_booking_id", groupBookingID),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to get group booking shares: %w", err)
+	}
+
+	return shares, nil
+}
+
+// ExistsForBookingSession reports whether the booking session belongs to a group booking
+func (r *GroupBookingRepository) ExistsForBookingSession(ctx context.Context, bookingSessionID string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM group_bookings WHERE booking_session_id = $1)`
+
+	var exists bool
+	if err := r.db.GetContext(ctx, &exists, query, bookingSessionID); err != nil {
+		return false, fmt.Errorf("failed to check group booking: %w", err)
+	}
+
+	return exists, nil
+}
+
+// AssignShare hands a pending share to another member of the group. It fails with
+// ErrGroupBookingClosed once the group stopped taking payments.
+func (r *GroupBookingRepository) AssignShare(ctx context.Context, groupBookingID, shareID, userID string) error {
+	query := `
+		UPDATE group_booking_shares SET
+			user_id = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND group_booking_id = $2 AND status = 'pending'
+	`
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenGroupBooking(ctx, tx, groupBookingID); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, query, shareID, groupBookingID, userID)
+	if err != nil {
+		r.logger.Error("Failed to assign group booking share",
+			zap.String("share_id", shareID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to assign group booking share: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrGroupShareNotPending
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit group booking share: %w", err)
+	}
+
+	return nil
+}
+
+// MarkSharePaid records the payment of a pending share. When it was the last unpaid
+// share, the group moves on to settling, leased to owner, and settle is true. It fails
+// with ErrGroupBookingClosed once the group stopped taking payments and with
+// ErrGroupShareNotPending when the share was paid already.
+func (r *GroupBookingRepository) MarkSharePaid(ctx context.Context, groupBookingID, shareID, paymentID, paymentMethod, owner string, lease time.Duration) (settle bool, err error) {
+	shareQuery := `
+		UPDATE group_booking_shares SET
+			status = 'paid', payment_id = NULLIF($3, ''), payment_method = NULLIF($4, ''),
+			paid_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND group_booking_id = $2 AND status = 'pending'
+	`
+
+	pendingQuery := `SELECT COUNT(*) FROM group_booking_shares WHERE group_booking_id = $1 AND status = 'pending'`
+
+	settleQuery := `
+		UPDATE group_bookings SET
+			status = 'settling', locked_by = $2,
+			locked_until = CURRENT_TIMESTAMP + $3::bigint * INTERVAL '1 millisecond',
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the group so the deadline cannot pass, and the group be settled, while the payment is recorded
+	if err := lockOpenGroupBooking(ctx, tx, groupBookingID); err != nil {
+		return false, err
+	}
+
+	result, err := tx.ExecContext(ctx, shareQuery, shareID, groupBookingID, paymentID, paymentMethod)
+	if err != nil {
+		r.logger.Error("Failed to mark group booking share paid",
+			zap.String("share_id", shareID),
+			zap.Error(err),
+		)
+		return false, fmt.Errorf("failed to mark group booking share paid: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return false, ErrGroupShareNotPending
+	}
+
+	var pending int
+	if err := tx.GetContext(ctx, &pending, pendingQuery, groupBookingID); err != nil {
+		return false, fmt.Errorf("failed to count unpaid group booking shares: %w", err)
+	}
+
+	if pending == 0 {
+		if _, err := tx.ExecContext(ctx, settleQuery, groupBookingID, owner, lease.Milliseconds()); err != nil {
+			return false, fmt.Errorf("failed to settle group booking: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit group booking payment: %w", err)
+	}
+
+	return pending == 0, nil
+}
+
+// SetSharePayment records the payment of a pending share that has not succeeded yet, so
+// paying again checks on it instead of charging again. It fails with
+// ErrGroupShareNotPending when the share is no longer pending.
+func (r *GroupBookingRepository) SetSharePayment(ctx context.Context, groupBookingID, shareID, paymentID, paymentMethod string) error {
+	query := `
+		UPDATE group_booking_shares SET
+			payment_id = $3, payment_method = COALESCE(NULLIF($4, ''), payment_method),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND group_booking_id = $2 AND status = 'pending'
+	`
+
+	result, err := r.db.ExecContext(ctx, query, shareID, groupBookingID, paymentID, paymentMethod)
+	if err != nil {
+		r.logger.Error("Failed to record group booking share payment",
+			zap.String("share_id", shareID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to record group booking share payment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrGroupShareNotPending
+	}
+
+	return nil
+}
+
+// MarkSharesReleased marks pending shares released once their seats were given back
+func (r *GroupBookingRepository) MarkSharesReleased(ctx context.Context, shareIDs []string) error {
+	if len(shareIDs) == 0 {
+		return nil
+	}
+
+	query := `
+		UPDATE group_booking_shares SET
+			status = 'released', released_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ANY($1) AND status = 'pending'
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, pq.Array(shareIDs)); err != nil {
+		r.logger.Error("Failed to release group booking shares",
+			zap.Strings("share_ids", shareIDs),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to release group booking shares: %w", err)
+	}
+
+	return nil
+}
+
+// MarkShareRefunded marks a paid share refunded
+func (r *GroupBookingRepository) MarkShareRefunded(ctx context.Context, shareID string) error {
+	query := `
+		UPDATE group_booking_shares SET
+			status = 'refunded', released_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'paid'
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, shareID); err != nil {
+		r.logger.Error("Failed to mark group booking share refunded",
+			zap.String("share_id", shareID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to mark group booking share refunded: %w", err)
+	}
+
+	return nil
+}
+
+// Claim moves a group booking from one of the statuses in from to status and leases it to
+// owner, recording reason when given. It returns nil when the group is in another status
+// or leased to another instance.
+func (r *GroupBookingRepository) Claim(ctx context.Context, id, owner string, lease time.Duration, from []string, status, reason string) (*models.GroupBooking, error) {
+	query := `
+		UPDATE group_bookings SET
+			status = $4, cancelled_reason = COALESCE(NULLIF($5, ''), cancelled_reason),
+			locked_by = $2, locked_until = CURRENT_TIMESTAMP + $3::bigint * INTERVAL '1 millisecond',
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = ANY($6)
+			AND (locked_until IS NULL OR locked_until < CURRENT_TIMESTAMP OR locked_by = $2)
+		RETURNING *
+	`
+
+	var group models.GroupBooking
+	err := r.db.GetContext(ctx, &group, query, id, owner, lease.Milliseconds(), status, reason, pq.Array(from))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim group booking: %w", err)
+	}
+
+	if group.Shares, err = r.GetShares(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+// ClaimDue leases up to limit group bookings that have work to do: open groups whose
+// payment deadline passed, which move on to settling, and groups left settling or
+// cancelling by an instance whose lease ran out
+func (r *GroupBookingRepository) ClaimDue(ctx context.Context, owner string, lease time.Duration, limit int) ([]*models.GroupBooking, error) {
+	query := `
+		UPDATE group_bookings SET
+			status = CASE WHEN status = 'open' THEN 'settling' ELSE status END,
+			locked_by = $1, locked_until = CURRENT_TIMESTAMP + $2::bigint * INTERVAL '1 millisecond',
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id IN (
+			SELECT id FROM group_bookings
+			WHERE ((status = 'open' AND payment_deadline <= CURRENT_TIMESTAMP)
+					OR status IN ('settling', 'cancelling'))
+				AND (locked_until IS NULL OR locked_until < CURRENT_TIMESTAMP)
+			ORDER BY payment_deadline ASC
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`
+
+	var groups []*models.GroupBooking
+	err := r.db.SelectContext(ctx, &groups, query, owner, lease.Milliseconds(), limit)
+	if err != nil {
+		r.logger.Error("Failed to claim due group bookings", zap.Error(err))
+		return nil, fmt.Errorf("failed to claim due group bookings: %w", err)
+	}
+
+	for _, group := range groups {
+		if group.Shares, err = r.GetShares(ctx, group.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return groups, nil
+}
+
+// Finish ends a group booking leased to owner with its final status and releases the
+// lease. It fails with ErrGroupBookingLeaseLost when owner no longer holds the lease.
+func (r *GroupBookingRepository) Finish(ctx context.Context, id, owner, status string) error {
+	query := `
+		UPDATE group_bookings SET
+			status = $3, settled_at = CURRENT_TIMESTAMP,
+			locked_by = NULL, locked_until = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND locked_by = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, owner, status)
+	if err != nil {
+		r.logger.Error("Failed to finish group booking",
+			zap.String("group_booking_id", id),
+			zap.String("status", status),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to finish group booking: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrGroupBookingLeaseLost
+	}
+
+	return nil
+}
+
+// lockOpenGroupBooking locks a group booking for the rest of the transaction and fails
+// with ErrGroupBookingClosed unless it still takes payments
+func lockOpenGroupBooking(ctx context.Context, tx *sqlx.Tx, id string) error {
+	query := `
+		SELECT status = 'open' AND payment_deadline > CURRENT_TIMESTAMP
+		FROM group_bookings WHERE id = $1 FOR UPDATE
+	`
+
+	var open bool
+	if err := tx.GetContext(ctx, &open, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: %s", ErrGroupBookingNotFound, id)
+		}
+		return fmt.Errorf("failed to lock group booking: %w", err)
+	}
+	if !open {
+		return ErrGroupBookingClosed
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"ticket-service/models"
+)
+
+// HoldPolicyRepository handles database operations for event hold policies
+type HoldPolicyRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewHoldPolicyRepository creates a new hold policy repository
+func NewHoldPolicyRepository(db *sqlx.DB, logger *zap.Logger) *HoldPolicyRepository {
+	return &HoldPolicyRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetByEventID retrieves the hold policy of an event, or the default policy when the
+// organizer has not set one
+func (r *HoldPolicyRepository) GetByEventID(ctx context.Context, eventID string) (*models.EventHoldPolicy, error) {
+	query := `SELECT * FROM event_hold_policies WHERE event_id = $1`
+
+	var policy models.EventHoldPolicy
+	err := r.db.GetContext(ctx, &policy, query, eventID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.DefaultEventHoldPolicy(eventID), nil
+		}
+		r.logger.Error("Failed to get event hold policy",
+			zap.String("event_id", eventID),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to get event hold policy: %w", err)
+	}
+
+	return &policy, nil
+}
+
+// Upsert creates or replaces the hold policy of an event
+func (r *HoldPolicyRepository) Upsert(ctx context.Context, policy *models.EventHoldPolicy) error {
+	query := `
+		INSERT INTO event_hold_policies (event_id, max_extensions, max_hold_minutes, extend_while_queued, updated_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (event_id) DO UPDATE SET
+			max_extensions = EXCLUDED.max_extensions,
+			max_hold_minutes = EXCLUDED.max_hold_minutes,
+			extend_while_queued = EXCLUDED.extend_while_queued,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING created_at, updated_at
+	`
+
+	err := r.db.QueryRowxContext(ctx, query,
+		policy.EventID, policy.MaxExtensions, policy.MaxHoldMinutes, policy.ExtendWhileQueued, policy.UpdatedBy,
+	).Scan(&policy.CreatedAt, &policy.UpdatedAt)
+	if err != nil {
+		r.logger.Error("Failed to save event hold policy",
+			zap.String("event_id", policy.EventID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to save event hold policy: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"ticket-service/models"
+)
+
+// OutboxRepository handles database operations for outbox events. Events are written by
+// the repositories whose changes they describe, in the same transaction; this repository
+// is what the outbox relay reads them back with.
+type OutboxRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewOutboxRepository creates a new outbox repository
+func NewOutboxRepository(db *sqlx.DB, logger *zap.Logger) *OutboxRepository {
+	return &OutboxRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// OutboxPublishFunc publishes one outbox event
+type OutboxPublishFunc func(ctx context.Context, event *models.OutboxEvent) error
+
+// PublishPending locks up to limit pending events that are due, oldest first, hands each
+// to publish and records the outcome before releasing them. Concurrent relays skip locked
+// events. A failed event is retried after retryDelay, doubled per failed attempt up to
+// maxRetryDelay.
+//
+// Every aggregate's events are published in order: an event is only picked once no earlier
+// event of its aggregate is pending, whether it waits for a retry or is locked by another
+// relay publishing it right now. A batch thus holds at most one event per aggregate.
+func (r *OutboxRepository) PublishPending(ctx context.Context, limit int, retryDelay, maxRetryDelay time.Duration, publish OutboxPublishFunc) (published, failed int, err error) {
+	selectQuery := `
+		SELECT * FROM outbox_events o
+		WHERE o.status = 'pending' AND o.available_at <= CURRENT_TIMESTAMP
+			AND NOT EXISTS (
+				SELECT 1 FROM outbox_events earlier
+				WHERE earlier.aggregate_type = o.aggregate_type
+					AND earlier.aggregate_id = o.aggregate_id
+					AND earlier.status = 'pending'
+					AND earlier.sequence < o.sequence
+			)
+		ORDER BY o.sequence ASC
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`
+
+	publishedQuery := `
+		UPDATE outbox_events SET status = 'published', published_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	failedQuery := `
+		UPDATE outbox_events SET
+			retry_count = retry_count + 1, last_error = $2,
+			available_at = CURRENT_TIMESTAMP + $3::bigint * INTERVAL '1 millisecond'
+		WHERE id = $1
+	`
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var events []*models.OutboxEvent
+	if err := tx.SelectContext(ctx, &events, selectQuery, limit); err != nil {
+		return 0, 0, fmt.Errorf("failed to get pending outbox events: %w", err)
+	}
+
+	for _, event := range events {
+		if publishErr := publish(ctx, event); publishErr != nil {
+			failed++
+
+			delay := retryDelay << min(event.RetryCount, 16)
+			if delay <= 0 || delay > maxRetryDelay {
+				delay = maxRetryDelay
+			}
+
+			r.logger.Warn("Failed to publish outbox event",
+				zap.String("event_id", event.ID),
+				zap.String("event_type", event.EventType),
+				zap.Int("retry_count", event.RetryCount+1),
+				zap.Duration("retry_in", delay),
+				zap.Error(publishErr),
+			)
+
+			if _, err := tx.ExecContext(ctx, failedQuery, event.ID, publishErr.Error(), delay.Milliseconds()); err != nil {
+				return published, failed, fmt.Errorf("failed to record outbox event failure: %w", err)
+			}
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, publishedQuery, event.ID); err != nil {
+			return published, failed, fmt.Errorf("failed to mark outbox event published: %w", err)
+		}
+		published++
+	}
+
+	// Events published before a failed commit are published again: delivery is at least once
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit outbox events: %w", err)
+	}
+
+	return published, failed, nil
+}
+
+// DeletePublishedBefore deletes events published before the given time
+func (r *OutboxRepository) DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM outbox_events WHERE status = 'published' AND published_at < $1`
+
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		r.logger.Error("Failed to delete published outbox events", zap.Error(err))
+		return 0, fmt.Errorf("failed to delete published outbox events: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// insertOutboxEvents writes events in tx, so they are published if and only if the
+// changes they describe commit
+func insertOutboxEvents(ctx context.Context, tx *sqlx.Tx, events []*models.OutboxEvent) error {
+	query := `
+		INSERT INTO outbox_events (
+			id, aggregate_type, aggregate_id, event_type, payload, status
+		) VALUES (
+			$1, $2, $3, $4, $5, $6
+		)
+	`
+
+	for _, event := range events {
+		// Generate UUID if not provided
+		if event.ID == "" {
+			event.ID = uuid.New().String()
+		}
+
+		_, err := tx.ExecContext(ctx, query,
+			event.ID, event.AggregateType, event.AggregateID,
+			event.EventType, event.Payload, event.Status,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to write %s outbox event: %w", event.EventType, err)
+		}
+	}
+
+	return nil
+}
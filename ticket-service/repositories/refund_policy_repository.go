@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"ticket-service/models"
+)
+
+// RefundPolicyRepository handles database operations for event refund policies
+type RefundPolicyRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewRefundPolicyRepository creates a new refund policy repository
+func NewRefundPolicyRepository(db *sqlx.DB, logger *zap.Logger) *RefundPolicyRepository {
+	return &RefundPolicyRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetByEventID retrieves the refund policy of an event, or the default policy when the
+// organizer has not set one
+func (r *RefundPolicyRepository) GetByEventID(ctx context.Context, eventID string) (*models.EventRefundPolicy, error) {
+	query := `
+		SELECT * FROM event_refund_policy_rules
+		WHERE event_id = $1
+		ORDER BY hours_before_event DESC
+	`
+
+	var rules []*models.RefundPolicyRule
+	if err := r.db.SelectContext(ctx, &rules, query, eventID); err != nil {
+		r.logger.Error("Failed to get event refund policy",
+			zap.String("event_id", eventID),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to get event refund policy: %w", err)
+	}
+
+	if len(rules) == 0 {
+		return models.DefaultEventRefundPolicy(eventID), nil
+	}
+
+	return models.NewEventRefundPolicy(eventID, rules), nil
+}
+
+// Replace replaces the rules of an event's refund policy. Without rules, the event falls
+// back to the default policy.
+func (r *RefundPolicyRepository) Replace(ctx context.Context, policy *models.EventRefundPolicy, updatedBy string) error {
+	deleteQuery := `DELETE FROM event_refund_policy_rules WHERE event_id = $1`
+
+	insertQuery := `
+		INSERT INTO event_refund_policy_rules (event_id, hours_before_event, refund_percent, updated_by)
+		VALUES ($1, $2, $3, NULLIF($4, '')::uuid)
+	`
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, deleteQuery, policy.EventID); err != nil {
+		return fmt.Errorf("failed to clear event refund policy: %w", err)
+	}
+
+	for _, rule := range policy.Rules {
+		_, err := tx.ExecContext(ctx, insertQuery, policy.EventID, rule.HoursBeforeEvent, rule.RefundPercent, updatedBy)
+		if err != nil {
+			r.logger.Error("Failed to save event refund policy",
+				zap.String("event_id", policy.EventID),
+				zap.Error(err),
+			)
+			return fmt.Errorf("failed to save event refund policy: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit event refund policy: %w", err)
+	}
+
+	return nil
+}
@@ -3,16 +3,28 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 
 	"ticket-service/models"
 )
 
+// activeHoldIndex is the unique index allowing one reserved hold per event seat
+const activeHoldIndex = "uq_seat_reservations_active_hold"
+
+// ErrSeatAlreadyHeld is returned when another active reservation holds the seat
+var ErrSeatAlreadyHeld = errors.New("seat is already held")
+
+// ErrSessionNotOpen is returned when seats are added to a booking session that is no
+// longer active, is being completed or belongs to a group booking
+var ErrSessionNotOpen = errors.New("booking session cannot take seats")
+
 // SeatReservationRepository handles database operations for seat reservations
 type SeatReservationRepository struct {
 	db     *sqlx.DB
@@ -27,8 +39,29 @@ func NewSeatReservationRepository(db *sqlx.DB, logger *zap.Logger) *SeatReservat
 	}
 }
 
-// Create creates a new seat reservation
+// Create creates a new seat reservation. The insert is the hold itself: a unique index on
+// active reservations makes it fail with ErrSeatAlreadyHeld when the seat is already held.
+// Holds on the seat that expired but were not cleaned up yet are retired first, except those
+// ExpireReservations leaves alone: a session being completed by a saga or an unfinished group
+// booking keeps its seats past the deadline.
 func (r *SeatReservationRepository) Create(ctx context.Context, reservation *models.SeatReservation) error {
+	expireQuery := `
+		UPDATE seat_reservations SET
+			status = 'expired', released_at = CURRENT_TIMESTAMP,
+			released_reason = 'Reservation expired', updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE event_id = $1 AND seat_id = $2 AND status = 'reserved' AND expires_at <= CURRENT_TIMESTAMP
+			AND NOT EXISTS (
+				SELECT 1 FROM booking_sagas
+				WHERE booking_sagas.booking_session_id = seat_reservations.booking_session_id
+					AND booking_sagas.status IN ('running', 'compensating')
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM group_bookings
+				WHERE group_bookings.booking_session_id = seat_reservations.booking_session_id
+					AND group_bookings.status IN ('open', 'settling', 'cancelling')
+			)
+	`
+
 	query := `
 		INSERT INTO seat_reservations (
 			id, booking_session_id, event_id, seat_id, zone_id,
@@ -45,7 +78,17 @@ func (r *SeatReservationRepository) Create(ctx context.Context, reservation *mod
 		reservation.ID = uuid.New().String()
 	}
 
-	_, err := r.db.ExecContext(ctx, query,
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, expireQuery, reservation.EventID, reservation.SeatID); err != nil {
+		return fmt.Errorf("failed to expire stale seat holds: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, query,
 		reservation.ID, reservation.BookingSessionID, reservation.EventID,
 		reservation.SeatID, reservation.ZoneID, reservation.ReservationToken,
 		reservation.Status, reservation.ReservedAt, reservation.ExpiresAt,
@@ -55,6 +98,9 @@ func (r *SeatReservationRepository) Create(ctx context.Context, reservation *mod
 	)
 
 	if err != nil {
+		if isActiveHoldViolation(err) {
+			return fmt.Errorf("%w: %s", ErrSeatAlreadyHeld, reservation.SeatID)
+		}
 		r.logger.Error("Failed to create seat reservation",
 			zap.String("reservation_id", reservation.ID),
 			zap.String("seat_id", reservation.SeatID),
@@ -63,6 +109,10 @@ func (r *SeatReservationRepository) Create(ctx context.Context, reservation *mod
 		return fmt.Errorf("failed to create seat reservation: %w", err)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit seat reservation: %w", err)
+	}
+
 	r.logger.Info("Seat reservation created successfully",
 		zap.String("reservation_id", reservation.ID),
 		zap.String("seat_id", reservation.SeatID),
@@ -71,6 +121,132 @@ func (r *SeatReservationRepository) Create(ctx context.Context, reservation *mod
 	return nil
 }
 
+// CreateBatch creates the reservations of a booking session in one transaction, all or
+// nothing. Seats held by another active reservation are returned and nothing is created.
+// Expired holds are retired first as in Create.
+// The session is locked meanwhile, and has to be open for seats; otherwise CreateBatch
+// fails with ErrSessionNotOpen.
+func (r *SeatReservationRepository) CreateBatch(ctx context.Context, bookingSessionID string, reservations []*models.SeatReservation) ([]string, error) {
+	sessionQuery := `
+		SELECT id FROM booking_sessions
+		WHERE id = $1 AND status = 'active' AND expires_at > CURRENT_TIMESTAMP
+			AND NOT EXISTS (
+				SELECT 1 FROM booking_sagas
+				WHERE booking_sagas.booking_session_id = booking_sessions.id
+					AND booking_sagas.status IN ('running', 'compensating')
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM group_bookings
+				WHERE group_bookings.booking_session_id = booking_sessions.id
+			)
+		FOR UPDATE
+	`
+
+	expireQuery := `
+		UPDATE seat_reservations SET
+			status = 'expired', released_at = CURRENT_TIMESTAMP,
+			released_reason = 'Reservation expired', updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE event_id = $1 AND seat_id = ANY($2) AND status = 'reserved' AND expires_at <= CURRENT_TIMESTAMP
+			AND NOT EXISTS (
+				SELECT 1 FROM booking_sagas
+				WHERE booking_sagas.booking_session_id = seat_reservations.booking_session_id
+					AND booking_sagas.status IN ('running', 'compensating')
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM group_bookings
+				WHERE group_bookings.booking_session_id = seat_reservations.booking_session_id
+					AND group_bookings.status IN ('open', 'settling', 'cancelling')
+			)
+	`
+
+	query := `
+		INSERT INTO seat_reservations (
+			id, booking_session_id, event_id, seat_id, zone_id,
+			reservation_token, status, reserved_at, expires_at,
+			pricing_category, base_price, final_price, currency,
+			metadata, created_by, updated_by
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
+		)
+		ON CONFLICT (event_id, seat_id) WHERE status = 'reserved' DO NOTHING
+	`
+
+	if len(reservations) == 0 {
+		return nil, nil
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var sessionID string
+	if err := tx.GetContext(ctx, &sessionID, sessionQuery, bookingSessionID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSessionNotOpen
+		}
+		return nil, fmt.Errorf("failed to lock booking session: %w", err)
+	}
+
+	seatIDsByEvent := make(map[string][]string)
+	for _, reservation := range reservations {
+		seatIDsByEvent[reservation.EventID] = append(seatIDsByEvent[reservation.EventID], reservation.SeatID)
+	}
+	for eventID, seatIDs := range seatIDsByEvent {
+		if _, err := tx.ExecContext(ctx, expireQuery, eventID, pq.Array(seatIDs)); err != nil {
+			return nil, fmt.Errorf("failed to expire stale seat holds: %w", err)
+		}
+	}
+
+	var held []string
+	for _, reservation := range reservations {
+		if reservation.ID == "" {
+			reservation.ID = uuid.New().String()
+		}
+
+		result, err := tx.ExecContext(ctx, query,
+			reservation.ID, reservation.BookingSessionID, reservation.EventID,
+			reservation.SeatID, reservation.ZoneID, reservation.ReservationToken,
+			reservation.Status, reservation.ReservedAt, reservation.ExpiresAt,
+			reservation.PricingCategory, reservation.BasePrice, reservation.FinalPrice,
+			reservation.Currency, reservation.Metadata, reservation.CreatedBy,
+			reservation.UpdatedBy,
+		)
+		if err != nil {
+			r.logger.Error("Failed to create seat reservations",
+				zap.String("booking_session_id", bookingSessionID),
+				zap.String("seat_id", reservation.SeatID),
+				zap.Error(err),
+			)
+			return nil, fmt.Errorf("failed to create seat reservations: %w", err)
+		}
+
+		created, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if created == 0 {
+			held = append(held, reservation.SeatID)
+		}
+	}
+
+	if len(held) > 0 {
+		return held, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit seat reservations: %w", err)
+	}
+
+	r.logger.Info("Seat reservations created successfully",
+		zap.String("booking_session_id", bookingSessionID),
+		zap.Int("count", len(reservations)),
+	)
+
+	return nil, nil
+}
+
 // GetByID retrieves a seat reservation by ID
 func (r *SeatReservationRepository) GetByID(ctx context.Context, id string) (*models.SeatReservation, error) {
 	query := `SELECT * FROM seat_reservations WHERE id = $1`
@@ -224,20 +400,27 @@ func (r *SeatReservationRepository) GetReservationsBySeatIDs(ctx context.Context
 	return reservations, nil
 }
 
-// Update updates a seat reservation
+// Update updates a seat reservation if it is still at the version it was read at, and moves
+// it to the next version. It fails with a *ConflictError if the reservation changed in the
+// meantime.
 func (r *SeatReservationRepository) Update(ctx context.Context, reservation *models.SeatReservation) error {
 	query := `
 		UPDATE seat_reservations SET
 			status = $2, released_at = $3, released_reason = $4,
-			metadata = $5, updated_by = $6, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $1
+			metadata = $5, updated_by = $6, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $1 AND version = $7
+		RETURNING version, updated_at
 	`
 
-	result, err := r.db.ExecContext(ctx, query,
+	err := r.db.QueryRowxContext(ctx, query,
 		reservation.ID, reservation.Status, reservation.ReleasedAt,
 		reservation.ReleasedReason, reservation.Metadata, reservation.UpdatedBy,
-	)
+		reservation.Version,
+	).Scan(&reservation.Version, &reservation.UpdatedAt)
 
+	if err == sql.ErrNoRows {
+		return staleVersion(ctx, r.db, "seat_reservations", "seat reservation", reservation.ID, reservation.Version)
+	}
 	if err != nil {
 		r.logger.Error("Failed to update seat reservation",
 			zap.String("reservation_id", reservation.ID),
@@ -246,17 +429,9 @@ func (r *SeatReservationRepository) Update(ctx context.Context, reservation *mod
 		return fmt.Errorf("failed to update seat reservation: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("seat reservation not found: %s", reservation.ID)
-	}
-
 	r.logger.Info("Seat reservation updated successfully",
 		zap.String("reservation_id", reservation.ID),
+		zap.Int("version", reservation.Version),
 	)
 
 	return nil
@@ -270,23 +445,23 @@ func (r *SeatReservationRepository) UpdateStatus(ctx context.Context, id, status
 	switch status {
 	case models.ReservationStatusReleased:
 		query = `
-			UPDATE seat_reservations SET 
+			UPDATE seat_reservations SET
 				status = $2, released_at = CURRENT_TIMESTAMP, 
-				updated_by = $3, updated_at = CURRENT_TIMESTAMP 
+				updated_by = $3, updated_at = CURRENT_TIMESTAMP, version = version + 1
 			WHERE id = $1
 		`
 		args = []interface{}{id, status, updatedBy}
 	case models.ReservationStatusConfirmed:
 		query = `
-			UPDATE seat_reservations SET 
-				status = $2, updated_by = $3, updated_at = CURRENT_TIMESTAMP 
+			UPDATE seat_reservations SET
+				status = $2, updated_by = $3, updated_at = CURRENT_TIMESTAMP, version = version + 1
 			WHERE id = $1
 		`
 		args = []interface{}{id, status, updatedBy}
 	default:
 		query = `
-			UPDATE seat_reservations SET 
-				status = $2, updated_by = $3, updated_at = CURRENT_TIMESTAMP 
+			UPDATE seat_reservations SET
+				status = $2, updated_by = $3, updated_at = CURRENT_TIMESTAMP, version = version + 1
 			WHERE id = $1
 		`
 		args = []interface{}{id, status, updatedBy}
@@ -317,9 +492,9 @@ func (r *SeatReservationRepository) UpdateStatus(ctx context.Context, id, status
 // Release releases a seat reservation
 func (r *SeatReservationRepository) Release(ctx context.Context, id, reason, releasedBy string) error {
 	query := `
-		UPDATE seat_reservations SET 
+		UPDATE seat_reservations SET
 			status = 'released', released_at = CURRENT_TIMESTAMP, 
-			released_reason = $2, updated_by = $3, updated_at = CURRENT_TIMESTAMP 
+			released_reason = $2, updated_by = $3, updated_at = CURRENT_TIMESTAMP, version = version + 1
 		WHERE id = $1
 	`
 
@@ -351,8 +526,8 @@ func (r *SeatReservationRepository) Release(ctx context.Context, id, reason, rel
 // Confirm confirms a seat reservation
 func (r *SeatReservationRepository) Confirm(ctx context.Context, id, confirmedBy string) error {
 	query := `
-		UPDATE seat_reservations SET 
-			status = 'confirmed', updated_by = $2, updated_at = CURRENT_TIMESTAMP 
+		UPDATE seat_reservations SET
+			status = 'confirmed', updated_by = $2, updated_at = CURRENT_TIMESTAMP, version = version + 1
 		WHERE id = $1
 	`
 
@@ -381,53 +556,199 @@ func (r *SeatReservationRepository) Confirm(ctx context.Context, id, confirmedBy
 	return nil
 }
 
-// ReleaseByBookingSession releases all reservations for a booking session
-func (r *SeatReservationRepository) ReleaseByBookingSession(ctx context.Context, bookingSessionID, reason, releasedBy string) error {
+// ReleaseByBookingSession releases the held reservations of a booking session and returns
+// the seats it released. Seats of reservations that had already lapsed or been released
+// are not returned: someone else may hold them by now.
+func (r *SeatReservationRepository) ReleaseByBookingSession(ctx context.Context, bookingSessionID, reason, releasedBy string) ([]string, error) {
 	query := `
-		UPDATE seat_reservations SET 
-			status = 'released', released_at = CURRENT_TIMESTAMP, 
-			released_reason = $2, updated_by = $3, updated_at = CURRENT_TIMESTAMP 
+		UPDATE seat_reservations SET
+			status = 'released', released_at = CURRENT_TIMESTAMP,
+			released_reason = $2, updated_by = NULLIF($3, '')::uuid, updated_at = CURRENT_TIMESTAMP, version = version + 1
 		WHERE booking_session_id = $1 AND status = 'reserved'
+		RETURNING seat_id
 	`
 
-	result, err := r.db.ExecContext(ctx, query, bookingSessionID, reason, releasedBy)
-	if err != nil {
+	var seatIDs []string
+	if err := r.db.SelectContext(ctx, &seatIDs, query, bookingSessionID, reason, releasedBy); err != nil {
 		r.logger.Error("Failed to release seat reservations by booking session",
 			zap.String("booking_session_id", bookingSessionID),
 			zap.Error(err),
 		)
-		return fmt.Errorf("failed to release seat reservations: %w", err)
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return nil, fmt.Errorf("failed to release seat reservations: %w", err)
 	}
 
 	r.logger.Info("Seat reservations released by booking session",
 		zap.String("booking_session_id", bookingSessionID),
-		zap.Int64("rows_affected", rowsAffected),
+		zap.Int("rows_affected", len(seatIDs)),
 	)
 
+	return seatIDs, nil
+}
+
+// ExtendHolds moves the expiry of a booking session's unexpired holds to expiresAt and
+// returns how many were extended
+func (r *SeatReservationRepository) ExtendHolds(ctx context.Context, bookingSessionID string, expiresAt time.Time) (int64, error) {
+	query := `
+		UPDATE seat_reservations SET
+			expires_at = $2, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE booking_session_id = $1 AND status = 'reserved' AND expires_at > CURRENT_TIMESTAMP
+	`
+
+	result, err := r.db.ExecContext(ctx, query, bookingSessionID, expiresAt)
+	if err != nil {
+		r.logger.Error("Failed to extend seat holds",
+			zap.String("booking_session_id", bookingSessionID),
+			zap.Error(err),
+		)
+		return 0, fmt.Errorf("failed to extend seat holds: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// ConfirmByBookingSession confirms all reserved holds of a booking session and returns how many were confirmed
+func (r *SeatReservationRepository) ConfirmByBookingSession(ctx context.Context, bookingSessionID, confirmedBy string) (int64, error) {
+	query := `
+		UPDATE seat_reservations SET
+			status = 'confirmed', updated_by = NULLIF($2, '')::uuid, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE booking_session_id = $1 AND status = 'reserved'
+	`
+
+	result, err := r.db.ExecContext(ctx, query, bookingSessionID, confirmedBy)
+	if err != nil {
+		r.logger.Error("Failed to confirm seat reservations by booking session",
+			zap.String("booking_session_id", bookingSessionID),
+			zap.Error(err),
+		)
+		return 0, fmt.Errorf("failed to confirm seat reservations: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// ReleaseConfirmedByBookingSession releases the confirmed reservations of a booking session,
+// undoing ConfirmByBookingSession when the booking is rolled back
+func (r *SeatReservationRepository) ReleaseConfirmedByBookingSession(ctx context.Context, bookingSessionID, reason string) error {
+	query := `
+		UPDATE seat_reservations SET
+			status = 'released', released_at = CURRENT_TIMESTAMP, 
+			released_reason = $2, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE booking_session_id = $1 AND status = 'confirmed'
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, bookingSessionID, reason); err != nil {
+		r.logger.Error("Failed to release confirmed seat reservations",
+			zap.String("booking_session_id", bookingSessionID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to release confirmed seat reservations: %w", err)
+	}
+
 	return nil
 }
 
-// GetExpiredReservations retrieves expired seat reservations
-func (r *SeatReservationRepository) GetExpiredReservations(ctx context.Context, before time.Time) ([]*models.SeatReservation, error) {
+// ReleaseHolds releases reserved holds by ID, e.g. when the matching block in Event Service
+// failed, and returns the seats it released. Seats of holds that had already lapsed or been
+// released are not returned: someone else may hold them by now.
+func (r *SeatReservationRepository) ReleaseHolds(ctx context.Context, ids []string, reason string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
 	query := `
-		SELECT * FROM seat_reservations 
-		WHERE expires_at < $1 AND status = 'reserved'
-		ORDER BY expires_at ASC
+		UPDATE seat_reservations SET
+			status = 'released', released_at = CURRENT_TIMESTAMP,
+			released_reason = $2, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = ANY($1) AND status = 'reserved'
+		RETURNING seat_id
+	`
+
+	var seatIDs []string
+	if err := r.db.SelectContext(ctx, &seatIDs, query, pq.Array(ids), reason); err != nil {
+		r.logger.Error("Failed to release seat holds",
+			zap.Strings("reservation_ids", ids),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to release seat holds: %w", err)
+	}
+
+	return seatIDs, nil
+}
+
+// ReleaseReservations releases the reserved holds among ids and returns them. Holds of a
+// session being completed by a saga, or of an unfinished group booking, are left alone.
+func (r *SeatReservationRepository) ReleaseReservations(ctx context.Context, ids []string, reason, releasedBy string) ([]*models.SeatReservation, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		UPDATE seat_reservations SET
+			status = 'released', released_at = CURRENT_TIMESTAMP, released_reason = $2,
+			updated_by = NULLIF($3, '')::uuid, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = ANY($1) AND status = 'reserved'
+			AND NOT EXISTS (
+				SELECT 1 FROM booking_sagas
+				WHERE booking_sagas.booking_session_id = seat_reservations.booking_session_id
+					AND booking_sagas.status IN ('running', 'compensating')
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM group_bookings
+				WHERE group_bookings.booking_session_id = seat_reservations.booking_session_id
+					AND group_bookings.status IN ('open', 'settling', 'cancelling')
+			)
+		RETURNING *
+	`
+
+	var reservations []*models.SeatReservation
+	if err := r.db.SelectContext(ctx, &reservations, query, pq.Array(ids), reason, releasedBy); err != nil {
+		r.logger.Error("Failed to release seat reservations",
+			zap.Strings("reservation_ids", ids),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to release seat reservations: %w", err)
+	}
+
+	return reservations, nil
+}
+
+// ExpireReservations marks up to limit holds that expired before the given time as
+// expired and returns them, longest expired first. Holds of a session being completed by
+// a saga are left to the saga, and those of an unfinished group booking to the group.
+// Concurrent callers never expire the same hold twice.
+func (r *SeatReservationRepository) ExpireReservations(ctx context.Context, before time.Time, limit int) ([]*models.SeatReservation, error) {
+	query := `
+		UPDATE seat_reservations SET
+			status = 'expired', released_at = CURRENT_TIMESTAMP,
+			released_reason = 'Reservation expired', updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id IN (
+			SELECT id FROM seat_reservations
+			WHERE expires_at < $1 AND status = 'reserved'
+				AND NOT EXISTS (
+					SELECT 1 FROM booking_sagas
+					WHERE booking_sagas.booking_session_id = seat_reservations.booking_session_id
+						AND booking_sagas.status IN ('running', 'compensating')
+				)
+				AND NOT EXISTS (
+					SELECT 1 FROM group_bookings
+					WHERE group_bookings.booking_session_id = seat_reservations.booking_session_id
+						AND group_bookings.status IN ('open', 'settling', 'cancelling')
+				)
+			ORDER BY expires_at ASC
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
 	`
 
 	var reservations []*models.SeatReservation
-	err := r.db.SelectContext(ctx, &reservations, query, before)
+	err := r.db.SelectContext(ctx, &reservations, query, before, limit)
 	if err != nil {
-		r.logger.Error("Failed to get expired seat reservations",
+		r.logger.Error("Failed to expire seat reservations",
 			zap.Time("before", before),
 			zap.Error(err),
 		)
-		return nil, fmt.Errorf("failed to get expired seat reservations: %w", err)
+		return nil, fmt.Errorf("failed to expire seat reservations: %w", err)
 	}
 
 	return reservations, nil
@@ -518,3 +839,9 @@ func (r *SeatReservationRepository) CheckSeatAvailability(ctx context.Context, s
 
 	return count == 0, nil
 }
+
+// isActiveHoldViolation reports whether err is a unique violation of the active hold index
+func isActiveHoldViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == activeHoldIndex
+}
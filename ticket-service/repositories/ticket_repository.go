@@ -179,9 +179,10 @@ func (r *TicketRepository) GetByEventID(ctx context.Context, eventID string, pag
 	return tickets, total, nil
 }
 
-// GetByBookingSessionID retrieves tickets for a booking session
+// GetByBookingSessionID retrieves the tickets of a booking session, i.e. the full order.
+// Tickets issued together share created_at, so they are ordered by ticket number within it.
 func (r *TicketRepository) GetByBookingSessionID(ctx context.Context, bookingSessionID string) ([]*models.Ticket, error) {
-	query := `SELECT * FROM tickets WHERE booking_session_id = $1 ORDER BY created_at ASC`
+	query := `SELECT * FROM tickets WHERE booking_session_id = $1 ORDER BY created_at ASC, ticket_number ASC`
 
 	var tickets []*models.Ticket
 	err := r.db.SelectContext(ctx, &tickets, query, bookingSessionID)
@@ -196,7 +197,8 @@ func (r *TicketRepository) GetByBookingSessionID(ctx context.Context, bookingSes
 	return tickets, nil
 }
 
-// Update updates a ticket
+// Update updates a ticket if it is still at the version it was read at, and moves it to
+// the next version. It fails with a *ConflictError if the ticket changed in the meantime.
 func (r *TicketRepository) Update(ctx context.Context, ticket *models.Ticket) error {
 	query := `
 		UPDATE tickets SET
@@ -204,18 +206,22 @@ func (r *TicketRepository) Update(ctx context.Context, ticket *models.Ticket) er
 			payment_reference = $5, qr_code = $6, barcode = $7,
 			used_at = $8, cancelled_at = $9, cancelled_reason = $10,
 			refunded_at = $11, refunded_amount = $12, metadata = $13,
-			updated_by = $14, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $1
+			updated_by = $14, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $1 AND version = $15
+		RETURNING version, updated_at
 	`
 
-	result, err := r.db.ExecContext(ctx, query,
+	err := r.db.QueryRowxContext(ctx, query,
 		ticket.ID, ticket.Status, ticket.PaymentStatus, ticket.PaymentMethod,
 		ticket.PaymentReference, ticket.QRCode, ticket.Barcode,
 		ticket.UsedAt, ticket.CancelledAt, ticket.CancelledReason,
 		ticket.RefundedAt, ticket.RefundedAmount, ticket.Metadata,
-		ticket.UpdatedBy,
-	)
+		ticket.UpdatedBy, ticket.Version,
+	).Scan(&ticket.Version, &ticket.UpdatedAt)
 
+	if err == sql.ErrNoRows {
+		return staleVersion(ctx, r.db, "tickets", "ticket", ticket.ID, ticket.Version)
+	}
 	if err != nil {
 		r.logger.Error("Failed to update ticket",
 			zap.String("ticket_id", ticket.ID),
@@ -224,17 +230,9 @@ func (r *TicketRepository) Update(ctx context.Context, ticket *models.Ticket) er
 		return fmt.Errorf("failed to update ticket: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("ticket not found: %s", ticket.ID)
-	}
-
 	r.logger.Info("Ticket updated successfully",
 		zap.String("ticket_id", ticket.ID),
+		zap.Int("version", ticket.Version),
 	)
 
 	return nil
@@ -242,7 +240,7 @@ func (r *TicketRepository) Update(ctx context.Context, ticket *models.Ticket) er
 
 // UpdateStatus updates ticket status
 func (r *TicketRepository) UpdateStatus(ctx context.Context, id, status, updatedBy string) error {
-	query := `UPDATE tickets SET status = $2, updated_by = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+	query := `UPDATE tickets SET status = $2, updated_by = $3, updated_at = CURRENT_TIMESTAMP, version = version + 1 WHERE id = $1`
 
 	result, err := r.db.ExecContext(ctx, query, id, status, updatedBy)
 	if err != nil {
@@ -271,7 +269,7 @@ func (r *TicketRepository) UpdatePaymentStatus(ctx context.Context, id, paymentS
 	query := `
 		UPDATE tickets SET 
 			payment_status = $2, payment_method = $3, payment_reference = $4,
-			updated_by = $5, updated_at = CURRENT_TIMESTAMP 
+			updated_by = $5, updated_at = CURRENT_TIMESTAMP, version = version + 1 
 		WHERE id = $1
 	`
 
@@ -297,16 +295,22 @@ func (r *TicketRepository) UpdatePaymentStatus(ctx context.Context, id, paymentS
 	return nil
 }
 
-// Delete deletes a ticket (soft delete by updating status)
-func (r *TicketRepository) Delete(ctx context.Context, id, reason, deletedBy string) error {
+// Delete deletes a ticket (soft delete by updating status), together with events
+func (r *TicketRepository) Delete(ctx context.Context, id, reason, deletedBy string, events ...*models.OutboxEvent) error {
 	query := `
 		UPDATE tickets SET 
 			status = 'cancelled', cancelled_at = CURRENT_TIMESTAMP, 
-			cancelled_reason = $2, updated_by = $3, updated_at = CURRENT_TIMESTAMP 
+			cancelled_reason = $2, updated_by = $3, updated_at = CURRENT_TIMESTAMP, version = version + 1 
 		WHERE id = $1
 	`
 
-	result, err := r.db.ExecContext(ctx, query, id, reason, deletedBy)
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, id, reason, deletedBy)
 	if err != nil {
 		r.logger.Error("Failed to delete ticket",
 			zap.String("ticket_id", id),
@@ -324,6 +328,14 @@ func (r *TicketRepository) Delete(ctx context.Context, id, reason, deletedBy str
 		return fmt.Errorf("ticket not found: %s", id)
 	}
 
+	if err := insertOutboxEvents(ctx, tx, events); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit ticket deletion: %w", err)
+	}
+
 	r.logger.Info("Ticket deleted successfully",
 		zap.String("ticket_id", id),
 	)
@@ -331,72 +343,173 @@ func (r *TicketRepository) Delete(ctx context.Context, id, reason, deletedBy str
 	return nil
 }
 
-// Search searches tickets with filters
-func (r *TicketRepository) Search(ctx context.Context, filters map[string]interface{}, page, limit int) ([]*models.Ticket, int, error) {
-	whereClause := "WHERE 1=1"
-	args := []interface{}{}
-	argIndex := 1
+// MarkRefunded records a refund of amount on a ticket, together with events. paymentStatus
+// is refunded or, when amount is less than the price, partially_refunded.
+func (r *TicketRepository) MarkRefunded(ctx context.Context, id string, amount float64, paymentStatus, updatedBy string, events ...*models.OutboxEvent) error {
+	query := `
+		UPDATE tickets SET
+			status = 'refunded', payment_status = $3,
+			refunded_at = CURRENT_TIMESTAMP, refunded_amount = $2,
+			updated_by = NULLIF($4, '')::uuid, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $1
+	`
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-	// Build where clause dynamically
-	if eventID, ok := filters["event_id"].(string); ok && eventID != "" {
-		whereClause += fmt.Sprintf(" AND event_id = $%d", argIndex)
-		args = append(args, eventID)
-		argIndex++
+	result, err := tx.ExecContext(ctx, query, id, amount, paymentStatus, updatedBy)
+	if err != nil {
+		r.logger.Error("Failed to mark ticket refunded",
+			zap.String("ticket_id", id),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to mark ticket refunded: %w", err)
 	}
 
-	if userID, ok := filters["user_id"].(string); ok && userID != "" {
-		whereClause += fmt.Sprintf(" AND user_id = $%d", argIndex)
-		args = append(args, userID)
-		argIndex++
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 
-	if status, ok := filters["status"].(string); ok && status != "" {
-		whereClause += fmt.Sprintf(" AND status = $%d", argIndex)
-		args = append(args, status)
-		argIndex++
+	if rowsAffected == 0 {
+		return fmt.Errorf("ticket not found: %s", id)
 	}
 
-	if paymentStatus, ok := filters["payment_status"].(string); ok && paymentStatus != "" {
-		whereClause += fmt.Sprintf(" AND payment_status = $%d", argIndex)
-		args = append(args, paymentStatus)
-		argIndex++
+	if err := insertOutboxEvents(ctx, tx, events); err != nil {
+		return err
 	}
 
-	if ticketType, ok := filters["ticket_type"].(string); ok && ticketType != "" {
-		whereClause += fmt.Sprintf(" AND ticket_type = $%d", argIndex)
-		args = append(args, ticketType)
-		argIndex++
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit ticket refund: %w", err)
 	}
 
-	// Count total
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM tickets %s", whereClause)
-	var total int
-	err := r.db.GetContext(ctx, &total, countQuery, args...)
+	return nil
+}
+
+// IssueForBookingSession creates the tickets of a booking session and completes the
+// session in one transaction, together with events when tickets were created. Seats that
+// already have a live ticket in the session are skipped, so issuing again after a failure
+// is safe. It returns the number of tickets created.
+func (r *TicketRepository) IssueForBookingSession(ctx context.Context, bookingSessionID, completedBy string, tickets []*models.Ticket, events ...*models.OutboxEvent) (int64, error) {
+	lockQuery := `SELECT status FROM booking_sessions WHERE id = $1 FOR UPDATE`
+
+	insertQuery := `
+		INSERT INTO tickets (
+			id, event_id, seat_id, zone_id, user_id, booking_session_id,
+			ticket_number, ticket_type, pricing_category, base_price, final_price,
+			currency, discount_amount, discount_reason, status, payment_status,
+			payment_method, payment_reference, qr_code, barcode, valid_from,
+			valid_until, metadata, created_by, updated_by
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
+			$17, $18, $19, $20, $21, $22, $23, $24, $25
+		)
+		ON CONFLICT (booking_session_id, seat_id) WHERE status NOT IN ('cancelled', 'refunded') DO NOTHING
+	`
+
+	completeQuery := `
+		UPDATE booking_sessions SET
+			status = 'completed', completed_at = CURRENT_TIMESTAMP,
+			updated_by = NULLIF($2, '')::uuid, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $1 AND status = 'active'
+	`
+
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count tickets: %w", err)
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	// Get tickets with pagination
-	query := fmt.Sprintf(`
-		SELECT * FROM tickets %s 
-		ORDER BY created_at DESC 
-		LIMIT $%d OFFSET $%d
-	`, whereClause, argIndex, argIndex+1)
+	// Lock the session so it cannot be cancelled or expired while its tickets are issued
+	var sessionStatus string
+	if err := tx.GetContext(ctx, &sessionStatus, lockQuery, bookingSessionID); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("booking session not found: %s", bookingSessionID)
+		}
+		return 0, fmt.Errorf("failed to lock booking session: %w", err)
+	}
+	if sessionStatus != models.BookingSessionStatusActive && sessionStatus != models.BookingSessionStatusCompleted {
+		return 0, fmt.Errorf("booking session is not active, status: %s", sessionStatus)
+	}
 
-	offset := (page - 1) * limit
-	args = append(args, limit, offset)
+	var issued int64
+	for _, ticket := range tickets {
+		// Generate UUID if not provided
+		if ticket.ID == "" {
+			ticket.ID = uuid.New().String()
+		}
 
-	var tickets []*models.Ticket
-	err = r.db.SelectContext(ctx, &tickets, query, args...)
+		result, err := tx.ExecContext(ctx, insertQuery,
+			ticket.ID, ticket.EventID, ticket.SeatID, ticket.ZoneID, ticket.UserID,
+			ticket.BookingSessionID, ticket.TicketNumber, ticket.TicketType,
+			ticket.PricingCategory, ticket.BasePrice, ticket.FinalPrice,
+			ticket.Currency, ticket.DiscountAmount, ticket.DiscountReason,
+			ticket.Status, ticket.PaymentStatus, ticket.PaymentMethod,
+			ticket.PaymentReference, ticket.QRCode, ticket.Barcode,
+			ticket.ValidFrom, ticket.ValidUntil, ticket.Metadata,
+			ticket.CreatedBy, ticket.UpdatedBy,
+		)
+		if err != nil {
+			r.logger.Error("Failed to issue ticket",
+				zap.String("booking_session_id", bookingSessionID),
+				zap.String("seat_id", ticket.SeatID),
+				zap.Error(err),
+			)
+			return 0, fmt.Errorf("failed to issue ticket for seat %s: %w", ticket.SeatID, err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		issued += rowsAffected
+	}
+
+	if _, err := tx.ExecContext(ctx, completeQuery, bookingSessionID, completedBy); err != nil {
+		return 0, fmt.Errorf("failed to complete booking session: %w", err)
+	}
+
+	// A retry after the first commit issues nothing, and the events are out already
+	if issued > 0 {
+		if err := insertOutboxEvents(ctx, tx, events); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit issued tickets: %w", err)
+	}
+
+	r.logger.Info("Tickets issued for booking session",
+		zap.String("booking_session_id", bookingSessionID),
+		zap.Int64("issued", issued),
+	)
+
+	return issued, nil
+}
+
+// CancelByBookingSession cancels the tickets of a booking session that are not cancelled yet
+func (r *TicketRepository) CancelByBookingSession(ctx context.Context, bookingSessionID, reason string) (int64, error) {
+	query := `
+		UPDATE tickets SET 
+			status = 'cancelled', cancelled_at = CURRENT_TIMESTAMP, 
+			cancelled_reason = $2, updated_at = CURRENT_TIMESTAMP, version = version + 1 
+		WHERE booking_session_id = $1 AND status NOT IN ('cancelled', 'refunded')
+	`
+
+	result, err := r.db.ExecContext(ctx, query, bookingSessionID, reason)
 	if err != nil {
-		r.logger.Error("Failed to search tickets",
-			zap.Any("filters", filters),
+		r.logger.Error("Failed to cancel tickets by booking session",
+			zap.String("booking_session_id", bookingSessionID),
 			zap.Error(err),
 		)
-		return nil, 0, fmt.Errorf("failed to search tickets: %w", err)
+		return 0, fmt.Errorf("failed to cancel tickets: %w", err)
 	}
 
-	return tickets, total, nil
+	return result.RowsAffected()
 }
 
 // GetExpiredTickets retrieves expired tickets
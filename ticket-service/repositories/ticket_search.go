@@ -0,0 +1,207 @@
+package repositories
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+
+	"ticket-service/models"
+)
+
+// ErrInvalidSearchCursor is returned when a search cursor is malformed or belongs to a
+// search with another sort order
+var ErrInvalidSearchCursor = errors.New("invalid ticket search cursor")
+
+// ticketSortColumns maps the sort fields to their column and the SQL type of its values
+var ticketSortColumns = map[string]struct{ column, sqlType string }{
+	models.TicketSortCreatedAt:    {"created_at", "timestamptz"},
+	models.TicketSortFinalPrice:   {"final_price", "numeric"},
+	models.TicketSortTicketNumber: {"ticket_number", "text"},
+}
+
+// ticketSearchCursor is the position after the last ticket of a page: its sort value and
+// ID, the tie-breaker of tickets with the same sort value
+type ticketSearchCursor struct {
+	SortBy string `json:"s"`
+	Desc   bool   `json:"d"`
+	Value  string `json:"v"`
+	ID     string `json:"i"`
+}
+
+// Search searches tickets. Pages are read with keyset pagination, from the position of
+// the query's cursor, so reading deep pages costs as much as reading the first one.
+func (r *TicketRepository) Search(ctx context.Context, query *models.TicketSearchQuery) (*models.TicketSearchPage, error) {
+	query.Normalize()
+	sort, ok := ticketSortColumns[query.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("invalid sort_by: %s", query.SortBy)
+	}
+
+	where := []string{}
+	args := []interface{}{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if query.EventID != "" {
+		where = append(where, "event_id = "+arg(query.EventID))
+	}
+	if query.UserID != "" {
+		where = append(where, "user_id = "+arg(query.UserID))
+	}
+	if len(query.Statuses) > 0 {
+		where = append(where, fmt.Sprintf("status = ANY(%s)", arg(pq.Array(query.Statuses))))
+	}
+	if len(query.PaymentStatuses) > 0 {
+		where = append(where, fmt.Sprintf("payment_status = ANY(%s)", arg(pq.Array(query.PaymentStatuses))))
+	}
+	if len(query.TicketTypes) > 0 {
+		where = append(where, fmt.Sprintf("ticket_type = ANY(%s)", arg(pq.Array(query.TicketTypes))))
+	}
+	if len(query.ZoneIDs) > 0 {
+		where = append(where, fmt.Sprintf("zone_id = ANY(%s::uuid[])", arg(pq.Array(query.ZoneIDs))))
+	}
+	if len(query.PricingCategories) > 0 {
+		where = append(where, fmt.Sprintf("pricing_category = ANY(%s)", arg(pq.Array(query.PricingCategories))))
+	}
+	if query.TicketNumberPrefix != "" {
+		where = append(where, "ticket_number LIKE "+arg(escapeLike(query.TicketNumberPrefix)+"%"))
+	}
+	if query.MinPrice != nil {
+		where = append(where, "final_price >= "+arg(*query.MinPrice))
+	}
+	if query.MaxPrice != nil {
+		where = append(where, "final_price <= "+arg(*query.MaxPrice))
+	}
+	for _, timeRange := range []struct {
+		column string
+		models.TimeRange
+	}{
+		{"created_at", query.Created},
+		{"used_at", query.Used},
+		{"cancelled_at", query.Cancelled},
+	} {
+		if timeRange.From != nil {
+			where = append(where, fmt.Sprintf("%s >= %s", timeRange.column, arg(*timeRange.From)))
+		}
+		if timeRange.To != nil {
+			where = append(where, fmt.Sprintf("%s < %s", timeRange.column, arg(*timeRange.To)))
+		}
+	}
+
+	direction, comparison := "ASC", ">"
+	if query.SortDesc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeTicketSearchCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.SortBy != query.SortBy || cursor.Desc != query.SortDesc {
+			return nil, fmt.Errorf("%w: cursor of another sort order", ErrInvalidSearchCursor)
+		}
+		if !validTicketSortValue(cursor.Value, query.SortBy) {
+			return nil, ErrInvalidSearchCursor
+		}
+		where = append(where, fmt.Sprintf("(%s, id) %s (%s::%s, %s::uuid)",
+			sort.column, comparison, arg(cursor.Value), sort.sqlType, arg(cursor.ID)))
+	}
+
+	whereClause := ""
+	if len(where) > 0 {
+		whereClause = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	// Read one ticket more than the page to know whether another page follows
+	sqlQuery := fmt.Sprintf(`
+		SELECT * FROM tickets %s
+		ORDER BY %s %s, id %s
+		LIMIT %s
+	`, whereClause, sort.column, direction, direction, arg(query.Limit+1))
+
+	var tickets []*models.Ticket
+	if err := r.db.SelectContext(ctx, &tickets, sqlQuery, args...); err != nil {
+		r.logger.Error("Failed to search tickets",
+			zap.Any("query", query),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to search tickets: %w", err)
+	}
+
+	page := &models.TicketSearchPage{Tickets: tickets}
+	if len(tickets) > query.Limit {
+		page.Tickets = tickets[:query.Limit]
+		last := page.Tickets[query.Limit-1]
+		page.NextCursor = encodeTicketSearchCursor(&ticketSearchCursor{
+			SortBy: query.SortBy,
+			Desc:   query.SortDesc,
+			Value:  ticketSortValue(last, query.SortBy),
+			ID:     last.ID,
+		})
+	}
+
+	return page, nil
+}
+
+// ticketSortValue returns the value a ticket is sorted by, as the cursor stores it
+func ticketSortValue(ticket *models.Ticket, sortBy string) string {
+	switch sortBy {
+	case models.TicketSortFinalPrice:
+		return strconv.FormatFloat(ticket.FinalPrice, 'f', -1, 64)
+	case models.TicketSortTicketNumber:
+		return ticket.TicketNumber
+	default:
+		return ticket.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// validTicketSortValue reports whether a cursor's sort value parses as the sort column's type
+func validTicketSortValue(value, sortBy string) bool {
+	switch sortBy {
+	case models.TicketSortFinalPrice:
+		_, err := strconv.ParseFloat(value, 64)
+		return err == nil
+	case models.TicketSortTicketNumber:
+		return true
+	default:
+		_, err := time.Parse(time.RFC3339Nano, value)
+		return err == nil
+	}
+}
+
+func encodeTicketSearchCursor(cursor *ticketSearchCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeTicketSearchCursor(encoded string) (*ticketSearchCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidSearchCursor
+	}
+	var cursor ticketSearchCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidSearchCursor
+	}
+	if _, err := uuid.Parse(cursor.ID); err != nil {
+		return nil, ErrInvalidSearchCursor
+	}
+	return &cursor, nil
+}
+
+// escapeLike escapes the LIKE wildcards of s, so it matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
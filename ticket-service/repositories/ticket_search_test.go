@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"ticket-service/models"
+)
+
+func TestTicketSearchCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor ticketSearchCursor
+	}{
+		{
+			name:   "created at ascending",
+			cursor: ticketSearchCursor{SortBy: models.TicketSortCreatedAt, Value: "2026-03-01T18:30:00.123456789Z", ID: "0b4c3f0e-8a5e-4a4b-9d0c-2f5e6a7b8c9d"},
+		},
+		{
+			name:   "final price descending",
+			cursor: ticketSearchCursor{SortBy: models.TicketSortFinalPrice, Desc: true, Value: "149.5", ID: "6f1d2c3b-4a59-4e8f-8a7b-1c2d3e4f5a6b"},
+		},
+		{
+			name:   "ticket number with separators",
+			cursor: ticketSearchCursor{SortBy: models.TicketSortTicketNumber, Value: "TKT-A/12:07", ID: "9a8b7c6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := encodeTicketSearchCursor(&tt.cursor)
+			decoded, err := decodeTicketSearchCursor(encoded)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if *decoded != tt.cursor {
+				t.Errorf("decoded cursor = %+v, want %+v", *decoded, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeTicketSearchCursorRejects(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"not base64", "not a cursor!"},
+		{"not json", encode("created_at|0b4c3f0e")},
+		{"missing id", encode(`{"s":"created_at","v":"2026-03-01T18:30:00Z"}`)},
+		{"id not a uuid", encode(`{"s":"created_at","v":"2026-03-01T18:30:00Z","i":"1 OR 1=1"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeTicketSearchCursor(tt.encoded); !errors.Is(err, ErrInvalidSearchCursor) {
+				t.Errorf("decode error = %v, want ErrInvalidSearchCursor", err)
+			}
+		})
+	}
+}
+
+func TestTicketSortValueRoundTrip(t *testing.T) {
+	createdAt := time.Date(2026, 3, 1, 20, 30, 0, 123456789, time.FixedZone("ICT", 7*60*60))
+	ticket := &models.Ticket{
+		CreatedAt:    createdAt,
+		FinalPrice:   149.99,
+		TicketNumber: "TKT-0042",
+	}
+
+	for _, sortBy := range []string{models.TicketSortCreatedAt, models.TicketSortFinalPrice, models.TicketSortTicketNumber} {
+		t.Run(sortBy, func(t *testing.T) {
+			value := ticketSortValue(ticket, sortBy)
+			if !validTicketSortValue(value, sortBy) {
+				t.Fatalf("sort value %q is not valid for %s", value, sortBy)
+			}
+		})
+	}
+
+	// The cursor has to point at the exact row, so created_at keeps its nanoseconds
+	parsed, err := time.Parse(time.RFC3339Nano, ticketSortValue(ticket, models.TicketSortCreatedAt))
+	if err != nil {
+		t.Fatalf("parse created_at sort value: %v", err)
+	}
+	if !parsed.Equal(createdAt) {
+		t.Errorf("created_at sort value = %s, want %s", parsed, createdAt)
+	}
+
+	if validTicketSortValue("yesterday", models.TicketSortCreatedAt) {
+		t.Error("created_at sort value accepted a non-timestamp")
+	}
+	if validTicketSortValue("1e", models.TicketSortFinalPrice) {
+		t.Error("final_price sort value accepted a non-number")
+	}
+}
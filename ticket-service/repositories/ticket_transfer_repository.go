@@ -0,0 +1,349 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+
+	"ticket-service/models"
+)
+
+// pendingTransferIndex is the unique index allowing one pending transfer per ticket
+const pendingTransferIndex = "uq_ticket_transfers_pending"
+
+var (
+	// ErrTransferPending is returned when the ticket already has a pending transfer
+	ErrTransferPending = errors.New("ticket already has a pending transfer")
+	// ErrTransferNotFound is returned when there is no transfer with the given ID
+	ErrTransferNotFound = errors.New("ticket transfer not found")
+	// ErrTransferNotPending is returned when a transfer was accepted, cancelled or expired already
+	ErrTransferNotPending = errors.New("ticket transfer is no longer pending")
+	// ErrTicketOwnerChanged is returned when the ticket no longer belongs to the transfer's
+	// sender, or can no longer be transferred
+	ErrTicketOwnerChanged = errors.New("ticket can no longer be transferred by its sender")
+)
+
+// TicketTransferRepository handles database operations for ticket transfers, the ticket
+// ownership audit trail and the per-event transfer policies
+type TicketTransferRepository struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewTicketTransferRepository creates a new ticket transfer repository
+func NewTicketTransferRepository(db *sqlx.DB, logger *zap.Logger) *TicketTransferRepository {
+	return &TicketTransferRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create creates a new ticket transfer. It fails with ErrTransferPending when the ticket
+// already has a pending transfer.
+func (r *TicketTransferRepository) Create(ctx context.Context, transfer *models.TicketTransfer) error {
+	query := `
+		INSERT INTO ticket_transfers (
+			id, ticket_id, event_id, from_user_id, to_user_id, transfer_type,
+			status, price, currency, expires_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+		)
+	`
+
+	// Generate UUID if not provided
+	if transfer.ID == "" {
+		transfer.ID = uuid.New().String()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		transfer.ID, transfer.TicketID, transfer.EventID, transfer.FromUserID,
+		transfer.ToUserID, transfer.TransferType, transfer.Status,
+		transfer.Price, transfer.Currency, transfer.ExpiresAt,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == pendingTransferIndex {
+			return ErrTransferPending
+		}
+		r.logger.Error("Failed to create ticket transfer",
+			zap.String("ticket_id", transfer.TicketID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to create ticket transfer: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a ticket transfer by ID
+func (r *TicketTransferRepository) GetByID(ctx context.Context, id string) (*models.TicketTransfer, error) {
+	query := `SELECT * FROM ticket_transfers WHERE id = $1`
+
+	var transfer models.TicketTransfer
+	err := r.db.GetContext(ctx, &transfer, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %s", ErrTransferNotFound, id)
+		}
+		r.logger.Error("Failed to get ticket transfer by ID",
+			zap.String("transfer_id", id),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to get ticket transfer: %w", err)
+	}
+
+	return &transfer, nil
+}
+
+// Close ends a pending transfer without moving the ticket, as cancelled or expired.
+// It fails with ErrTransferNotPending when the transfer is not pending anymore.
+func (r *TicketTransferRepository) Close(ctx context.Context, id, status, reason string) error {
+	query := `
+		UPDATE ticket_transfers SET
+			status = $2, cancelled_at = CURRENT_TIMESTAMP,
+			cancelled_reason = NULLIF($3, ''), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending'
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, status, reason)
+	if err != nil {
+		r.logger.Error("Failed to close ticket transfer",
+			zap.String("transfer_id", id),
+			zap.String("status", status),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to close ticket transfer: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrTransferNotPending
+	}
+
+	return nil
+}
+
+// SetPaymentReference records the recipient's payment of a pending resale that has not
+// succeeded yet, so it is given back if the transfer is closed before it settles. It fails
+// with ErrTransferNotPending when the transfer is not pending anymore.
+func (r *TicketTransferRepository) SetPaymentReference(ctx context.Context, id, paymentReference string) error {
+	query := `
+		UPDATE ticket_transfers SET
+			payment_reference = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending'
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, paymentReference)
+	if err != nil {
+		r.logger.Error("Failed to record ticket transfer payment",
+			zap.String("transfer_id", id),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to record ticket transfer payment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrTransferNotPending
+	}
+
+	return nil
+}
+
+// Complete accepts a pending transfer in one transaction: the ticket moves to the
+// recipient with its new codes and payment, the transfer is marked accepted and the
+// change is appended to the ownership audit trail, together with events. It fails with
+// ErrTicketOwnerChanged when the ticket no longer belongs to the sender or is no longer
+// confirmed, and with ErrTransferNotPending when the transfer was closed meanwhile.
+func (r *TicketTransferRepository) Complete(ctx context.Context, transfer *models.TicketTransfer, ticket *models.Ticket, change *models.TicketOwnershipChange, events ...*models.OutboxEvent) error {
+	lockQuery := `SELECT user_id, status, qr_code FROM tickets WHERE id = $1 FOR UPDATE`
+
+	ticketQuery := `
+		UPDATE tickets SET
+			user_id = $2, qr_code = $3, barcode = $4,
+			payment_method = $5, payment_reference = $6,
+			updated_by = NULLIF($7, '')::uuid, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $1
+	`
+
+	transferQuery := `
+		UPDATE ticket_transfers SET
+			status = 'accepted', accepted_at = CURRENT_TIMESTAMP,
+			payment_reference = $2, refund_reference = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending'
+	`
+
+	historyQuery := `
+		INSERT INTO ticket_ownership_history (
+			id, ticket_id, transfer_id, from_user_id, to_user_id, change_type,
+			price, currency, payment_reference, refund_reference, previous_qr_code, changed_by
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+		)
+	`
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current struct {
+		UserID string  `db:"user_id"`
+		Status string  `db:"status"`
+		QRCode *string `db:"qr_code"`
+	}
+	if err := tx.GetContext(ctx, &current, lockQuery, ticket.ID); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("ticket not found: %s", ticket.ID)
+		}
+		return fmt.Errorf("failed to lock ticket: %w", err)
+	}
+	if current.UserID != transfer.FromUserID || current.Status != models.TicketStatusConfirmed {
+		return ErrTicketOwnerChanged
+	}
+
+	updatedBy := ""
+	if change.ChangedBy != nil {
+		updatedBy = *change.ChangedBy
+	}
+
+	_, err = tx.ExecContext(ctx, ticketQuery,
+		ticket.ID, ticket.UserID, ticket.QRCode, ticket.Barcode,
+		ticket.PaymentMethod, ticket.PaymentReference, updatedBy,
+	)
+	if err != nil {
+		r.logger.Error("Failed to transfer ticket",
+			zap.String("ticket_id", ticket.ID),
+			zap.String("transfer_id", transfer.ID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to transfer ticket: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, transferQuery, transfer.ID, transfer.PaymentReference, transfer.RefundReference)
+	if err != nil {
+		return fmt.Errorf("failed to accept ticket transfer: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrTransferNotPending
+	}
+
+	// Generate UUID if not provided
+	if change.ID == "" {
+		change.ID = uuid.New().String()
+	}
+	change.PreviousQRCode = current.QRCode
+
+	_, err = tx.ExecContext(ctx, historyQuery,
+		change.ID, change.TicketID, change.TransferID, change.FromUserID, change.ToUserID,
+		change.ChangeType, change.Price, change.Currency, change.PaymentReference,
+		change.RefundReference, change.PreviousQRCode, change.ChangedBy,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record ticket ownership change: %w", err)
+	}
+
+	if err := insertOutboxEvents(ctx, tx, events); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit ticket transfer: %w", err)
+	}
+
+	r.logger.Info("Ticket transferred",
+		zap.String("ticket_id", ticket.ID),
+		zap.String("transfer_id", transfer.ID),
+		zap.String("from_user_id", transfer.FromUserID),
+		zap.String("to_user_id", transfer.ToUserID),
+	)
+
+	return nil
+}
+
+// GetOwnershipHistory retrieves the ownership audit trail of a ticket, oldest first
+func (r *TicketTransferRepository) GetOwnershipHistory(ctx context.Context, ticketID string) ([]*models.TicketOwnershipChange, error) {
+	query := `
+		SELECT * FROM ticket_ownership_history
+		WHERE ticket_id = $1
+		ORDER BY created_at ASC
+	`
+
+	var changes []*models.TicketOwnershipChange
+	if err := r.db.SelectContext(ctx, &changes, query, ticketID); err != nil {
+		r.logger.Error("Failed to get ticket ownership history",
+			zap.String("ticket_id", ticketID),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to get ticket ownership history: %w", err)
+	}
+
+	return changes, nil
+}
+
+// GetEventPolicy retrieves the transfer policy of an event, or the default policy when
+// the organizer has not set one
+func (r *TicketTransferRepository) GetEventPolicy(ctx context.Context, eventID string) (*models.EventTransferPolicy, error) {
+	query := `SELECT * FROM event_transfer_policies WHERE event_id = $1`
+
+	var policy models.EventTransferPolicy
+	err := r.db.GetContext(ctx, &policy, query, eventID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.DefaultEventTransferPolicy(eventID), nil
+		}
+		r.logger.Error("Failed to get event transfer policy",
+			zap.String("event_id", eventID),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to get event transfer policy: %w", err)
+	}
+
+	return &policy, nil
+}
+
+// UpsertEventPolicy creates or replaces the transfer policy of an event
+func (r *TicketTransferRepository) UpsertEventPolicy(ctx context.Context, policy *models.EventTransferPolicy) error {
+	query := `
+		INSERT INTO event_transfer_policies (event_id, transfers_enabled, resale_enabled, updated_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (event_id) DO UPDATE SET
+			transfers_enabled = EXCLUDED.transfers_enabled,
+			resale_enabled = EXCLUDED.resale_enabled,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING created_at, updated_at
+	`
+
+	err := r.db.QueryRowxContext(ctx, query,
+		policy.EventID, policy.TransfersEnabled, policy.ResaleEnabled, policy.UpdatedBy,
+	).Scan(&policy.CreatedAt, &policy.UpdatedAt)
+	if err != nil {
+		r.logger.Error("Failed to save event transfer policy",
+			zap.String("event_id", policy.EventID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to save event transfer policy: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,338 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	eventpb "ticket-service/internal/protos/event"
+)
+
+// ErrNotEnoughSeats is returned when fewer seats are on sale than requested, or none
+// together when the seats have to be next to each other
+var ErrNotEnoughSeats = errors.New("not enough seats available")
+
+// bestAvailableAttempts bounds how often seats are picked again when some of those picked
+// were taken between reading availability and holding them
+const bestAvailableAttempts = 3
+
+// seatAvailable is the Event Service status of a seat on sale
+const seatAvailable = "available"
+
+// seatLayoutTTL bounds how long an event's seat layout is reused to pick seats. Layouts
+// rarely change once seats are on sale; availability is read afresh every time.
+const seatLayoutTTL = 5 * time.Minute
+
+// BestAvailableRequest asks for the best seats on sale to be picked and held for a
+// booking session
+type BestAvailableRequest struct {
+	BookingSessionID string `json:"booking_session_id"`
+	EventID          string `json:"event_id"`
+	Quantity         int    `json:"quantity"`
+	ZoneID           string `json:"zone_id,omitempty"`          // Any zone when empty
+	PricingCategory  string `json:"pricing_category,omitempty"` // Any category when empty
+	// Contiguous only accepts seats next to each other in one row; otherwise they are
+	// preferred but the best seats apart are taken when no row has enough together
+	Contiguous     bool   `json:"contiguous"`
+	TimeoutMinutes int    `json:"timeout_minutes,omitempty"`
+	CreatedBy      string `json:"created_by,omitempty"`
+}
+
+// BestAvailableReservation is the outcome of a best available reservation
+type BestAvailableReservation struct {
+	BulkReservation
+	Contiguous bool `json:"contiguous"` // Whether the seats are next to each other in one row
+}
+
+// availableSeat is a seat on sale and its number in the row
+type availableSeat struct {
+	seat   *eventpb.EventSeatFull
+	number int
+}
+
+// seatRow is the seats on sale in a row of a zone, by seat number
+type seatRow struct {
+	zoneID string
+	label  string
+	seats  []availableSeat
+	first  int // Lowest and highest seat number in the row, sold seats included
+	last   int
+}
+
+// seatLayoutCache keeps the seat layouts read from Event Service by event and zone, so
+// picking seats reads only their availability
+type seatLayoutCache struct {
+	mu      sync.Mutex
+	layouts map[string]cachedSeatLayout
+}
+
+// cachedSeatLayout is a seat layout and when it has to be read again
+type cachedSeatLayout struct {
+	seats     []*eventpb.EventSeatFull
+	expiresAt time.Time
+}
+
+func newSeatLayoutCache() *seatLayoutCache {
+	return &seatLayoutCache{layouts: make(map[string]cachedSeatLayout)}
+}
+
+// get returns the layout cached under key unless it expired
+func (c *seatLayoutCache) get(key string) ([]*eventpb.EventSeatFull, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	layout, ok := c.layouts[key]
+	if !ok || time.Now().After(layout.expiresAt) {
+		return nil, false
+	}
+	return layout.seats, true
+}
+
+// put caches a layout under key for seatLayoutTTL, dropping the layouts that expired
+func (c *seatLayoutCache) put(key string, seats []*eventpb.EventSeatFull) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for cachedKey, layout := range c.layouts {
+		if now.After(layout.expiresAt) {
+			delete(c.layouts, cachedKey)
+		}
+	}
+	c.layouts[key] = cachedSeatLayout{seats: seats, expiresAt: now.Add(seatLayoutTTL)}
+}
+
+// centre is the middle of the row
+func (r *seatRow) centre() float64 {
+	return float64(r.first+r.last) / 2
+}
+
+// bestBlock returns the quantity seats next to each other closest to the middle of the
+// row, or nil if the row has no such block on sale
+func (r *seatRow) bestBlock(quantity int) []availableSeat {
+	var best []availableSeat
+	bestDistance := math.Inf(1)
+	runStart := 0
+	for i := range r.seats {
+		if i > 0 && r.seats[i].number != r.seats[i-1].number+1 {
+			runStart = i
+		}
+		if i-runStart+1 < quantity {
+			continue
+		}
+		block := r.seats[i-quantity+1 : i+1]
+		middle := float64(block[0].number+block[quantity-1].number) / 2
+		if distance := math.Abs(middle - r.centre()); distance < bestDistance {
+			best, bestDistance = block, distance
+		}
+	}
+	return best
+}
+
+// ReserveBestAvailable picks the best seats on sale for a booking session and holds them
+// all or nothing, like ReserveSeatsBulk. Front rows come first and, within a row, seats
+// closer to the middle; seats together in one row are preferred over better seats apart.
+// Seats taken before they could be held are left out and the seats picked again.
+func (s *ReservationService) ReserveBestAvailable(ctx context.Context, req *BestAvailableRequest) (*BestAvailableReservation, error) {
+	if req.BookingSessionID == "" {
+		return nil, fmt.Errorf("invalid request: booking_session_id is required")
+	}
+	if req.EventID == "" {
+		return nil, fmt.Errorf("invalid request: event_id is required")
+	}
+	if req.Quantity <= 0 || req.Quantity > maxBulkSeats {
+		return nil, fmt.Errorf("invalid request: quantity must be between 1 and %d", maxBulkSeats)
+	}
+	if s.eventClient == nil {
+		return nil, fmt.Errorf("event service client is not configured")
+	}
+
+	taken := make(map[string]bool)
+	for attempt := 1; ; attempt++ {
+		rows, err := s.availableRows(ctx, req, taken)
+		if err != nil {
+			return nil, err
+		}
+
+		picked, contiguous, err := pickBestSeats(rows, req.Quantity, req.Contiguous)
+		if err != nil {
+			return nil, err
+		}
+
+		seats := make([]BulkSeat, len(picked))
+		for i, pick := range picked {
+			seats[i] = BulkSeat{
+				SeatID:          pick.seat.Id,
+				ZoneID:          pick.seat.ZoneId,
+				PricingCategory: pick.seat.PricingCategory,
+				BasePrice:       pick.seat.BasePrice,
+				FinalPrice:      pick.seat.FinalPrice,
+				Currency:        pick.seat.Currency,
+			}
+		}
+
+		reservation, err := s.ReserveSeatsBulk(ctx, &ReserveSeatsBulkRequest{
+			BookingSessionID: req.BookingSessionID,
+			EventID:          req.EventID,
+			Seats:            seats,
+			TimeoutMinutes:   req.TimeoutMinutes,
+			CreatedBy:        req.CreatedBy,
+		})
+
+		var unavailable *SeatsUnavailableError
+		if errors.As(err, &unavailable) && attempt < bestAvailableAttempts {
+			for _, failure := range unavailable.Failures {
+				taken[failure.SeatID] = true
+			}
+			s.logger.Info("Best available seats were taken, picking again",
+				zap.String("booking_session_id", req.BookingSessionID),
+				zap.Int("taken", len(unavailable.Failures)),
+				zap.Int("attempt", attempt),
+			)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		s.logger.Info("Best available seats reserved",
+			zap.String("booking_session_id", req.BookingSessionID),
+			zap.String("event_id", req.EventID),
+			zap.Int("quantity", req.Quantity),
+			zap.Bool("contiguous", contiguous),
+		)
+
+		return &BestAvailableReservation{BulkReservation: *reservation, Contiguous: contiguous}, nil
+	}
+}
+
+// seatLayout returns the seats of an event's layout, or of one zone when zoneID is set,
+// reading them from Event Service when they are not cached
+func (s *ReservationService) seatLayout(ctx context.Context, eventID, zoneID string) ([]*eventpb.EventSeatFull, error) {
+	key := eventID + "/" + zoneID
+	if layout, ok := s.layouts.get(key); ok {
+		return layout, nil
+	}
+
+	layout, err := s.eventClient.ListSeats(ctx, eventID, zoneID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seats of event: %w", err)
+	}
+	s.layouts.put(key, layout)
+	return layout, nil
+}
+
+// availableRows reads the event's layout and availability and returns the rows with seats
+// on sale matching the request, front rows first. The layout is cached, availability is
+// read from Event Service every time. Seats without a seat number cannot be placed in
+// their row and are left out.
+func (s *ReservationService) availableRows(ctx context.Context, req *BestAvailableRequest, taken map[string]bool) ([]*seatRow, error) {
+	layout, err := s.seatLayout(ctx, req.EventID, req.ZoneID)
+	if err != nil {
+		return nil, err
+	}
+	availability, err := s.eventClient.GetAvailability(ctx, req.EventID, req.ZoneID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seat availability: %w", err)
+	}
+
+	onSale := make(map[string]bool, len(availability))
+	for _, seat := range availability {
+		if seat.AvailabilityStatus == seatAvailable {
+			onSale[seat.SeatId] = true
+		}
+	}
+
+	var rows []*seatRow
+	rowsByKey := make(map[string]*seatRow)
+	for _, seat := range layout {
+		number, ok := seatNumber(seat.SeatNumber)
+		if !ok {
+			continue
+		}
+
+		key := seat.ZoneId + "/" + seat.RowNumber
+		row, ok := rowsByKey[key]
+		if !ok {
+			row = &seatRow{zoneID: seat.ZoneId, label: seat.RowNumber, first: number, last: number}
+			rowsByKey[key] = row
+			rows = append(rows, row)
+		}
+		row.first = min(row.first, number)
+		row.last = max(row.last, number)
+
+		if !onSale[seat.Id] || taken[seat.Id] || (seat.Status != "" && seat.Status != seatAvailable) {
+			continue
+		}
+		if req.PricingCategory != "" && !strings.EqualFold(seat.PricingCategory, req.PricingCategory) {
+			continue
+		}
+		row.seats = append(row.seats, availableSeat{seat: seat, number: number})
+	}
+
+	available := rows[:0]
+	for _, row := range rows {
+		if len(row.seats) == 0 {
+			continue
+		}
+		sort.Slice(row.seats, func(i, j int) bool { return row.seats[i].number < row.seats[j].number })
+		available = append(available, row)
+	}
+	sort.SliceStable(available, func(i, j int) bool {
+		if available[i].label != available[j].label {
+			return rowLess(available[i].label, available[j].label)
+		}
+		return available[i].zoneID < available[j].zoneID
+	})
+
+	return available, nil
+}
+
+// pickBestSeats picks quantity seats from rows, front rows first: the first row with
+// enough seats together, otherwise, unless they have to be together, the seats closest to
+// the middle of the front rows. It reports whether the seats picked are together.
+func pickBestSeats(rows []*seatRow, quantity int, contiguous bool) ([]availableSeat, bool, error) {
+	for _, row := range rows {
+		if block := row.bestBlock(quantity); block != nil {
+			return block, true, nil
+		}
+	}
+	if contiguous {
+		return nil, false, fmt.Errorf("%w: no %d seats together in one row", ErrNotEnoughSeats, quantity)
+	}
+
+	var picked []availableSeat
+	for _, row := range rows {
+		seats := append([]availableSeat(nil), row.seats...)
+		sort.SliceStable(seats, func(i, j int) bool {
+			return math.Abs(float64(seats[i].number)-row.centre()) < math.Abs(float64(seats[j].number)-row.centre())
+		})
+		for _, seat := range seats {
+			picked = append(picked, seat)
+			if len(picked) == quantity {
+				return picked, false, nil
+			}
+		}
+	}
+
+	return nil, false, fmt.Errorf("%w: %d of %d seats available", ErrNotEnoughSeats, len(picked), quantity)
+}
+
+// rowLess orders row labels front to back: numerically when both are numbers, so row 2
+// comes before row 10, and alphabetically otherwise
+func rowLess(a, b string) bool {
+	x, errX := strconv.Atoi(a)
+	y, errY := strconv.Atoi(b)
+	if errX == nil && errY == nil {
+		return x < y
+	}
+	return a < b
+}
@@ -0,0 +1,806 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"ticket-service/config"
+	"ticket-service/database"
+	"ticket-service/grpcclient"
+	paymentpb "ticket-service/internal/protos/payment"
+	realtimepb "ticket-service/internal/protos/realtime"
+	"ticket-service/metrics"
+	"ticket-service/models"
+	"ticket-service/repositories"
+)
+
+var (
+	// ErrBookingFailed is returned when a booking could not be completed and was rolled back
+	ErrBookingFailed = errors.New("booking failed")
+	// ErrBookingInProgress is returned when a booking session is changed while it is being completed
+	ErrBookingInProgress = errors.New("booking session is being completed")
+
+	// errSagaAborted marks a step failure that retrying cannot fix, such as a declined payment
+	errSagaAborted = errors.New("booking aborted")
+	// errSagaRetryLater means the saga was handed to the resume loop to retry a failed step
+	errSagaRetryLater = errors.New("booking saga step will be retried")
+)
+
+// sagaCompensationReason is recorded on everything a rolled back booking releases or cancels
+const sagaCompensationReason = "Booking failed"
+
+// sagaRun is what the steps of one saga work on
+type sagaRun struct {
+	saga         *models.BookingSaga
+	session      *models.BookingSession
+	reservations []*models.SeatReservation
+}
+
+// sagaStep is one step of the booking saga and the action that undoes it. Both must be
+// idempotent: after a restart a step or its compensation may run again.
+type sagaStep struct {
+	name       string
+	action     func(ctx context.Context, run *sagaRun) error
+	compensate func(ctx context.Context, run *sagaRun) error
+}
+
+// BookingSagaOrchestrator completes booking sessions with a persistent saga: hold, pay,
+// confirm seats, issue tickets and notify. Progress is saved after every step so an
+// instance that restarts, or another instance once the lease runs out, resumes the saga
+// where it stopped. A step that fails for good compensates the steps before it in reverse.
+type BookingSagaOrchestrator struct {
+	sagaRepo        *repositories.BookingSagaRepository
+	bookingRepo     *repositories.BookingSessionRepository
+	reservationRepo *repositories.SeatReservationRepository
+	ticketRepo      *repositories.TicketRepository
+	eventClient     *grpcclient.EventServiceClient
+	paymentClient   *grpcclient.PaymentServiceClient
+	realtimeClient  *grpcclient.RealtimeServiceClient
+	codeIssuer      *TicketCodeIssuer
+	holder          *seatHolder
+	config          config.SagaConfig
+	owner           string
+	steps           []sagaStep
+	logger          *zap.Logger
+	ctx             context.Context
+	cancel          context.CancelFunc
+}
+
+// NewBookingSagaOrchestrator creates a new booking saga orchestrator
+func NewBookingSagaOrchestrator(
+	sagaRepo *repositories.BookingSagaRepository,
+	bookingRepo *repositories.BookingSessionRepository,
+	reservationRepo *repositories.SeatReservationRepository,
+	ticketRepo *repositories.TicketRepository,
+	eventClient *grpcclient.EventServiceClient,
+	paymentClient *grpcclient.PaymentServiceClient,
+	realtimeClient *grpcclient.RealtimeServiceClient,
+	codeIssuer *TicketCodeIssuer,
+	cfg config.SagaConfig,
+	logger *zap.Logger,
+) *BookingSagaOrchestrator {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	hostname, _ := os.Hostname()
+	o := &BookingSagaOrchestrator{
+		sagaRepo:        sagaRepo,
+		bookingRepo:     bookingRepo,
+		reservationRepo: reservationRepo,
+		ticketRepo:      ticketRepo,
+		eventClient:     eventClient,
+		paymentClient:   paymentClient,
+		realtimeClient:  realtimeClient,
+		codeIssuer:      codeIssuer,
+		holder: &seatHolder{
+			reservationRepo: reservationRepo,
+			eventClient:     eventClient,
+			logger:          logger,
+		},
+		config: cfg,
+		owner:  fmt.Sprintf("%s-%s", hostname, uuid.New().String()[:8]),
+		logger: logger,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	o.steps = []sagaStep{
+		{name: models.BookingSagaStepHold, action: o.holdSeats, compensate: o.releaseSeats},
+		{name: models.BookingSagaStepPay, action: o.pay, compensate: o.refund},
+		{name: models.BookingSagaStepConfirmSeats, action: o.confirmSeats, compensate: o.unconfirmSeats},
+		{name: models.BookingSagaStepIssueTickets, action: o.issueTickets, compensate: o.cancelTickets},
+		// Notifying is best effort and the last step, so there is nothing to undo
+		{name: models.BookingSagaStepNotify, action: o.notify},
+	}
+
+	return o
+}
+
+// Start resumes in-flight sagas now and then every ResumeInterval until Stop is called
+func (o *BookingSagaOrchestrator) Start() {
+	o.logger.Info("Starting booking saga resumer",
+		zap.String("owner", o.owner),
+		zap.Duration("interval", o.config.ResumeInterval),
+	)
+
+	o.resumeOnce()
+
+	ticker := time.NewTicker(o.config.ResumeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-o.ctx.Done():
+			o.logger.Info("Booking saga resumer stopping")
+			return
+		case <-ticker.C:
+			o.resumeOnce()
+		}
+	}
+}
+
+// Stop stops the resume loop
+func (o *BookingSagaOrchestrator) Stop() {
+	o.cancel()
+}
+
+// Complete starts the saga that completes a booking session and drives it as far as it
+// gets now. Calling it again for the same session returns, or resumes, the existing saga.
+// The returned saga is still running when a step failed and waits to be retried.
+func (o *BookingSagaOrchestrator) Complete(ctx context.Context, cmd *BookingSessionCompleteCommand) (*models.BookingSaga, error) {
+	saga, err := o.sagaRepo.GetByBookingSessionID(ctx, cmd.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if saga == nil {
+		session, err := o.bookingRepo.GetByID(ctx, cmd.SessionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get booking session: %w", err)
+		}
+		if !session.IsActive() {
+			return nil, fmt.Errorf("booking session is not active, status: %s", session.Status)
+		}
+
+		saga = models.NewBookingSaga(cmd.SessionID, cmd.PaymentMethod, cmd.CompletedBy)
+		err = o.sagaRepo.Create(ctx, saga, o.owner, o.config.LeaseDuration)
+		if err == nil {
+			return saga, o.driveDetached(ctx, saga)
+		}
+		if !errors.Is(err, repositories.ErrBookingSagaExists) {
+			return nil, err
+		}
+
+		// A concurrent request created it first
+		if saga, err = o.sagaRepo.GetByBookingSessionID(ctx, cmd.SessionID); err != nil {
+			return nil, err
+		}
+		if saga == nil {
+			return nil, fmt.Errorf("booking saga not found for session %s", cmd.SessionID)
+		}
+	}
+
+	if !saga.IsInFlight() {
+		return saga, nil
+	}
+
+	claimed, err := o.sagaRepo.Claim(ctx, saga.ID, o.owner, o.config.LeaseDuration)
+	if err != nil {
+		return nil, err
+	}
+	if claimed == nil {
+		// Someone is driving it, or a failed step waits for its retry
+		return saga, nil
+	}
+
+	return claimed, o.driveDetached(ctx, claimed)
+}
+
+// driveDetached drives a saga for a request. It keeps going if the caller goes away, as a
+// saga dropped mid-step would wait for its lease to run out.
+func (o *BookingSagaOrchestrator) driveDetached(ctx context.Context, saga *models.BookingSaga) error {
+	if err := o.drive(context.WithoutCancel(ctx), saga); err != nil && !errors.Is(err, errSagaRetryLater) {
+		return err
+	}
+	return nil
+}
+
+// InFlight reports whether the booking session is being completed by a saga
+func (o *BookingSagaOrchestrator) InFlight(ctx context.Context, sessionID string) (bool, error) {
+	return o.sagaRepo.HasInFlight(ctx, sessionID)
+}
+
+// IssuedTickets returns the tickets issued for a booking session
+func (o *BookingSagaOrchestrator) IssuedTickets(ctx context.Context, sessionID string) ([]*models.Ticket, error) {
+	return o.ticketRepo.GetByBookingSessionID(ctx, sessionID)
+}
+
+// resumeOnce claims sagas whose lease ran out and drives them
+func (o *BookingSagaOrchestrator) resumeOnce() {
+	sagas, err := o.sagaRepo.ClaimResumable(o.ctx, o.owner, o.config.LeaseDuration, o.config.ResumeBatchSize)
+	if err != nil {
+		o.logger.Warn("Failed to claim booking sagas to resume", zap.Error(err))
+		return
+	}
+
+	for _, saga := range sagas {
+		o.logger.Info("Resuming booking saga",
+			zap.String("saga_id", saga.ID),
+			zap.String("session_id", saga.BookingSessionID),
+			zap.String("status", saga.Status),
+			zap.String("step", saga.CurrentStep),
+		)
+
+		if err := o.drive(o.ctx, saga); err != nil && !errors.Is(err, errSagaRetryLater) {
+			o.logger.Warn("Failed to resume booking saga",
+				zap.String("saga_id", saga.ID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// drive runs the saga's remaining steps, or compensations, until it finishes or a step has to wait
+func (o *BookingSagaOrchestrator) drive(ctx context.Context, saga *models.BookingSaga) error {
+	run, err := o.load(ctx, saga)
+	if err != nil {
+		return err
+	}
+
+	for saga.IsInFlight() {
+		if saga.Status == models.BookingSagaStatusRunning {
+			err = o.advance(ctx, run)
+		} else {
+			err = o.compensateStep(ctx, run)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	o.finish(ctx, run)
+	return nil
+}
+
+// advance runs the current step. A transient failure hands the saga to the resume loop;
+// a permanent one, or running out of attempts, switches it to compensating.
+func (o *BookingSagaOrchestrator) advance(ctx context.Context, run *sagaRun) error {
+	saga := run.saga
+	idx := o.stepIndex(saga.CurrentStep)
+	if idx < 0 {
+		saga.Status = models.BookingSagaStatusCompleted
+		return o.save(ctx, saga, o.config.LeaseDuration)
+	}
+	step := o.steps[idx]
+
+	stepCtx, cancel := context.WithTimeout(ctx, o.stepTimeout())
+	err := step.action(stepCtx, run)
+	cancel()
+
+	if err == nil {
+		saga.CurrentStep = o.stepAfter(idx)
+		saga.Attempts = 0
+		saga.LastError = nil
+		return o.save(ctx, saga, o.config.LeaseDuration)
+	}
+
+	// A pending payment is polled without using up attempts, PaymentTimeout bounds it
+	if errors.Is(err, errPaymentPending) {
+		o.logger.Info("Booking saga waiting for payment",
+			zap.String("saga_id", saga.ID),
+			zap.Error(err),
+		)
+		if err := o.save(ctx, saga, o.config.RetryDelay); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %s: %v", errSagaRetryLater, step.name, err)
+	}
+
+	saga.Attempts++
+	lastError := err.Error()
+	saga.LastError = &lastError
+
+	o.logger.Warn("Booking saga step failed",
+		zap.String("saga_id", saga.ID),
+		zap.String("step", step.name),
+		zap.Int("attempts", saga.Attempts),
+		zap.Error(err),
+	)
+
+	if !isPermanentSagaError(err) && saga.Attempts < o.config.MaxAttempts {
+		if err := o.save(ctx, saga, o.config.RetryDelay); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %s: %v", errSagaRetryLater, step.name, err)
+	}
+
+	saga.Status = models.BookingSagaStatusCompensating
+	saga.FailedStep = &step.name
+	saga.Attempts = 0
+	return o.save(ctx, saga, o.config.LeaseDuration)
+}
+
+// compensateStep undoes the current step and moves back to the one before it. Compensations
+// have to succeed eventually, so a failed one is retried by the resume loop without limit.
+func (o *BookingSagaOrchestrator) compensateStep(ctx context.Context, run *sagaRun) error {
+	saga := run.saga
+	idx := o.stepIndex(saga.CurrentStep)
+	if idx < 0 {
+		idx = len(o.steps) - 1
+	}
+	step := o.steps[idx]
+
+	if step.compensate != nil {
+		stepCtx, cancel := context.WithTimeout(ctx, o.stepTimeout())
+		err := step.compensate(stepCtx, run)
+		cancel()
+
+		if err != nil {
+			saga.Attempts++
+			o.logger.Error("Booking saga compensation failed",
+				zap.String("saga_id", saga.ID),
+				zap.String("step", step.name),
+				zap.Int("attempts", saga.Attempts),
+				zap.Error(err),
+			)
+			if err := o.save(ctx, saga, o.config.RetryDelay); err != nil {
+				return err
+			}
+			return fmt.Errorf("%w: compensate %s: %v", errSagaRetryLater, step.name, err)
+		}
+	}
+
+	saga.Attempts = 0
+	if idx == 0 {
+		saga.Status = models.BookingSagaStatusCompensated
+	} else {
+		saga.CurrentStep = o.steps[idx-1].name
+	}
+	return o.save(ctx, saga, o.config.LeaseDuration)
+}
+
+// finish records the outcome of a saga that ended during this run
+func (o *BookingSagaOrchestrator) finish(ctx context.Context, run *sagaRun) {
+	saga := run.saga
+	failedStep := ""
+	if saga.FailedStep != nil {
+		failedStep = *saga.FailedStep
+	}
+	metrics.IncrementBookingSagaFinished(saga.Status, failedStep)
+
+	if saga.Status == models.BookingSagaStatusCompleted {
+		o.logger.Info("Booking saga completed",
+			zap.String("saga_id", saga.ID),
+			zap.String("session_id", saga.BookingSessionID),
+		)
+		return
+	}
+
+	o.logger.Warn("Booking saga compensated",
+		zap.String("saga_id", saga.ID),
+		zap.String("session_id", saga.BookingSessionID),
+		zap.String("failed_step", failedStep),
+	)
+
+	message := sagaCompensationReason
+	if saga.LastError != nil {
+		message = fmt.Sprintf("%s: %s", sagaCompensationReason, *saga.LastError)
+	}
+	o.notifyResult(ctx, run, false, message)
+}
+
+// load fetches what the steps of a saga work on
+func (o *BookingSagaOrchestrator) load(ctx context.Context, saga *models.BookingSaga) (*sagaRun, error) {
+	session, err := o.bookingRepo.GetByID(ctx, saga.BookingSessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking session: %w", err)
+	}
+
+	reservations, err := o.reservationRepo.GetByBookingSessionID(ctx, saga.BookingSessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seat reservations: %w", err)
+	}
+
+	return &sagaRun{saga: saga, session: session, reservations: reservations}, nil
+}
+
+// save persists saga progress and keeps the lease for lease, or hands the saga to the
+// resume loop once lease passes
+func (o *BookingSagaOrchestrator) save(ctx context.Context, saga *models.BookingSaga, lease time.Duration) error {
+	if err := o.sagaRepo.Save(ctx, saga, o.owner, lease); err != nil {
+		return fmt.Errorf("failed to save booking saga %s: %w", saga.ID, err)
+	}
+	return nil
+}
+
+// stepTimeout bounds a step well within the lease so the saga is not resumed while it runs
+func (o *BookingSagaOrchestrator) stepTimeout() time.Duration {
+	return o.config.LeaseDuration / 2
+}
+
+func (o *BookingSagaOrchestrator) stepIndex(name string) int {
+	for i, step := range o.steps {
+		if step.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (o *BookingSagaOrchestrator) stepAfter(idx int) string {
+	if idx+1 < len(o.steps) {
+		return o.steps[idx+1].name
+	}
+	return models.BookingSagaStepDone
+}
+
+// Steps
+
+// holdSeats pins the session's seat holds for the rest of the saga: the reservations and
+// the Event Service blocks are extended by HoldExtension
+func (o *BookingSagaOrchestrator) holdSeats(ctx context.Context, run *sagaRun) error {
+	session := run.session
+	if !session.IsActive() {
+		return fmt.Errorf("%w: booking session is not active, status: %s", errSagaAborted, session.Status)
+	}
+
+	held := reservationsWithStatus(run.reservations, models.ReservationStatusReserved)
+	if len(held) == 0 {
+		return fmt.Errorf("%w: no seats reserved in session", errSagaAborted)
+	}
+
+	holdUntil := time.Now().Add(o.config.HoldExtension)
+	extended, err := o.reservationRepo.ExtendHolds(ctx, session.ID, holdUntil)
+	if err != nil {
+		return err
+	}
+	if int(extended) != len(held) {
+		return fmt.Errorf("%w: %d of %d seat holds expired", errSagaAborted, len(held)-int(extended), len(held))
+	}
+
+	blockedReason := fmt.Sprintf("Completing booking session %s for user %s", session.ID, session.UserID)
+	return o.holder.blockSeats(ctx, session.EventID, seatIDsOf(held), blockedReason, holdUntil)
+}
+
+// releaseSeats undoes the hold: reservations and seat blocks are released and the session
+// is cancelled with booking.failed
+func (o *BookingSagaOrchestrator) releaseSeats(ctx context.Context, run *sagaRun) error {
+	session := run.session
+	actor := sagaActor(run.saga)
+
+	// Only seats this session still held are unblocked: a lapsed hold's seat may be
+	// someone else's by now. Blocks left behind by a retry after the release committed
+	// lapse on their own once the extended hold runs out.
+	released, err := o.reservationRepo.ReleaseByBookingSession(ctx, session.ID, sagaCompensationReason, actor)
+	if err != nil {
+		return err
+	}
+
+	if o.eventClient != nil && len(released) > 0 {
+		if _, err := o.eventClient.ReleaseSeats(ctx, session.EventID, released); err != nil {
+			return fmt.Errorf("failed to release seats in Event Service: %w", err)
+		}
+	}
+	for range released {
+		metrics.IncrementSeatReservationReleased(session.EventID, sagaCompensationReason)
+	}
+
+	if session.IsCancelled() {
+		return nil
+	}
+
+	payload := bookingEventPayload(session, models.BookingEventStatusFailed, seatIDsOf(run.reservations))
+	if run.saga.LastError != nil {
+		payload.FailureReason = *run.saga.LastError
+	}
+	event, err := newBookingEvent(models.EventTypeBookingFailed, payload)
+	if err != nil {
+		return err
+	}
+
+	if err := o.bookingRepo.Cancel(ctx, session.ID, sagaCompensationReason, actor, event); err != nil {
+		return err
+	}
+	session.Status = models.BookingSessionStatusCancelled
+	return nil
+}
+
+// pay charges the session total. The saga ID is the idempotency key, so a retry after a
+// lost response or a restart returns the payment made the first time. The payment is
+// recorded on the saga as soon as it exists: one still pending is polled by the resume
+// loop until it succeeds, fails or PaymentTimeout passes, and cancelled if the booking fails.
+func (o *BookingSagaOrchestrator) pay(ctx context.Context, run *sagaRun) error {
+	saga := run.saga
+	session := run.session
+	if session.TotalAmount <= 0 || o.paymentClient == nil {
+		return nil
+	}
+
+	paymentMethod := ""
+	if saga.PaymentMethod != nil {
+		paymentMethod = *saga.PaymentMethod
+	}
+
+	var payment *paymentpb.Payment
+	var err error
+	if saga.PaymentID != nil {
+		payment, err = getPayment(ctx, o.paymentClient, *saga.PaymentID)
+	} else {
+		payment, err = chargePayment(ctx, o.paymentClient, session.EventID, &paymentpb.CreatePaymentRequest{
+			BookingId:      session.ID,
+			Amount:         session.TotalAmount,
+			Currency:       session.Currency,
+			PaymentMethod:  paymentMethod,
+			UserId:         session.UserID,
+			IdempotencyKey: "booking-saga-" + saga.ID,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	paymentID := payment.PaymentId
+	saga.PaymentID = &paymentID
+
+	err = paymentSettled(payment)
+	switch {
+	case errors.Is(err, errPaymentPending):
+		if time.Since(saga.CreatedAt) > o.config.PaymentTimeout {
+			return fmt.Errorf("%w: %v after %s", errSagaAborted, err, o.config.PaymentTimeout)
+		}
+		return err
+	case err != nil:
+		return fmt.Errorf("%w: %v", errSagaAborted, err)
+	}
+	return nil
+}
+
+// refund gives the payment back: a payment still in progress is cancelled, a settled one refunded
+func (o *BookingSagaOrchestrator) refund(ctx context.Context, run *sagaRun) error {
+	saga := run.saga
+	if saga.PaymentID == nil || o.paymentClient == nil {
+		return nil
+	}
+
+	return givePaymentBack(ctx, o.paymentClient, *saga.PaymentID, sagaCompensationReason, "booking-saga-refund-"+saga.ID)
+}
+
+// confirmSeats confirms the session's reservations and marks their seats sold in Event Service.
+// The saga aborts unless every seat the session was charged for is confirmed.
+func (o *BookingSagaOrchestrator) confirmSeats(ctx context.Context, run *sagaRun) error {
+	session := run.session
+	if _, err := o.reservationRepo.ConfirmByBookingSession(ctx, session.ID, sagaActor(run.saga)); err != nil {
+		return err
+	}
+
+	reservations, err := o.reservationRepo.GetByBookingSessionID(ctx, session.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get seat reservations: %w", err)
+	}
+	run.reservations = reservations
+
+	// The payment covered the session's seats; if any hold was lost on the way, the booking
+	// is rolled back and the payment given back rather than issuing fewer tickets than paid for.
+	// The reloaded rows are counted, not the update's, so a retry reaches the same verdict.
+	confirmed := reservationsWithStatus(reservations, models.ReservationStatusConfirmed)
+	count, total := sessionTotals(confirmed)
+	if count == 0 || count != session.SeatCount || total != session.TotalAmount {
+		return fmt.Errorf("%w: %d of %d seat reservations confirmed", errSagaAborted, count, session.SeatCount)
+	}
+
+	if o.eventClient != nil {
+		for _, reservation := range confirmed {
+			if err := o.eventClient.UpdateSeatAvailability(ctx, reservation.EventID, reservation.SeatID, "sold", reservation.ID); err != nil {
+				return fmt.Errorf("failed to mark seat %s sold: %w", reservation.SeatID, err)
+			}
+		}
+	}
+
+	for _, reservation := range confirmed {
+		metrics.IncrementSeatReservationConfirmed(reservation.EventID, reservation.ZoneID)
+	}
+	return nil
+}
+
+// unconfirmSeats puts sold seats back on sale and releases the confirmed reservations
+func (o *BookingSagaOrchestrator) unconfirmSeats(ctx context.Context, run *sagaRun) error {
+	confirmed := reservationsWithStatus(run.reservations, models.ReservationStatusConfirmed)
+
+	if o.eventClient != nil {
+		for _, reservation := range confirmed {
+			if err := o.eventClient.UpdateSeatAvailability(ctx, reservation.EventID, reservation.SeatID, "available", ""); err != nil {
+				return fmt.Errorf("failed to mark seat %s available: %w", reservation.SeatID, err)
+			}
+		}
+	}
+
+	return o.reservationRepo.ReleaseConfirmedByBookingSession(ctx, run.session.ID, sagaCompensationReason)
+}
+
+// issueTickets turns every confirmed reservation into a ticket, with its codes, and
+// completes the session and writes booking.confirmed in the same transaction
+func (o *BookingSagaOrchestrator) issueTickets(ctx context.Context, run *sagaRun) error {
+	saga := run.saga
+	session := run.session
+
+	paymentStatus, err := o.ticketPaymentStatus(ctx, run)
+	if err != nil {
+		return err
+	}
+
+	// Tickets are valid from issuance until the event ends
+	validUntil := o.codeIssuer.eventValidUntil(ctx, session.EventID)
+
+	confirmed := reservationsWithStatus(run.reservations, models.ReservationStatusConfirmed)
+	tickets := make([]*models.Ticket, 0, len(confirmed))
+	for _, reservation := range confirmed {
+		ticket := models.NewTicket(
+			reservation.EventID, reservation.SeatID, reservation.ZoneID, session.UserID,
+			database.GenerateTicketNumber(reservation.EventID, reservation.SeatID),
+			reservation.PricingCategory, reservation.BasePrice, reservation.FinalPrice, reservation.Currency,
+		)
+		ticket.ID = uuid.New().String()
+		ticket.BookingSessionID = &session.ID
+		ticket.Status = models.TicketStatusConfirmed
+		ticket.PaymentMethod = saga.PaymentMethod
+		ticket.PaymentReference = saga.PaymentID
+		ticket.PaymentStatus = paymentStatus
+		ticket.CreatedBy = saga.CompletedBy
+		ticket.ValidUntil = validUntil
+		if err := o.codeIssuer.assign(ticket); err != nil {
+			return err
+		}
+
+		tickets = append(tickets, ticket)
+	}
+
+	payload := bookingEventPayload(session, models.BookingEventStatusConfirmed, seatIDsOf(confirmed))
+	payload.TicketIDs = make([]string, len(tickets))
+	for i, ticket := range tickets {
+		payload.TicketIDs[i] = ticket.ID
+	}
+	if saga.PaymentID != nil {
+		payload.PaymentReference = *saga.PaymentID
+	}
+	payload.ConfirmedAt = payload.Timestamp
+	event, err := newBookingEvent(models.EventTypeBookingConfirmed, payload)
+	if err != nil {
+		return err
+	}
+
+	issued, err := o.ticketRepo.IssueForBookingSession(ctx, session.ID, sagaActor(saga), tickets, event)
+	if err != nil {
+		return err
+	}
+
+	// A retry after the transaction committed issues nothing new and completes nothing
+	if issued > 0 {
+		for _, ticket := range tickets {
+			metrics.IncrementTicketCreated(ticket.EventID, ticket.TicketType, ticket.Status)
+		}
+	}
+	if !session.IsCompleted() {
+		metrics.IncrementBookingSessionCompleted(session.EventID)
+	}
+	session.Status = models.BookingSessionStatusCompleted
+	return nil
+}
+
+// ticketPaymentStatus is the payment status the session's tickets are issued with: paid
+// once Payment Service reports the payment succeeded, or when there was nothing to pay,
+// and pending otherwise
+func (o *BookingSagaOrchestrator) ticketPaymentStatus(ctx context.Context, run *sagaRun) (string, error) {
+	if run.session.TotalAmount <= 0 {
+		return models.PaymentStatusPaid, nil
+	}
+	if run.saga.PaymentID == nil || o.paymentClient == nil {
+		return models.PaymentStatusPending, nil
+	}
+
+	payment, err := getPayment(ctx, o.paymentClient, *run.saga.PaymentID)
+	if err != nil {
+		return "", err
+	}
+	if paymentSettled(payment) != nil {
+		return models.PaymentStatusPending, nil
+	}
+	return models.PaymentStatusPaid, nil
+}
+
+// cancelTickets cancels the tickets issued for the session
+func (o *BookingSagaOrchestrator) cancelTickets(ctx context.Context, run *sagaRun) error {
+	cancelled, err := o.ticketRepo.CancelByBookingSession(ctx, run.session.ID, sagaCompensationReason)
+	if err != nil {
+		return err
+	}
+
+	for i := int64(0); i < cancelled; i++ {
+		metrics.IncrementTicketCancelled(run.session.EventID, sagaCompensationReason)
+	}
+	return nil
+}
+
+// notify tells the user their booking is confirmed. It never fails the saga: the booking
+// is complete at this point and the user can still look it up.
+func (o *BookingSagaOrchestrator) notify(ctx context.Context, run *sagaRun) error {
+	o.notifyResult(ctx, run, true, "Booking confirmed")
+	return nil
+}
+
+// notifyResult pushes the booking outcome to the user, logging failures
+func (o *BookingSagaOrchestrator) notifyResult(ctx context.Context, run *sagaRun, success bool, message string) {
+	if o.realtimeClient == nil {
+		return
+	}
+
+	session := run.session
+	req := &realtimepb.NotifyBookingResultRequest{
+		UserId:      session.UserID,
+		BookingId:   session.ID,
+		Success:     success,
+		Message:     message,
+		EventId:     session.EventID,
+		SeatNumbers: seatIDsOf(run.reservations),
+		TotalAmount: fmt.Sprintf("%.2f", session.TotalAmount),
+		Currency:    session.Currency,
+	}
+	if run.saga.PaymentID != nil {
+		req.BookingReference = *run.saga.PaymentID
+	}
+
+	if err := o.realtimeClient.NotifyBookingResult(ctx, req); err != nil {
+		o.logger.Warn("Failed to notify booking result",
+			zap.String("session_id", session.ID),
+			zap.Bool("success", success),
+			zap.Error(err),
+		)
+	}
+}
+
+// Helper functions
+
+// isPermanentSagaError reports whether retrying the failed step cannot help
+func isPermanentSagaError(err error) bool {
+	if errors.Is(err, errSagaAborted) || errors.Is(err, ErrSeatUnavailable) {
+		return true
+	}
+
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.PermissionDenied, codes.NotFound:
+		return true
+	}
+	return false
+}
+
+// sagaActor is the user recorded as making the changes a saga does on their behalf
+func sagaActor(saga *models.BookingSaga) string {
+	if saga.CompletedBy == nil {
+		return ""
+	}
+	return *saga.CompletedBy
+}
+
+func reservationsWithStatus(reservations []*models.SeatReservation, reservationStatus string) []*models.SeatReservation {
+	matched := make([]*models.SeatReservation, 0, len(reservations))
+	for _, reservation := range reservations {
+		if reservation.Status == reservationStatus {
+			matched = append(matched, reservation)
+		}
+	}
+	return matched
+}
+
+func seatIDsOf(reservations []*models.SeatReservation) []string {
+	seatIDs := make([]string, len(reservations))
+	for i, reservation := range reservations {
+		seatIDs[i] = reservation.SeatID
+	}
+	return seatIDs
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"ticket-service/config"
+	"ticket-service/grpcclient"
+	paymentpb "ticket-service/internal/protos/payment"
+	"ticket-service/models"
+)
+
+// fakePaymentService is an in-memory Payment Service that records the calls the saga makes
+type fakePaymentService struct {
+	paymentpb.UnimplementedPaymentServiceServer
+
+	mu        sync.Mutex
+	payments  map[string]*paymentpb.Payment
+	created   []*paymentpb.CreatePaymentRequest
+	cancelled []string
+	refunds   []*paymentpb.CreateRefundRequest
+}
+
+func (f *fakePaymentService) CreatePayment(_ context.Context, req *paymentpb.CreatePaymentRequest) (*paymentpb.PaymentResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.created = append(f.created, req)
+	return &paymentpb.PaymentResponse{Payment: f.payments[req.IdempotencyKey]}, nil
+}
+
+func (f *fakePaymentService) GetPayment(_ context.Context, req *paymentpb.GetPaymentRequest) (*paymentpb.PaymentResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, payment := range f.payments {
+		if payment.PaymentId == req.PaymentId {
+			return &paymentpb.PaymentResponse{Payment: payment}, nil
+		}
+	}
+	return nil, status.Error(codes.NotFound, "payment not found")
+}
+
+func (f *fakePaymentService) CancelPayment(_ context.Context, req *paymentpb.CancelPaymentRequest) (*paymentpb.PaymentResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.cancelled = append(f.cancelled, req.PaymentId)
+	return &paymentpb.PaymentResponse{}, nil
+}
+
+func (f *fakePaymentService) CreateRefund(_ context.Context, req *paymentpb.CreateRefundRequest) (*paymentpb.RefundResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.refunds = append(f.refunds, req)
+	return &paymentpb.RefundResponse{Refund: &paymentpb.Refund{PaymentId: req.PaymentId, Amount: req.Amount}}, nil
+}
+
+func (f *fakePaymentService) ListRefunds(_ context.Context, req *paymentpb.ListRefundsRequest) (*paymentpb.ListRefundsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var refunds []*paymentpb.Refund
+	for _, refund := range f.refunds {
+		if refund.PaymentId == req.PaymentId {
+			refunds = append(refunds, &paymentpb.Refund{PaymentId: refund.PaymentId, Amount: refund.Amount, Metadata: refund.Metadata})
+		}
+	}
+	return &paymentpb.ListRefundsResponse{Refunds: refunds}, nil
+}
+
+// newTestPaymentClient serves payments and returns a Payment Service client talking to it
+func newTestPaymentClient(t *testing.T, payments *fakePaymentService) *grpcclient.PaymentServiceClient {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := grpc.NewServer()
+	paymentpb.RegisterPaymentServiceServer(server, payments)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+	paymentClient, err := grpcclient.NewPaymentServiceClient(config.PaymentServiceConfig{Host: "127.0.0.1", Port: port}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("payment client: %v", err)
+	}
+	t.Cleanup(func() { paymentClient.Close() })
+	return paymentClient
+}
+
+// newTestSaga returns an orchestrator whose Payment Service client talks to payments, and
+// a saga with its session to run the payment steps on
+func newTestSaga(t *testing.T, payments *fakePaymentService) (*BookingSagaOrchestrator, *sagaRun) {
+	t.Helper()
+
+	orchestrator := &BookingSagaOrchestrator{
+		paymentClient: newTestPaymentClient(t, payments),
+		config:        config.SagaConfig{PaymentTimeout: 15 * time.Minute},
+		logger:        zap.NewNop(),
+	}
+
+	session := models.NewBookingSession("user-1", "event-1", "token-1", 2, 120, "USD", time.Now().Add(time.Hour))
+	session.ID = "session-1"
+	saga := models.NewBookingSaga(session.ID, "card", "user-1")
+	saga.ID = "saga-1"
+	saga.CreatedAt = time.Now()
+
+	return orchestrator, &sagaRun{saga: saga, session: session}
+}
+
+func TestSagaPay(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    string
+		createdAt time.Duration // how long ago the saga started
+		wantErr   error
+	}{
+		{name: "success", status: "success"},
+		{name: "pending is polled", status: "pending", wantErr: errPaymentPending},
+		{name: "processing is polled", status: "PROCESSING", wantErr: errPaymentPending},
+		{name: "pending past the payment timeout aborts", status: "pending", createdAt: 20 * time.Minute, wantErr: errSagaAborted},
+		{name: "failed aborts", status: "failed", wantErr: errSagaAborted},
+		{name: "cancelled aborts", status: "cancelled", wantErr: errSagaAborted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payments := &fakePaymentService{payments: map[string]*paymentpb.Payment{
+				"booking-saga-saga-1": {PaymentId: "payment-1", Amount: 120, Status: tt.status},
+			}}
+			orchestrator, run := newTestSaga(t, payments)
+			run.saga.CreatedAt = time.Now().Add(-tt.createdAt)
+
+			err := orchestrator.pay(context.Background(), run)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("pay: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("pay error = %v, want %v", err, tt.wantErr)
+			}
+
+			// The payment is recorded whatever its status, so compensation can give it back
+			if run.saga.PaymentID == nil || *run.saga.PaymentID != "payment-1" {
+				t.Errorf("saga payment ID = %v, want payment-1", run.saga.PaymentID)
+			}
+		})
+	}
+}
+
+func TestSagaPayPollsRecordedPayment(t *testing.T) {
+	payments := &fakePaymentService{payments: map[string]*paymentpb.Payment{
+		"booking-saga-saga-1": {PaymentId: "payment-1", Amount: 120, Status: "success"},
+	}}
+	orchestrator, run := newTestSaga(t, payments)
+	paymentID := "payment-1"
+	run.saga.PaymentID = &paymentID
+
+	if err := orchestrator.pay(context.Background(), run); err != nil {
+		t.Fatalf("pay: %v", err)
+	}
+	if len(payments.created) != 0 {
+		t.Errorf("pay created %d payments for a saga that has one", len(payments.created))
+	}
+}
+
+func TestSagaRefund(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		wantCancel bool
+		wantRefund bool
+	}{
+		{name: "pending is cancelled", status: "pending", wantCancel: true},
+		{name: "processing is cancelled", status: "processing", wantCancel: true},
+		{name: "success is refunded", status: "success", wantRefund: true},
+		{name: "failed has nothing to give back", status: "failed"},
+		{name: "cancelled has nothing to give back", status: "cancelled"},
+		{name: "refunded has nothing to give back", status: "refunded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payments := &fakePaymentService{payments: map[string]*paymentpb.Payment{
+				"booking-saga-saga-1": {PaymentId: "payment-1", Amount: 120, Status: tt.status},
+			}}
+			orchestrator, run := newTestSaga(t, payments)
+			paymentID := "payment-1"
+			run.saga.PaymentID = &paymentID
+
+			if err := orchestrator.refund(context.Background(), run); err != nil {
+				t.Fatalf("refund: %v", err)
+			}
+
+			if cancelled := len(payments.cancelled) == 1; cancelled != tt.wantCancel {
+				t.Errorf("payment cancelled = %v, want %v", cancelled, tt.wantCancel)
+			}
+			if refunded := len(payments.refunds) == 1; refunded != tt.wantRefund {
+				t.Fatalf("payment refunded = %v, want %v", refunded, tt.wantRefund)
+			}
+			if tt.wantRefund {
+				refund := payments.refunds[0]
+				if refund.Amount != 120 || refund.RefundType != "full" {
+					t.Errorf("refund = %v %s, want 120 full", refund.Amount, refund.RefundType)
+				}
+				if refund.IdempotencyKey != "booking-saga-refund-saga-1" {
+					t.Errorf("refund idempotency key = %q, want the saga's", refund.IdempotencyKey)
+				}
+			}
+		})
+	}
+}
+
+func TestSagaRefundWithoutPayment(t *testing.T) {
+	payments := &fakePaymentService{payments: map[string]*paymentpb.Payment{}}
+	orchestrator, run := newTestSaga(t, payments)
+
+	if err := orchestrator.refund(context.Background(), run); err != nil {
+		t.Fatalf("refund: %v", err)
+	}
+	if len(payments.cancelled) != 0 || len(payments.refunds) != 0 {
+		t.Error("refund gave back a payment the saga never made")
+	}
+}
+
+func TestSagaTicketPaymentStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		totalAmount float64
+		status      string
+		noPayment   bool
+		want        string
+	}{
+		{name: "paid", totalAmount: 120, status: "success", want: models.PaymentStatusPaid},
+		{name: "pending payment is not paid", totalAmount: 120, status: "pending", want: models.PaymentStatusPending},
+		{name: "processing payment is not paid", totalAmount: 120, status: "processing", want: models.PaymentStatusPending},
+		{name: "no payment recorded", totalAmount: 120, noPayment: true, want: models.PaymentStatusPending},
+		{name: "nothing to pay", totalAmount: 0, noPayment: true, want: models.PaymentStatusPaid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payments := &fakePaymentService{payments: map[string]*paymentpb.Payment{
+				"booking-saga-saga-1": {PaymentId: "payment-1", Amount: tt.totalAmount, Status: tt.status},
+			}}
+			orchestrator, run := newTestSaga(t, payments)
+			run.session.TotalAmount = tt.totalAmount
+			if !tt.noPayment {
+				paymentID := "payment-1"
+				run.saga.PaymentID = &paymentID
+			}
+
+			got, err := orchestrator.ticketPaymentStatus(context.Background(), run)
+			if err != nil {
+				t.Fatalf("ticketPaymentStatus: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ticket payment status = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPermanentSagaError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"aborted", errSagaAborted, true},
+		{"seat unavailable", ErrSeatUnavailable, true},
+		{"invalid argument", status.Error(codes.InvalidArgument, "bad request"), true},
+		{"not found", status.Error(codes.NotFound, "no payment"), true},
+		{"pending payment", errPaymentPending, false},
+		{"unavailable", status.Error(codes.Unavailable, "connection refused"), false},
+		{"deadline exceeded", context.DeadlineExceeded, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPermanentSagaError(tt.err); got != tt.want {
+				t.Errorf("isPermanentSagaError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
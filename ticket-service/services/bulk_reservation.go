@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"ticket-service/metrics"
+	"ticket-service/models"
+)
+
+// maxBulkSeats caps the seats reserved or released by one bulk call
+const maxBulkSeats = 50
+
+// Reasons a seat of a bulk reservation could not be held
+const (
+	SeatFailureInvalid     = "invalid"
+	SeatFailureDuplicate   = "duplicate"
+	SeatFailureAlreadyHeld = "already_held"
+	SeatFailureUnavailable = "unavailable"
+	SeatFailureNotHeld     = "not_held"
+)
+
+// SeatFailure is why a seat of a bulk call failed
+type SeatFailure struct {
+	SeatID        string `json:"seat_id,omitempty"`
+	ReservationID string `json:"reservation_id,omitempty"`
+	Reason        string `json:"reason"`
+	Message       string `json:"message"`
+}
+
+// SeatsUnavailableError is returned when a bulk reservation held no seats because some of
+// them could not be held. It matches ErrSeatUnavailable.
+type SeatsUnavailableError struct {
+	Failures []SeatFailure
+}
+
+func (e *SeatsUnavailableError) Error() string {
+	return fmt.Sprintf("%s: %d seats cannot be held", ErrSeatUnavailable, len(e.Failures))
+}
+
+// Is reports whether target is ErrSeatUnavailable
+func (e *SeatsUnavailableError) Is(target error) bool {
+	return target == ErrSeatUnavailable
+}
+
+// BulkSeat is a seat of a bulk reservation
+type BulkSeat struct {
+	SeatID          string  `json:"seat_id"`
+	ZoneID          string  `json:"zone_id"`
+	PricingCategory string  `json:"pricing_category"`
+	BasePrice       float64 `json:"base_price"`
+	FinalPrice      float64 `json:"final_price"`
+	Currency        string  `json:"currency"`
+}
+
+// ReserveSeatsBulkRequest asks for several seats to be held for a booking session at once
+type ReserveSeatsBulkRequest struct {
+	BookingSessionID string     `json:"booking_session_id"`
+	EventID          string     `json:"event_id"`
+	Seats            []BulkSeat `json:"seats"`
+	// TimeoutMinutes shortens the holds; they never outlast the booking session
+	TimeoutMinutes int    `json:"timeout_minutes,omitempty"`
+	CreatedBy      string `json:"created_by,omitempty"`
+}
+
+// ReleaseSeatsBulkRequest asks for several reservations to be released at once
+type ReleaseSeatsBulkRequest struct {
+	ReservationIDs []string `json:"reservation_ids"`
+	Reason         string   `json:"reason"`
+	ReleasedBy     string   `json:"released_by"`
+}
+
+// BulkReservation is the outcome of a bulk reservation
+type BulkReservation struct {
+	Reservations []*models.SeatReservation `json:"reservations"`
+	TotalAmount  float64                   `json:"total_amount"`
+}
+
+// BulkRelease is the outcome of a bulk release
+type BulkRelease struct {
+	Released []*models.SeatReservation `json:"released"`
+	Failures []SeatFailure             `json:"failures"`
+}
+
+// ReserveSeatsBulk holds several seats for a booking session, all or nothing: the
+// reservations are created in one transaction and the seats blocked in one Event Service
+// call. If any seat cannot be held none is, and the *SeatsUnavailableError returned says
+// why for every seat that failed.
+func (s *ReservationService) ReserveSeatsBulk(ctx context.Context, req *ReserveSeatsBulkRequest) (*BulkReservation, error) {
+	if req.BookingSessionID == "" {
+		return nil, fmt.Errorf("invalid request: booking_session_id is required")
+	}
+	if req.EventID == "" {
+		return nil, fmt.Errorf("invalid request: event_id is required")
+	}
+	if len(req.Seats) == 0 || len(req.Seats) > maxBulkSeats {
+		return nil, fmt.Errorf("invalid request: between 1 and %d seats are required", maxBulkSeats)
+	}
+
+	session, err := s.bookingRepo.GetByID(ctx, req.BookingSessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking session: %w", err)
+	}
+	if session.EventID != req.EventID {
+		return nil, fmt.Errorf("invalid request: booking session is for another event")
+	}
+
+	expiresAt := session.ExpiresAt
+	if req.TimeoutMinutes > 0 {
+		if timeout := time.Now().Add(time.Duration(req.TimeoutMinutes) * time.Minute); timeout.Before(expiresAt) {
+			expiresAt = timeout
+		}
+	}
+
+	reservations := make([]*models.SeatReservation, 0, len(req.Seats))
+	var failures []SeatFailure
+	seen := make(map[string]bool, len(req.Seats))
+	for _, seat := range req.Seats {
+		if seen[seat.SeatID] {
+			failures = append(failures, SeatFailure{SeatID: seat.SeatID, Reason: SeatFailureDuplicate, Message: "seat is requested more than once"})
+			continue
+		}
+		seen[seat.SeatID] = true
+
+		reservation := models.NewSeatReservation(
+			session.ID, req.EventID, seat.SeatID, seat.ZoneID,
+			s.generateReservationToken(session.ID, seat.SeatID),
+			seat.PricingCategory, seat.BasePrice, seat.FinalPrice, seat.Currency,
+			expiresAt,
+		)
+		if req.CreatedBy != "" {
+			reservation.CreatedBy = &req.CreatedBy
+		}
+		if err := reservation.Validate(); err != nil {
+			failures = append(failures, SeatFailure{SeatID: seat.SeatID, Reason: SeatFailureInvalid, Message: err.Error()})
+			continue
+		}
+		reservations = append(reservations, reservation)
+	}
+	if len(failures) > 0 {
+		return nil, &SeatsUnavailableError{Failures: failures}
+	}
+
+	blockedReason := fmt.Sprintf("Booking session %s for user %s", session.ID, session.UserID)
+	if err := s.holder.holdAll(ctx, session.ID, req.EventID, reservations, blockedReason, expiresAt); err != nil {
+		return nil, err
+	}
+
+	// The seats are not part of the session without the totals, so give them back
+	session, err = refreshSessionTotals(ctx, s.bookingRepo, s.reservationRepo, session.ID)
+	if err != nil {
+		s.holder.releaseHolds(ctx, reservations, "Session update failed", true)
+		return nil, err
+	}
+
+	result := &BulkReservation{Reservations: reservations}
+	for _, reservation := range reservations {
+		result.TotalAmount += reservation.FinalPrice
+		metrics.IncrementSeatReservationCreated(reservation.EventID, reservation.ZoneID)
+	}
+
+	s.logger.Info("Seats reserved in bulk",
+		zap.String("booking_session_id", session.ID),
+		zap.Int("seats", len(reservations)),
+		zap.Int("seat_count", session.SeatCount),
+		zap.Float64("total_amount", session.TotalAmount),
+	)
+
+	return result, nil
+}
+
+// ReleaseSeatsBulk releases several reservations at once and gives their seats back in
+// Event Service. Reservations that are not held are reported as failures; the others are
+// released regardless.
+func (s *ReservationService) ReleaseSeatsBulk(ctx context.Context, req *ReleaseSeatsBulkRequest) (*BulkRelease, error) {
+	if len(req.ReservationIDs) == 0 || len(req.ReservationIDs) > maxBulkSeats {
+		return nil, fmt.Errorf("invalid request: between 1 and %d reservation_ids are required", maxBulkSeats)
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "Released in bulk"
+	}
+
+	released, err := s.reservationRepo.ReleaseReservations(ctx, req.ReservationIDs, reason, req.ReleasedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BulkRelease{Released: released}
+	releasedIDs := make(map[string]bool, len(released))
+	for _, reservation := range released {
+		releasedIDs[reservation.ID] = true
+	}
+	for _, id := range req.ReservationIDs {
+		if !releasedIDs[id] {
+			result.Failures = append(result.Failures, SeatFailure{ReservationID: id, Reason: SeatFailureNotHeld, Message: "reservation is not held or is being completed"})
+		}
+	}
+
+	releaseSeatsOf(ctx, s.eventClient, s.realtimeClient, s.logger, released, reason)
+
+	var sessionIDs []string
+	sessions := make(map[string]bool)
+	for _, reservation := range released {
+		if !sessions[reservation.BookingSessionID] {
+			sessions[reservation.BookingSessionID] = true
+			sessionIDs = append(sessionIDs, reservation.BookingSessionID)
+		}
+	}
+
+	for _, sessionID := range sessionIDs {
+		if _, err := refreshSessionTotals(ctx, s.bookingRepo, s.reservationRepo, sessionID); err != nil {
+			s.logger.Warn("Failed to update booking session totals after bulk release",
+				zap.String("booking_session_id", sessionID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	s.logger.Info("Seats released in bulk",
+		zap.Int("released", len(released)),
+		zap.Int("failed", len(result.Failures)),
+		zap.String("released_by", req.ReleasedBy),
+	)
+
+	return result, nil
+}
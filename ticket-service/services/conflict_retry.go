@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ticket-service/repositories"
+)
+
+// conflictRetries bounds how often an update that lost a race to a concurrent update is
+// retried, and conflictRetryDelay is the wait before the first retry, doubled per retry
+const (
+	conflictRetries    = 5
+	conflictRetryDelay = 10 * time.Millisecond
+)
+
+// retryOnConflict runs update until it does not fail with a version conflict, up to
+// conflictRetries times. update has to read the rows it changes again on every run.
+func retryOnConflict(ctx context.Context, update func() error) error {
+	delay := conflictRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := update()
+		if !errors.Is(err, repositories.ErrConflict) || attempt == conflictRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"ticket-service/models"
+)
+
+// bookingEventPayload builds the payload of a booking event for a session and the seats it booked
+func bookingEventPayload(session *models.BookingSession, status string, seatIDs []string) *models.BookingEventPayload {
+	paymentStatus := models.PaymentStatusPending
+	switch status {
+	case models.BookingEventStatusConfirmed:
+		paymentStatus = models.PaymentStatusPaid
+	case models.BookingEventStatusFailed:
+		paymentStatus = models.PaymentStatusFailed
+	}
+
+	return &models.BookingEventPayload{
+		BookingID:        session.ID,
+		BookingReference: bookingReference(session.ID),
+		UserID:           session.UserID,
+		EventID:          session.EventID,
+		Status:           status,
+		PaymentStatus:    strings.ToUpper(paymentStatus),
+		TotalAmount:      session.TotalAmount,
+		Currency:         session.Currency,
+		SeatCount:        len(seatIDs),
+		SeatNumbers:      seatIDs,
+		Timestamp:        time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// newBookingEvent wraps a booking event payload in an outbox event
+func newBookingEvent(eventType string, payload *models.BookingEventPayload) (*models.OutboxEvent, error) {
+	return models.NewOutboxEvent(models.AggregateTypeBooking, payload.BookingID, eventType, payload)
+}
+
+// newTicketEvent builds a ticket event outbox event from the ticket's state after the change
+func newTicketEvent(eventType string, ticket *models.Ticket, reason string) (*models.OutboxEvent, error) {
+	return models.NewOutboxEvent(models.AggregateTypeTicket, ticket.ID, eventType, ticketEventPayload(ticket, reason))
+}
+
+// newTicketTransferredEvent builds the ticket.transferred outbox event of a ticket that
+// moved from previousUserID to its current owner
+func newTicketTransferredEvent(ticket *models.Ticket, previousUserID, transferType string) (*models.OutboxEvent, error) {
+	payload := ticketEventPayload(ticket, transferType)
+	payload.PreviousUserID = previousUserID
+	return models.NewOutboxEvent(models.AggregateTypeTicket, ticket.ID, models.EventTypeTicketTransferred, payload)
+}
+
+// ticketEventPayload builds the payload of a ticket event from the ticket's state
+func ticketEventPayload(ticket *models.Ticket, reason string) *models.TicketEventPayload {
+	payload := &models.TicketEventPayload{
+		TicketID:      ticket.ID,
+		TicketNumber:  ticket.TicketNumber,
+		UserID:        ticket.UserID,
+		EventID:       ticket.EventID,
+		SeatID:        ticket.SeatID,
+		Status:        ticket.Status,
+		PaymentStatus: ticket.PaymentStatus,
+		FinalPrice:    ticket.FinalPrice,
+		Currency:      ticket.Currency,
+		Reason:        reason,
+		Timestamp:     time.Now().UTC().Format(time.RFC3339),
+	}
+	if ticket.BookingSessionID != nil {
+		payload.BookingID = *ticket.BookingSessionID
+	}
+	if ticket.PaymentReference != nil {
+		payload.PaymentReference = *ticket.PaymentReference
+	}
+	if ticket.RefundedAmount != nil {
+		payload.RefundedAmount = *ticket.RefundedAmount
+	}
+
+	return payload
+}
+
+// bookingReference is the short, human readable reference of a booking session
+func bookingReference(sessionID string) string {
+	reference := strings.ReplaceAll(sessionID, "-", "")
+	if len(reference) > 8 {
+		reference = reference[:8]
+	}
+	return "BKG-" + strings.ToUpper(reference)
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"ticket-service/config"
+	"ticket-service/metrics"
+	"ticket-service/repositories"
+)
+
+// leadScript makes the caller leader, or keeps it leader, for the lease TTL: a lapsed
+// lease is taken over, the leader's own lease is renewed, anyone else's is left alone.
+//
+// KEYS: leader key. ARGV: owner, TTL (ms).
+var leadScript = redis.NewScript(`
+local holder = redis.call('GET', KEYS[1])
+if holder == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+if not holder then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// resignScript deletes the leader key if the caller still holds it
+var resignScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// ExpirySweeper expires lapsed booking sessions and seat holds in the background, so their
+// seats go back on sale within seconds instead of when someone calls the cleanup RPCs.
+// Every instance runs it, but only the one holding the leader lease in Redis sweeps; if
+// the leader goes away, another instance takes over once its lease lapses.
+type ExpirySweeper struct {
+	bookingService     *TicketBookingSessionService
+	reservationService *ReservationService
+	redis              *redis.Client
+	config             config.SweeperConfig
+	owner              string
+	leader             bool
+	logger             *zap.Logger
+	ctx                context.Context
+	cancel             context.CancelFunc
+	done               chan struct{}
+}
+
+// NewExpirySweeper creates a new expiry sweeper
+func NewExpirySweeper(
+	bookingService *TicketBookingSessionService,
+	reservationService *ReservationService,
+	redisClient *redis.Client,
+	cfg config.SweeperConfig,
+	logger *zap.Logger,
+) *ExpirySweeper {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	hostname, _ := os.Hostname()
+	return &ExpirySweeper{
+		bookingService:     bookingService,
+		reservationService: reservationService,
+		redis:              redisClient,
+		config:             cfg,
+		owner:              fmt.Sprintf("%s-%s", hostname, uuid.New().String()[:8]),
+		logger:             logger,
+		ctx:                ctx,
+		cancel:             cancel,
+		done:               make(chan struct{}),
+	}
+}
+
+// Start sweeps every Interval while this instance is leader, until Stop is called
+func (s *ExpirySweeper) Start() {
+	s.logger.Info("Starting expiry sweeper",
+		zap.String("owner", s.owner),
+		zap.Duration("interval", s.config.Interval),
+		zap.Int("batch_size", s.config.BatchSize),
+	)
+
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			s.resign()
+			s.logger.Info("Expiry sweeper stopping")
+			return
+		case <-ticker.C:
+			if s.lead() {
+				s.sweepOnce()
+			}
+		}
+	}
+}
+
+// Stop stops sweeping and gives up the leader lease, so another instance takes over
+// without waiting for it to lapse. It waits for the sweep in progress to stop, for a
+// bounded time.
+func (s *ExpirySweeper) Stop() {
+	s.cancel()
+
+	select {
+	case <-s.done:
+	case <-time.After(holdRollbackTimeout):
+		s.logger.Warn("Expiry sweeper did not stop in time")
+	}
+}
+
+// sweepOnce expires sessions first, which releases their holds with them, then the holds
+// left over. Full batches are followed by another batch while this instance is leader.
+// Sessions are passed over once per sweep, so those failing to expire are retried next
+// sweep without blocking the rest.
+func (s *ExpirySweeper) sweepOnce() {
+	var cursor *repositories.ExpiredSessionCursor
+	for {
+		_, next, err := s.bookingService.ExpireSessions(s.ctx, cursor, s.config.BatchSize)
+		if err != nil {
+			s.logger.Warn("Failed to expire booking sessions", zap.Error(err))
+			break
+		}
+		if next == nil || !s.lead() {
+			break
+		}
+		cursor = next
+	}
+
+	for {
+		reservations, err := s.reservationService.ExpireReservations(s.ctx, s.config.BatchSize)
+		if err != nil {
+			s.logger.Warn("Failed to expire seat reservations", zap.Error(err))
+			break
+		}
+		if reservations < s.config.BatchSize || !s.lead() {
+			break
+		}
+	}
+}
+
+// lead takes or renews the leader lease and reports whether this instance is leader
+func (s *ExpirySweeper) lead() bool {
+	led, err := leadScript.Run(s.ctx, s.redis,
+		[]string{s.config.LeaderKey}, s.owner, s.config.LeaderTTL.Milliseconds(),
+	).Int()
+	if err != nil {
+		if s.ctx.Err() == nil {
+			s.logger.Warn("Failed to renew expiry sweeper leadership", zap.Error(err))
+		}
+		// Without Redis no instance can tell who leads; this one stops until it can
+		led = 0
+	}
+
+	leader := led == 1
+	if leader != s.leader {
+		s.logger.Info("Expiry sweeper leadership changed",
+			zap.String("owner", s.owner),
+			zap.Bool("leader", leader),
+		)
+		metrics.SetExpirySweeperLeader(leader)
+	}
+	s.leader = leader
+	return leader
+}
+
+// resign gives up the leader lease if this instance holds it
+func (s *ExpirySweeper) resign() {
+	if !s.leader {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), holdRollbackTimeout)
+	defer cancel()
+
+	if err := resignScript.Run(ctx, s.redis, []string{s.config.LeaderKey}, s.owner).Err(); err != nil {
+		s.logger.Warn("Failed to give up expiry sweeper leadership", zap.Error(err))
+	}
+	s.leader = false
+	metrics.SetExpirySweeperLeader(false)
+}
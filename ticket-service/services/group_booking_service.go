@@ -0,0 +1,893 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"ticket-service/config"
+	"ticket-service/database"
+	"ticket-service/grpcclient"
+	paymentpb "ticket-service/internal/protos/payment"
+	"ticket-service/metrics"
+	"ticket-service/models"
+	"ticket-service/repositories"
+)
+
+var (
+	// ErrGroupBookingSession is returned when a group booking session is changed through
+	// the regular booking session calls
+	ErrGroupBookingSession = errors.New("booking session belongs to a group booking")
+	// ErrNotGroupOrganizer is returned when someone other than the organizer manages a group booking
+	ErrNotGroupOrganizer = errors.New("user is not the organizer of the group booking")
+	// ErrNotShareHolder is returned when a member pays for a seat assigned to someone else
+	ErrNotShareHolder = errors.New("group booking seat is assigned to another user")
+	// ErrGroupShareNotFound is returned when a group booking has no share with the given ID
+	ErrGroupShareNotFound = errors.New("group booking share not found")
+	// ErrSeatsNotAdjacent is returned when the seats of a group booking are not next to each other
+	ErrSeatsNotAdjacent = errors.New("group booking seats are not adjacent")
+	// ErrGroupSharePaymentFailed is returned when the payment of a share was declined or could not be made
+	ErrGroupSharePaymentFailed = errors.New("group booking share payment failed")
+	// ErrGroupSharePaymentPending is returned when the payment of a share was accepted but
+	// has not succeeded yet; paying again once it has marks the share paid
+	ErrGroupSharePaymentPending = errors.New("group booking share payment is still pending")
+)
+
+const (
+	// groupUnpaidReason is recorded on the seats released because their share was not paid in time
+	groupUnpaidReason = "Group booking share unpaid"
+	// groupCancelledReason is recorded when the organizer gives no reason for cancelling
+	groupCancelledReason = "Group booking cancelled"
+	// groupSeatLostReason is recorded on paid shares refunded because their seat hold ran out
+	groupSeatLostReason = "Group booking seat hold expired"
+	// groupClosedReason is recorded on payments given back because the group closed while they were made
+	groupClosedReason = "Group booking closed"
+)
+
+// GroupBookingService runs group bookings: an organizer holds adjacent seats in one
+// booking session, every member pays for their own seat, and when the last share is paid
+// or the payment deadline passes the group is settled. Settling releases the unpaid
+// seats and issues tickets for the paid ones to their members. A sweeper settles groups
+// past their deadline and resumes settlements an instance left unfinished.
+type GroupBookingService struct {
+	groupRepo       *repositories.GroupBookingRepository
+	bookingRepo     *repositories.BookingSessionRepository
+	reservationRepo *repositories.SeatReservationRepository
+	ticketRepo      *repositories.TicketRepository
+	bookingService  *TicketBookingSessionService
+	eventClient     *grpcclient.EventServiceClient
+	paymentClient   *grpcclient.PaymentServiceClient
+	codeIssuer      *TicketCodeIssuer
+	holder          *seatHolder
+	config          config.GroupBookingConfig
+	owner           string
+	logger          *zap.Logger
+	ctx             context.Context
+	cancel          context.CancelFunc
+}
+
+// NewGroupBookingService creates a new group booking service
+func NewGroupBookingService(
+	groupRepo *repositories.GroupBookingRepository,
+	bookingRepo *repositories.BookingSessionRepository,
+	reservationRepo *repositories.SeatReservationRepository,
+	ticketRepo *repositories.TicketRepository,
+	bookingService *TicketBookingSessionService,
+	eventClient *grpcclient.EventServiceClient,
+	paymentClient *grpcclient.PaymentServiceClient,
+	codeIssuer *TicketCodeIssuer,
+	cfg config.GroupBookingConfig,
+	logger *zap.Logger,
+) *GroupBookingService {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	hostname, _ := os.Hostname()
+	return &GroupBookingService{
+		groupRepo:       groupRepo,
+		bookingRepo:     bookingRepo,
+		reservationRepo: reservationRepo,
+		ticketRepo:      ticketRepo,
+		bookingService:  bookingService,
+		eventClient:     eventClient,
+		paymentClient:   paymentClient,
+		codeIssuer:      codeIssuer,
+		holder: &seatHolder{
+			reservationRepo: reservationRepo,
+			eventClient:     eventClient,
+			logger:          logger,
+		},
+		config: cfg,
+		owner:  fmt.Sprintf("%s-%s", hostname, uuid.New().String()[:8]),
+		logger: logger,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start settles due group bookings every SweepInterval until Stop is called
+func (s *GroupBookingService) Start() {
+	s.logger.Info("Starting group booking sweeper",
+		zap.String("owner", s.owner),
+		zap.Duration("interval", s.config.SweepInterval),
+	)
+
+	ticker := time.NewTicker(s.config.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			s.logger.Info("Group booking sweeper stopping")
+			return
+		case <-ticker.C:
+			s.sweepOnce()
+		}
+	}
+}
+
+// Stop stops the sweeper
+func (s *GroupBookingService) Stop() {
+	s.cancel()
+}
+
+// CreateGroupBooking starts a booking session for the organizer, holds the adjacent seats
+// until the payment deadline and opens a share for every seat. Seats without a member are
+// the organizer's to pay for until they invite someone.
+func (s *GroupBookingService) CreateGroupBooking(ctx context.Context, cmd *GroupBookingCreateCommand) (*models.GroupBooking, error) {
+	if err := s.validateCreateCommand(cmd); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	if err := s.checkAdjacent(ctx, cmd.EventID, cmd.Seats); err != nil {
+		return nil, err
+	}
+
+	window := s.config.DefaultPaymentWindow
+	if cmd.PaymentWindowMinutes > 0 {
+		window = time.Duration(cmd.PaymentWindowMinutes) * time.Minute
+	}
+	if window > s.config.MaxPaymentWindow {
+		window = s.config.MaxPaymentWindow
+	}
+
+	// The session expires at the payment deadline; the group settles it, not the session cleanup
+	session, err := s.bookingService.CreateBookingSession(ctx, &BookingSessionCreateCommand{
+		UserID:         cmd.OrganizerID,
+		EventID:        cmd.EventID,
+		Currency:       cmd.Currency,
+		TimeoutMinutes: int(window / time.Minute),
+		IPAddress:      cmd.IPAddress,
+		UserAgent:      cmd.UserAgent,
+		CreatedBy:      cmd.OrganizerID,
+		AdmissionToken: cmd.AdmissionToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Seats stay held past the deadline so the paid ones are still held while the group settles
+	holdUntil := session.ExpiresAt.Add(s.config.SettleGrace)
+	blockedReason := fmt.Sprintf("Group booking session %s for user %s", session.ID, cmd.OrganizerID)
+
+	group := models.NewGroupBooking(session.ID, cmd.EventID, cmd.OrganizerID, cmd.Currency, session.ExpiresAt)
+	for _, seat := range cmd.Seats {
+		reservation := models.NewSeatReservation(
+			session.ID, cmd.EventID, seat.SeatID, seat.ZoneID,
+			s.bookingService.generateReservationToken(session.ID, seat.SeatID),
+			seat.PricingCategory, seat.BasePrice, seat.FinalPrice, cmd.Currency,
+			holdUntil,
+		)
+		reservation.CreatedBy = &cmd.OrganizerID
+
+		if err := reservation.Validate(); err != nil {
+			s.abandon(ctx, session, "Group booking seat invalid")
+			return nil, fmt.Errorf("reservation validation failed: %w", err)
+		}
+
+		if err := s.holder.hold(ctx, reservation, blockedReason, holdUntil); err != nil {
+			s.abandon(ctx, session, "Group booking seat unavailable")
+			return nil, err
+		}
+
+		memberID := seat.UserID
+		if memberID == "" {
+			memberID = cmd.OrganizerID
+		}
+		group.Shares = append(group.Shares, models.NewGroupBookingShare(reservation, memberID))
+	}
+
+	if _, err := refreshSessionTotals(ctx, s.bookingRepo, s.reservationRepo, session.ID); err != nil {
+		s.abandon(ctx, session, "Session update failed")
+		return nil, err
+	}
+
+	if err := s.groupRepo.Create(ctx, group); err != nil {
+		s.abandon(ctx, session, "Group booking creation failed")
+		return nil, err
+	}
+
+	for _, seat := range cmd.Seats {
+		metrics.IncrementSeatReservationCreated(cmd.EventID, seat.ZoneID)
+	}
+
+	s.logger.Info("Group booking created successfully",
+		zap.String("group_booking_id", group.ID),
+		zap.String("session_id", session.ID),
+		zap.String("organizer_id", cmd.OrganizerID),
+		zap.Int("seat_count", session.SeatCount),
+		zap.Time("payment_deadline", group.PaymentDeadline),
+	)
+
+	return group, nil
+}
+
+// GetGroupBooking retrieves a group booking with its shares
+func (s *GroupBookingService) GetGroupBooking(ctx context.Context, groupBookingID string) (*models.GroupBooking, error) {
+	return s.groupRepo.GetByID(ctx, groupBookingID)
+}
+
+// InviteToGroupSeat assigns an unpaid seat of the group to a member, who then pays for it
+func (s *GroupBookingService) InviteToGroupSeat(ctx context.Context, cmd *GroupShareInviteCommand) (*models.GroupBookingShare, error) {
+	if cmd.UserID == "" {
+		return nil, fmt.Errorf("invalid request: user_id is required")
+	}
+
+	group, err := s.groupRepo.GetByID(ctx, cmd.GroupBookingID)
+	if err != nil {
+		return nil, err
+	}
+	if group.OrganizerID != cmd.OrganizerID {
+		return nil, ErrNotGroupOrganizer
+	}
+
+	share := group.Share(cmd.ShareID)
+	if share == nil {
+		return nil, fmt.Errorf("%w: %s", ErrGroupShareNotFound, cmd.ShareID)
+	}
+
+	if err := s.groupRepo.AssignShare(ctx, group.ID, share.ID, cmd.UserID); err != nil {
+		return nil, err
+	}
+	share.UserID = cmd.UserID
+
+	s.logger.Info("Member invited to group booking seat",
+		zap.String("group_booking_id", group.ID),
+		zap.String("share_id", share.ID),
+		zap.String("seat_id", share.SeatID),
+		zap.String("user_id", cmd.UserID),
+	)
+
+	return share, nil
+}
+
+// PayGroupShare charges a member for their seat. Paying again returns the paid share, or
+// checks on a payment that was still pending. The last payment settles the group right
+// away instead of waiting for the deadline.
+func (s *GroupBookingService) PayGroupShare(ctx context.Context, cmd *GroupSharePayCommand) (*models.GroupBookingShare, error) {
+	group, err := s.groupRepo.GetByID(ctx, cmd.GroupBookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	share := group.Share(cmd.ShareID)
+	if share == nil {
+		return nil, fmt.Errorf("%w: %s", ErrGroupShareNotFound, cmd.ShareID)
+	}
+	if share.UserID != cmd.UserID {
+		return nil, ErrNotShareHolder
+	}
+	if share.Status == models.GroupShareStatusPaid {
+		return share, nil
+	}
+	if share.Status != models.GroupShareStatusPending {
+		return nil, repositories.ErrGroupShareNotPending
+	}
+	if !group.IsOpen() {
+		return nil, repositories.ErrGroupBookingClosed
+	}
+
+	paymentID, err := s.payShare(ctx, group, share, cmd.PaymentMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	settle, err := s.groupRepo.MarkSharePaid(ctx, group.ID, share.ID, paymentID, cmd.PaymentMethod, s.owner, s.config.LeaseDuration)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrGroupBookingClosed):
+			// The deadline passed while the member paid, so the seat may be gone already
+			if paymentID != "" {
+				if refundErr := givePaymentBack(context.WithoutCancel(ctx), s.paymentClient, paymentID, groupClosedReason, "group-share-refund-"+share.ID); refundErr != nil {
+					s.logger.Error("Failed to give back payment for closed group booking",
+						zap.String("group_booking_id", group.ID),
+						zap.String("share_id", share.ID),
+						zap.String("payment_id", paymentID),
+						zap.Error(refundErr),
+					)
+				}
+			}
+			return nil, err
+		case errors.Is(err, repositories.ErrGroupShareNotPending):
+			// A concurrent request with the same idempotency key recorded it first
+			shares, getErr := s.groupRepo.GetShares(ctx, group.ID)
+			if getErr != nil {
+				return nil, getErr
+			}
+			for _, current := range shares {
+				if current.ID == share.ID && current.Status == models.GroupShareStatusPaid {
+					return current, nil
+				}
+			}
+			return nil, err
+		}
+		return nil, err
+	}
+
+	share.Status = models.GroupShareStatusPaid
+	if paymentID != "" {
+		share.PaymentID = &paymentID
+	}
+	if cmd.PaymentMethod != "" {
+		share.PaymentMethod = &cmd.PaymentMethod
+	}
+	now := time.Now()
+	share.PaidAt = &now
+
+	s.logger.Info("Group booking share paid",
+		zap.String("group_booking_id", group.ID),
+		zap.String("share_id", share.ID),
+		zap.String("user_id", share.UserID),
+		zap.String("payment_id", paymentID),
+		zap.Bool("settle", settle),
+	)
+
+	if settle {
+		// The settlement keeps going if the caller goes away; the sweeper resumes it otherwise
+		settling, err := s.groupRepo.GetByID(ctx, group.ID)
+		if err != nil {
+			s.logger.Warn("Failed to load group booking to settle, the sweeper will settle it",
+				zap.String("group_booking_id", group.ID),
+				zap.Error(err),
+			)
+			return share, nil
+		}
+		s.process(context.WithoutCancel(ctx), settling)
+	}
+
+	return share, nil
+}
+
+// CancelGroupBooking cancels an open group booking: paid shares are refunded and every
+// seat is released
+func (s *GroupBookingService) CancelGroupBooking(ctx context.Context, cmd *GroupBookingCancelCommand) (*models.GroupBooking, error) {
+	group, err := s.groupRepo.GetByID(ctx, cmd.GroupBookingID)
+	if err != nil {
+		return nil, err
+	}
+	if group.OrganizerID != cmd.OrganizerID {
+		return nil, ErrNotGroupOrganizer
+	}
+	if !group.IsOpen() {
+		return nil, repositories.ErrGroupBookingClosed
+	}
+
+	reason := cmd.Reason
+	if reason == "" {
+		reason = groupCancelledReason
+	}
+
+	claimed, err := s.groupRepo.Claim(ctx, group.ID, s.owner, s.config.LeaseDuration,
+		[]string{models.GroupBookingStatusOpen}, models.GroupBookingStatusCancelling, reason)
+	if err != nil {
+		return nil, err
+	}
+	if claimed == nil {
+		return nil, repositories.ErrGroupBookingClosed
+	}
+
+	s.process(context.WithoutCancel(ctx), claimed)
+
+	return s.groupRepo.GetByID(ctx, group.ID)
+}
+
+// sweepOnce claims group bookings with work to do and finishes them
+func (s *GroupBookingService) sweepOnce() {
+	groups, err := s.groupRepo.ClaimDue(s.ctx, s.owner, s.config.LeaseDuration, s.config.SweepBatchSize)
+	if err != nil {
+		s.logger.Warn("Failed to claim due group bookings", zap.Error(err))
+		return
+	}
+
+	for _, group := range groups {
+		s.process(s.ctx, group)
+	}
+}
+
+// process settles or cancels a group booking leased to this instance. A failure is
+// logged and left to the sweeper, which takes the group over once the lease runs out.
+func (s *GroupBookingService) process(ctx context.Context, group *models.GroupBooking) {
+	var err error
+	switch group.Status {
+	case models.GroupBookingStatusSettling:
+		err = s.settle(ctx, group)
+	case models.GroupBookingStatusCancelling:
+		err = s.cancelGroup(ctx, group)
+	default:
+		return
+	}
+
+	if err != nil {
+		s.logger.Warn("Failed to finish group booking, it will be retried",
+			zap.String("group_booking_id", group.ID),
+			zap.String("status", group.Status),
+			zap.Error(err),
+		)
+	}
+}
+
+// settle releases the seats of unpaid shares and issues tickets for the paid ones to
+// their members. Every step can run again after a failure.
+func (s *GroupBookingService) settle(ctx context.Context, group *models.GroupBooking) error {
+	session, err := s.bookingRepo.GetByID(ctx, group.BookingSessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get booking session: %w", err)
+	}
+
+	if err := s.releaseShares(ctx, group, group.SharesWithStatus(models.GroupShareStatusPending), groupUnpaidReason); err != nil {
+		return err
+	}
+
+	// Only the holds of paid shares are left to confirm
+	if _, err := s.reservationRepo.ConfirmByBookingSession(ctx, session.ID, ""); err != nil {
+		return err
+	}
+	reservations, err := s.reservationRepo.GetByBookingSessionID(ctx, session.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get seat reservations: %w", err)
+	}
+	reservationsByID := make(map[string]*models.SeatReservation, len(reservations))
+	for _, reservation := range reservations {
+		reservationsByID[reservation.ID] = reservation
+	}
+
+	// A paid share whose hold ran out before the settlement has no seat to issue
+	var paid []*models.GroupBookingShare
+	for _, share := range group.SharesWithStatus(models.GroupShareStatusPaid) {
+		reservation := reservationsByID[share.SeatReservationID]
+		if reservation != nil && reservation.Status == models.ReservationStatusConfirmed {
+			paid = append(paid, share)
+			continue
+		}
+		if err := s.refundShare(ctx, group, share, groupSeatLostReason); err != nil {
+			return err
+		}
+	}
+
+	if len(paid) == 0 {
+		return s.expire(ctx, group, session, reservations)
+	}
+
+	if s.eventClient != nil {
+		for _, share := range paid {
+			reservation := reservationsByID[share.SeatReservationID]
+			if err := s.eventClient.UpdateSeatAvailability(ctx, reservation.EventID, reservation.SeatID, "sold", reservation.ID); err != nil {
+				return fmt.Errorf("failed to mark seat %s sold: %w", reservation.SeatID, err)
+			}
+		}
+	}
+
+	// Tickets are valid from issuance until the event ends
+	validUntil := s.codeIssuer.eventValidUntil(ctx, group.EventID)
+
+	tickets := make([]*models.Ticket, 0, len(paid))
+	seatIDs := make([]string, 0, len(paid))
+	for _, share := range paid {
+		reservation := reservationsByID[share.SeatReservationID]
+		ticket := models.NewTicket(
+			reservation.EventID, reservation.SeatID, reservation.ZoneID, share.UserID,
+			database.GenerateTicketNumber(reservation.EventID, reservation.SeatID),
+			reservation.PricingCategory, reservation.BasePrice, reservation.FinalPrice, reservation.Currency,
+		)
+		ticket.ID = uuid.New().String()
+		ticket.BookingSessionID = &session.ID
+		ticket.Status = models.TicketStatusConfirmed
+		ticket.PaymentStatus = models.PaymentStatusPaid
+		ticket.PaymentMethod = share.PaymentMethod
+		ticket.PaymentReference = share.PaymentID
+		ticket.CreatedBy = &group.OrganizerID
+		ticket.ValidUntil = validUntil
+		if err := s.codeIssuer.assign(ticket); err != nil {
+			return err
+		}
+
+		tickets = append(tickets, ticket)
+		seatIDs = append(seatIDs, reservation.SeatID)
+	}
+
+	// The session covers the issued seats only, whose holds are the confirmed ones
+	session, err = refreshSessionTotals(ctx, s.bookingRepo, s.reservationRepo, session.ID)
+	if err != nil {
+		return err
+	}
+
+	payload := bookingEventPayload(session, models.BookingEventStatusConfirmed, seatIDs)
+	payload.TicketIDs = make([]string, len(tickets))
+	for i, ticket := range tickets {
+		payload.TicketIDs[i] = ticket.ID
+	}
+	payload.ConfirmedAt = payload.Timestamp
+	event, err := newBookingEvent(models.EventTypeBookingConfirmed, payload)
+	if err != nil {
+		return err
+	}
+
+	issued, err := s.ticketRepo.IssueForBookingSession(ctx, session.ID, group.OrganizerID, tickets, event)
+	if err != nil {
+		return err
+	}
+	if issued > 0 {
+		for _, ticket := range tickets {
+			metrics.IncrementTicketCreated(ticket.EventID, ticket.TicketType, ticket.Status)
+		}
+		metrics.IncrementBookingSessionCompleted(session.EventID)
+	}
+
+	return s.finish(ctx, group, models.GroupBookingStatusCompleted)
+}
+
+// cancelGroup refunds the paid shares, releases every seat and cancels the session
+func (s *GroupBookingService) cancelGroup(ctx context.Context, group *models.GroupBooking) error {
+	session, err := s.bookingRepo.GetByID(ctx, group.BookingSessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get booking session: %w", err)
+	}
+
+	reservations, err := s.reservationRepo.GetByBookingSessionID(ctx, session.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get seat reservations: %w", err)
+	}
+
+	reason := groupCancelledReason
+	if group.CancelledReason != nil {
+		reason = *group.CancelledReason
+	}
+
+	for _, share := range group.SharesWithStatus(models.GroupShareStatusPaid) {
+		if err := s.refundShare(ctx, group, share, reason); err != nil {
+			return err
+		}
+	}
+
+	// The seats of refunded shares are given back along with the unpaid ones
+	unissued := append(group.SharesWithStatus(models.GroupShareStatusPending), group.SharesWithStatus(models.GroupShareStatusRefunded)...)
+	if err := s.releaseShares(ctx, group, unissued, reason); err != nil {
+		return err
+	}
+
+	if !session.IsCancelled() {
+		payload := bookingEventPayload(session, models.BookingEventStatusCancelled, seatIDsOf(reservations))
+		payload.CancellationReason = reason
+		payload.CancelledAt = payload.Timestamp
+		event, err := newBookingEvent(models.EventTypeBookingCancelled, payload)
+		if err != nil {
+			return err
+		}
+		if err := s.bookingRepo.Cancel(ctx, session.ID, reason, group.OrganizerID, event); err != nil {
+			return err
+		}
+	}
+
+	return s.finish(ctx, group, models.GroupBookingStatusCancelled)
+}
+
+// expire ends a group booking nobody paid for: the session is cancelled as expired
+func (s *GroupBookingService) expire(ctx context.Context, group *models.GroupBooking, session *models.BookingSession, reservations []*models.SeatReservation) error {
+	if !session.IsCancelled() {
+		payload := bookingEventPayload(session, models.BookingEventStatusExpired, seatIDsOf(reservations))
+		payload.CancellationReason = groupUnpaidReason
+		payload.CancelledAt = payload.Timestamp
+		event, err := newBookingEvent(models.EventTypeBookingExpired, payload)
+		if err != nil {
+			return err
+		}
+		if err := s.bookingRepo.Cancel(ctx, session.ID, groupUnpaidReason, "", event); err != nil {
+			return err
+		}
+		metrics.IncrementBookingSessionExpired(session.EventID)
+	}
+
+	return s.finish(ctx, group, models.GroupBookingStatusExpired)
+}
+
+// finish records the final status of a group booking and lets the organizer leave the waiting room
+func (s *GroupBookingService) finish(ctx context.Context, group *models.GroupBooking, finalStatus string) error {
+	if err := s.groupRepo.Finish(ctx, group.ID, s.owner, finalStatus); err != nil {
+		return err
+	}
+	group.Status = finalStatus
+
+	s.bookingService.leaveWaitingRoom(ctx, group.OrganizerID, group.EventID)
+	metrics.IncrementGroupBookingFinished(group.EventID, finalStatus)
+
+	s.logger.Info("Group booking finished",
+		zap.String("group_booking_id", group.ID),
+		zap.String("status", finalStatus),
+		zap.Int("paid_shares", len(group.SharesWithStatus(models.GroupShareStatusPaid))),
+		zap.Float64("paid_amount", group.PaidAmount()),
+	)
+
+	return nil
+}
+
+// releaseShares gives back the seats of shares that will not be issued. A payment still
+// on a pending share is given back first, so a failure leaves the share to be released
+// again on the next attempt. Only holds still reserved are released and their seats given
+// back in Event Service: the seat of a hold that ran out may be someone else's by now.
+// Pending shares are marked released.
+func (s *GroupBookingService) releaseShares(ctx context.Context, group *models.GroupBooking, shares []*models.GroupBookingShare, reason string) error {
+	if len(shares) == 0 {
+		return nil
+	}
+
+	shareIDs := make([]string, len(shares))
+	reservationIDs := make([]string, len(shares))
+	for i, share := range shares {
+		shareIDs[i] = share.ID
+		reservationIDs[i] = share.SeatReservationID
+
+		if share.Status == models.GroupShareStatusPending && share.PaymentID != nil && s.paymentClient != nil {
+			if err := givePaymentBack(ctx, s.paymentClient, *share.PaymentID, reason, "group-share-refund-"+share.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	seatIDs, err := s.reservationRepo.ReleaseHolds(ctx, reservationIDs, reason)
+	if err != nil {
+		return err
+	}
+
+	// The holds are released already; a block left behind lapses on its own
+	if s.eventClient != nil && len(seatIDs) > 0 {
+		if _, err := s.eventClient.ReleaseSeats(ctx, group.EventID, seatIDs); err != nil {
+			s.logger.Warn("Failed to release seats in Event Service",
+				zap.String("group_booking_id", group.ID),
+				zap.Strings("seat_ids", seatIDs),
+				zap.Error(err),
+			)
+		}
+	}
+
+	if err := s.groupRepo.MarkSharesReleased(ctx, shareIDs); err != nil {
+		return err
+	}
+
+	for _, share := range shares {
+		if share.Status == models.GroupShareStatusPending {
+			share.Status = models.GroupShareStatusReleased
+			metrics.IncrementSeatReservationReleased(group.EventID, reason)
+		}
+	}
+	return nil
+}
+
+// refundShare gives a paid share's payment back and marks the share refunded. The share
+// ID is the idempotency key, so refunding again does not pay out twice.
+func (s *GroupBookingService) refundShare(ctx context.Context, group *models.GroupBooking, share *models.GroupBookingShare, reason string) error {
+	if share.PaymentID != nil && s.paymentClient != nil {
+		if err := givePaymentBack(ctx, s.paymentClient, *share.PaymentID, reason, "group-share-refund-"+share.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.groupRepo.MarkShareRefunded(ctx, share.ID); err != nil {
+		return err
+	}
+	share.Status = models.GroupShareStatusRefunded
+
+	metrics.IncrementTicketRefunded(group.EventID, reason)
+	return nil
+}
+
+// payShare charges a member for their share and returns the payment ID once it succeeded.
+// The share ID is the idempotency key, so paying again returns the payment made the first
+// time. A payment still pending is recorded on the share, so it is given back if the
+// share is released before it settles, and ErrGroupSharePaymentPending returned. Free
+// seats are not charged.
+func (s *GroupBookingService) payShare(ctx context.Context, group *models.GroupBooking, share *models.GroupBookingShare, paymentMethod string) (string, error) {
+	if share.Amount <= 0 {
+		return "", nil
+	}
+	if s.paymentClient == nil {
+		return "", fmt.Errorf("%w: payment service not available", ErrGroupSharePaymentFailed)
+	}
+
+	payment, err := chargePayment(ctx, s.paymentClient, group.EventID, &paymentpb.CreatePaymentRequest{
+		BookingId:      group.BookingSessionID,
+		Amount:         share.Amount,
+		Currency:       share.Currency,
+		PaymentMethod:  paymentMethod,
+		UserId:         share.UserID,
+		IdempotencyKey: "group-share-" + share.ID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrGroupSharePaymentFailed, err)
+	}
+
+	err = paymentSettled(payment)
+	switch {
+	case errors.Is(err, errPaymentPending):
+		if err := s.groupRepo.SetSharePayment(ctx, group.ID, share.ID, payment.PaymentId, paymentMethod); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("%w: %v", ErrGroupSharePaymentPending, err)
+	case err != nil:
+		return "", fmt.Errorf("%w: %v", ErrGroupSharePaymentFailed, err)
+	}
+
+	return payment.PaymentId, nil
+}
+
+// checkAdjacent checks with Event Service that the seats are next to each other: in the
+// same zone and row, with consecutive seat numbers. Seats without a zone get their own.
+func (s *GroupBookingService) checkAdjacent(ctx context.Context, eventID string, seats []GroupBookingSeat) error {
+	if s.eventClient == nil {
+		return nil
+	}
+
+	type seatPosition struct {
+		zoneID string
+		row    string
+		number int
+	}
+
+	positions := make([]seatPosition, len(seats))
+	for i := range seats {
+		seat, err := s.eventClient.GetSeat(ctx, seats[i].SeatID)
+		if err != nil {
+			return fmt.Errorf("failed to get seat %s: %w", seats[i].SeatID, err)
+		}
+		if seat.EventId != "" && seat.EventId != eventID {
+			return fmt.Errorf("seat %s does not belong to event %s", seats[i].SeatID, eventID)
+		}
+		if seats[i].ZoneID == "" {
+			seats[i].ZoneID = seat.ZoneId
+		}
+
+		number, ok := seatNumber(seat.SeatNumber)
+		if !ok {
+			return fmt.Errorf("%w: seat %s has no seat number", ErrSeatsNotAdjacent, seats[i].SeatID)
+		}
+		positions[i] = seatPosition{zoneID: seat.ZoneId, row: seat.RowNumber, number: number}
+	}
+
+	sort.Slice(positions, func(i, j int) bool { return positions[i].number < positions[j].number })
+	for i := 1; i < len(positions); i++ {
+		if positions[i].zoneID != positions[0].zoneID || positions[i].row != positions[0].row {
+			return fmt.Errorf("%w: seats are in different rows", ErrSeatsNotAdjacent)
+		}
+		if positions[i].number != positions[i-1].number+1 {
+			return fmt.Errorf("%w: seats %d and %d are not next to each other", ErrSeatsNotAdjacent, positions[i-1].number, positions[i].number)
+		}
+	}
+
+	return nil
+}
+
+// abandon cancels the session of a group booking that could not be created, releasing
+// the seats held so far. It runs even if the request context was cancelled.
+func (s *GroupBookingService) abandon(ctx context.Context, session *models.BookingSession, reason string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), holdRollbackTimeout)
+	defer cancel()
+
+	err := s.bookingService.CancelBookingSession(ctx, &BookingSessionCancelCommand{
+		SessionID:   session.ID,
+		Reason:      reason,
+		CancelledBy: session.UserID,
+	})
+	if err != nil {
+		s.logger.Error("Failed to cancel session of abandoned group booking, its seats will expire",
+			zap.String("session_id", session.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+func (s *GroupBookingService) validateCreateCommand(cmd *GroupBookingCreateCommand) error {
+	if cmd.OrganizerID == "" {
+		return fmt.Errorf("organizer_id is required")
+	}
+	if cmd.EventID == "" {
+		return fmt.Errorf("event_id is required")
+	}
+	if cmd.Currency == "" {
+		return fmt.Errorf("currency is required")
+	}
+	if len(cmd.Seats) < 2 {
+		return fmt.Errorf("a group booking needs at least 2 seats")
+	}
+	if len(cmd.Seats) > s.config.MaxSeats {
+		return fmt.Errorf("a group booking can have at most %d seats", s.config.MaxSeats)
+	}
+	if cmd.PaymentWindowMinutes < 0 {
+		return fmt.Errorf("payment_window_minutes cannot be negative")
+	}
+
+	seen := make(map[string]bool, len(cmd.Seats))
+	for _, seat := range cmd.Seats {
+		if seat.SeatID == "" {
+			return fmt.Errorf("seat_id is required")
+		}
+		if seen[seat.SeatID] {
+			return fmt.Errorf("seat %s is listed twice", seat.SeatID)
+		}
+		seen[seat.SeatID] = true
+		if seat.BasePrice < 0 || seat.FinalPrice < 0 {
+			return fmt.Errorf("prices cannot be negative")
+		}
+	}
+	return nil
+}
+
+// seatNumber is the number a seat label such as "12" or "A12" ends with
+func seatNumber(label string) (int, bool) {
+	start := len(label)
+	for start > 0 && unicode.IsDigit(rune(label[start-1])) {
+		start--
+	}
+	number, err := strconv.Atoi(label[start:])
+	if err != nil {
+		return 0, false
+	}
+	return number, true
+}
+
+// Request/Response types
+
+type GroupBookingSeat struct {
+	SeatID          string  `json:"seat_id"`
+	ZoneID          string  `json:"zone_id,omitempty"` // Taken from Event Service when empty
+	PricingCategory string  `json:"pricing_category"`
+	BasePrice       float64 `json:"base_price"`
+	FinalPrice      float64 `json:"final_price"`
+	UserID          string  `json:"user_id,omitempty"` // Member paying for the seat; the organizer when empty
+}
+
+type GroupBookingCreateCommand struct {
+	OrganizerID          string             `json:"organizer_id"`
+	EventID              string             `json:"event_id"`
+	Currency             string             `json:"currency"`
+	Seats                []GroupBookingSeat `json:"seats"`
+	PaymentWindowMinutes int                `json:"payment_window_minutes,omitempty"`
+	AdmissionToken       string             `json:"admission_token,omitempty"`
+	IPAddress            string             `json:"ip_address,omitempty"`
+	UserAgent            string             `json:"user_agent,omitempty"`
+}
+
+type GroupShareInviteCommand struct {
+	GroupBookingID string `json:"group_booking_id"`
+	ShareID        string `json:"share_id"`
+	OrganizerID    string `json:"organizer_id"`
+	UserID         string `json:"user_id"`
+}
+
+type GroupSharePayCommand struct {
+	GroupBookingID string `json:"group_booking_id"`
+	ShareID        string `json:"share_id"`
+	UserID         string `json:"user_id"`
+	PaymentMethod  string `json:"payment_method"`
+}
+
+type GroupBookingCancelCommand struct {
+	GroupBookingID string `json:"group_booking_id"`
+	OrganizerID    string `json:"organizer_id"`
+	Reason         string `json:"reason,omitempty"`
+}
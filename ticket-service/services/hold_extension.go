@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"ticket-service/metrics"
+	"ticket-service/models"
+	"ticket-service/repositories"
+)
+
+var (
+	// ErrHoldExtensionLimit is returned when a booking session used up its extensions or
+	// its holds already last as long as the event's hold policy allows
+	ErrHoldExtensionLimit = errors.New("seat hold extension limit reached")
+	// ErrHoldExtensionQueued is returned when users wait in the event's waiting room and
+	// the event's hold policy does not allow extensions meanwhile
+	ErrHoldExtensionQueued = errors.New("seat holds cannot be extended while users are queueing")
+)
+
+// ExtendReservationRequest asks for the seat holds of the reservation's booking session
+// to be extended
+type ExtendReservationRequest struct {
+	ReservationID    string `json:"reservation_id"`
+	ExtensionMinutes int    `json:"extension_minutes"`
+	ExtendedBy       string `json:"extended_by"`
+}
+
+// HoldExtension is the outcome of extending the seat holds of a booking session
+type HoldExtension struct {
+	BookingSessionID    string    `json:"booking_session_id"`
+	ExpiresAt           time.Time `json:"expires_at"`
+	ExtensionsUsed      int       `json:"extensions_used"`
+	ExtensionsRemaining int       `json:"extensions_remaining"`
+}
+
+// ExtendReservation extends the hold of the booking session a reservation belongs to:
+// every seat held by the session, its Event Service blocks and the session deadline move
+// together, within the event's hold policy. Nothing is extended if any part fails.
+func (s *ReservationService) ExtendReservation(ctx context.Context, req *ExtendReservationRequest) (*HoldExtension, error) {
+	if req.ExtensionMinutes <= 0 {
+		return nil, fmt.Errorf("invalid request: extension_minutes must be positive")
+	}
+
+	reservation, err := s.reservationRepo.GetByID(ctx, req.ReservationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seat reservation: %w", err)
+	}
+	if !reservation.IsReserved() {
+		return nil, fmt.Errorf("%w: reservation status is %s", repositories.ErrHoldNotExtended, reservation.Status)
+	}
+
+	session, err := s.bookingRepo.GetByID(ctx, reservation.BookingSessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking session: %w", err)
+	}
+	if !session.IsActive() {
+		return nil, fmt.Errorf("%w: booking session status is %s", repositories.ErrHoldNotExtended, session.Status)
+	}
+
+	extension, err := s.extendHold(ctx, session, req)
+	if err != nil {
+		metrics.IncrementSeatHoldExtension(session.EventID, holdExtensionResult(err))
+		return nil, err
+	}
+	metrics.IncrementSeatHoldExtension(session.EventID, "extended")
+
+	s.logger.Info("Seat holds extended successfully",
+		zap.String("reservation_id", req.ReservationID),
+		zap.String("booking_session_id", session.ID),
+		zap.Time("expires_at", extension.ExpiresAt),
+		zap.Int("extensions_used", extension.ExtensionsUsed),
+		zap.String("extended_by", req.ExtendedBy),
+	)
+
+	return extension, nil
+}
+
+// GetEventHoldPolicy retrieves the hold policy of an event
+func (s *ReservationService) GetEventHoldPolicy(ctx context.Context, eventID string) (*models.EventHoldPolicy, error) {
+	return s.holdPolicyRepo.GetByEventID(ctx, eventID)
+}
+
+// SetEventHoldPolicy sets the hold policy of an event. Sessions that already extended
+// their holds keep their extensions and are capped by the new policy from then on.
+func (s *ReservationService) SetEventHoldPolicy(ctx context.Context, policy *models.EventHoldPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return fmt.Errorf("invalid hold policy: %w", err)
+	}
+
+	if err := s.holdPolicyRepo.Upsert(ctx, policy); err != nil {
+		return err
+	}
+
+	s.logger.Info("Event hold policy updated",
+		zap.String("event_id", policy.EventID),
+		zap.Int("max_extensions", policy.MaxExtensions),
+		zap.Int("max_hold_minutes", policy.MaxHoldMinutes),
+		zap.Bool("extend_while_queued", policy.ExtendWhileQueued),
+	)
+
+	return nil
+}
+
+// extendHold checks the event's hold policy, blocks the session's seats in Event Service
+// until the new deadline, then extends the session and its reservations. If the
+// reservations cannot be extended, the blocks are put back to the previous deadline.
+func (s *ReservationService) extendHold(ctx context.Context, session *models.BookingSession, req *ExtendReservationRequest) (*HoldExtension, error) {
+	policy, err := s.holdPolicyRepo.GetByEventID(ctx, session.EventID)
+	if err != nil {
+		return nil, err
+	}
+	if session.HoldExtensions >= policy.MaxExtensions {
+		return nil, fmt.Errorf("%w: %d of %d extensions used", ErrHoldExtensionLimit, session.HoldExtensions, policy.MaxExtensions)
+	}
+
+	if !policy.ExtendWhileQueued && s.waitingRoom != nil {
+		queueStatus, err := s.waitingRoom.GetQueueStatus(ctx, session.EventID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check waiting room: %w", err)
+		}
+		if queueStatus.TotalUsers > 0 {
+			return nil, fmt.Errorf("%w: %d users waiting", ErrHoldExtensionQueued, queueStatus.TotalUsers)
+		}
+	}
+
+	expiresAt := time.Now().Add(time.Duration(req.ExtensionMinutes) * time.Minute)
+	if deadline := policy.HoldDeadline(session.CreatedAt); expiresAt.After(deadline) {
+		expiresAt = deadline
+	}
+	if !expiresAt.After(session.ExpiresAt) {
+		return nil, fmt.Errorf("%w: holds already last until %s", ErrHoldExtensionLimit, session.ExpiresAt.Format(time.RFC3339))
+	}
+
+	reservations, err := s.reservationRepo.GetByBookingSessionID(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	var held []*models.SeatReservation
+	heldUntil := session.ExpiresAt
+	for _, reservation := range reservations {
+		if !reservation.IsReserved() {
+			continue
+		}
+		held = append(held, reservation)
+		if reservation.ExpiresAt.After(heldUntil) {
+			heldUntil = reservation.ExpiresAt
+		}
+	}
+	if len(held) == 0 {
+		return nil, fmt.Errorf("%w: no seats held in session", repositories.ErrHoldNotExtended)
+	}
+
+	blockedReason := fmt.Sprintf("Extended hold for session %s", session.ID)
+	if err := s.holder.blockSeats(ctx, session.EventID, seatIDsOf(held), blockedReason, expiresAt); err != nil {
+		return nil, err
+	}
+
+	extended, err := s.bookingRepo.ExtendHold(ctx, session.ID, expiresAt, policy.MaxExtensions, len(held), req.ExtendedBy)
+	if err != nil {
+		s.restoreBlocks(ctx, session, held, heldUntil)
+		return nil, err
+	}
+
+	return &HoldExtension{
+		BookingSessionID:    extended.ID,
+		ExpiresAt:           extended.ExpiresAt,
+		ExtensionsUsed:      extended.HoldExtensions,
+		ExtensionsRemaining: max(policy.MaxExtensions-extended.HoldExtensions, 0),
+	}, nil
+}
+
+// restoreBlocks puts the Event Service blocks of an extension that failed back to the
+// deadline the seats were held until. It runs even if the request context was cancelled.
+func (s *ReservationService) restoreBlocks(ctx context.Context, session *models.BookingSession, held []*models.SeatReservation, heldUntil time.Time) {
+	if s.eventClient == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), holdRollbackTimeout)
+	defer cancel()
+
+	blockedReason := fmt.Sprintf("Hold for session %s", session.ID)
+	if _, err := s.eventClient.BlockSeats(ctx, session.EventID, seatIDsOf(held), blockedReason, heldUntil); err != nil {
+		s.logger.Warn("Failed to restore seat blocks after a failed hold extension",
+			zap.String("booking_session_id", session.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+// holdExtensionResult is the metrics result of a refused or failed hold extension
+func holdExtensionResult(err error) string {
+	switch {
+	case errors.Is(err, ErrHoldExtensionLimit):
+		return "limit_reached"
+	case errors.Is(err, ErrHoldExtensionQueued):
+		return "queue_waiting"
+	case errors.Is(err, repositories.ErrHoldNotExtended):
+		return "not_extendable"
+	case errors.Is(err, ErrSeatUnavailable):
+		return "seat_unavailable"
+	}
+	return "failed"
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/status"
+
+	"ticket-service/grpcclient"
+	paymentpb "ticket-service/internal/protos/payment"
+	"ticket-service/metrics"
+)
+
+// Payment Service payment statuses
+const (
+	paymentStatusPending    = "pending"
+	paymentStatusProcessing = "processing"
+	paymentStatusSuccess    = "success"
+)
+
+// Payment Service refund statuses that give nothing back
+const (
+	refundStatusFailed    = "failed"
+	refundStatusCancelled = "cancelled"
+)
+
+var (
+	// errPaymentPending means a payment was accepted but has not succeeded or failed yet
+	errPaymentPending = errors.New("payment is still pending")
+	// errPaymentDeclined means a payment failed or was cancelled and will not succeed
+	errPaymentDeclined = errors.New("payment declined")
+)
+
+// chargePayment creates a payment, or returns the one created before under the same
+// idempotency key. The payment may still be pending; see paymentSettled.
+func chargePayment(ctx context.Context, paymentClient *grpcclient.PaymentServiceClient, eventID string, req *paymentpb.CreatePaymentRequest) (*paymentpb.Payment, error) {
+	startTime := time.Now()
+	resp, err := paymentClient.CreatePayment(ctx, req)
+	metrics.ObservePaymentDuration(eventID, req.PaymentMethod, time.Since(startTime).Seconds())
+	if err != nil {
+		metrics.IncrementPaymentFailed(eventID, status.Code(err).String())
+		return nil, fmt.Errorf("payment processing failed: %w", err)
+	}
+	if resp.Payment == nil {
+		return nil, fmt.Errorf("payment service returned no payment")
+	}
+
+	metrics.IncrementPaymentProcessed(eventID, strings.ToLower(resp.Payment.Status), req.PaymentMethod)
+	return resp.Payment, nil
+}
+
+// getPayment retrieves a payment from Payment Service
+func getPayment(ctx context.Context, paymentClient *grpcclient.PaymentServiceClient, paymentID string) (*paymentpb.Payment, error) {
+	resp, err := paymentClient.GetPayment(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	if resp.Payment == nil {
+		return nil, fmt.Errorf("payment %s not found", paymentID)
+	}
+	return resp.Payment, nil
+}
+
+// paymentSettled reports whether a payment succeeded: it returns nil once it did,
+// errPaymentPending while it is pending or processing and errPaymentDeclined otherwise
+func paymentSettled(payment *paymentpb.Payment) error {
+	switch paymentStatus := strings.ToLower(payment.Status); paymentStatus {
+	case paymentStatusSuccess:
+		return nil
+	case paymentStatusPending, paymentStatusProcessing:
+		return fmt.Errorf("%w: payment %s is %s", errPaymentPending, payment.PaymentId, paymentStatus)
+	default:
+		return fmt.Errorf("%w: payment %s is %s: %s", errPaymentDeclined, payment.PaymentId, paymentStatus, payment.FailureReason)
+	}
+}
+
+// givePaymentBack cancels a payment still in progress or refunds a settled one in full.
+// Failed, cancelled and refunded payments have nothing left to give back.
+func givePaymentBack(ctx context.Context, paymentClient *grpcclient.PaymentServiceClient, paymentID, reason, idempotencyKey string) error {
+	payment, err := getPayment(ctx, paymentClient, paymentID)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(payment.Status) {
+	case paymentStatusPending, paymentStatusProcessing:
+		if _, err := paymentClient.CancelPayment(ctx, paymentID, reason); err != nil {
+			return fmt.Errorf("failed to cancel payment: %w", err)
+		}
+	case paymentStatusSuccess:
+		_, err := paymentClient.CreateRefund(ctx, &paymentpb.CreateRefundRequest{
+			PaymentId:      paymentID,
+			Amount:         payment.Amount,
+			Reason:         reason,
+			RefundType:     "full",
+			IdempotencyKey: idempotencyKey,
+		})
+		if err != nil {
+			return fmt.Errorf("refund processing failed: %w", err)
+		}
+	}
+	return nil
+}
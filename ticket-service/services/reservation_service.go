@@ -10,26 +10,47 @@ import (
 	"ticket-service/grpcclient"
 	"ticket-service/metrics"
 	"ticket-service/models"
+	"ticket-service/queue"
 	"ticket-service/repositories"
 )
 
 // ReservationService handles seat reservation business logic
 type ReservationService struct {
 	reservationRepo *repositories.SeatReservationRepository
+	bookingRepo     *repositories.BookingSessionRepository
+	holdPolicyRepo  *repositories.HoldPolicyRepository
 	eventClient     *grpcclient.EventServiceClient
+	realtimeClient  *grpcclient.RealtimeServiceClient
+	holder          *seatHolder
+	layouts         *seatLayoutCache
+	waitingRoom     *queue.QueueManager
 	logger          *zap.Logger
 }
 
 // NewReservationService creates a new reservation service
 func NewReservationService(
 	reservationRepo *repositories.SeatReservationRepository,
+	bookingRepo *repositories.BookingSessionRepository,
+	holdPolicyRepo *repositories.HoldPolicyRepository,
 	eventClient *grpcclient.EventServiceClient,
+	realtimeClient *grpcclient.RealtimeServiceClient,
+	waitingRoom *queue.QueueManager,
 	logger *zap.Logger,
 ) *ReservationService {
 	return &ReservationService{
 		reservationRepo: reservationRepo,
+		bookingRepo:     bookingRepo,
+		holdPolicyRepo:  holdPolicyRepo,
 		eventClient:     eventClient,
-		logger:          logger,
+		realtimeClient:  realtimeClient,
+		holder: &seatHolder{
+			reservationRepo: reservationRepo,
+			eventClient:     eventClient,
+			logger:          logger,
+		},
+		layouts:     newSeatLayoutCache(),
+		waitingRoom: waitingRoom,
+		logger:      logger,
 	}
 }
 
@@ -47,7 +68,7 @@ func (s *ReservationService) CreateReservation(ctx context.Context, req *CreateR
 			return nil, fmt.Errorf("failed to check seat availability: %w", err)
 		}
 		if !available {
-			return nil, fmt.Errorf("seat %s is not available", req.SeatID)
+			return nil, fmt.Errorf("%w: seat %s", ErrSeatUnavailable, req.SeatID)
 		}
 	}
 
@@ -73,23 +94,11 @@ func (s *ReservationService) CreateReservation(ctx context.Context, req *CreateR
 		return nil, fmt.Errorf("reservation validation failed: %w", err)
 	}
 
-	// Create reservation in database
-	if err := s.reservationRepo.Create(ctx, reservation); err != nil {
-		return nil, fmt.Errorf("failed to create seat reservation: %w", err)
-	}
-
-	// Block seat in Event Service
-	if s.eventClient != nil {
-		blockedReason := fmt.Sprintf("Reserved for session %s by user %s", req.BookingSessionID, req.UserID)
-		_, err := s.eventClient.BlockSeats(ctx, req.EventID, []string{req.SeatID},
-			blockedReason, expiresAt)
-		if err != nil {
-			s.logger.Warn("Failed to block seat in Event Service",
-				zap.String("event_id", req.EventID),
-				zap.String("seat_id", req.SeatID),
-				zap.Error(err),
-			)
-		}
+	// Hold the seat: the reservation insert and the Event Service block succeed or fail together.
+	// The insert locks the session, which has to be open for seats and not being completed.
+	blockedReason := fmt.Sprintf("Reserved for session %s by user %s", req.BookingSessionID, req.UserID)
+	if err := s.holder.holdAll(ctx, req.BookingSessionID, req.EventID, []*models.SeatReservation{reservation}, blockedReason, expiresAt); err != nil {
+		return nil, err
 	}
 
 	// Increment metrics
@@ -251,18 +260,13 @@ func (s *ReservationService) ReleaseReservationsBySession(ctx context.Context, r
 	}
 
 	// Release all reservations
-	err = s.reservationRepo.ReleaseByBookingSession(ctx, req.SessionID, req.Reason, req.ReleasedBy)
+	seatIDs, err := s.reservationRepo.ReleaseByBookingSession(ctx, req.SessionID, req.Reason, req.ReleasedBy)
 	if err != nil {
 		return fmt.Errorf("failed to release session reservations: %w", err)
 	}
 
-	// Release seats in Event Service
-	if s.eventClient != nil && len(reservations) > 0 {
-		seatIDs := make([]string, len(reservations))
-		for i, reservation := range reservations {
-			seatIDs[i] = reservation.SeatID
-		}
-
+	// Release seats in Event Service, only those the session still held
+	if s.eventClient != nil && len(seatIDs) > 0 {
 		_, err := s.eventClient.ReleaseSeats(ctx, reservations[0].EventID, seatIDs)
 		if err != nil {
 			s.logger.Warn("Failed to release seats in Event Service",
@@ -273,14 +277,14 @@ func (s *ReservationService) ReleaseReservationsBySession(ctx context.Context, r
 	}
 
 	// Increment metrics
-	for _, reservation := range reservations {
-		metrics.IncrementSeatReservationReleased(reservation.EventID, req.Reason)
+	for range seatIDs {
+		metrics.IncrementSeatReservationReleased(reservations[0].EventID, req.Reason)
 	}
 
 	s.logger.Info("Session reservations released successfully",
 		zap.String("session_id", req.SessionID),
 		zap.String("reason", req.Reason),
-		zap.Int("reservation_count", len(reservations)),
+		zap.Int("reservation_count", len(seatIDs)),
 	)
 
 	return nil
@@ -306,80 +310,39 @@ func (s *ReservationService) GetReservationStats(ctx context.Context, eventID st
 	return stats, nil
 }
 
-// CleanupExpiredReservations cleans up expired seat reservations
+// CleanupExpiredReservations cleans up expired seat reservations, a batch at a time
 func (s *ReservationService) CleanupExpiredReservations(ctx context.Context) error {
-	expiredReservations, err := s.reservationRepo.GetExpiredReservations(ctx, time.Now())
-	if err != nil {
-		return fmt.Errorf("failed to get expired reservations: %w", err)
-	}
-
-	for _, reservation := range expiredReservations {
-		req := &ReleaseReservationRequest{
-			ReservationID: reservation.ID,
-			Reason:        "Reservation expired",
-			ReleasedBy:    "system",
+	total := 0
+	for {
+		expired, err := s.ExpireReservations(ctx, cleanupBatchSize)
+		if err != nil {
+			return err
 		}
+		total += expired
 
-		if err := s.ReleaseReservation(ctx, req); err != nil {
-			s.logger.Error("Failed to cleanup expired reservation",
-				zap.String("reservation_id", reservation.ID),
-				zap.Error(err),
-			)
+		if expired < cleanupBatchSize {
+			break
 		}
 	}
 
 	s.logger.Info("Cleanup expired reservations completed",
-		zap.Int("expired_count", len(expiredReservations)),
+		zap.Int("expired_count", total),
 	)
 
 	return nil
 }
 
-// ExtendReservation extends the expiration time of a reservation
-func (s *ReservationService) ExtendReservation(ctx context.Context, req *ExtendReservationRequest) error {
-	// Get reservation
-	reservation, err := s.reservationRepo.GetByID(ctx, req.ReservationID)
+// ExpireReservations expires up to limit seat holds that lapsed, releases their seats in
+// Event Service and announces them back on sale. It returns how many were expired.
+func (s *ReservationService) ExpireReservations(ctx context.Context, limit int) (int, error) {
+	expiredReservations, err := s.reservationRepo.ExpireReservations(ctx, time.Now(), limit)
 	if err != nil {
-		return fmt.Errorf("failed to get seat reservation: %w", err)
+		return 0, fmt.Errorf("failed to expire reservations: %w", err)
 	}
 
-	// Validate reservation can be extended
-	if !reservation.IsReserved() {
-		return fmt.Errorf("reservation cannot be extended, current status: %s", reservation.Status)
-	}
+	releaseSeatsOf(ctx, s.eventClient, s.realtimeClient, s.logger, expiredReservations, seatReservationExpiredReason)
 
-	// Calculate new expiration time
-	newExpiresAt := time.Now().Add(time.Duration(req.ExtensionMinutes) * time.Minute)
-
-	// Update reservation expiration
-	reservation.ExpiresAt = newExpiresAt
-	reservation.UpdatedBy = &req.ExtendedBy
-
-	if err := s.reservationRepo.Update(ctx, reservation); err != nil {
-		return fmt.Errorf("failed to extend reservation: %w", err)
-	}
-
-	// Update seat block in Event Service
-	if s.eventClient != nil {
-		blockedReason := fmt.Sprintf("Extended reservation for session %s", reservation.BookingSessionID)
-		_, err := s.eventClient.BlockSeats(ctx, reservation.EventID, []string{reservation.SeatID},
-			blockedReason, newExpiresAt)
-		if err != nil {
-			s.logger.Warn("Failed to update seat block in Event Service",
-				zap.String("event_id", reservation.EventID),
-				zap.String("seat_id", reservation.SeatID),
-				zap.Error(err),
-			)
-		}
-	}
-
-	s.logger.Info("Seat reservation extended successfully",
-		zap.String("reservation_id", req.ReservationID),
-		zap.Int("extension_minutes", req.ExtensionMinutes),
-		zap.String("extended_by", req.ExtendedBy),
-	)
-
-	return nil
+	return len(expiredReservations), nil
 }
 
 // Helper methods
@@ -463,9 +426,3 @@ type ReleaseReservationsBySessionRequest struct {
 	Reason     string `json:"reason"`
 	ReleasedBy string `json:"released_by"`
 }
-
-type ExtendReservationRequest struct {
-	ReservationID    string `json:"reservation_id"`
-	ExtensionMinutes int    `json:"extension_minutes"`
-	ExtendedBy       string `json:"extended_by"`
-}
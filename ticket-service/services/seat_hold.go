@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"ticket-service/grpcclient"
+	"ticket-service/models"
+	"ticket-service/repositories"
+)
+
+// ErrSeatUnavailable is returned when a seat cannot be held because it is taken
+var ErrSeatUnavailable = errors.New("seat is not available")
+
+// holdRollbackTimeout bounds undoing a hold after the request context is gone
+const holdRollbackTimeout = 5 * time.Second
+
+// seatHolder places seat holds. A hold is the reservation row plus the seat block in
+// Event Service; either both are in place or neither is.
+type seatHolder struct {
+	reservationRepo *repositories.SeatReservationRepository
+	eventClient     *grpcclient.EventServiceClient
+	logger          *zap.Logger
+}
+
+// hold inserts the reservation and blocks its seat in Event Service until blockedUntil.
+// The insert fails when another reservation holds the seat; a failed block releases the
+// reservation again and fails the hold.
+func (h *seatHolder) hold(ctx context.Context, reservation *models.SeatReservation, blockedReason string, blockedUntil time.Time) error {
+	if err := h.reservationRepo.Create(ctx, reservation); err != nil {
+		if errors.Is(err, repositories.ErrSeatAlreadyHeld) {
+			return fmt.Errorf("%w: seat %s is already held", ErrSeatUnavailable, reservation.SeatID)
+		}
+		return fmt.Errorf("failed to create seat reservation: %w", err)
+	}
+
+	if err := h.blockSeats(ctx, reservation.EventID, []string{reservation.SeatID}, blockedReason, blockedUntil); err != nil {
+		h.releaseHolds(ctx, []*models.SeatReservation{reservation}, "Seat block failed", false)
+		return err
+	}
+
+	return nil
+}
+
+// holdAll holds the seats of a booking session together: the reservations are inserted in
+// one transaction and their seats blocked in one Event Service call, and either every seat
+// is held or none is. Seats that could not be held fail the hold with a *SeatsUnavailableError
+// explaining why.
+func (h *seatHolder) holdAll(ctx context.Context, sessionID, eventID string, reservations []*models.SeatReservation, blockedReason string, blockedUntil time.Time) error {
+	held, err := h.reservationRepo.CreateBatch(ctx, sessionID, reservations)
+	if err != nil {
+		return err
+	}
+	if len(held) > 0 {
+		failures := make([]SeatFailure, len(held))
+		for i, seatID := range held {
+			failures[i] = SeatFailure{SeatID: seatID, Reason: SeatFailureAlreadyHeld, Message: "seat is held by another booking"}
+		}
+		return &SeatsUnavailableError{Failures: failures}
+	}
+
+	if h.eventClient == nil {
+		return nil
+	}
+
+	seatIDs := seatIDsOf(reservations)
+	resp, err := h.eventClient.BlockSeats(ctx, eventID, seatIDs, blockedReason, blockedUntil)
+	if err != nil {
+		h.releaseHolds(ctx, reservations, "Seat block failed", false)
+		return fmt.Errorf("failed to block seats in Event Service: %w", err)
+	}
+	if resp.Error == "" && int(resp.BlockedCount) == len(seatIDs) {
+		return nil
+	}
+
+	h.releaseHolds(ctx, reservations, "Seat block failed", false)
+	if len(resp.BlockedSeatIds) > 0 {
+		h.releaseBlocks(ctx, eventID, resp.BlockedSeatIds)
+	}
+
+	blocked := make(map[string]bool, len(resp.BlockedSeatIds))
+	for _, seatID := range resp.BlockedSeatIds {
+		blocked[seatID] = true
+	}
+	message := resp.Error
+	if message == "" {
+		message = "seat is not available in Event Service"
+	}
+	var failures []SeatFailure
+	for _, seatID := range seatIDs {
+		// Event Service may refuse the whole call after blocking every seat
+		if !blocked[seatID] || len(resp.BlockedSeatIds) == len(seatIDs) {
+			failures = append(failures, SeatFailure{SeatID: seatID, Reason: SeatFailureUnavailable, Message: message})
+		}
+	}
+	return &SeatsUnavailableError{Failures: failures}
+}
+
+// blockSeats blocks seats in Event Service and fails unless every seat was blocked.
+// Seats blocked by a partially successful call are released again.
+func (h *seatHolder) blockSeats(ctx context.Context, eventID string, seatIDs []string, blockedReason string, blockedUntil time.Time) error {
+	if h.eventClient == nil {
+		return nil
+	}
+
+	resp, err := h.eventClient.BlockSeats(ctx, eventID, seatIDs, blockedReason, blockedUntil)
+	if err != nil {
+		return fmt.Errorf("failed to block seats in Event Service: %w", err)
+	}
+	if resp.Error == "" && int(resp.BlockedCount) == len(seatIDs) {
+		return nil
+	}
+
+	if len(resp.BlockedSeatIds) > 0 {
+		h.releaseBlocks(ctx, eventID, resp.BlockedSeatIds)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("%w: Event Service refused to block seats: %s", ErrSeatUnavailable, resp.Error)
+	}
+	return fmt.Errorf("%w: Event Service blocked %d of %d seats", ErrSeatUnavailable, resp.BlockedCount, len(seatIDs))
+}
+
+// releaseHolds undoes holds that could not be completed. It runs even if the request
+// context was cancelled so a failed hold does not keep the seat until it expires.
+func (h *seatHolder) releaseHolds(ctx context.Context, reservations []*models.SeatReservation, reason string, blocked bool) {
+	if len(reservations) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), holdRollbackTimeout)
+	defer cancel()
+
+	ids := make([]string, len(reservations))
+	for i, reservation := range reservations {
+		ids[i] = reservation.ID
+	}
+
+	// Only the seats of holds released here are unblocked; a hold left reserved keeps its
+	// block until both expire
+	seatIDs, err := h.reservationRepo.ReleaseHolds(ctx, ids, reason)
+	if err != nil {
+		h.logger.Error("Failed to release seat holds, they will expire",
+			zap.Strings("reservation_ids", ids),
+			zap.Error(err),
+		)
+	}
+
+	if blocked && len(seatIDs) > 0 {
+		h.releaseBlocks(ctx, reservations[0].EventID, seatIDs)
+	}
+}
+
+// releaseBlocks releases seat blocks in Event Service, logging failures
+func (h *seatHolder) releaseBlocks(ctx context.Context, eventID string, seatIDs []string) {
+	if h.eventClient == nil {
+		return
+	}
+
+	if _, err := h.eventClient.ReleaseSeats(ctx, eventID, seatIDs); err != nil {
+		h.logger.Warn("Failed to release seats in Event Service",
+			zap.String("event_id", eventID),
+			zap.Strings("seat_ids", seatIDs),
+			zap.Error(err),
+		)
+	}
+}
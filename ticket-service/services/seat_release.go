@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go.uber.org/zap"
+
+	"ticket-service/grpcclient"
+	"ticket-service/metrics"
+	"ticket-service/models"
+)
+
+// ticketReleasedEvent tells the clients viewing an event that seats are back on sale
+const ticketReleasedEvent = "ticket:released"
+
+// seatReservationExpiredReason is recorded on everything released because its hold lapsed
+const seatReservationExpiredReason = "Reservation expired"
+
+// cleanupBatchSize bounds how many sessions or reservations a cleanup expires at a time
+const cleanupBatchSize = 100
+
+// seatsReleasedPayload is the payload of ticketReleasedEvent
+type seatsReleasedPayload struct {
+	EventID    string   `json:"event_id"`
+	SeatIDs    []string `json:"seat_ids"`
+	Reason     string   `json:"reason"`
+	ReleasedAt int64    `json:"released_at"`
+}
+
+// announceSeatsReleased broadcasts ticketReleasedEvent to the room of an event. It is best
+// effort: clients that miss it see the seats once they reload the seat map.
+func announceSeatsReleased(ctx context.Context, realtimeClient *grpcclient.RealtimeServiceClient, logger *zap.Logger, eventID string, seatIDs []string, reason string) {
+	if realtimeClient == nil || len(seatIDs) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(&seatsReleasedPayload{
+		EventID:    eventID,
+		SeatIDs:    seatIDs,
+		Reason:     reason,
+		ReleasedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		logger.Warn("Failed to encode released seats", zap.Error(err))
+		return
+	}
+
+	if _, err := realtimeClient.BroadcastEvent(ctx, ticketReleasedEvent, "event:"+eventID, string(payload)); err != nil {
+		logger.Warn("Failed to announce released seats",
+			zap.String("event_id", eventID),
+			zap.Int("seats", len(seatIDs)),
+			zap.Error(err),
+		)
+	}
+}
+
+// releaseSeatsOf gives the seats of reservations released for reason back in Event
+// Service and announces them back on sale, the seats of each event together. The
+// reservations are released already, so a block left behind lapses on its own.
+func releaseSeatsOf(ctx context.Context, eventClient *grpcclient.EventServiceClient, realtimeClient *grpcclient.RealtimeServiceClient, logger *zap.Logger, reservations []*models.SeatReservation, reason string) {
+	var eventIDs []string
+	seatsByEvent := make(map[string][]string)
+	for _, reservation := range reservations {
+		if _, ok := seatsByEvent[reservation.EventID]; !ok {
+			eventIDs = append(eventIDs, reservation.EventID)
+		}
+		seatsByEvent[reservation.EventID] = append(seatsByEvent[reservation.EventID], reservation.SeatID)
+		metrics.IncrementSeatReservationReleased(reservation.EventID, reason)
+	}
+
+	for _, eventID := range eventIDs {
+		seatIDs := seatsByEvent[eventID]
+
+		if eventClient != nil {
+			if _, err := eventClient.ReleaseSeats(ctx, eventID, seatIDs); err != nil {
+				logger.Warn("Failed to release seats in Event Service",
+					zap.String("event_id", eventID),
+					zap.Strings("seat_ids", seatIDs),
+					zap.String("reason", reason),
+					zap.Error(err),
+				)
+			}
+		}
+
+		announceSeatsReleased(ctx, realtimeClient, logger, eventID, seatIDs, reason)
+	}
+}
@@ -3,14 +3,15 @@ package services
 import (
 	"context"
 	"fmt"
+	"math"
 	"time"
 
 	"go.uber.org/zap"
 
-	paymentpb "ticket-service/internal/protos/payment"
 	"ticket-service/grpcclient"
 	"ticket-service/metrics"
 	"ticket-service/models"
+	"ticket-service/queue"
 	"ticket-service/repositories"
 )
 
@@ -20,8 +21,13 @@ import (
 type TicketBookingSessionService struct {
 	bookingRepo     *repositories.BookingSessionRepository
 	reservationRepo *repositories.SeatReservationRepository
+	groupRepo       *repositories.GroupBookingRepository
 	eventClient     *grpcclient.EventServiceClient
 	paymentClient   *grpcclient.PaymentServiceClient
+	realtimeClient  *grpcclient.RealtimeServiceClient
+	holder          *seatHolder
+	saga            *BookingSagaOrchestrator
+	waitingRoom     *queue.QueueManager
 	logger          *zap.Logger
 }
 
@@ -29,16 +35,29 @@ type TicketBookingSessionService struct {
 func NewTicketBookingSessionService(
 	bookingRepo *repositories.BookingSessionRepository,
 	reservationRepo *repositories.SeatReservationRepository,
+	groupRepo *repositories.GroupBookingRepository,
 	eventClient *grpcclient.EventServiceClient,
 	paymentClient *grpcclient.PaymentServiceClient,
+	realtimeClient *grpcclient.RealtimeServiceClient,
+	saga *BookingSagaOrchestrator,
+	waitingRoom *queue.QueueManager,
 	logger *zap.Logger,
 ) *TicketBookingSessionService {
 	return &TicketBookingSessionService{
 		bookingRepo:     bookingRepo,
 		reservationRepo: reservationRepo,
+		groupRepo:       groupRepo,
 		eventClient:     eventClient,
 		paymentClient:   paymentClient,
-		logger:          logger,
+		realtimeClient:  realtimeClient,
+		holder: &seatHolder{
+			reservationRepo: reservationRepo,
+			eventClient:     eventClient,
+			logger:          logger,
+		},
+		saga:        saga,
+		waitingRoom: waitingRoom,
+		logger:      logger,
 	}
 }
 
@@ -76,8 +95,27 @@ func (s *TicketBookingSessionService) CreateBookingSession(ctx context.Context,
 		return nil, fmt.Errorf("session validation failed: %w", err)
 	}
 
+	// Events with an open waiting room are booked by admitted users only, once per admission
+	var admission *queue.AdmissionClaims
+	if s.waitingRoom != nil {
+		var err error
+		admission, err = s.waitingRoom.ConsumeAdmission(ctx, req.EventID, req.UserID, req.AdmissionToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Create session in database
 	if err := s.bookingRepo.Create(ctx, session); err != nil {
+		if admission != nil {
+			if restoreErr := s.waitingRoom.RestoreAdmission(ctx, admission); restoreErr != nil {
+				s.logger.Warn("Failed to restore waiting room admission",
+					zap.String("user_id", req.UserID),
+					zap.String("event_id", req.EventID),
+					zap.Error(restoreErr),
+				)
+			}
+		}
 		return nil, fmt.Errorf("failed to create booking session: %w", err)
 	}
 
@@ -126,6 +164,16 @@ func (s *TicketBookingSessionService) AddSeatToSession(ctx context.Context, req
 		return fmt.Errorf("booking session is not active, status: %s", session.Status)
 	}
 
+	// The seats are locked in while the session is being completed
+	if err := s.ensureNotCompleting(ctx, req.SessionID); err != nil {
+		return err
+	}
+
+	// Group bookings hold their seats until the group settles
+	if err := s.ensureNotGroup(ctx, req.SessionID); err != nil {
+		return err
+	}
+
 	// Check seat availability
 	if s.eventClient != nil {
 		available, err := s.checkSeatAvailability(ctx, req.EventID, req.SeatID)
@@ -133,7 +181,7 @@ func (s *TicketBookingSessionService) AddSeatToSession(ctx context.Context, req
 			return fmt.Errorf("failed to check seat availability: %w", err)
 		}
 		if !available {
-			return fmt.Errorf("seat %s is not available", req.SeatID)
+			return fmt.Errorf("%w: seat %s", ErrSeatUnavailable, req.SeatID)
 		}
 	}
 
@@ -154,31 +202,20 @@ func (s *TicketBookingSessionService) AddSeatToSession(ctx context.Context, req
 		return fmt.Errorf("reservation validation failed: %w", err)
 	}
 
-	// Create reservation in database
-	if err := s.reservationRepo.Create(ctx, reservation); err != nil {
-		return fmt.Errorf("failed to create seat reservation: %w", err)
-	}
-
-	// Block seat in Event Service
-	if s.eventClient != nil {
-		blockedReason := fmt.Sprintf("Booking session %s for user %s", req.SessionID, session.UserID)
-		_, err := s.eventClient.BlockSeats(ctx, req.EventID, []string{req.SeatID},
-			blockedReason, session.ExpiresAt)
-		if err != nil {
-			s.logger.Warn("Failed to block seat in Event Service",
-				zap.String("event_id", req.EventID),
-				zap.String("seat_id", req.SeatID),
-				zap.Error(err),
-			)
-		}
+	// Hold the seat: the reservation insert and the Event Service block succeed or fail together.
+	// The insert locks the session and checks again that no saga has started completing it,
+	// so a seat cannot slip into a booking after its payment was taken.
+	blockedReason := fmt.Sprintf("Booking session %s for user %s", req.SessionID, session.UserID)
+	if err := s.holder.holdAll(ctx, req.SessionID, req.EventID, []*models.SeatReservation{reservation}, blockedReason, session.ExpiresAt); err != nil {
+		return err
 	}
 
 	// Update session totals
-	session.SeatCount++
-	session.TotalAmount += req.FinalPrice
-
-	if err := s.bookingRepo.Update(ctx, session); err != nil {
-		return fmt.Errorf("failed to update booking session: %w", err)
+	session, err = refreshSessionTotals(ctx, s.bookingRepo, s.reservationRepo, req.SessionID)
+	if err != nil {
+		// The seat is not part of the session without the totals, so give it back
+		s.holder.releaseHolds(ctx, []*models.SeatReservation{reservation}, "Session update failed", true)
+		return err
 	}
 
 	// Increment metrics
@@ -207,6 +244,16 @@ func (s *TicketBookingSessionService) RemoveSeatFromSession(ctx context.Context,
 		return fmt.Errorf("booking session is not active, status: %s", session.Status)
 	}
 
+	// The seats are locked in while the session is being completed
+	if err := s.ensureNotCompleting(ctx, req.SessionID); err != nil {
+		return err
+	}
+
+	// Group bookings hold their seats until the group settles
+	if err := s.ensureNotGroup(ctx, req.SessionID); err != nil {
+		return err
+	}
+
 	// Get seat reservation
 	reservations, err := s.reservationRepo.GetByBookingSessionID(ctx, req.SessionID)
 	if err != nil {
@@ -245,11 +292,9 @@ func (s *TicketBookingSessionService) RemoveSeatFromSession(ctx context.Context,
 	}
 
 	// Update session totals
-	session.SeatCount--
-	session.TotalAmount -= targetReservation.FinalPrice
-
-	if err := s.bookingRepo.Update(ctx, session); err != nil {
-		return fmt.Errorf("failed to update booking session: %w", err)
+	session, err = refreshSessionTotals(ctx, s.bookingRepo, s.reservationRepo, req.SessionID)
+	if err != nil {
+		return err
 	}
 
 	// Increment metrics
@@ -265,83 +310,70 @@ func (s *TicketBookingSessionService) RemoveSeatFromSession(ctx context.Context,
 	return nil
 }
 
-// CompleteBookingSession completes a booking session
+// CompleteBookingSession completes a booking session through the booking saga. The
+// result is not successful yet while a failed step waits to be retried; completing the
+// session again reports the saga's progress. A booking that was rolled back fails with
+// ErrBookingFailed.
 func (s *TicketBookingSessionService) CompleteBookingSession(ctx context.Context, req *BookingSessionCompleteCommand) (*BookingSessionCompleteResult, error) {
-	// Get booking session
-	session, err := s.bookingRepo.GetByID(ctx, req.SessionID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get booking session: %w", err)
+	// Every member of a group pays for their own seat instead
+	if err := s.ensureNotGroup(ctx, req.SessionID); err != nil {
+		return nil, err
 	}
 
-	// Validate session is active
-	if !session.IsActive() {
-		return nil, fmt.Errorf("booking session is not active, status: %s", session.Status)
-	}
-
-	// Get seat reservations
-	reservations, err := s.reservationRepo.GetByBookingSessionID(ctx, req.SessionID)
+	saga, err := s.saga.Complete(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get seat reservations: %w", err)
+		return nil, err
 	}
 
-	if len(reservations) == 0 {
-		return nil, fmt.Errorf("no seats reserved in session")
+	result := &BookingSessionCompleteResult{
+		SagaID: saga.ID,
+		Status: saga.Status,
+	}
+	if saga.PaymentID != nil {
+		result.PaymentID = *saga.PaymentID
 	}
 
-	// Process payment if required
-	var paymentID string
-	if session.TotalAmount > 0 && s.paymentClient != nil {
-		paymentReq := &paymentpb.CreatePaymentRequest{
-			BookingId:     req.SessionID,
-			Amount:        session.TotalAmount,
-			Currency:      session.Currency,
-			PaymentMethod: req.PaymentMethod,
-			UserId:        session.UserID,
+	switch saga.Status {
+	case models.BookingSagaStatusCompensated:
+		reason := "booking was rolled back"
+		if saga.LastError != nil {
+			reason = *saga.LastError
 		}
-
-		paymentResp, err := s.paymentClient.CreatePayment(ctx, paymentReq)
+		return nil, fmt.Errorf("%w: %s", ErrBookingFailed, reason)
+	case models.BookingSagaStatusCompleted:
+		session, err := s.bookingRepo.GetByID(ctx, req.SessionID)
 		if err != nil {
-			return nil, fmt.Errorf("payment processing failed: %w", err)
+			return nil, fmt.Errorf("failed to get booking session: %w", err)
 		}
 
-		if paymentResp.Payment != nil {
-			paymentID = paymentResp.Payment.PaymentId
-		}
-	}
-
-	// Confirm all seat reservations
-	for _, reservation := range reservations {
-		err = s.reservationRepo.Confirm(ctx, reservation.ID, req.CompletedBy)
+		tickets, err := s.saga.IssuedTickets(ctx, req.SessionID)
 		if err != nil {
-			s.logger.Error("Failed to confirm seat reservation",
-				zap.String("reservation_id", reservation.ID),
-				zap.Error(err),
-			)
+			return nil, err
 		}
-	}
 
-	// Complete booking session
-	err = s.bookingRepo.Complete(ctx, req.SessionID, req.CompletedBy)
-	if err != nil {
-		return nil, fmt.Errorf("failed to complete booking session: %w", err)
-	}
+		result.Success = true
+		result.SeatCount = session.SeatCount
+		result.TotalAmount = session.TotalAmount
+		result.Tickets = tickets
 
-	// Increment metrics
-	metrics.IncrementBookingSessionCompleted(session.EventID)
-	metrics.IncrementSeatReservationConfirmed(session.EventID, reservations[0].ZoneID)
+		s.leaveWaitingRoom(ctx, session.UserID, session.EventID)
 
-	s.logger.Info("Booking session completed successfully",
-		zap.String("session_id", req.SessionID),
-		zap.String("payment_id", paymentID),
-		zap.Int("seat_count", len(reservations)),
-	)
+		s.logger.Info("Booking session completed successfully",
+			zap.String("session_id", req.SessionID),
+			zap.String("saga_id", saga.ID),
+			zap.String("payment_id", result.PaymentID),
+			zap.Int("seat_count", session.SeatCount),
+		)
+	default:
+		s.logger.Info("Booking session completion in progress",
+			zap.String("session_id", req.SessionID),
+			zap.String("saga_id", saga.ID),
+			zap.String("status", saga.Status),
+			zap.String("step", saga.CurrentStep),
+		)
+	}
 
-	return &BookingSessionCompleteResult{
-		Success:     true,
-		PaymentID:   paymentID,
-		SeatCount:   len(reservations),
-		TotalAmount: session.TotalAmount,
-	}, nil
+	return result, nil
 }
 
 // CancelBookingSession cancels a booking session
@@ -357,8 +389,18 @@ func (s *TicketBookingSessionService) CancelBookingSession(ctx context.Context,
 		return fmt.Errorf("booking session is already completed")
 	}
 
+	// A saga completing the session cancels it itself if the booking fails
+	if err := s.ensureNotCompleting(ctx, req.SessionID); err != nil {
+		return err
+	}
+
+	// A group booking is cancelled by its organizer and expires once it settles
+	if err := s.ensureNotGroup(ctx, req.SessionID); err != nil {
+		return err
+	}
+
 	// Release all seat reservations
-	err = s.reservationRepo.ReleaseByBookingSession(ctx, req.SessionID, req.Reason, req.CancelledBy)
+	released, err := s.reservationRepo.ReleaseByBookingSession(ctx, req.SessionID, req.Reason, req.CancelledBy)
 	if err != nil {
 		s.logger.Error("Failed to release seat reservations",
 			zap.String("session_id", req.SessionID),
@@ -366,31 +408,42 @@ func (s *TicketBookingSessionService) CancelBookingSession(ctx context.Context,
 		)
 	}
 
-	// Release seats in Event Service
-	if s.eventClient != nil {
-		reservations, err := s.reservationRepo.GetByBookingSessionID(ctx, req.SessionID)
-		if err == nil && len(reservations) > 0 {
-			seatIDs := make([]string, len(reservations))
-			for i, reservation := range reservations {
-				seatIDs[i] = reservation.SeatID
-			}
+	reservations, err := s.reservationRepo.GetByBookingSessionID(ctx, req.SessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get seat reservations: %w", err)
+	}
 
-			_, err := s.eventClient.ReleaseSeats(ctx, session.EventID, seatIDs)
-			if err != nil {
-				s.logger.Warn("Failed to release seats in Event Service",
-					zap.String("event_id", session.EventID),
-					zap.Error(err),
-				)
-			}
+	// Release seats in Event Service, only those this session still held
+	if s.eventClient != nil && len(released) > 0 {
+		_, err := s.eventClient.ReleaseSeats(ctx, session.EventID, released)
+		if err != nil {
+			s.logger.Warn("Failed to release seats in Event Service",
+				zap.String("event_id", session.EventID),
+				zap.Error(err),
+			)
 		}
 	}
 
-	// Cancel booking session
-	err = s.bookingRepo.Cancel(ctx, req.SessionID, req.Reason, req.CancelledBy)
+	// Cancel booking session, announcing it with the cancellation
+	eventType, eventStatus := models.EventTypeBookingCancelled, models.BookingEventStatusCancelled
+	if req.Expired {
+		eventType, eventStatus = models.EventTypeBookingExpired, models.BookingEventStatusExpired
+	}
+	payload := bookingEventPayload(session, eventStatus, seatIDsOf(reservations))
+	payload.CancellationReason = req.Reason
+	payload.CancelledAt = payload.Timestamp
+	event, err := newBookingEvent(eventType, payload)
+	if err != nil {
+		return err
+	}
+
+	err = s.bookingRepo.Cancel(ctx, req.SessionID, req.Reason, req.CancelledBy, event)
 	if err != nil {
 		return fmt.Errorf("failed to cancel booking session: %w", err)
 	}
 
+	s.leaveWaitingRoom(ctx, session.UserID, session.EventID)
+
 	// Increment metrics
 	metrics.IncrementBookingSessionExpired(session.EventID)
 
@@ -413,18 +466,57 @@ func (s *TicketBookingSessionService) GetSessionReservations(ctx context.Context
 	return reservations, nil
 }
 
-// CleanupExpiredSessions cleans up expired booking sessions
+// CleanupExpiredSessions cleans up expired booking sessions, a batch at a time
 func (s *TicketBookingSessionService) CleanupExpiredSessions(ctx context.Context) error {
-	expiredSessions, err := s.bookingRepo.GetExpiredSessions(ctx, time.Now())
+	total := 0
+	var cursor *repositories.ExpiredSessionCursor
+	for {
+		expired, next, err := s.ExpireSessions(ctx, cursor, cleanupBatchSize)
+		if err != nil {
+			return err
+		}
+		total += expired
+
+		// Sessions that failed to expire are passed over and wait for the next cleanup
+		if next == nil {
+			break
+		}
+		cursor = next
+	}
+
+	s.logger.Info("Cleanup expired sessions completed",
+		zap.Int("expired_count", total),
+	)
+
+	return nil
+}
+
+// ExpireSessions cancels up to limit expired booking sessions after cursor, releasing their
+// seats in Event Service and announcing them back on sale. It returns how many were expired
+// and the cursor to continue from, or nil once no expired session is left after this batch.
+// Sessions that fail to expire are skipped, so they never hold up the ones behind them.
+func (s *TicketBookingSessionService) ExpireSessions(ctx context.Context, cursor *repositories.ExpiredSessionCursor, limit int) (int, *repositories.ExpiredSessionCursor, error) {
+	expiredSessions, err := s.bookingRepo.GetExpiredSessions(ctx, time.Now(), cursor, limit)
 	if err != nil {
-		return fmt.Errorf("failed to get expired sessions: %w", err)
+		return 0, nil, fmt.Errorf("failed to get expired sessions: %w", err)
 	}
 
+	expired := 0
 	for _, session := range expiredSessions {
+		reservations, err := s.reservationRepo.GetByBookingSessionID(ctx, session.ID)
+		if err != nil {
+			s.logger.Error("Failed to get reservations of expired session",
+				zap.String("session_id", session.ID),
+				zap.Error(err),
+			)
+			continue
+		}
+
 		req := &BookingSessionCancelCommand{
 			SessionID:   session.ID,
 			Reason:      "Session expired",
 			CancelledBy: "system",
+			Expired:     true,
 		}
 
 		if err := s.CancelBookingSession(ctx, req); err != nil {
@@ -432,17 +524,64 @@ func (s *TicketBookingSessionService) CleanupExpiredSessions(ctx context.Context
 				zap.String("session_id", session.ID),
 				zap.Error(err),
 			)
+			continue
 		}
+		expired++
+
+		held := reservationsWithStatus(reservations, models.ReservationStatusReserved)
+		announceSeatsReleased(ctx, s.realtimeClient, s.logger, session.EventID, seatIDsOf(held), req.Reason)
 	}
 
-	s.logger.Info("Cleanup expired sessions completed",
-		zap.Int("expired_count", len(expiredSessions)),
-	)
+	if len(expiredSessions) < limit {
+		return expired, nil, nil
+	}
+	last := expiredSessions[len(expiredSessions)-1]
+	return expired, &repositories.ExpiredSessionCursor{ExpiresAt: last.ExpiresAt, ID: last.ID}, nil
+}
+
+// Helper methods
 
+// ensureNotCompleting fails with ErrBookingInProgress while a saga is completing the session
+func (s *TicketBookingSessionService) ensureNotCompleting(ctx context.Context, sessionID string) error {
+	inFlight, err := s.saga.InFlight(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if inFlight {
+		return ErrBookingInProgress
+	}
 	return nil
 }
 
-// Helper methods
+// ensureNotGroup fails with ErrGroupBookingSession when the session belongs to a group booking
+func (s *TicketBookingSessionService) ensureNotGroup(ctx context.Context, sessionID string) error {
+	if s.groupRepo == nil {
+		return nil
+	}
+	isGroup, err := s.groupRepo.ExistsForBookingSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if isGroup {
+		return ErrGroupBookingSession
+	}
+	return nil
+}
+
+// leaveWaitingRoom removes a user whose booking session ended from the active users of
+// the event's waiting room
+func (s *TicketBookingSessionService) leaveWaitingRoom(ctx context.Context, userID, eventID string) {
+	if s.waitingRoom == nil {
+		return
+	}
+	if err := s.waitingRoom.CompleteUserBooking(ctx, userID, eventID); err != nil {
+		s.logger.Warn("Failed to leave waiting room",
+			zap.String("user_id", userID),
+			zap.String("event_id", eventID),
+			zap.Error(err),
+		)
+	}
+}
 
 func (s *TicketBookingSessionService) validateCreateBookingSessionRequest(req *BookingSessionCreateCommand) error {
 	if req.UserID == "" {
@@ -491,6 +630,7 @@ type BookingSessionCreateCommand struct {
 	IPAddress      string `json:"ip_address,omitempty"`
 	UserAgent      string `json:"user_agent,omitempty"`
 	CreatedBy      string `json:"created_by,omitempty"`
+	AdmissionToken string `json:"admission_token,omitempty"` // Required by events with an open waiting room
 }
 
 type BookingSessionAddSeatCommand struct {
@@ -519,14 +659,57 @@ type BookingSessionCompleteCommand struct {
 }
 
 type BookingSessionCompleteResult struct {
-	Success     bool    `json:"success"`
-	PaymentID   string  `json:"payment_id"`
-	SeatCount   int     `json:"seat_count"`
-	TotalAmount float64 `json:"total_amount"`
+	Success     bool             `json:"success"`
+	SagaID      string           `json:"saga_id"`
+	Status      string           `json:"status"`
+	PaymentID   string           `json:"payment_id"`
+	SeatCount   int              `json:"seat_count"`
+	TotalAmount float64          `json:"total_amount"`
+	Tickets     []*models.Ticket `json:"tickets,omitempty"`
 }
 
 type BookingSessionCancelCommand struct {
 	SessionID   string `json:"session_id"`
 	Reason      string `json:"reason"`
 	CancelledBy string `json:"cancelled_by"`
+	Expired     bool   `json:"expired,omitempty"`
+}
+
+// refreshSessionTotals recomputes the seat count and total amount of a booking session
+// from its reservations. Concurrent updates of the session are retried.
+func refreshSessionTotals(ctx context.Context, bookingRepo *repositories.BookingSessionRepository, reservationRepo *repositories.SeatReservationRepository, sessionID string) (*models.BookingSession, error) {
+	var session *models.BookingSession
+	err := retryOnConflict(ctx, func() error {
+		var err error
+		session, err = bookingRepo.GetByID(ctx, sessionID)
+		if err != nil {
+			return fmt.Errorf("failed to get booking session: %w", err)
+		}
+
+		reservations, err := reservationRepo.GetByBookingSessionID(ctx, sessionID)
+		if err != nil {
+			return fmt.Errorf("failed to get seat reservations: %w", err)
+		}
+
+		session.SeatCount, session.TotalAmount = sessionTotals(reservations)
+		return bookingRepo.Update(ctx, session)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update booking session totals: %w", err)
+	}
+
+	return session, nil
+}
+
+// sessionTotals counts the seats held or bought through a booking session and adds up
+// their price, rounded to cents
+func sessionTotals(reservations []*models.SeatReservation) (int, float64) {
+	count, total := 0, 0.0
+	for _, reservation := range reservations {
+		if reservation.Status == models.ReservationStatusReserved || reservation.Status == models.ReservationStatusConfirmed {
+			count++
+			total += reservation.FinalPrice
+		}
+	}
+	return count, math.Round(total*100) / 100
 }
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"ticketqr"
+
+	"ticket-service/config"
+	"ticket-service/grpcclient"
+	"ticket-service/models"
+)
+
+// TicketCodeIssuer creates the QR codes and barcodes of tickets. QR codes are signed
+// tokens that check-in verifies offline, so they cannot be forged or altered.
+type TicketCodeIssuer struct {
+	signer          *ticketqr.Signer
+	defaultValidity time.Duration
+	eventClient     *grpcclient.EventServiceClient
+	logger          *zap.Logger
+}
+
+// NewTicketCodeIssuer creates a ticket code issuer signing with the configured key.
+// eventClient, which may be nil, supplies the event schedules tickets are valid for.
+func NewTicketCodeIssuer(cfg config.TicketQRConfig, eventClient *grpcclient.EventServiceClient, logger *zap.Logger) (*TicketCodeIssuer, error) {
+	keys, err := ticketqr.ParsePrivateKeys(cfg.SigningKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := keys[cfg.SigningKeyID]
+	if !ok {
+		return nil, fmt.Errorf("ticket QR signing key %q is not configured", cfg.SigningKeyID)
+	}
+
+	signer, err := ticketqr.NewSigner(cfg.SigningKeyID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TicketCodeIssuer{
+		signer:          signer,
+		defaultValidity: cfg.DefaultValidity,
+		eventClient:     eventClient,
+		logger:          logger,
+	}, nil
+}
+
+// eventValidUntil returns when tickets of an event stop being valid: when the event ends.
+// It returns nil when Event Service cannot tell or the event is over, leaving the tickets'
+// codes valid for the default validity.
+func (i *TicketCodeIssuer) eventValidUntil(ctx context.Context, eventID string) *time.Time {
+	if i.eventClient == nil {
+		return nil
+	}
+
+	event, err := i.eventClient.GetEvent(ctx, eventID)
+	if err != nil || event == nil {
+		i.logger.Warn("Failed to get event schedule for ticket validity",
+			zap.String("event_id", eventID),
+			zap.Error(err),
+		)
+		return nil
+	}
+
+	endsAt, ok := parseEventTime(event.EndDate)
+	if !ok || !endsAt.After(time.Now()) {
+		i.logger.Warn("Event has no upcoming end date for ticket validity",
+			zap.String("event_id", eventID),
+			zap.String("end_date", event.EndDate),
+		)
+		return nil
+	}
+	return &endsAt
+}
+
+// assign sets the QR code and barcode of a ticket. The ticket needs its ID. The QR code
+// is valid from the ticket's valid_from to its valid_until, or for the default validity.
+// Every call yields a new QR code, so assigning again replaces the previous one.
+func (i *TicketCodeIssuer) assign(ticket *models.Ticket) error {
+	validUntil := ticket.ValidFrom.Add(i.defaultValidity)
+	if ticket.ValidUntil != nil {
+		validUntil = *ticket.ValidUntil
+	}
+
+	qrCode, err := i.signer.Sign(ticketqr.Claims{
+		TicketID:  ticket.ID,
+		EventID:   ticket.EventID,
+		SeatID:    ticket.SeatID,
+		NotBefore: ticket.ValidFrom,
+		ExpiresAt: validUntil,
+		IssuedAt:  time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to sign ticket QR code: %w", err)
+	}
+	ticket.QRCode = &qrCode
+
+	// Generate barcode
+	barcode := fmt.Sprintf("%s%s%s", ticket.ID[:8], ticket.EventID[:8], ticket.SeatID[:8])
+	ticket.Barcode = &barcode
+
+	return nil
+}
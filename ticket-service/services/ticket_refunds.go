@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"ticket-service/grpcclient"
+	paymentpb "ticket-service/internal/protos/payment"
+	"ticket-service/metrics"
+	"ticket-service/models"
+)
+
+// RefundQuote is what cancelling a ticket refunds under its event's refund policy
+type RefundQuote struct {
+	TicketID      string                    `json:"ticket_id"`
+	EventID       string                    `json:"event_id"`
+	FinalPrice    float64                   `json:"final_price"`
+	RefundPercent float64                   `json:"refund_percent"`
+	RefundAmount  float64                   `json:"refund_amount"`
+	Currency      string                    `json:"currency"`
+	EventStartsAt *time.Time                `json:"event_starts_at,omitempty"`
+	Refundable    bool                      `json:"refundable"`
+	Reason        string                    `json:"reason,omitempty"` // Why nothing is refunded
+	Policy        *models.EventRefundPolicy `json:"policy"`
+}
+
+// eventTimeLayouts are the formats event-service returns event dates in
+var eventTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999-07",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+// PreviewRefund shows what cancelling a ticket now would refund, without cancelling it
+func (s *TicketService) PreviewRefund(ctx context.Context, ticketID string) (*RefundQuote, error) {
+	ticket, err := s.ticketRepo.GetByID(ctx, ticketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+
+	if !ticket.CanBeCancelled() {
+		return nil, fmt.Errorf("ticket cannot be cancelled, current status: %s", ticket.Status)
+	}
+
+	return s.quoteRefund(ctx, ticket, time.Now())
+}
+
+// GetEventRefundPolicy retrieves the refund policy of an event
+func (s *TicketService) GetEventRefundPolicy(ctx context.Context, eventID string) (*models.EventRefundPolicy, error) {
+	return s.refundPolicyRepo.GetByEventID(ctx, eventID)
+}
+
+// SetEventRefundPolicy replaces the refund policy of an event. It applies to tickets
+// cancelled afterwards.
+func (s *TicketService) SetEventRefundPolicy(ctx context.Context, policy *models.EventRefundPolicy, updatedBy string) error {
+	if err := policy.Validate(); err != nil {
+		return fmt.Errorf("invalid refund policy: %w", err)
+	}
+
+	if err := s.refundPolicyRepo.Replace(ctx, policy, updatedBy); err != nil {
+		return err
+	}
+
+	s.logger.Info("Event refund policy updated",
+		zap.String("event_id", policy.EventID),
+		zap.Int("rules", len(policy.Rules)),
+		zap.String("updated_by", updatedBy),
+	)
+
+	return nil
+}
+
+// quoteRefund works out the refund of cancelling a ticket at now. Amounts are rounded to cents.
+func (s *TicketService) quoteRefund(ctx context.Context, ticket *models.Ticket, now time.Time) (*RefundQuote, error) {
+	quote := &RefundQuote{
+		TicketID:   ticket.ID,
+		EventID:    ticket.EventID,
+		FinalPrice: ticket.FinalPrice,
+		Currency:   ticket.Currency,
+	}
+
+	policy, err := s.refundPolicyRepo.GetByEventID(ctx, ticket.EventID)
+	if err != nil {
+		return nil, err
+	}
+	quote.Policy = policy
+
+	switch {
+	case !ticket.IsPaid():
+		quote.Reason = "ticket is not paid"
+		return quote, nil
+	case ticket.FinalPrice <= 0:
+		quote.Reason = "ticket is free"
+		return quote, nil
+	case ticket.PaymentReference == nil:
+		quote.Reason = "ticket has no payment to refund"
+		return quote, nil
+	}
+
+	quote.RefundPercent = 100
+	if !policy.IsDefault {
+		startsAt, err := s.eventStart(ctx, ticket.EventID)
+		if err != nil {
+			return nil, err
+		}
+		quote.EventStartsAt = &startsAt
+		quote.RefundPercent = policy.RefundPercent(startsAt.Sub(now))
+	}
+
+	quote.RefundAmount = math.Round(ticket.FinalPrice*quote.RefundPercent) / 100
+	quote.Refundable = quote.RefundAmount > 0
+	if !quote.Refundable {
+		quote.Reason = "the event's refund policy does not refund cancellations this close to the event"
+	}
+
+	return quote, nil
+}
+
+// eventStart retrieves when an event starts from Event Service
+func (s *TicketService) eventStart(ctx context.Context, eventID string) (time.Time, error) {
+	if s.eventClient == nil {
+		return time.Time{}, fmt.Errorf("event service not available")
+	}
+
+	event, err := s.eventClient.GetEvent(ctx, eventID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get event: %w", err)
+	}
+	if event == nil {
+		return time.Time{}, fmt.Errorf("event %s not found", eventID)
+	}
+
+	startsAt, ok := parseEventTime(event.StartDate)
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid start date %q of event %s", event.StartDate, eventID)
+	}
+	return startsAt, nil
+}
+
+// parseEventTime parses an event date as returned by event-service
+func parseEventTime(value string) (time.Time, bool) {
+	for _, layout := range eventTimeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// processRefund refunds a cancelled ticket as quoted and marks it refunded, in full or in part
+func (s *TicketService) processRefund(ctx context.Context, ticket *models.Ticket, quote *RefundQuote, reason string) error {
+	if !quote.Refundable {
+		return nil
+	}
+	if s.paymentClient == nil {
+		return fmt.Errorf("payment service not available")
+	}
+
+	refund, err := refundPayment(ctx, s.paymentClient, *ticket.PaymentReference, quote.RefundAmount, reason, "ticket-refund-"+ticket.ID)
+	if err != nil {
+		return err
+	}
+
+	amount := quote.RefundAmount
+	if refund.Amount > 0 {
+		amount = refund.Amount
+	}
+
+	paymentStatus := models.PaymentStatusRefunded
+	if amount < ticket.FinalPrice {
+		paymentStatus = models.PaymentStatusPartiallyRefunded
+	}
+
+	// Mark the ticket refunded, announcing it with the refund
+	refunded := *ticket
+	refunded.Status = models.TicketStatusRefunded
+	refunded.PaymentStatus = paymentStatus
+	refunded.RefundedAmount = &amount
+	event, err := newTicketEvent(models.EventTypeTicketRefunded, &refunded, reason)
+	if err != nil {
+		return err
+	}
+
+	err = s.ticketRepo.MarkRefunded(ctx, ticket.ID, amount, paymentStatus, "", event)
+	if err != nil {
+		return fmt.Errorf("failed to update ticket status: %w", err)
+	}
+
+	// Increment metrics
+	metrics.IncrementTicketRefunded(ticket.EventID, reason)
+
+	s.logger.Info("Ticket refunded",
+		zap.String("ticket_id", ticket.ID),
+		zap.Float64("refund_amount", amount),
+		zap.Float64("refund_percent", quote.RefundPercent),
+	)
+
+	return nil
+}
+
+// refundIdempotencyKeyMetadata tags a refund with its idempotency key, so a retry finds
+// the refund made the first time instead of counting it against the refundable balance
+const refundIdempotencyKeyMetadata = "idempotency_key"
+
+// refundPayment refunds amount of a payment, capped at what is left to refund of it. A
+// payment may cover several tickets that are refunded one at a time, so the earlier
+// refunds of the payment, unless they failed, are taken off its amount and every refund
+// is partial. Refunding again under the same idempotency key returns the earlier refund.
+func refundPayment(ctx context.Context, paymentClient *grpcclient.PaymentServiceClient, paymentID string, amount float64, reason, idempotencyKey string) (*paymentpb.Refund, error) {
+	payment, err := getPayment(ctx, paymentClient, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	refundsResp, err := paymentClient.ListRefunds(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refunds: %w", err)
+	}
+
+	refundable := payment.Amount
+	for _, refund := range refundsResp.Refunds {
+		switch strings.ToLower(refund.Status) {
+		case refundStatusFailed, refundStatusCancelled:
+			continue
+		}
+		if refund.Metadata[refundIdempotencyKeyMetadata] == idempotencyKey {
+			return refund, nil
+		}
+		refundable -= refund.Amount
+	}
+	if refundable <= 0 {
+		return nil, fmt.Errorf("payment %s has nothing left to refund", paymentID)
+	}
+
+	refundResp, err := paymentClient.CreateRefund(ctx, &paymentpb.CreateRefundRequest{
+		PaymentId:      paymentID,
+		Amount:         min(amount, refundable),
+		Reason:         reason,
+		RefundType:     "partial",
+		IdempotencyKey: idempotencyKey,
+		Metadata:       map[string]string{refundIdempotencyKeyMetadata: idempotencyKey},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("refund processing failed: %w", err)
+	}
+	if refundResp.Refund == nil {
+		return nil, fmt.Errorf("payment service returned no refund")
+	}
+
+	return refundResp.Refund, nil
+}
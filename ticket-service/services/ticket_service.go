@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -14,26 +15,35 @@ import (
 	"ticket-service/repositories"
 )
 
+// ErrInvalidSearchQuery is returned when a ticket search query is invalid
+var ErrInvalidSearchQuery = errors.New("invalid ticket search query")
+
 // TicketService handles ticket business logic
 type TicketService struct {
-	ticketRepo    *repositories.TicketRepository
-	eventClient   *grpcclient.EventServiceClient
-	paymentClient *grpcclient.PaymentServiceClient
-	logger        *zap.Logger
+	ticketRepo       *repositories.TicketRepository
+	refundPolicyRepo *repositories.RefundPolicyRepository
+	eventClient      *grpcclient.EventServiceClient
+	paymentClient    *grpcclient.PaymentServiceClient
+	codeIssuer       *TicketCodeIssuer
+	logger           *zap.Logger
 }
 
 // NewTicketService creates a new ticket service
 func NewTicketService(
 	ticketRepo *repositories.TicketRepository,
+	refundPolicyRepo *repositories.RefundPolicyRepository,
 	eventClient *grpcclient.EventServiceClient,
 	paymentClient *grpcclient.PaymentServiceClient,
+	codeIssuer *TicketCodeIssuer,
 	logger *zap.Logger,
 ) *TicketService {
 	return &TicketService{
-		ticketRepo:    ticketRepo,
-		eventClient:   eventClient,
-		paymentClient: paymentClient,
-		logger:        logger,
+		ticketRepo:       ticketRepo,
+		refundPolicyRepo: refundPolicyRepo,
+		eventClient:      eventClient,
+		paymentClient:    paymentClient,
+		codeIssuer:       codeIssuer,
+		logger:           logger,
 	}
 }
 
@@ -76,6 +86,9 @@ func (s *TicketService) CreateTicket(ctx context.Context, req *CreateTicketReque
 	}
 	if req.ValidUntil != nil {
 		ticket.ValidUntil = req.ValidUntil
+	} else {
+		// Valid from issuance until the event ends
+		ticket.ValidUntil = s.codeIssuer.eventValidUntil(ctx, req.EventID)
 	}
 	if req.CreatedBy != "" {
 		ticket.CreatedBy = &req.CreatedBy
@@ -273,8 +286,25 @@ func (s *TicketService) CancelTicket(ctx context.Context, req *CancelTicketReque
 		return fmt.Errorf("ticket cannot be cancelled, current status: %s", ticket.Status)
 	}
 
-	// Cancel ticket
-	err = s.ticketRepo.Delete(ctx, req.TicketID, req.Reason, req.CancelledBy)
+	// Work out the refund before cancelling, so a ticket is not cancelled when its refund
+	// cannot be determined
+	var quote *RefundQuote
+	if ticket.IsPaid() {
+		quote, err = s.quoteRefund(ctx, ticket, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to determine refund: %w", err)
+		}
+	}
+
+	// Cancel ticket, announcing it with the cancellation
+	cancelled := *ticket
+	cancelled.Status = models.TicketStatusCancelled
+	event, err := newTicketEvent(models.EventTypeTicketCancelled, &cancelled, req.Reason)
+	if err != nil {
+		return err
+	}
+
+	err = s.ticketRepo.Delete(ctx, req.TicketID, req.Reason, req.CancelledBy, event)
 	if err != nil {
 		return fmt.Errorf("failed to cancel ticket: %w", err)
 	}
@@ -291,9 +321,9 @@ func (s *TicketService) CancelTicket(ctx context.Context, req *CancelTicketReque
 		}
 	}
 
-	// Process refund if ticket was paid
-	if ticket.IsPaid() {
-		if err := s.processRefund(ctx, ticket, req.Reason); err != nil {
+	// Process refund if ticket was paid, as far as the event's refund policy allows
+	if quote != nil {
+		if err := s.processRefund(ctx, ticket, quote, req.Reason); err != nil {
 			s.logger.Error("Failed to process refund",
 				zap.String("ticket_id", req.TicketID),
 				zap.Error(err),
@@ -313,14 +343,18 @@ func (s *TicketService) CancelTicket(ctx context.Context, req *CancelTicketReque
 	return nil
 }
 
-// SearchTickets searches tickets with filters
-func (s *TicketService) SearchTickets(ctx context.Context, filters map[string]interface{}, page, limit int) ([]*models.Ticket, int, error) {
-	tickets, total, err := s.ticketRepo.Search(ctx, filters, page, limit)
+// SearchTickets searches tickets, a page at a time
+func (s *TicketService) SearchTickets(ctx context.Context, query *models.TicketSearchQuery) (*models.TicketSearchPage, error) {
+	if err := query.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSearchQuery, err)
+	}
+
+	page, err := s.ticketRepo.Search(ctx, query)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to search tickets: %w", err)
+		return nil, fmt.Errorf("failed to search tickets: %w", err)
 	}
 
-	return tickets, total, nil
+	return page, nil
 }
 
 // Helper methods
@@ -420,52 +454,22 @@ func (s *TicketService) handleTicketUsage(ctx context.Context, ticket *models.Ti
 }
 
 func (s *TicketService) generateTicketCodes(ctx context.Context, ticket *models.Ticket) error {
-	// Generate QR code
-	qrCode := fmt.Sprintf("TICKET:%s:%s:%s", ticket.ID, ticket.EventID, ticket.SeatID)
-	ticket.QRCode = &qrCode
-
-	// Generate barcode
-	barcode := fmt.Sprintf("%s%s%s", ticket.ID[:8], ticket.EventID[:8], ticket.SeatID[:8])
-	ticket.Barcode = &barcode
-
-	// Update ticket in database
-	return s.ticketRepo.Update(ctx, ticket)
-}
-
-func (s *TicketService) processRefund(ctx context.Context, ticket *models.Ticket, reason string) error {
-	if s.paymentClient == nil {
-		return fmt.Errorf("payment service not available")
-	}
-
-	refundReq := &paymentpb.CreateRefundRequest{
-		PaymentId: *ticket.PaymentReference,
-		Amount:    ticket.FinalPrice,
-		Reason:    reason,
-	}
-
-	refundResp, err := s.paymentClient.CreateRefund(ctx, refundReq)
-	if err != nil {
-		return fmt.Errorf("refund processing failed: %w", err)
-	}
-
-	if refundResp.Refund != nil {
-		// Update ticket status to refunded
-		err = s.ticketRepo.UpdateStatus(ctx, ticket.ID, models.TicketStatusRefunded, "system")
-		if err != nil {
-			return fmt.Errorf("failed to update ticket status: %w", err)
+	return retryOnConflict(ctx, func() error {
+		if err := s.codeIssuer.assign(ticket); err != nil {
+			return err
 		}
 
-		// Update payment status
-		err = s.ticketRepo.UpdatePaymentStatus(ctx, ticket.ID, models.PaymentStatusRefunded, "", "", "system")
-		if err != nil {
-			return fmt.Errorf("failed to update payment status: %w", err)
+		// Update ticket in database, reading it again if it changed meanwhile
+		err := s.ticketRepo.Update(ctx, ticket)
+		if errors.Is(err, repositories.ErrConflict) {
+			current, getErr := s.ticketRepo.GetByID(ctx, ticket.ID)
+			if getErr != nil {
+				return getErr
+			}
+			*ticket = *current
 		}
-
-		// Increment metrics
-		metrics.IncrementTicketRefunded(ticket.EventID, reason)
-	}
-
-	return nil
+		return err
+	})
 }
 
 // Request/Response types
@@ -0,0 +1,440 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"ticket-service/config"
+	"ticket-service/grpcclient"
+	paymentpb "ticket-service/internal/protos/payment"
+	"ticket-service/metrics"
+	"ticket-service/models"
+	"ticket-service/repositories"
+)
+
+var (
+	// ErrTransferNotAllowed is returned when the ticket or its event does not allow the transfer
+	ErrTransferNotAllowed = errors.New("ticket transfer is not allowed")
+	// ErrNotTicketOwner is returned when a user transfers a ticket they do not own
+	ErrNotTicketOwner = errors.New("user does not own the ticket")
+	// ErrNotTransferParty is returned when a user acts on a transfer they are not part of
+	ErrNotTransferParty = errors.New("user is not a party to the ticket transfer")
+	// ErrTransferExpired is returned when a transfer is accepted after it expired
+	ErrTransferExpired = errors.New("ticket transfer has expired")
+	// ErrTransferPaymentFailed is returned when the recipient's payment for a resale fails
+	ErrTransferPaymentFailed = errors.New("resale payment failed")
+	// ErrTransferPaymentPending is returned when the recipient's payment for a resale was
+	// accepted but has not succeeded yet; accepting again once it has moves the ticket
+	ErrTransferPaymentPending = errors.New("resale payment is still pending")
+)
+
+const (
+	// resaleRefundReason is the reason recorded on the refund of a resold ticket's previous payment
+	resaleRefundReason = "Ticket resold"
+	// resaleFailedReason is the reason recorded on the refund of a resale that could not complete
+	resaleFailedReason = "Ticket resale failed"
+)
+
+// TicketTransferService moves tickets between users. The owner offers a ticket to a
+// recipient, either as a gift or, where the organizer allows it, as a resale at face value
+// that the recipient pays for and the previous owner is refunded. When the recipient
+// accepts, the ticket gets a new QR code, which invalidates the old one, and the change is
+// written to the ticket's ownership audit trail.
+type TicketTransferService struct {
+	ticketRepo    *repositories.TicketRepository
+	transferRepo  *repositories.TicketTransferRepository
+	paymentClient *grpcclient.PaymentServiceClient
+	codeIssuer    *TicketCodeIssuer
+	config        config.TransferConfig
+	logger        *zap.Logger
+}
+
+// NewTicketTransferService creates a new ticket transfer service
+func NewTicketTransferService(
+	ticketRepo *repositories.TicketRepository,
+	transferRepo *repositories.TicketTransferRepository,
+	paymentClient *grpcclient.PaymentServiceClient,
+	codeIssuer *TicketCodeIssuer,
+	cfg config.TransferConfig,
+	logger *zap.Logger,
+) *TicketTransferService {
+	return &TicketTransferService{
+		ticketRepo:    ticketRepo,
+		transferRepo:  transferRepo,
+		paymentClient: paymentClient,
+		codeIssuer:    codeIssuer,
+		config:        cfg,
+		logger:        logger,
+	}
+}
+
+// InitiateTransfer offers a ticket to another user. The ticket stays with its owner until
+// the recipient accepts; a ticket has at most one pending transfer.
+func (s *TicketTransferService) InitiateTransfer(ctx context.Context, req *InitiateTransferRequest) (*models.TicketTransfer, error) {
+	if err := s.validateInitiateTransferRequest(req); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	ticket, err := s.ticketRepo.GetByID(ctx, req.TicketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+
+	if ticket.UserID != req.FromUserID {
+		return nil, ErrNotTicketOwner
+	}
+	if err := s.checkTransferable(ctx, ticket, req.TransferType); err != nil {
+		return nil, err
+	}
+
+	expiresIn := req.ExpiresIn
+	if expiresIn <= 0 {
+		expiresIn = s.config.DefaultExpiry
+	}
+	if expiresIn > s.config.MaxExpiry {
+		expiresIn = s.config.MaxExpiry
+	}
+
+	transfer := models.NewTicketTransfer(ticket, req.ToUserID, req.TransferType, time.Now().Add(expiresIn))
+	if err := s.transferRepo.Create(ctx, transfer); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Ticket transfer initiated",
+		zap.String("transfer_id", transfer.ID),
+		zap.String("ticket_id", ticket.ID),
+		zap.String("from_user_id", transfer.FromUserID),
+		zap.String("to_user_id", transfer.ToUserID),
+		zap.String("transfer_type", transfer.TransferType),
+	)
+
+	return transfer, nil
+}
+
+// AcceptTransfer moves the ticket to the recipient of a pending transfer. For a resale,
+// the recipient pays the face value and the previous owner is refunded first; while the
+// payment is pending the transfer stays pending and ErrTransferPaymentPending is returned.
+func (s *TicketTransferService) AcceptTransfer(ctx context.Context, req *AcceptTransferRequest) (*models.TicketTransfer, *models.Ticket, error) {
+	transfer, err := s.transferRepo.GetByID(ctx, req.TransferID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if transfer.ToUserID != req.UserID {
+		return nil, nil, ErrNotTransferParty
+	}
+	if transfer.Status != models.TransferStatusPending {
+		return nil, nil, repositories.ErrTransferNotPending
+	}
+	if transfer.IsExpired() {
+		err := s.transferRepo.Close(ctx, transfer.ID, models.TransferStatusExpired, "")
+		if err == nil {
+			s.giveBackResalePayment(ctx, transfer)
+		} else if !errors.Is(err, repositories.ErrTransferNotPending) {
+			s.logger.Warn("Failed to expire ticket transfer",
+				zap.String("transfer_id", transfer.ID),
+				zap.Error(err),
+			)
+		}
+		return nil, nil, ErrTransferExpired
+	}
+
+	ticket, err := s.ticketRepo.GetByID(ctx, transfer.TicketID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+	if ticket.UserID != transfer.FromUserID || ticket.Status != models.TicketStatusConfirmed {
+		s.closeStaleTransfer(ctx, transfer)
+		return nil, nil, repositories.ErrTicketOwnerChanged
+	}
+
+	// Settle the resale before moving the ticket. Both payment calls are idempotent per
+	// transfer, so accepting again after a failure does not charge or refund twice.
+	moved := *ticket
+	moved.UserID = transfer.ToUserID
+	moved.UpdatedBy = &req.UserID
+	if transfer.TransferType == models.TransferTypeResale && transfer.Price > 0 {
+		if err := s.settleResale(ctx, transfer, ticket, req.PaymentMethod); err != nil {
+			return nil, nil, err
+		}
+		moved.PaymentReference = transfer.PaymentReference
+		if req.PaymentMethod != "" {
+			moved.PaymentMethod = &req.PaymentMethod
+		}
+	}
+
+	if err := s.codeIssuer.assign(&moved); err != nil {
+		return nil, nil, err
+	}
+
+	change := &models.TicketOwnershipChange{
+		TicketID:         ticket.ID,
+		TransferID:       &transfer.ID,
+		FromUserID:       transfer.FromUserID,
+		ToUserID:         transfer.ToUserID,
+		ChangeType:       transfer.TransferType,
+		Price:            transfer.Price,
+		Currency:         transfer.Currency,
+		PaymentReference: transfer.PaymentReference,
+		RefundReference:  transfer.RefundReference,
+		ChangedBy:        &req.UserID,
+	}
+
+	event, err := newTicketTransferredEvent(&moved, transfer.FromUserID, transfer.TransferType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.transferRepo.Complete(ctx, transfer, &moved, change, event); err != nil {
+		if transfer.PaymentReference != nil {
+			s.logger.Error("Resale was paid but the ticket was not transferred",
+				zap.String("transfer_id", transfer.ID),
+				zap.String("ticket_id", ticket.ID),
+				zap.Stringp("payment_reference", transfer.PaymentReference),
+				zap.Stringp("refund_reference", transfer.RefundReference),
+				zap.Error(err),
+			)
+		}
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	transfer.Status = models.TransferStatusAccepted
+	transfer.AcceptedAt = &now
+
+	metrics.IncrementTicketTransferred(ticket.EventID, transfer.TransferType)
+
+	s.logger.Info("Ticket transfer accepted",
+		zap.String("transfer_id", transfer.ID),
+		zap.String("ticket_id", ticket.ID),
+		zap.String("to_user_id", transfer.ToUserID),
+	)
+
+	return transfer, &moved, nil
+}
+
+// CancelTransfer withdraws a pending transfer; the sender cancels it, the recipient declines it
+func (s *TicketTransferService) CancelTransfer(ctx context.Context, transferID, userID, reason string) error {
+	transfer, err := s.transferRepo.GetByID(ctx, transferID)
+	if err != nil {
+		return err
+	}
+
+	if userID != transfer.FromUserID && userID != transfer.ToUserID {
+		return ErrNotTransferParty
+	}
+
+	if err := s.transferRepo.Close(ctx, transferID, models.TransferStatusCancelled, reason); err != nil {
+		return err
+	}
+	s.giveBackResalePayment(ctx, transfer)
+
+	s.logger.Info("Ticket transfer cancelled",
+		zap.String("transfer_id", transferID),
+		zap.String("cancelled_by", userID),
+		zap.String("reason", reason),
+	)
+
+	return nil
+}
+
+// GetTransfer retrieves a ticket transfer by ID
+func (s *TicketTransferService) GetTransfer(ctx context.Context, transferID string) (*models.TicketTransfer, error) {
+	return s.transferRepo.GetByID(ctx, transferID)
+}
+
+// GetOwnershipHistory retrieves the ownership audit trail of a ticket
+func (s *TicketTransferService) GetOwnershipHistory(ctx context.Context, ticketID string) ([]*models.TicketOwnershipChange, error) {
+	return s.transferRepo.GetOwnershipHistory(ctx, ticketID)
+}
+
+// GetEventPolicy retrieves the transfer policy of an event
+func (s *TicketTransferService) GetEventPolicy(ctx context.Context, eventID string) (*models.EventTransferPolicy, error) {
+	return s.transferRepo.GetEventPolicy(ctx, eventID)
+}
+
+// SetEventPolicy sets the transfer policy of an event. It applies to transfers initiated
+// afterwards; pending transfers can still be accepted.
+func (s *TicketTransferService) SetEventPolicy(ctx context.Context, policy *models.EventTransferPolicy) error {
+	if policy.EventID == "" {
+		return fmt.Errorf("invalid request: event_id is required")
+	}
+	if policy.ResaleEnabled && !policy.TransfersEnabled {
+		return fmt.Errorf("invalid request: resale requires transfers to be enabled")
+	}
+
+	if err := s.transferRepo.UpsertEventPolicy(ctx, policy); err != nil {
+		return err
+	}
+
+	s.logger.Info("Event transfer policy updated",
+		zap.String("event_id", policy.EventID),
+		zap.Bool("transfers_enabled", policy.TransfersEnabled),
+		zap.Bool("resale_enabled", policy.ResaleEnabled),
+	)
+
+	return nil
+}
+
+// Helper methods
+
+func (s *TicketTransferService) validateInitiateTransferRequest(req *InitiateTransferRequest) error {
+	if req.TicketID == "" {
+		return fmt.Errorf("ticket_id is required")
+	}
+	if req.FromUserID == "" {
+		return fmt.Errorf("from_user_id is required")
+	}
+	if req.ToUserID == "" {
+		return fmt.Errorf("to_user_id is required")
+	}
+	if req.FromUserID == req.ToUserID {
+		return fmt.Errorf("cannot transfer a ticket to its owner")
+	}
+	if req.TransferType == "" {
+		req.TransferType = models.TransferTypeGift
+	}
+	if req.TransferType != models.TransferTypeGift && req.TransferType != models.TransferTypeResale {
+		return fmt.Errorf("transfer_type must be %s or %s", models.TransferTypeGift, models.TransferTypeResale)
+	}
+	return nil
+}
+
+// checkTransferable checks that the ticket can change hands and that its event allows it
+func (s *TicketTransferService) checkTransferable(ctx context.Context, ticket *models.Ticket, transferType string) error {
+	if ticket.Status != models.TicketStatusConfirmed || ticket.UsedAt != nil {
+		return fmt.Errorf("%w: ticket is %s", ErrTransferNotAllowed, ticket.Status)
+	}
+	if ticket.ValidUntil != nil && time.Now().After(*ticket.ValidUntil) {
+		return fmt.Errorf("%w: ticket is no longer valid", ErrTransferNotAllowed)
+	}
+
+	policy, err := s.transferRepo.GetEventPolicy(ctx, ticket.EventID)
+	if err != nil {
+		return err
+	}
+	if !policy.TransfersEnabled {
+		return fmt.Errorf("%w: the event does not allow transfers", ErrTransferNotAllowed)
+	}
+
+	if transferType == models.TransferTypeResale {
+		if !policy.ResaleEnabled {
+			return fmt.Errorf("%w: the event does not allow resale", ErrTransferNotAllowed)
+		}
+		if ticket.FinalPrice > 0 && (!ticket.IsPaid() || ticket.PaymentReference == nil) {
+			return fmt.Errorf("%w: ticket has no payment to refund", ErrTransferNotAllowed)
+		}
+		if s.paymentClient == nil {
+			return fmt.Errorf("payment service not available")
+		}
+	}
+
+	return nil
+}
+
+// settleResale charges the recipient the face value and refunds it to the previous owner,
+// recording both references on transfer. The previous owner is refunded only once the
+// recipient's payment succeeded: a payment still pending is recorded on the transfer, so it
+// is given back if the transfer is closed first, and ErrTransferPaymentPending returned.
+// When the refund fails, the recipient's payment is given back.
+func (s *TicketTransferService) settleResale(ctx context.Context, transfer *models.TicketTransfer, ticket *models.Ticket, paymentMethod string) error {
+	if s.paymentClient == nil {
+		return fmt.Errorf("payment service not available")
+	}
+
+	payment, err := chargePayment(ctx, s.paymentClient, ticket.EventID, &paymentpb.CreatePaymentRequest{
+		TicketId:       ticket.ID,
+		UserId:         transfer.ToUserID,
+		Amount:         transfer.Price,
+		Currency:       transfer.Currency,
+		PaymentMethod:  paymentMethod,
+		IdempotencyKey: "ticket-transfer-" + transfer.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrTransferPaymentFailed, err)
+	}
+	paymentID := payment.PaymentId
+
+	if err := paymentSettled(payment); err != nil {
+		if !errors.Is(err, errPaymentPending) {
+			return fmt.Errorf("%w: %v", ErrTransferPaymentFailed, err)
+		}
+		if err := s.transferRepo.SetPaymentReference(ctx, transfer.ID, paymentID); err != nil {
+			return err
+		}
+		transfer.PaymentReference = &paymentID
+		return fmt.Errorf("%w: %v", ErrTransferPaymentPending, err)
+	}
+	transfer.PaymentReference = &paymentID
+
+	refund, err := refundPayment(ctx, s.paymentClient, *ticket.PaymentReference, transfer.Price, resaleRefundReason, "ticket-transfer-refund-"+transfer.ID)
+	if err != nil {
+		s.logger.Error("Failed to refund previous owner of resold ticket, refunding recipient",
+			zap.String("transfer_id", transfer.ID),
+			zap.String("ticket_id", ticket.ID),
+			zap.Error(err),
+		)
+		if _, rollbackErr := refundPayment(ctx, s.paymentClient, paymentID, transfer.Price, resaleFailedReason, "ticket-transfer-rollback-"+transfer.ID); rollbackErr != nil {
+			s.logger.Error("Failed to refund recipient of failed resale",
+				zap.String("transfer_id", transfer.ID),
+				zap.String("payment_id", paymentID),
+				zap.Error(rollbackErr),
+			)
+		}
+		return fmt.Errorf("failed to refund previous owner: %w", err)
+	}
+	transfer.RefundReference = &refund.RefundId
+
+	metrics.IncrementTicketRefunded(ticket.EventID, resaleRefundReason)
+	return nil
+}
+
+// closeStaleTransfer cancels a transfer whose ticket moved or changed status since it was initiated
+func (s *TicketTransferService) closeStaleTransfer(ctx context.Context, transfer *models.TicketTransfer) {
+	err := s.transferRepo.Close(ctx, transfer.ID, models.TransferStatusCancelled, "Ticket can no longer be transferred")
+	if err == nil {
+		s.giveBackResalePayment(ctx, transfer)
+	} else if !errors.Is(err, repositories.ErrTransferNotPending) {
+		s.logger.Warn("Failed to cancel stale ticket transfer",
+			zap.String("transfer_id", transfer.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+// giveBackResalePayment cancels or refunds the recipient's payment of a resale that was closed
+// while the payment was still pending
+func (s *TicketTransferService) giveBackResalePayment(ctx context.Context, transfer *models.TicketTransfer) {
+	if transfer.PaymentReference == nil || s.paymentClient == nil {
+		return
+	}
+
+	err := givePaymentBack(ctx, s.paymentClient, *transfer.PaymentReference, resaleFailedReason, "ticket-transfer-rollback-"+transfer.ID)
+	if err != nil {
+		s.logger.Error("Failed to give back payment of closed ticket transfer",
+			zap.String("transfer_id", transfer.ID),
+			zap.String("payment_id", *transfer.PaymentReference),
+			zap.Error(err),
+		)
+	}
+}
+
+// Request types
+
+type InitiateTransferRequest struct {
+	TicketID     string        `json:"ticket_id"`
+	FromUserID   string        `json:"from_user_id"`
+	ToUserID     string        `json:"to_user_id"`
+	TransferType string        `json:"transfer_type"`
+	ExpiresIn    time.Duration `json:"expires_in,omitempty"`
+}
+
+type AcceptTransferRequest struct {
+	TransferID    string `json:"transfer_id"`
+	UserID        string `json:"user_id"`
+	PaymentMethod string `json:"payment_method,omitempty"`
+}
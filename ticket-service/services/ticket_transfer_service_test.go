@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+
+	paymentpb "ticket-service/internal/protos/payment"
+	"ticket-service/models"
+)
+
+func TestSettleResale(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		wantErr    error
+		wantRefund bool
+	}{
+		{name: "success refunds the previous owner", status: "success", wantRefund: true},
+		{name: "failed keeps the ticket", status: "failed", wantErr: ErrTransferPaymentFailed},
+		{name: "cancelled keeps the ticket", status: "cancelled", wantErr: ErrTransferPaymentFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payments := &fakePaymentService{payments: map[string]*paymentpb.Payment{
+				"ticket-transfer-transfer-1": {PaymentId: "payment-recipient", Amount: 80, Status: tt.status},
+				"booking-saga-saga-1":        {PaymentId: "payment-owner", Amount: 80, Status: "success"},
+			}}
+			service := &TicketTransferService{paymentClient: newTestPaymentClient(t, payments), logger: zap.NewNop()}
+
+			paymentReference := "payment-owner"
+			ticket := &models.Ticket{ID: "ticket-1", EventID: "event-1", PaymentReference: &paymentReference}
+			transfer := &models.TicketTransfer{ID: "transfer-1", TicketID: ticket.ID, ToUserID: "user-2", Price: 80, Currency: "USD"}
+
+			err := service.settleResale(context.Background(), transfer, ticket, "card")
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("settle resale: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("settle resale error = %v, want %v", err, tt.wantErr)
+			}
+
+			if refunded := len(payments.refunds) == 1; refunded != tt.wantRefund {
+				t.Fatalf("previous owner refunded = %v, want %v", refunded, tt.wantRefund)
+			}
+			if tt.wantRefund {
+				if refund := payments.refunds[0]; refund.PaymentId != "payment-owner" || refund.Amount != 80 {
+					t.Errorf("refund = %v of %s, want 80 of payment-owner", refund.Amount, refund.PaymentId)
+				}
+				if transfer.PaymentReference == nil || *transfer.PaymentReference != "payment-recipient" {
+					t.Errorf("transfer payment reference = %v, want payment-recipient", transfer.PaymentReference)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+
+	"ticket-service/models"
+	"ticket-service/repositories"
+)
+
+// testDatabaseURLEnv names the PostgreSQL database, migrated with migrations/, that the
+// repository tests run against. They are skipped when it is not set, except in CI, which
+// provides one (see .github/workflows/ci.yml).
+const testDatabaseURLEnv = "TICKET_SERVICE_TEST_DATABASE_URL"
+
+// openTestDB connects to the test database or skips the test
+func openTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	url := os.Getenv(testDatabaseURLEnv)
+	if url == "" {
+		if os.Getenv("CI") != "" {
+			t.Fatalf("%s must be set in CI", testDatabaseURLEnv)
+		}
+		t.Skipf("%s is not set", testDatabaseURLEnv)
+	}
+
+	db, err := sqlx.Connect("postgres", url)
+	if err != nil {
+		t.Fatalf("connect to test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// createHeldSession creates an active booking session holding seats seats and removes it,
+// with everything written for it, when the test ends
+func createHeldSession(t *testing.T, db *sqlx.DB, seats int) (*models.BookingSession, []*models.SeatReservation) {
+	t.Helper()
+	ctx := context.Background()
+	logger := zap.NewNop()
+
+	eventID, zoneID, userID := uuid.New().String(), uuid.New().String(), uuid.New().String()
+	expiresAt := time.Now().Add(time.Hour)
+
+	session := models.NewBookingSession(userID, eventID, uuid.New().String(), seats, float64(seats)*50, "USD", expiresAt)
+	if err := repositories.NewBookingSessionRepository(db, logger).Create(ctx, session); err != nil {
+		t.Fatalf("create booking session: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM outbox_events WHERE aggregate_id = $1`, session.ID)
+		db.Exec(`DELETE FROM tickets WHERE booking_session_id = $1`, session.ID)
+		db.Exec(`DELETE FROM booking_sessions WHERE id = $1`, session.ID)
+	})
+
+	reservationRepo := repositories.NewSeatReservationRepository(db, logger)
+	reservations := make([]*models.SeatReservation, seats)
+	for i := range reservations {
+		reservations[i] = models.NewSeatReservation(
+			session.ID, eventID, uuid.New().String(), zoneID, uuid.New().String(),
+			"standard", 50, 50, "USD", expiresAt,
+		)
+		if err := reservationRepo.Create(ctx, reservations[i]); err != nil {
+			t.Fatalf("create seat reservation: %v", err)
+		}
+	}
+
+	return session, reservations
+}
+
+// ticketsFor builds the tickets of a session's reservations the way issuance does, with
+// new IDs and numbers every time like a retry would
+func ticketsFor(session *models.BookingSession, reservations []*models.SeatReservation) []*models.Ticket {
+	tickets := make([]*models.Ticket, len(reservations))
+	for i, reservation := range reservations {
+		ticket := models.NewTicket(
+			reservation.EventID, reservation.SeatID, reservation.ZoneID, session.UserID,
+			"TKT-"+uuid.New().String()[:13],
+			reservation.PricingCategory, reservation.BasePrice, reservation.FinalPrice, reservation.Currency,
+		)
+		ticket.ID = uuid.New().String()
+		ticket.BookingSessionID = &session.ID
+		ticket.Status = models.TicketStatusConfirmed
+		ticket.PaymentStatus = models.PaymentStatusPaid
+		tickets[i] = ticket
+	}
+	return tickets
+}
+
+// expireHold lapses a reservation behind the repository's back, as the expiry sweep would
+func expireHold(t *testing.T, db *sqlx.DB, reservation *models.SeatReservation) {
+	t.Helper()
+	if _, err := db.Exec(`UPDATE seat_reservations SET status = 'expired', released_at = CURRENT_TIMESTAMP WHERE id = $1`, reservation.ID); err != nil {
+		t.Fatalf("expire seat reservation: %v", err)
+	}
+}
+
+func TestIssueForBookingSessionRetryDoesNotRepeatEvent(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	ticketRepo := repositories.NewTicketRepository(db, zap.NewNop())
+
+	session, reservations := createHeldSession(t, db, 2)
+
+	issue := func() int64 {
+		t.Helper()
+		event, err := models.NewOutboxEvent(models.AggregateTypeBooking, session.ID, models.EventTypeBookingConfirmed,
+			map[string]string{"bookingId": session.ID})
+		if err != nil {
+			t.Fatalf("new outbox event: %v", err)
+		}
+		issued, err := ticketRepo.IssueForBookingSession(ctx, session.ID, "", ticketsFor(session, reservations), event)
+		if err != nil {
+			t.Fatalf("issue tickets: %v", err)
+		}
+		return issued
+	}
+
+	if issued := issue(); issued != 2 {
+		t.Fatalf("first issuance created %d tickets, want 2", issued)
+	}
+	if issued := issue(); issued != 0 {
+		t.Fatalf("retried issuance created %d tickets, want 0", issued)
+	}
+
+	var events int
+	if err := db.Get(&events, `SELECT COUNT(*) FROM outbox_events WHERE aggregate_id = $1 AND event_type = $2`,
+		session.ID, models.EventTypeBookingConfirmed); err != nil {
+		t.Fatalf("count outbox events: %v", err)
+	}
+	if events != 1 {
+		t.Errorf("%d booking.confirmed events written, want 1", events)
+	}
+
+	tickets, err := ticketRepo.GetByBookingSessionID(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("get tickets: %v", err)
+	}
+	if len(tickets) != 2 {
+		t.Errorf("session has %d tickets, want 2", len(tickets))
+	}
+
+	completed, err := repositories.NewBookingSessionRepository(db, zap.NewNop()).GetByID(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("get booking session: %v", err)
+	}
+	if completed.Status != models.BookingSessionStatusCompleted {
+		t.Errorf("session status = %s, want %s", completed.Status, models.BookingSessionStatusCompleted)
+	}
+}
+
+func TestReleaseByBookingSessionReturnsOnlyHeldSeats(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	reservationRepo := repositories.NewSeatReservationRepository(db, zap.NewNop())
+
+	session, reservations := createHeldSession(t, db, 3)
+	expireHold(t, db, reservations[0])
+
+	released, err := reservationRepo.ReleaseByBookingSession(ctx, session.ID, "Booking failed", "")
+	if err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	assertSeats(t, released, reservations[1].SeatID, reservations[2].SeatID)
+
+	// Compensation may run again after a restart; nothing is left to release then
+	released, err = reservationRepo.ReleaseByBookingSession(ctx, session.ID, "Booking failed", "")
+	if err != nil {
+		t.Fatalf("release again: %v", err)
+	}
+	assertSeats(t, released)
+}
+
+func TestReleaseHoldsReturnsOnlyHeldSeats(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	reservationRepo := repositories.NewSeatReservationRepository(db, zap.NewNop())
+
+	_, reservations := createHeldSession(t, db, 3)
+	expireHold(t, db, reservations[1])
+
+	ids := []string{reservations[0].ID, reservations[1].ID, reservations[2].ID}
+	released, err := reservationRepo.ReleaseHolds(ctx, ids, "Group booking share unpaid")
+	if err != nil {
+		t.Fatalf("release holds: %v", err)
+	}
+	assertSeats(t, released, reservations[0].SeatID, reservations[2].SeatID)
+}
+
+func TestCreateBatchRefusesSessionBeingCompleted(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	logger := zap.NewNop()
+	reservationRepo := repositories.NewSeatReservationRepository(db, logger)
+
+	session, reservations := createHeldSession(t, db, 1)
+	saga := models.NewBookingSaga(session.ID, "card", session.UserID)
+	if err := repositories.NewBookingSagaRepository(db, logger).Create(ctx, saga, "test", time.Minute); err != nil {
+		t.Fatalf("create booking saga: %v", err)
+	}
+	t.Cleanup(func() { db.Exec(`DELETE FROM booking_sagas WHERE id = $1`, saga.ID) })
+
+	// A seat added once the saga may have charged would be confirmed without being paid for
+	held := reservations[0]
+	seat := models.NewSeatReservation(
+		session.ID, held.EventID, uuid.New().String(), held.ZoneID, uuid.New().String(),
+		"standard", 50, 50, "USD", session.ExpiresAt,
+	)
+	if _, err := reservationRepo.CreateBatch(ctx, session.ID, []*models.SeatReservation{seat}); !errors.Is(err, repositories.ErrSessionNotOpen) {
+		t.Fatalf("create batch error = %v, want ErrSessionNotOpen", err)
+	}
+}
+
+func TestCreateBatchKeepsLapsedHoldOfSessionBeingCompleted(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	logger := zap.NewNop()
+	reservationRepo := repositories.NewSeatReservationRepository(db, logger)
+
+	paying, reservations := createHeldSession(t, db, 1)
+	saga := models.NewBookingSaga(paying.ID, "card", paying.UserID)
+	if err := repositories.NewBookingSagaRepository(db, logger).Create(ctx, saga, "test", time.Minute); err != nil {
+		t.Fatalf("create booking saga: %v", err)
+	}
+	t.Cleanup(func() { db.Exec(`DELETE FROM booking_sagas WHERE id = $1`, saga.ID) })
+
+	held := reservations[0]
+	if _, err := db.Exec(`UPDATE seat_reservations SET expires_at = CURRENT_TIMESTAMP - INTERVAL '1 minute' WHERE id = $1`, held.ID); err != nil {
+		t.Fatalf("lapse seat reservation: %v", err)
+	}
+
+	// Another session asking for the seat must not take it from the booking being paid for
+	other, _ := createHeldSession(t, db, 0)
+	seat := models.NewSeatReservation(
+		other.ID, held.EventID, held.SeatID, held.ZoneID, uuid.New().String(),
+		"standard", 50, 50, "USD", other.ExpiresAt,
+	)
+	taken, err := reservationRepo.CreateBatch(ctx, other.ID, []*models.SeatReservation{seat})
+	if err != nil {
+		t.Fatalf("create batch: %v", err)
+	}
+	assertSeats(t, taken, held.SeatID)
+
+	var status string
+	if err := db.Get(&status, `SELECT status FROM seat_reservations WHERE id = $1`, held.ID); err != nil {
+		t.Fatalf("get seat reservation: %v", err)
+	}
+	if status != models.ReservationStatusReserved {
+		t.Errorf("lapsed hold status = %s, want it still reserved for the saga", status)
+	}
+}
+
+// assertSeats checks that got holds exactly the seats want, in any order
+func assertSeats(t *testing.T, got []string, want ...string) {
+	t.Helper()
+
+	got = append([]string(nil), got...)
+	want = append([]string(nil), want...)
+	sort.Strings(got)
+	sort.Strings(want)
+
+	if len(got) != len(want) {
+		t.Fatalf("seats %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("seats %v, want %v", got, want)
+		}
+	}
+}